@@ -6,15 +6,22 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"session-service/internal/audit"
 	"session-service/internal/auth"
+	"session-service/internal/bootstrap"
 	"session-service/internal/cache"
 	"session-service/internal/config"
 	"session-service/internal/database"
 	"session-service/internal/handlers"
+	"session-service/internal/logging"
+	"session-service/internal/middleware"
+	"session-service/internal/server"
+	"session-service/internal/tracing"
 	"syscall"
 	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	_ "session-service/docs" // swagger docs
 )
@@ -56,52 +63,137 @@ func main() {
 		logger.Fatal("Failed to load configuration", zap.Error(err))
 	}
 
-	// Initialize database
+	// Redact personal data and raw tokens from every log entry
+	redactKeys := cfg.LogRedactKeys
+	if len(redactKeys) == 0 {
+		redactKeys = logging.DefaultRedactedKeys
+	}
+	logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return logging.NewRedactingCore(core, redactKeys)
+	}))
+
+	// Initialize tracing; spans are only exported with OTEL_EXPORTER_OTLP_ENDPOINT
 	ctx := context.Background()
+	shutdownTracing, err := tracing.Setup(ctx, cfg.OTLPEndpoint)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
+	// Initialize database
 	repo, err := database.NewRepository(ctx, cfg.DatabaseURL, logger)
 	if err != nil {
 		logger.Fatal("Failed to initialize database", zap.Error(err))
 	}
+	repo = database.NewInstrumentedRepository(repo, cfg.SlowOpThreshold, logger)
 	defer repo.Close()
 
+	// Seed an initial tenant and client on a fresh deployment
+	bootstrapCfg := bootstrap.Config{
+		TenantID:     middleware.CanonicalTenantID(cfg.BootstrapTenantID),
+		ClientID:     cfg.BootstrapClientID,
+		ClientSecret: cfg.BootstrapClientSecret,
+		ClientName:   cfg.BootstrapClientName,
+	}
+	if err := bootstrap.Run(ctx, repo, bootstrapCfg, logger); err != nil {
+		logger.Fatal("Failed to bootstrap tenant and client", zap.Error(err))
+	}
+
 	// Initialize cache
-	cacheClient, err := cache.NewCache(cfg.RedisURL, logger)
+	cacheClient, err := cache.NewCache(cfg.RedisURL, cache.ConnectOptions{
+		Retries: cfg.RedisConnectRetries,
+		Timeout: cfg.RedisConnectTimeout,
+		Backoff: time.Second,
+	}, logger)
 	if err != nil {
 		logger.Fatal("Failed to initialize cache", zap.Error(err))
 	}
+	if cfg.GlobalRedisURL != "" {
+		globalCache, err := cache.NewCache(cfg.GlobalRedisURL, cache.ConnectOptions{
+			Retries: cfg.RedisConnectRetries,
+			Timeout: cfg.RedisConnectTimeout,
+			Backoff: time.Second,
+		}, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize global session cache", zap.Error(err))
+		}
+		cacheClient = cache.NewGlobalSessionCache(cacheClient, globalCache)
+		logger.Info("Session state stored in the global Redis")
+	}
+	cacheClient = cache.NewInstrumentedCache(cacheClient, cfg.SlowOpThreshold, logger)
 	defer cacheClient.Close()
 
+	// Preload recently used clients so a cold cache doesn't stampede the database
+	if cfg.ClientCacheWarmup {
+		warmupCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		warmed, err := cache.WarmClients(warmupCtx, repo, cacheClient, cfg.ClientCacheWarmupMax, 15*time.Minute, logger)
+		cancel()
+		if err != nil {
+			logger.Warn("Client cache warm-up failed", zap.Error(err))
+		} else {
+			logger.Info("Client cache warmed", zap.Int("clients", warmed))
+		}
+	}
+
 	// Initialize key manager
-	keyManager, err := auth.NewKeyManager(cfg.JWTPrivateKey, cfg.JWTPublicKey)
+	keyManager, err := auth.NewKeyManagerWithAlgorithm(cfg.JWTSigningAlg, cfg.JWTPrivateKey, cfg.JWTPublicKey)
 	if err != nil {
 		logger.Fatal("Failed to initialize key manager", zap.Error(err))
 	}
-
-	// Start key rotation scheduler (Azure/Hydra-style)
-	go func() {
-		rotationDays := cfg.KeyRotationDays
-		if rotationDays <= 0 {
-			rotationDays = 90
+	for _, alg := range cfg.AdditionalSigningAlgs {
+		if err := keyManager.AddAlgorithm(alg); err != nil {
+			logger.Fatal("Failed to add signing algorithm", zap.String("alg", alg), zap.Error(err))
 		}
-		graceDays := cfg.KeyGraceDays
-		if graceDays <= 0 {
-			graceDays = 14
+	}
+	if cfg.MaxAdvertisedKeys > 0 {
+		keyManager.SetMaxAdvertisedKeys(cfg.MaxAdvertisedKeys)
+	}
+	for _, audience := range cfg.AudienceSigningKeys {
+		if err := keyManager.AddAudienceKeys(audience); err != nil {
+			logger.Fatal("Failed to add audience signing keys", zap.String("audience", audience), zap.Error(err))
 		}
-
-		rotationInterval := time.Duration(rotationDays) * 24 * time.Hour
-		gracePeriod := time.Duration(graceDays) * 24 * time.Hour
-
-		ticker := time.NewTicker(rotationInterval)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			logger.Info("Rotating signing keys", zap.Int("rotation_days", rotationDays), zap.Int("grace_days", graceDays))
-			if err := keyManager.RotateKeys(gracePeriod); err != nil {
-				logger.Error("Failed to rotate keys", zap.Error(err))
+	}
+	if cfg.StandbyPrivateKey != "" {
+		standby, err := auth.NewKeyManagerWithAlgorithm(cfg.StandbySigningAlg, cfg.StandbyPrivateKey, cfg.StandbyPublicKey)
+		if err != nil {
+			logger.Fatal("Failed to initialize standby key manager", zap.Error(err))
+		}
+		// The standby replaces every algorithm at promotion, so it gets a
+		// key of its own for each one the active keys sign with
+		for _, alg := range keyManager.Algorithms() {
+			if err := standby.AddAlgorithm(alg); err != nil {
+				logger.Fatal("Failed to add standby signing algorithm", zap.String("alg", alg), zap.Error(err))
 			}
-			keyManager.CleanupExpiredKeys()
 		}
-	}()
+		if err := keyManager.SetStandby(standby); err != nil {
+			logger.Fatal("Standby signing key failed validation", zap.Error(err))
+		}
+		logger.Info("Standby signing key published", zap.String("alg", cfg.StandbySigningAlg))
+	}
+
+	// Start key rotation scheduler (Azure/Hydra-style)
+	rotationDays := cfg.KeyRotationDays
+	if rotationDays <= 0 {
+		rotationDays = 90
+	}
+	graceDays := cfg.KeyGraceDays
+	if graceDays <= 0 {
+		graceDays = 14
+	}
+	if cfg.PersistSigningKeys {
+		keyCipher, err := auth.NewSecretCipher(cfg.SigningKeyEncryptionKey)
+		if err != nil {
+			logger.Fatal("Failed to initialize signing key cipher", zap.Error(err))
+		}
+		if err := keyManager.EnableKeyPersistence(ctx, repo, keyCipher, time.Duration(graceDays)*24*time.Hour); err != nil {
+			logger.Fatal("Failed to restore persisted signing keys", zap.Error(err))
+		}
+		logger.Info("Signing keys restored from the database", zap.String("kid", keyManager.GetCurrentKeyID()))
+	}
+	go auth.RunRotationSchedule(ctx, keyManager, auth.RotationSchedule{
+		Interval:    time.Duration(rotationDays) * 24 * time.Hour,
+		GracePeriod: time.Duration(graceDays) * 24 * time.Hour,
+		Preroll:     cfg.KeyPreroll,
+	}, logger)
 
 	// Initialize token generator
 	tokenGen := auth.NewTokenGenerator(
@@ -112,6 +204,13 @@ func main() {
 		cfg.RefreshTokenLength,
 	)
 
+	if len(cfg.RefreshTokenHMACKey) > 0 {
+		tokenGen.EnableRefreshTokenMAC(cfg.RefreshTokenHMACKey)
+		if len(cfg.PreviousRefreshTokenHMACKey) > 0 {
+			tokenGen.AcceptPreviousRefreshTokenMAC(cfg.PreviousRefreshTokenHMACKey, cfg.PreviousRefreshTokenHMACKeyUntil)
+		}
+	}
+
 	// Initialize token validator
 	tokenValidator := auth.NewTokenValidator(
 		keyManager,
@@ -119,6 +218,66 @@ func main() {
 		cfg.JWTAudience,
 		cacheClient,
 	)
+	tokenValidator.EnableClockDriftWarnings(cfg.ClockDriftWarnWindow, logger)
+	if cfg.ClockSkewLeeway > 0 {
+		tokenValidator.EnableClockSkewLeeway(cfg.ClockSkewLeeway)
+	}
+	if cfg.RevokeTokensOnRoleChange {
+		tokenValidator.EnableRoleChangeRevocation()
+	}
+	if cfg.AcceptTenantIssuers {
+		tokenValidator.EnableTenantIssuers()
+	}
+	if len(cfg.JWTPreviousAudiences) > 0 {
+		tokenValidator.EnablePreviousAudiences(cfg.JWTPreviousAudiences)
+	}
+	if len(cfg.CurrentKeyScopes) > 0 {
+		tokenValidator.EnableCurrentKeyScopes(cfg.CurrentKeyScopes)
+	}
+	if len(cfg.ScopeAudiences) > 0 {
+		var audiences []string
+		clientAudience := false
+		for _, audience := range cfg.ScopeAudiences {
+			if audience == config.ClientIDAudience {
+				clientAudience = true
+			} else {
+				audiences = append(audiences, audience)
+			}
+		}
+		tokenValidator.EnableScopeAudiences(audiences, clientAudience)
+	}
+	if claimNames := (auth.ClaimNames{Roles: cfg.RolesClaim, Scopes: cfg.ScopesClaim, Tenant: cfg.TenantClaim}); claimNames != auth.DefaultClaimNames || cfg.RFC9068AccessTokens {
+		tokenGen.EnableClaimNames(claimNames)
+		tokenValidator.EnableClaimNames(claimNames)
+	}
+	if cfg.RoleCompressionThreshold > 0 {
+		tokenGen.EnableRoleCompression(cfg.RoleCompressionThreshold)
+	}
+	if cfg.RFC9068AccessTokens {
+		tokenGen.EnableRFC9068()
+	}
+	if cfg.RequireAccessTokenType {
+		tokenValidator.RequireAccessTokenType()
+	}
+
+	// HS256 tenants sign with a shared secret encrypted at rest
+	var secretCipher *auth.SecretCipher
+	if len(cfg.TenantSecretKey) > 0 {
+		secretCipher, err = auth.NewSecretCipher(cfg.TenantSecretKey)
+		if err != nil {
+			logger.Fatal("Failed to initialize tenant secret cipher", zap.Error(err))
+		}
+		tokenGen.EnableHMACTenants(secretCipher)
+		tokenValidator.EnableHMACTenants(secretCipher, repo.GetTenantByID)
+	}
+
+	// Catch key and issuer/audience misconfigurations before serving traffic
+	if cfg.StartupSelfTest {
+		if err := auth.SelfTest(ctx, tokenGen, tokenValidator); err != nil {
+			logger.Fatal("Startup self-test failed", zap.Error(err))
+		}
+		logger.Info("Startup self-test passed")
+	}
 
 	// Initialize handlers
 	tokenHandler := handlers.NewTokenHandler(
@@ -129,18 +288,100 @@ func main() {
 		cfg,
 		logger,
 	)
+	if cfg.KeyRotationGuard {
+		tokenHandler.EnableRotationGuard(keyManager, cfg.KeyRotationRetryAfter)
+	}
+	if cfg.SessionCookies {
+		tokenHandler.EnableCookieSessions(cfg.SessionCookieName)
+	}
+	if cfg.TenantBootstrapPublicKey != "" {
+		tenantBootstrap, err := auth.NewTenantBootstrapVerifier(cfg.TenantBootstrapPublicKey)
+		if err != nil {
+			logger.Fatal("Failed to initialize tenant bootstrap verifier", zap.Error(err))
+		}
+		tokenHandler.EnableTenantBootstrap(tenantBootstrap)
+	}
 
-	verifyHandler := handlers.NewVerifyHandler(tokenValidator, logger)
+	verifyCache := auth.NewVerificationCache(cfg.VerifyCacheTTL, cfg.VerifyCacheMaxEntries)
+	verifyHandler := handlers.NewVerifyHandler(tokenValidator, verifyCache, cfg.VerifyIncludeKeyStatus, logger)
+	if cfg.JTISourceWarnThreshold > 0 {
+		verifyHandler.EnableTokenSourceTracking(cacheClient, cfg.JTISourceWarnThreshold, cfg.JTISourceReject, cfg.TrustedProxies)
+	}
 	jwksHandler := handlers.NewJWKSHandler(repo, keyManager, logger)
+	if cfg.JWKSStaleIfError > 0 {
+		jwksHandler.EnableStaleIfError(cfg.JWKSStaleIfError)
+	}
 	oidcHandler := handlers.NewOIDCConfigurationHandler(cfg.BaseURL, cfg.JWTIssuer, logger)
+	oidcHandler.SetIDTokenSigningKeys(keyManager)
+	var auditRecorder audit.Recorder = audit.NewLogRecorder(logger)
+	if cfg.AuditPersist {
+		var deadLetter *audit.DeadLetter
+		if cfg.AuditDeadLetterPath != "" {
+			deadLetter = audit.NewDeadLetter(cfg.AuditDeadLetterPath)
+		}
+		storeRecorder := audit.NewStoreRecorder(repo, auditRecorder, deadLetter, logger)
+		go storeRecorder.Run(ctx, cfg.AuditReplayInterval)
+		auditRecorder = storeRecorder
+	}
+	var auditEvents *audit.Broadcaster
+	if cfg.AdminEventStream {
+		auditEvents = audit.NewBroadcaster(auditRecorder, 64)
+		auditRecorder = auditEvents
+	}
+	adminHandler := handlers.NewAdminHandler(repo, cacheClient, cfg, auditRecorder, logger)
+	if secretCipher != nil {
+		adminHandler.EnableHMACTenants(secretCipher)
+	}
+	if auditEvents != nil {
+		adminHandler.EnableEventStream(auditEvents)
+	}
+	if keyManager.HasStandby() {
+		adminHandler.EnableStandbyPromotion(keyManager, time.Duration(graceDays)*24*time.Hour)
+		if cfg.OperatorToken == "" {
+			logger.Warn("Standby signing key cannot be promoted without OPERATOR_TOKEN")
+		}
+	}
+	adminAuth := middleware.RequireRole(tokenValidator, cfg.AdminRole, logger)
+	userAuth := middleware.RequireTenantToken(tokenValidator, logger)
 
-	// Setup router
-	router := SetupRouter(tokenHandler, verifyHandler, jwksHandler, oidcHandler, logger)
+	// Request recorder for support debugging; never enabled in production
+	var recorder *middleware.RequestRecorder
+	var debugHandler *handlers.DebugHandler
+	if cfg.DebugRequestRecorder {
+		if cfg.IsProduction() {
+			logger.Warn("DEBUG_REQUEST_RECORDER is ignored in production")
+		} else {
+			recorder = middleware.NewRequestRecorder(cfg.DebugRequestRecorderSize)
+			debugHandler = handlers.NewDebugHandler(recorder, logger)
+			logger.Info("Debug request recorder enabled", zap.Int("size", cfg.DebugRequestRecorderSize))
+		}
+	}
+	debugAuth := middleware.RequireRoleAnyTenant(tokenValidator, cfg.AdminRole, logger)
+	operatorAuth := middleware.RequireOperatorToken(cfg.OperatorToken, logger)
+
+	healthHandler := handlers.NewHealthHandler(repo, cacheClient, logger)
+	if cfg.ReadinessSigningCheck {
+		healthHandler.EnableSigningCheck(tokenGen, tokenValidator)
+	}
+
+	// Setup routers; with ADMIN_PORT the operational endpoints move off the public port
+	separateAdmin := cfg.AdminPort != ""
+	router := server.SetupRouter(tokenHandler, verifyHandler, jwksHandler, oidcHandler, adminHandler, healthHandler, adminAuth, userAuth, debugHandler, recorder, debugAuth, operatorAuth, cfg.TenantIDPattern, separateAdmin, logger)
+
+	// Compress larger responses, and shed load beyond MAX_CONCURRENT_REQUESTS
+	// before it reaches the database and Redis
+	var publicHandler http.Handler = router
+	if cfg.ResponseCompression {
+		publicHandler = middleware.Compression(cfg.CompressionMinSize, cfg.CompressionEncodings)(publicHandler)
+	}
+	if cfg.MaxConcurrentRequests > 0 {
+		publicHandler = middleware.LoadShedding(cfg.MaxConcurrentRequests, cfg.ConcurrentRequestWait, logger)(publicHandler)
+	}
 
 	// Create server
 	srv := &http.Server{
 		Addr:         ":" + cfg.ServerPort,
-		Handler:      router,
+		Handler:      publicHandler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -154,6 +395,28 @@ func main() {
 		}
 	}()
 
+	var adminSrv *http.Server
+	if separateAdmin {
+		var adminRouter http.Handler = server.SetupAdminRouter(adminHandler, healthHandler, adminAuth, debugHandler, recorder, debugAuth, operatorAuth, cfg.TenantIDPattern, logger)
+		if cfg.ResponseCompression {
+			adminRouter = middleware.Compression(cfg.CompressionMinSize, cfg.CompressionEncodings)(adminRouter)
+		}
+		adminSrv = &http.Server{
+			Addr:         ":" + cfg.AdminPort,
+			Handler:      adminRouter,
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 60 * time.Second, // tenant exports can stream for a while
+			IdleTimeout:  60 * time.Second,
+		}
+
+		go func() {
+			logger.Info("Admin server starting", zap.String("port", cfg.AdminPort))
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("Admin server failed to start", zap.Error(err))
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -168,6 +431,14 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("Server forced to shutdown", zap.Error(err))
 	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			logger.Error("Admin server forced to shutdown", zap.Error(err))
+		}
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Warn("Failed to flush trace spans", zap.Error(err))
+	}
 
 	logger.Info("Server exited")
 }
@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Event types recorded by the service.
+const (
+	EventUserExport        = "user.export"
+	EventTenantUsersExport = "tenant.users.export"
+	EventTenantUsersList   = "tenant.users.list"
+	EventUserDelete        = "user.delete"
+
+	EventTenantSigningSecretRotate = "tenant.signing_secret.rotate"
+	EventTenantSigningSecretDelete = "tenant.signing_secret.delete"
+
+	EventTenantConfigRead   = "tenant.config.read"
+	EventTenantConfigUpdate = "tenant.config.update"
+
+	EventTenantClientsList = "tenant.clients.list"
+	EventClientUpdate      = "client.update"
+
+	EventTenantEventsStream = "tenant.events.stream"
+
+	EventSigningKeyPromote = "signing_key.promote"
+)
+
+// Event represents a single auditable action.
+type Event struct {
+	ID        string            `json:"id,omitempty"` // assigned when the event is persisted
+	Type      string            `json:"type"`
+	TenantID  string            `json:"tenant_id"`
+	ActorID   string            `json:"actor_id,omitempty"`  // sub of the caller performing the action
+	TargetID  string            `json:"target_id,omitempty"` // e.g. the user being exported
+	Timestamp time.Time         `json:"timestamp"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Recorder defines the interface for recording audit events.
+// Recording must never fail the request being audited, so implementations
+// handle (and log) their own errors.
+type Recorder interface {
+	Record(ctx context.Context, event Event)
+}
+
+// LogRecorder records audit events as structured log entries.
+type LogRecorder struct {
+	logger *zap.Logger
+}
+
+// NewLogRecorder creates a new log-backed audit recorder
+func NewLogRecorder(logger *zap.Logger) *LogRecorder {
+	return &LogRecorder{logger: logger}
+}
+
+// Record writes the event to the audit log
+func (r *LogRecorder) Record(ctx context.Context, event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	r.logger.Info("Audit event",
+		zap.String("audit_type", event.Type),
+		zap.String("tenant_id", event.TenantID),
+		zap.String("actor_id", event.ActorID),
+		zap.String("target_id", event.TargetID),
+		zap.Time("timestamp", event.Timestamp),
+		zap.Any("metadata", event.Metadata),
+	)
+}
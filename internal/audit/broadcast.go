@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Broadcaster passes audit events on to next and publishes each one to the
+// subscribers of its tenant, such as admin event streams. A subscriber that
+// falls behind misses events rather than slowing down the audited request.
+type Broadcaster struct {
+	next   Recorder
+	buffer int
+
+	mu          sync.Mutex
+	subscribers map[string]map[chan Event]struct{}
+}
+
+// NewBroadcaster creates a broadcaster in front of next. Each subscriber can
+// have up to buffer events waiting to be read.
+func NewBroadcaster(next Recorder, buffer int) *Broadcaster {
+	return &Broadcaster{
+		next:        next,
+		buffer:      buffer,
+		subscribers: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+// Record passes the event on to next, then publishes it to the event's
+// tenant's subscribers
+func (b *Broadcaster) Record(ctx context.Context, event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	b.next.Record(ctx, event)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[event.TenantID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving tenantID's events from now on, and
+// a function that ends the subscription and closes the channel.
+func (b *Broadcaster) Subscribe(tenantID string) (<-chan Event, func()) {
+	ch := make(chan Event, b.buffer)
+
+	b.mu.Lock()
+	if b.subscribers[tenantID] == nil {
+		b.subscribers[tenantID] = make(map[chan Event]struct{})
+	}
+	b.subscribers[tenantID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers[tenantID], ch)
+			if len(b.subscribers[tenantID]) == 0 {
+				delete(b.subscribers, tenantID)
+			}
+			close(ch)
+		})
+	}
+}
+
+// Subscribers returns the number of open subscriptions to tenantID's events.
+func (b *Broadcaster) Subscribers(tenantID string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers[tenantID])
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// DeadLetter is an append-only file of audit events, one JSON object per
+// line, that could not be persisted to the Store.
+type DeadLetter struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewDeadLetter creates a dead-letter backed by the file at path. The file is
+// created on the first failed write.
+func NewDeadLetter(path string) *DeadLetter {
+	return &DeadLetter{path: path}
+}
+
+// Append adds event to the dead-letter, syncing it to disk before returning.
+func (d *DeadLetter) Append(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f, err := os.OpenFile(d.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Replay inserts the dead-lettered events into store in order and removes
+// the ones that were stored. It stops at the first failed insert, as the
+// store is most likely still unavailable, and keeps that event and the rest
+// for the next attempt. Lines that cannot be decoded are kept as they are.
+func (d *DeadLetter) Replay(ctx context.Context, store Store) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	data, err := os.ReadFile(d.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		remaining bytes.Buffer
+		replayed  int
+		storeErr  error
+		undecoded int
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if storeErr == nil {
+			var event Event
+			if err := json.Unmarshal(line, &event); err != nil {
+				undecoded++
+			} else if storeErr = insertWithTimeout(ctx, store, event); storeErr == nil {
+				replayed++
+				continue
+			}
+		}
+		remaining.Write(line)
+		remaining.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return replayed, err
+	}
+
+	if replayed > 0 {
+		if err := d.rewrite(remaining.Bytes()); err != nil {
+			// The replayed events will be stored again next time; their
+			// IDs keep that from duplicating them.
+			return replayed, err
+		}
+	}
+	if storeErr != nil {
+		return replayed, storeErr
+	}
+	if undecoded > 0 {
+		return replayed, fmt.Errorf("%d dead-lettered audit events could not be decoded", undecoded)
+	}
+	return replayed, nil
+}
+
+// rewrite atomically replaces the dead-letter with data, removing the file
+// when nothing is left.
+func (d *DeadLetter) rewrite(data []byte) error {
+	if len(data) == 0 {
+		return os.Remove(d.path)
+	}
+
+	tmp := d.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, d.path)
+}
+
+func insertWithTimeout(ctx context.Context, store Store, event Event) error {
+	ctx, cancel := context.WithTimeout(ctx, storeWriteTimeout)
+	defer cancel()
+	return store.InsertAuditEvent(ctx, event)
+}
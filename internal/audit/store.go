@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"context"
+	"session-service/internal/metrics"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// storeWriteTimeout bounds each attempt to persist an event, so a slow
+// database delays the audited request by at most this long.
+const storeWriteTimeout = 5 * time.Second
+
+// Store persists audit events. Inserting an event whose ID is already stored
+// must be a no-op, so replayed events are never duplicated.
+type Store interface {
+	InsertAuditEvent(ctx context.Context, event Event) error
+}
+
+// StoreRecorder persists audit events to a Store in addition to recording
+// them with next. Events that cannot be persisted are written to the
+// dead-letter, if one is configured, and replayed by Run once the store
+// recovers; without a dead-letter they are only logged.
+type StoreRecorder struct {
+	store      Store
+	next       Recorder
+	deadLetter *DeadLetter
+	logger     *zap.Logger
+}
+
+// NewStoreRecorder creates a recorder persisting to store. deadLetter may be nil.
+func NewStoreRecorder(store Store, next Recorder, deadLetter *DeadLetter, logger *zap.Logger) *StoreRecorder {
+	return &StoreRecorder{
+		store:      store,
+		next:       next,
+		deadLetter: deadLetter,
+		logger:     logger,
+	}
+}
+
+// Record persists the event and passes it on to next
+func (r *StoreRecorder) Record(ctx context.Context, event Event) {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	r.next.Record(ctx, event)
+
+	// The request may be finishing; the write must not be cancelled with it
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), storeWriteTimeout)
+	defer cancel()
+	err := r.store.InsertAuditEvent(writeCtx, event)
+	if err == nil {
+		return
+	}
+
+	if r.deadLetter == nil {
+		r.logger.Error("Failed to persist audit event; event lost", zap.String("audit_id", event.ID), zap.String("audit_type", event.Type), zap.Error(err))
+		return
+	}
+	if dlErr := r.deadLetter.Append(event); dlErr != nil {
+		r.logger.Error("Failed to persist audit event or write it to the dead-letter; event lost",
+			zap.String("audit_id", event.ID), zap.String("audit_type", event.Type), zap.Error(err), zap.NamedError("dead_letter_error", dlErr))
+		return
+	}
+	r.logger.Warn("Failed to persist audit event; written to the dead-letter", zap.String("audit_id", event.ID), zap.String("audit_type", event.Type), zap.Error(err))
+}
+
+// Run replays dead-lettered events into the store every interval until ctx
+// is done, recovering from a panic in any one replay. It returns immediately
+// when there is no dead-letter.
+func (r *StoreRecorder) Run(ctx context.Context, interval time.Duration) {
+	if r.deadLetter == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			metrics.RunRecovered("audit_replay", r.logger, func() { r.Replay(ctx) })
+		}
+	}
+}
+
+// Replay makes one attempt to move dead-lettered events into the store and
+// reports how many were replayed.
+func (r *StoreRecorder) Replay(ctx context.Context) int {
+	if r.deadLetter == nil {
+		return 0
+	}
+
+	replayed, err := r.deadLetter.Replay(ctx, r.store)
+	if replayed > 0 {
+		r.logger.Info("Replayed dead-lettered audit events", zap.Int("count", replayed))
+	}
+	if err != nil {
+		r.logger.Warn("Audit dead-letter replay incomplete; will retry", zap.Error(err))
+	}
+	return replayed
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AccessTokenType is the typ header of JWT access tokens (RFC 9068).
+const AccessTokenType = "at+jwt"
+
+// RFC9068ScopesClaim is the claim RFC 9068 access tokens carry their scopes
+// in, unless EnableClaimNames names another.
+const RFC9068ScopesClaim = "scope"
+
+// IDTokenType is the typ header of ID tokens. They are signed with the same
+// keys as access tokens, and may have the same aud, so the validator always
+// rejects tokens of this type.
+const IDTokenType = "id_token+jwt"
+
+// EnableRFC9068 makes the generator issue access tokens in the RFC 9068
+// profile: with a typ header of AccessTokenType and scopes as a
+// space-delimited string instead of an array, under RFC9068ScopesClaim
+// unless EnableClaimNames names another claim.
+func (tg *TokenGenerator) EnableRFC9068() {
+	tg.rfc9068 = true
+}
+
+// RequireAccessTokenType makes the validator reject tokens whose typ header
+// is not AccessTokenType, so JWTs other than access tokens that are signed
+// with the same keys are not accepted. ID tokens are rejected regardless.
+func (tv *TokenValidator) RequireAccessTokenType() {
+	tv.requireAccessTokenType = true
+}
+
+// scopesClaim returns the claim access tokens carry their scopes in.
+func (tg *TokenGenerator) scopesClaim() string {
+	if tg.rfc9068 && !tg.claimNamesSet {
+		return RFC9068ScopesClaim
+	}
+	return tg.claimNames.Scopes
+}
+
+// newAccessToken returns an unsigned token for claims, typed as an access
+// token in the RFC 9068 profile.
+func (tg *TokenGenerator) newAccessToken(method jwt.SigningMethod, claims jwt.MapClaims) *jwt.Token {
+	token := jwt.NewWithClaims(method, claims)
+	if tg.rfc9068 {
+		token.Header["typ"] = AccessTokenType
+	}
+	return token
+}
+
+// isIDTokenType reports whether typ names an ID token, compared like
+// isAccessTokenType.
+func isIDTokenType(typ interface{}) bool {
+	s, _ := typ.(string)
+	s = strings.ToLower(s)
+	return s == IDTokenType || s == "application/"+IDTokenType
+}
+
+// isAccessTokenType reports whether typ names a JWT access token. RFC 9068
+// allows the full media type as well, and media types are case-insensitive.
+func isAccessTokenType(typ interface{}) bool {
+	s, _ := typ.(string)
+	s = strings.ToLower(s)
+	return s == AccessTokenType || s == "application/"+AccessTokenType
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// AddAudienceKeys gives audience its own signing keys, one for each
+// algorithm km signs with, so tokens for it are signed with keys no other
+// audience's tokens use. They rotate along with km's keys and are published
+// only in the audience's own key set (see GetJWKSetForAudience), so a
+// resource server trusting them trusts no other audience's tokens.
+func (km *KeyManager) AddAudienceKeys(audience string) error {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	if _, ok := km.audiences[audience]; ok {
+		return nil
+	}
+
+	audienceKeys := &KeyManager{
+		keys:          make(map[string]*KeyPair),
+		currentKeyIDs: make(map[string]string),
+		nextKeyIDs:    make(map[string]string),
+		defaultAlg:    km.defaultAlg,
+		maxAdvertised: km.maxAdvertised,
+	}
+	for alg := range km.currentKeyIDs {
+		key, err := generateKeyPair(alg)
+		if err != nil {
+			return err
+		}
+		audienceKeys.keys[key.KeyID] = key
+		audienceKeys.currentKeyIDs[alg] = key.KeyID
+	}
+
+	if km.audiences == nil {
+		km.audiences = make(map[string]*KeyManager)
+	}
+	km.audiences[audience] = audienceKeys
+	return nil
+}
+
+// GetSigningKeyForAudiences returns the current signing key for alg, or for
+// the default algorithm when alg is empty, for a token issued to audiences:
+// their own key when they have one (see AddAudienceKeys), otherwise km's.
+// Audiences signed with different keys cannot share a token.
+func (km *KeyManager) GetSigningKeyForAudiences(alg string, audiences []string) (*KeyPair, error) {
+	signer, err := km.audienceKeyManager(audiences)
+	if err != nil {
+		return nil, err
+	}
+	return signer.GetSigningKey(alg)
+}
+
+// GetJWKSetForAudience returns GetJWKSetFiltered(use, alg) of audience's own
+// keys, or of km's keys when audience has none or is empty.
+func (km *KeyManager) GetJWKSetForAudience(use, alg, audience string) jwk.Set {
+	km.mu.RLock()
+	audienceKeys, ok := km.audiences[audience]
+	km.mu.RUnlock()
+
+	if ok {
+		return audienceKeys.GetJWKSetFiltered(use, alg)
+	}
+	return km.GetJWKSetFiltered(use, alg)
+}
+
+// audienceKeyManager returns the key manager holding the signing keys for
+// audiences.
+func (km *KeyManager) audienceKeyManager(audiences []string) (*KeyManager, error) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	signer := km
+	for i, audience := range audiences {
+		keys, ok := km.audiences[audience]
+		if !ok {
+			keys = km
+		}
+		if i > 0 && keys != signer {
+			return nil, fmt.Errorf("audiences %s are signed with different keys", strings.Join(audiences, ", "))
+		}
+		signer = keys
+	}
+	return signer, nil
+}
+
+// audienceKey returns the audience key with the given kid. The caller must
+// hold km.mu.
+func (km *KeyManager) audienceKey(keyID string) (*KeyPair, bool) {
+	for _, audienceKeys := range km.audiences {
+		audienceKeys.mu.RLock()
+		key, ok := audienceKeys.keys[keyID]
+		audienceKeys.mu.RUnlock()
+		if ok {
+			return key, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,58 @@
+package auth
+
+import "github.com/golang-jwt/jwt/v5"
+
+// ClaimNames are the claim keys access tokens carry roles, scopes and the
+// tenant under. Renaming them lets tokens mimic another IdP's claim layout,
+// e.g. roles under "groups" or "wids".
+type ClaimNames struct {
+	Roles  string
+	Scopes string
+	Tenant string
+}
+
+// DefaultClaimNames are the claim keys used unless EnableClaimNames says
+// otherwise.
+var DefaultClaimNames = ClaimNames{Roles: "roles", Scopes: "scp", Tenant: "tid"}
+
+// EnableClaimNames makes the generator emit roles, scopes and the tenant
+// under names instead of DefaultClaimNames.
+func (tg *TokenGenerator) EnableClaimNames(names ClaimNames) {
+	tg.claimNames = names
+	tg.claimNamesSet = true
+}
+
+// EnableClaimNames makes the validator read roles, scopes and the tenant
+// from names instead of DefaultClaimNames. It should match the generator's.
+func (tv *TokenValidator) EnableClaimNames(names ClaimNames) {
+	tv.claimNames = names
+	tv.claimNamesSet = true
+}
+
+// Roles returns the roles in a validated token's roles claim, or in its
+// compressed roles claim (see EnableRoleCompression).
+func (tv *TokenValidator) Roles(claims jwt.MapClaims) []string {
+	if compressed, ok := claims[tv.claimNames.Roles+CompressedClaimSuffix].(string); ok {
+		roles, err := DecompressRoles(compressed)
+		if err != nil {
+			return nil
+		}
+		return roles
+	}
+	return stringsClaim(claims[tv.claimNames.Roles])
+}
+
+// stringsClaim returns the strings of an array claim. Other values yield nil.
+func stringsClaim(claim interface{}) []string {
+	values, ok := claim.([]interface{})
+	if !ok {
+		return nil
+	}
+	var result []string
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
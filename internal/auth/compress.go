@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// CompressedClaimSuffix is appended to the roles claim name for the claim
+// that carries compressed roles, e.g. "roles_z".
+const CompressedClaimSuffix = "_z"
+
+// maxDecompressedRoles bounds the size of decompressed roles, so a small
+// claim cannot expand without limit.
+const maxDecompressedRoles = 1 << 20
+
+// EnableRoleCompression makes the generator replace the roles claim of
+// tokens with more than threshold roles by a compressed claim (see
+// CompressRoles), named after the roles claim with CompressedClaimSuffix.
+func (tg *TokenGenerator) EnableRoleCompression(threshold int) {
+	tg.roleCompressionThreshold = threshold
+}
+
+// CompressRoles encodes roles as the base64url (unpadded) encoding of the
+// raw DEFLATE (RFC 1951) compression of their JSON array.
+func CompressRoles(roles []string) (string, error) {
+	data, err := json.Marshal(roles)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecompressRoles reverses CompressRoles.
+func DecompressRoles(claim string) ([]string, error) {
+	compressed, err := base64.RawURLEncoding.DecodeString(claim)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed roles encoding: %w", err)
+	}
+
+	r := flate.NewReader(bytes.NewReader(compressed))
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, maxDecompressedRoles+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed roles: %w", err)
+	}
+	if len(data) > maxDecompressedRoles {
+		return nil, fmt.Errorf("compressed roles exceed %d bytes", maxDecompressedRoles)
+	}
+
+	var roles []string
+	if err := json.Unmarshal(data, &roles); err != nil {
+		return nil, fmt.Errorf("invalid compressed roles: %w", err)
+	}
+	return roles, nil
+}
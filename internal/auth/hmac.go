@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"session-service/internal/models"
+)
+
+// AlgHS256 signs tokens for tenants configured with a shared secret. HMAC
+// secrets are never published, so these tokens can only be verified by this
+// service and by the tenant holding the secret.
+const AlgHS256 = "HS256"
+
+// HMACSecretLength is the size in bytes of generated tenant signing secrets.
+const HMACSecretLength = 32
+
+// SecretCipher encrypts tenant signing secrets at rest with AES-256-GCM.
+// Unlike client secrets they cannot be hashed: signing needs the raw value.
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+// NewSecretCipher creates a cipher from a 32-byte key.
+func NewSecretCipher(key []byte) (*SecretCipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secret key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return &SecretCipher{aead: aead}, nil
+}
+
+// Encrypt seals secret, prefixing the random nonce to the ciphertext.
+func (c *SecretCipher) Encrypt(secret []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, secret, nil), nil
+}
+
+// Decrypt opens a value produced by Encrypt.
+func (c *SecretCipher) Decrypt(encrypted []byte) ([]byte, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(encrypted) < nonceSize {
+		return nil, fmt.Errorf("encrypted secret is too short")
+	}
+	secret, err := c.aead.Open(nil, encrypted[:nonceSize], encrypted[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return secret, nil
+}
+
+// GenerateHMACSecret returns a new random tenant signing secret.
+func GenerateHMACSecret() ([]byte, error) {
+	secret := make([]byte, HMACSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return secret, nil
+}
+
+// tenantHMACSecret returns the decrypted signing secret of an HS256 tenant,
+// or nil when the tenant signs with the published keys.
+func tenantHMACSecret(c *SecretCipher, tenant *models.Tenant) ([]byte, error) {
+	if tenant == nil || len(tenant.EncryptedHMACSecret) == 0 {
+		return nil, nil
+	}
+	if c == nil {
+		return nil, fmt.Errorf("tenant %s signs with %s but no secret key is configured", tenant.ID, AlgHS256)
+	}
+	return c.Decrypt(tenant.EncryptedHMACSecret)
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"fmt"
+	"session-service/internal/models"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// GenerateIDToken generates an OpenID Connect ID token for subject, issued
+// to clientID, that front-ends can read instead of calling userinfo. Its
+// claims are kept minimal and never include PII: only sub, the tenant,
+// auth_time and, when given, nonce besides iss, aud, iat and exp. It is
+// signed with the client's current default key (see AddAudienceKeys),
+// typed IDTokenType so that it is never accepted as an access token, and
+// lives as long as access tokens.
+func (tg *TokenGenerator) GenerateIDToken(subject *models.TokenSubject, clientID, nonce string) (string, error) {
+	key, err := tg.keyManager.GetSigningKeyForAudiences("", []string{clientID})
+	if err != nil {
+		return "", fmt.Errorf("failed to get signing key: %w", err)
+	}
+	method := jwt.GetSigningMethod(key.Algorithm)
+	if method == nil {
+		return "", fmt.Errorf("unsupported signing algorithm: %s", key.Algorithm)
+	}
+
+	// The provision_user and client_credentials grants authenticate the
+	// user with this request
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":       tg.issuer,
+		"sub":       subject.UserID,
+		"aud":       clientID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(tg.accessTokenExpiry).Unix(),
+		"auth_time": now.Unix(),
+	}
+	claims[tg.claimNames.Tenant] = subject.TenantID
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["typ"] = IDTokenType
+	token.Header["kid"] = key.KeyID
+
+	tokenString, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign ID token: %w", err)
+	}
+	return tokenString, nil
+}
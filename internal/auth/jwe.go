@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+)
+
+// Algorithms used for encrypted access tokens.
+const (
+	TokenKeyEncryption     = jwa.RSA_OAEP_256
+	TokenContentEncryption = jwa.A256GCM
+)
+
+// EncryptToken wraps a signed JWT in a JWE addressed to the resource server
+// owning publicKeyPEM (an RSA public key), producing a nested JWT. Only that
+// resource server can decrypt it and then verify the inner signature against
+// our JWKS as usual.
+func EncryptToken(signedToken, publicKeyPEM string) (string, error) {
+	publicKey, err := parseRSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse encryption key: %w", err)
+	}
+
+	headers := jwe.NewHeaders()
+	if err := headers.Set(jwe.ContentTypeKey, "JWT"); err != nil {
+		return "", fmt.Errorf("failed to set JWE headers: %w", err)
+	}
+
+	encrypted, err := jwe.Encrypt([]byte(signedToken),
+		jwe.WithKey(TokenKeyEncryption, publicKey),
+		jwe.WithContentEncryption(TokenContentEncryption),
+		jwe.WithProtectedHeaders(headers),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt token: %w", err)
+	}
+
+	return string(encrypted), nil
+}
@@ -1,24 +1,38 @@
 package auth
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lestrrat-go/jwx/v2/jwk"
 )
 
+// Supported signing algorithms.
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
 // KeyPair represents a single signing key and its metadata.
 type KeyPair struct {
 	KeyID      string
-	PrivateKey *rsa.PrivateKey
-	PublicKey  *rsa.PublicKey
+	Algorithm  string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
 	CreatedAt  time.Time
 	ExpiresAt  time.Time
 	IsActive   bool
@@ -26,23 +40,56 @@ type KeyPair struct {
 
 // KeyManager manages JWT keys, rotation, and JWKS.
 // It is designed to support multiple active keys (current + previous) like Azure AD / Hydra.
+// It can hold a key set per signing algorithm, each with its own current key,
+// so tokens can be issued with different algorithms side by side.
 type KeyManager struct {
-	mu           sync.RWMutex
-	keys         map[string]*KeyPair
-	currentKeyID string
+	mu            sync.RWMutex
+	keys          map[string]*KeyPair
+	currentKeyIDs map[string]string // algorithm -> kid of its current signing key
+	nextKeyIDs    map[string]string // algorithm -> kid of its pre-rolled next key
+	defaultAlg    string
+
+	// maxAdvertised caps the keys published per algorithm; 0 is unlimited
+	maxAdvertised int
+
+	// rotating is set for the duration of RotateKeys and PromoteStandby
+	rotating atomic.Bool
+
+	// standby's keys are published in JWKS until PromoteStandby makes them
+	// the signing keys; set by SetStandby
+	standby *KeyManager
+
+	// audiences holds the keys of audiences signed with their own keys; set
+	// by AddAudienceKeys. They are only changed while km.mu is held.
+	audiences map[string]*KeyManager
+
+	// store persists the keys, encrypted with keyCipher; set by
+	// EnableKeyPersistence. persisted holds the expiry last stored for each
+	// kid and, like the store calls, is guarded by persistMu.
+	store     KeyStore
+	keyCipher *SecretCipher
+	persisted map[string]time.Time
+	persistMu sync.Mutex
 }
 
-// NewKeyManager creates a new key manager from an initial PEM-encoded key pair.
-// Additional keys may be generated at runtime for rotation.
+// NewKeyManager creates a new key manager from an initial PEM-encoded RSA
+// key pair. Additional keys may be generated at runtime for rotation.
 func NewKeyManager(privateKeyPEM, publicKeyPEM string) (*KeyManager, error) {
+	return NewKeyManagerWithAlgorithm(AlgRS256, privateKeyPEM, publicKeyPEM)
+}
+
+// NewKeyManagerWithAlgorithm creates a new key manager whose default signing
+// algorithm is alg (RS256, ES256 or EdDSA), from an initial PEM-encoded key
+// pair of the matching type. Keys generated on rotation use the same algorithm.
+func NewKeyManagerWithAlgorithm(alg, privateKeyPEM, publicKeyPEM string) (*KeyManager, error) {
 	// Parse private key
-	privateKey, err := parseRSAPrivateKey(privateKeyPEM)
+	privateKey, err := parsePrivateKey(alg, privateKeyPEM)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
 	// Parse public key
-	publicKey, err := parseRSAPublicKey(publicKeyPEM)
+	publicKey, err := parsePublicKey(alg, publicKeyPEM)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse public key: %w", err)
 	}
@@ -52,10 +99,12 @@ func NewKeyManager(privateKeyPEM, publicKeyPEM string) (*KeyManager, error) {
 
 	initialKey := &KeyPair{
 		KeyID:      keyID,
+		Algorithm:  alg,
 		PrivateKey: privateKey,
 		PublicKey:  publicKey,
 		CreatedAt:  now,
-		// ExpiresAt is managed by rotation logic; zero means no explicit expiry yet.
+		// ExpiresAt stays zero while this is the current key; like any
+		// other key, it gets a grace expiry when RotateKeys replaces it.
 		IsActive: true,
 	}
 
@@ -63,51 +112,180 @@ func NewKeyManager(privateKeyPEM, publicKeyPEM string) (*KeyManager, error) {
 		keys: map[string]*KeyPair{
 			keyID: initialKey,
 		},
-		currentKeyID: keyID,
+		currentKeyIDs: map[string]string{alg: keyID},
+		nextKeyIDs:    make(map[string]string),
+		defaultAlg:    alg,
 	}, nil
 }
 
-// GetPrivateKey returns the current private key used for signing.
-func (km *KeyManager) GetPrivateKey() *rsa.PrivateKey {
+// AddAlgorithm generates a signing key for an additional algorithm so tokens
+// can also be issued with it. The default algorithm is unchanged.
+func (km *KeyManager) AddAlgorithm(alg string) error {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	if _, ok := km.currentKeyIDs[alg]; ok {
+		return nil
+	}
+
+	key, err := generateKeyPair(alg)
+	if err != nil {
+		return err
+	}
+
+	km.keys[key.KeyID] = key
+	km.currentKeyIDs[alg] = key.KeyID
+
+	for _, audienceKeys := range km.audiences {
+		if err := audienceKeys.AddAlgorithm(alg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Algorithms returns the signing algorithms the key manager can issue tokens with.
+func (km *KeyManager) Algorithms() []string {
 	km.mu.RLock()
 	defer km.mu.RUnlock()
 
-	if key, ok := km.keys[km.currentKeyID]; ok && key.IsActive {
-		return key.PrivateKey
+	algs := make([]string, 0, len(km.currentKeyIDs))
+	for alg := range km.currentKeyIDs {
+		algs = append(algs, alg)
 	}
-	return nil
+	sort.Strings(algs)
+	return algs
+}
+
+// DefaultAlgorithm returns the algorithm tokens, including ID tokens, are
+// signed with unless a client asks for another.
+func (km *KeyManager) DefaultAlgorithm() string {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	return km.defaultAlg
 }
 
-// GetCurrentKeyID returns the kid of the current signing key.
+// GetSigningKey returns the current signing key for alg, or for the default
+// algorithm when alg is empty.
+func (km *KeyManager) GetSigningKey(alg string) (*KeyPair, error) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	if alg == "" {
+		alg = km.defaultAlg
+	}
+	keyID, ok := km.currentKeyIDs[alg]
+	if !ok {
+		return nil, fmt.Errorf("no signing key for algorithm %s", alg)
+	}
+	key, ok := km.keys[keyID]
+	if !ok || !key.IsActive {
+		return nil, fmt.Errorf("signing key for algorithm %s is inactive", alg)
+	}
+	return key, nil
+}
+
+// GetPrivateKey returns the current private key used for signing with the default algorithm.
+func (km *KeyManager) GetPrivateKey() crypto.Signer {
+	key, err := km.GetSigningKey("")
+	if err != nil {
+		return nil
+	}
+	return key.PrivateKey
+}
+
+// GetCurrentKeyID returns the kid of the current signing key for the default algorithm.
 func (km *KeyManager) GetCurrentKeyID() string {
 	km.mu.RLock()
 	defer km.mu.RUnlock()
-	return km.currentKeyID
+	return km.currentKeyIDs[km.defaultAlg]
 }
 
 // GetPublicKeyByID returns the public key for a given kid, if present and active.
-func (km *KeyManager) GetPublicKeyByID(keyID string) (*rsa.PublicKey, error) {
+func (km *KeyManager) GetPublicKeyByID(keyID string) (crypto.PublicKey, error) {
+	key, err := km.GetVerificationKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return key.PublicKey, nil
+}
+
+// GetVerificationKey returns the key pair for a given kid, if present, active
+// and not expired. Callers must check the token's alg against key.Algorithm.
+func (km *KeyManager) GetVerificationKey(keyID string) (*KeyPair, error) {
 	km.mu.RLock()
 	defer km.mu.RUnlock()
 
 	key, ok := km.keys[keyID]
+	if !ok {
+		key, ok = km.audienceKey(keyID)
+	}
 	if !ok || !key.IsActive {
 		return nil, fmt.Errorf("key not found or inactive: %s", keyID)
 	}
 	if !key.ExpiresAt.IsZero() && key.ExpiresAt.Before(time.Now()) {
 		return nil, fmt.Errorf("key expired: %s", keyID)
 	}
-	return key.PublicKey, nil
+	return key, nil
+}
+
+// KeyStatus describes the lifecycle state of a signing key.
+type KeyStatus struct {
+	KeyID string
+	// ExpiresAt is set once the key has been rotated out; zero for the current key.
+	ExpiresAt time.Time
+	// InGrace reports whether the key has been rotated out but is still
+	// accepted for verification until ExpiresAt.
+	InGrace bool
+}
+
+// GetKeyStatus returns the lifecycle state of the key with the given kid.
+func (km *KeyManager) GetKeyStatus(keyID string) (*KeyStatus, bool) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	key, ok := km.keys[keyID]
+	if !ok {
+		key, ok = km.audienceKey(keyID)
+	}
+	if !ok || !key.IsActive {
+		return nil, false
+	}
+
+	return &KeyStatus{
+		KeyID:     key.KeyID,
+		ExpiresAt: key.ExpiresAt,
+		InGrace:   !key.ExpiresAt.IsZero() && key.ExpiresAt.After(time.Now()),
+	}, true
 }
 
 // GetJWKSet returns the JWK set for JWKS endpoint containing all active keys.
 func (km *KeyManager) GetJWKSet() jwk.Set {
+	return km.GetJWKSetFiltered("", "")
+}
+
+// PublicKeySet returns GetJWKSetForAudience(use, alg, audience). The keys
+// are held in memory, so it does not fail; it lets the JWKS endpoint treat
+// the key manager like any other key source.
+func (km *KeyManager) PublicKeySet(use, alg, audience string) (jwk.Set, error) {
+	return km.GetJWKSetForAudience(use, alg, audience), nil
+}
+
+// GetJWKSetFiltered returns the active public keys whose use and alg match the
+// given values. An empty use or alg matches every key.
+func (km *KeyManager) GetJWKSetFiltered(use, alg string) jwk.Set {
 	km.mu.RLock()
 	defer km.mu.RUnlock()
 
 	keySet := jwk.NewSet()
 	now := time.Now()
 
+	// Every key this manager holds is a signing key
+	if use != "" && use != "sig" {
+		return keySet
+	}
+
+	byAlg := make(map[string][]*KeyPair)
 	for _, kp := range km.keys {
 		if !kp.IsActive {
 			continue
@@ -115,56 +293,196 @@ func (km *KeyManager) GetJWKSet() jwk.Set {
 		if !kp.ExpiresAt.IsZero() && kp.ExpiresAt.Before(now) {
 			continue
 		}
-
-		jwkKey, err := jwk.FromRaw(kp.PublicKey)
-		if err != nil {
+		if alg != "" && kp.Algorithm != alg {
 			continue
 		}
-		_ = jwkKey.Set(jwk.KeyIDKey, kp.KeyID)
-		_ = jwkKey.Set(jwk.AlgorithmKey, "RS256")
-		_ = jwkKey.Set(jwk.KeyUsageKey, "sig")
+		byAlg[kp.Algorithm] = append(byAlg[kp.Algorithm], kp)
+	}
+
+	for keyAlg, keys := range byAlg {
+		for _, kp := range km.advertisedKeys(keyAlg, keys) {
+			jwkKey, err := jwk.FromRaw(kp.PublicKey)
+			if err != nil {
+				continue
+			}
+			_ = jwkKey.Set(jwk.KeyIDKey, kp.KeyID)
+			_ = jwkKey.Set(jwk.AlgorithmKey, kp.Algorithm)
+			_ = jwkKey.Set(jwk.KeyUsageKey, "sig")
+
+			_ = keySet.AddKey(jwkKey)
+		}
+	}
 
-		_ = keySet.AddKey(jwkKey)
+	// Standby keys are published ahead of promotion, so verifiers already
+	// have them by the time anything is signed with them
+	if km.standby != nil {
+		standbySet := km.standby.GetJWKSetFiltered(use, alg)
+		for i := 0; i < standbySet.Len(); i++ {
+			if key, ok := standbySet.Key(i); ok {
+				_ = keySet.AddKey(key)
+			}
+		}
 	}
 
 	return keySet
 }
 
-// RotateKeys generates a new key pair and marks the old one for graceful deactivation.
-// gracePeriod defines how long the old key remains valid for verification.
-func (km *KeyManager) RotateKeys(gracePeriod time.Duration) error {
+// SetMaxAdvertisedKeys caps the keys GetJWKSet publishes per algorithm at
+// n, not counting the pre-rolled next key, which is always published; 0 is
+// unlimited. Keys left out are still accepted for verification until they
+// expire.
+func (km *KeyManager) SetMaxAdvertisedKeys(n int) {
 	km.mu.Lock()
 	defer km.mu.Unlock()
+	km.maxAdvertised = n
 
-	// Generate new key pair
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
-	if err != nil {
-		return fmt.Errorf("failed to generate new RSA key: %w", err)
+	for _, audienceKeys := range km.audiences {
+		audienceKeys.SetMaxAdvertisedKeys(n)
 	}
-	publicKey := &privateKey.PublicKey
+}
 
-	keyID := uuid.New().String()
-	now := time.Now()
+// advertisedKeys orders alg's publishable keys for JWKS - the current key,
+// the most recent previous key, the pre-rolled next key, then older previous
+// keys newest first - and applies the advertisement cap. The current and most
+// recent previous keys are always kept, and the pre-rolled key is not counted
+// against the cap: verifiers must have it before it signs anything. The
+// caller must hold km.mu.
+func (km *KeyManager) advertisedKeys(alg string, keys []*KeyPair) []*KeyPair {
+	var current, next *KeyPair
+	var previous []*KeyPair
+	for _, kp := range keys {
+		switch kp.KeyID {
+		case km.currentKeyIDs[alg]:
+			current = kp
+		case km.nextKeyIDs[alg]:
+			next = kp
+		default:
+			previous = append(previous, kp)
+		}
+	}
+	sort.Slice(previous, func(i, j int) bool {
+		return previous[i].CreatedAt.After(previous[j].CreatedAt)
+	})
 
-	newKey := &KeyPair{
-		KeyID:      keyID,
-		PrivateKey: privateKey,
-		PublicKey:  publicKey,
-		CreatedAt:  now,
-		IsActive:   true,
+	signing := make([]*KeyPair, 0, len(keys))
+	if current != nil {
+		signing = append(signing, current)
+	}
+	signing = append(signing, previous...)
+	if km.maxAdvertised > 0 {
+		signing = signing[:min(len(signing), max(km.maxAdvertised, 2))]
 	}
 
-	// Mark previous current key to expire after gracePeriod
-	if current, ok := km.keys[km.currentKeyID]; ok {
-		current.ExpiresAt = now.Add(gracePeriod)
+	ordered := make([]*KeyPair, 0, len(signing)+1)
+	ordered = append(ordered, signing[:min(len(signing), 2)]...)
+	if next != nil {
+		ordered = append(ordered, next)
 	}
+	return append(ordered, signing[min(len(signing), 2):]...)
+}
 
-	km.keys[keyID] = newKey
-	km.currentKeyID = keyID
+// PrerollKeys generates the next key pair for every signing algorithm ahead
+// of RotateKeys. Pre-rolled keys are published in JWKS straight away, so
+// verifiers can fetch them before any token is signed with them, but the
+// current keys keep signing until the rotation. Calling it again before the
+// rotation is a no-op.
+func (km *KeyManager) PrerollKeys() error {
+	km.mu.Lock()
+	defer km.mu.Unlock()
 
+	for alg := range km.currentKeyIDs {
+		if _, ok := km.nextKeyIDs[alg]; ok {
+			continue
+		}
+		nextKey, err := generateKeyPair(alg)
+		if err != nil {
+			return err
+		}
+		km.keys[nextKey.KeyID] = nextKey
+		km.nextKeyIDs[alg] = nextKey.KeyID
+	}
+
+	for _, audienceKeys := range km.audiences {
+		if err := audienceKeys.PrerollKeys(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// RotateKeys makes a new key pair the signing key for every signing
+// algorithm, using the pre-rolled key if there is one, and marks the old
+// ones for graceful deactivation.
+// gracePeriod defines how long the old keys remain valid for verification.
+func (km *KeyManager) RotateKeys(gracePeriod time.Duration) error {
+	km.rotating.Store(true)
+	defer km.rotating.Store(false)
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	now := time.Now()
+	for alg, currentKeyID := range km.currentKeyIDs {
+		newKey, ok := km.keys[km.nextKeyIDs[alg]]
+		if !ok {
+			var err error
+			if newKey, err = generateKeyPair(alg); err != nil {
+				return err
+			}
+		}
+		delete(km.nextKeyIDs, alg)
+
+		// Mark previous current key to expire after gracePeriod
+		if current, ok := km.keys[currentKeyID]; ok {
+			current.ExpiresAt = now.Add(gracePeriod)
+		}
+
+		km.keys[newKey.KeyID] = newKey
+		km.currentKeyIDs[alg] = newKey.KeyID
+	}
+
+	for _, audienceKeys := range km.audiences {
+		if err := audienceKeys.RotateKeys(gracePeriod); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rotating reports whether a key rotation is in progress.
+func (km *KeyManager) Rotating() bool {
+	return km.rotating.Load()
+}
+
+// generateKeyPair creates a fresh, active key pair for alg.
+func generateKeyPair(alg string) (*KeyPair, error) {
+	var privateKey crypto.Signer
+	var err error
+	switch alg {
+	case AlgRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgES256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate new %s key: %w", alg, err)
+	}
+
+	return &KeyPair{
+		KeyID:      uuid.New().String(),
+		Algorithm:  alg,
+		PrivateKey: privateKey,
+		PublicKey:  privateKey.Public(),
+		CreatedAt:  time.Now(),
+		IsActive:   true,
+	}, nil
+}
+
 // CleanupExpiredKeys removes keys that are past their ExpiresAt.
 func (km *KeyManager) CleanupExpiredKeys() {
 	km.mu.Lock()
@@ -176,6 +494,103 @@ func (km *KeyManager) CleanupExpiredKeys() {
 			delete(km.keys, id)
 		}
 	}
+
+	for _, audienceKeys := range km.audiences {
+		audienceKeys.CleanupExpiredKeys()
+	}
+}
+
+// parsePrivateKey parses a PEM-encoded private key for signing with alg.
+func parsePrivateKey(alg, pemData string) (crypto.Signer, error) {
+	switch alg {
+	case AlgRS256:
+		return parseRSAPrivateKey(pemData)
+	case AlgES256:
+		return parseECPrivateKey(pemData)
+	case AlgEdDSA:
+		return parseEd25519PrivateKey(pemData)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", alg)
+	}
+}
+
+// parsePublicKey parses a PEM-encoded public key for verifying alg signatures.
+func parsePublicKey(alg, pemData string) (crypto.PublicKey, error) {
+	if alg == AlgRS256 {
+		return parseRSAPublicKey(pemData)
+	}
+
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch alg {
+	case AlgES256:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return nil, errors.New("key is not a P-256 EC public key")
+		}
+		return ecKey, nil
+	case AlgEdDSA:
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, errors.New("key is not an Ed25519 public key")
+		}
+		return edKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", alg)
+	}
+}
+
+// parseECPrivateKey parses a PEM-encoded P-256 EC private key in SEC 1 or
+// PKCS8 format.
+func parseECPrivateKey(pemData string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		// Try PKCS8 format
+		parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ecKey, ok := parsedKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("key is not an EC private key")
+		}
+		key = ecKey
+	}
+
+	if key.Curve != elliptic.P256() {
+		return nil, errors.New("EC private key is not on the P-256 curve")
+	}
+	return key, nil
+}
+
+// parseEd25519PrivateKey parses a PEM-encoded PKCS8 Ed25519 private key.
+func parseEd25519PrivateKey(pemData string) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := parsedKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("key is not an Ed25519 private key")
+	}
+	return edKey, nil
 }
 
 // parseRSAPrivateKey parses a PEM-encoded RSA private key.
@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"session-service/internal/models"
+	"sort"
+	"time"
+)
+
+// KeyStore persists signing keys so they survive restarts.
+// database.Repository implements it.
+type KeyStore interface {
+	// LoadSigningKeys returns the stored keys that have not expired, and
+	// any key with the given public key fingerprint, expired or not, so a
+	// configured key that was rotated out long ago is still recognised.
+	LoadSigningKeys(ctx context.Context, fingerprint string) ([]*models.SigningKey, error)
+	SaveSigningKey(ctx context.Context, key *models.SigningKey) error
+	MarkKeyExpired(ctx context.Context, keyID string, expiresAt time.Time) error
+}
+
+// KeyPersister is implemented by key rotators whose keys are stored;
+// RunRotationSchedule writes every pre-roll and rotation through it.
+type KeyPersister interface {
+	PersistKeys(ctx context.Context) error
+}
+
+// EnableKeyPersistence restores km's keys, and those of its audiences, from
+// store and from then on lets PersistKeys write new keys and expiries to it.
+// Private keys are stored encrypted with keyCipher. Call it after
+// AddAlgorithm and AddAudienceKeys and before any token is signed.
+//
+// For each algorithm with stored keys, the stored current and pre-rolled
+// keys replace the ones generated at startup, so kids stay stable across
+// restarts. The configured seed key keeps its stored kid; if it matches no
+// stored key, it was replaced in the configuration and becomes the current
+// key, with the stored one it replaces remaining valid for gracePeriod.
+// Stored keys of algorithms no longer configured are retired the same way.
+// A seed key that was stored and rotated out never signs again: if no
+// stored key took over from it, a new key is generated in its place.
+func (km *KeyManager) EnableKeyPersistence(ctx context.Context, store KeyStore, keyCipher *SecretCipher, gracePeriod time.Duration) error {
+	seed := km.keys[km.currentKeyIDs[km.defaultAlg]]
+	var seedFingerprint string
+	if seed != nil {
+		fingerprint, err := keyFingerprint(seed.PublicKey)
+		if err != nil {
+			return err
+		}
+		seedFingerprint = fingerprint
+	}
+
+	stored, err := store.LoadSigningKeys(ctx, seedFingerprint)
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	byAudience := make(map[string][]*KeyPair)
+	persisted := make(map[string]time.Time, len(stored))
+	for _, sk := range stored {
+		kp, err := decryptSigningKey(keyCipher, sk)
+		if err != nil {
+			return err
+		}
+		byAudience[sk.Audience] = append(byAudience[sk.Audience], kp)
+		persisted[sk.KeyID] = sk.ExpiresAt
+	}
+
+	km.persistMu.Lock()
+	km.mu.Lock()
+	km.store = store
+	km.keyCipher = keyCipher
+	km.persisted = persisted
+
+	now := time.Now()
+	err = km.restoreKeys(byAudience[""], seed, now, gracePeriod)
+	for audience, audienceKeys := range km.audiences {
+		if err != nil {
+			break
+		}
+		audienceKeys.mu.Lock()
+		err = audienceKeys.restoreKeys(byAudience[audience], nil, now, gracePeriod)
+		audienceKeys.mu.Unlock()
+	}
+	km.mu.Unlock()
+	km.persistMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return km.PersistKeys(ctx)
+}
+
+// restoreKeys adds the unexpired stored keys to km, letting them replace
+// the keys generated at startup as described in EnableKeyPersistence. seed
+// is the configured key, if km has one. The caller must hold km.mu.
+func (km *KeyManager) restoreKeys(stored []*KeyPair, seed *KeyPair, now time.Time, gracePeriod time.Duration) error {
+	// A seed key that is stored already, even if it has since expired, was
+	// not replaced in the configuration; the stored keys take over below.
+	// If it was stored with an expiry, it was rotated out
+	replacedSeed, retiredSeed := seed != nil, false
+	for _, kp := range stored {
+		if seed != nil && samePublicKey(kp.PublicKey, seed.PublicKey) {
+			replacedSeed = false
+			retiredSeed = retiredSeed || !kp.ExpiresAt.IsZero()
+		}
+	}
+
+	// Keys still in use have no expiry; of those, an algorithm's oldest is
+	// its current key and a newer one the pre-rolled next key
+	live := make(map[string][]*KeyPair)
+	for _, kp := range stored {
+		if !kp.ExpiresAt.IsZero() && !kp.ExpiresAt.After(now) {
+			continue
+		}
+		km.keys[kp.KeyID] = kp
+		if kp.ExpiresAt.IsZero() {
+			live[kp.Algorithm] = append(live[kp.Algorithm], kp)
+		}
+	}
+
+	for alg, keys := range live {
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		})
+
+		generated, configured := km.currentKeyIDs[alg]
+		if !configured || (replacedSeed && seed.Algorithm == alg) {
+			keys[0].ExpiresAt = now.Add(gracePeriod)
+			for _, next := range keys[1:] {
+				next.ExpiresAt = now
+			}
+			continue
+		}
+
+		if generated != "" && generated != keys[0].KeyID {
+			delete(km.keys, generated)
+		}
+		km.currentKeyIDs[alg] = keys[0].KeyID
+		if len(keys) > 1 {
+			km.nextKeyIDs[alg] = keys[1].KeyID
+		}
+		for _, stale := range keys[min(len(keys), 2):] {
+			stale.ExpiresAt = now
+		}
+	}
+
+	// With no stored key taking over from a retired seed key, the seed key
+	// would be current again; sign with a new key instead
+	if retiredSeed && km.currentKeyIDs[seed.Algorithm] == seed.KeyID {
+		fresh, err := generateKeyPair(seed.Algorithm)
+		if err != nil {
+			return fmt.Errorf("failed to replace retired signing key: %w", err)
+		}
+		delete(km.keys, seed.KeyID)
+		km.keys[fresh.KeyID] = fresh
+		km.currentKeyIDs[seed.Algorithm] = fresh.KeyID
+	}
+	return nil
+}
+
+// PersistKeys stores the keys km, and its audiences, have generated since
+// they were last persisted, and the expiries of keys rotated out since. It
+// is a no-op unless EnableKeyPersistence was called.
+func (km *KeyManager) PersistKeys(ctx context.Context) error {
+	km.persistMu.Lock()
+	defer km.persistMu.Unlock()
+
+	km.mu.RLock()
+	store, keyCipher := km.store, km.keyCipher
+	if store == nil {
+		km.mu.RUnlock()
+		return nil
+	}
+	snapshot := km.keySnapshot("")
+	for audience, audienceKeys := range km.audiences {
+		audienceKeys.mu.RLock()
+		snapshot = append(snapshot, audienceKeys.keySnapshot(audience)...)
+		audienceKeys.mu.RUnlock()
+	}
+	km.mu.RUnlock()
+
+	held := make(map[string]bool, len(snapshot))
+	for _, sk := range snapshot {
+		held[sk.key.KeyID] = true
+		expiresAt, ok := km.persisted[sk.key.KeyID]
+		switch {
+		case !ok:
+			record, err := encryptSigningKey(keyCipher, &sk.key, sk.audience)
+			if err != nil {
+				return err
+			}
+			if err := store.SaveSigningKey(ctx, record); err != nil {
+				return fmt.Errorf("failed to save signing key %s: %w", sk.key.KeyID, err)
+			}
+		case !expiresAt.Equal(sk.key.ExpiresAt):
+			if err := store.MarkKeyExpired(ctx, sk.key.KeyID, sk.key.ExpiresAt); err != nil {
+				return fmt.Errorf("failed to mark signing key %s expired: %w", sk.key.KeyID, err)
+			}
+		default:
+			continue
+		}
+		km.persisted[sk.key.KeyID] = sk.key.ExpiresAt
+	}
+
+	// Keys removed by CleanupExpiredKeys have expired in the store too
+	for keyID := range km.persisted {
+		if !held[keyID] {
+			delete(km.persisted, keyID)
+		}
+	}
+	return nil
+}
+
+// snapshotKey is a copy of a key, taken under its manager's lock, and the
+// audience it signs for.
+type snapshotKey struct {
+	key      KeyPair
+	audience string
+}
+
+// keySnapshot returns km's keys for PersistKeys. The caller must hold km.mu.
+func (km *KeyManager) keySnapshot(audience string) []snapshotKey {
+	snapshot := make([]snapshotKey, 0, len(km.keys))
+	for _, kp := range km.keys {
+		snapshot = append(snapshot, snapshotKey{key: *kp, audience: audience})
+	}
+	return snapshot
+}
+
+// encryptSigningKey returns kp as stored in signing_keys: its private key
+// PKCS8-encoded and encrypted with keyCipher.
+func encryptSigningKey(keyCipher *SecretCipher, kp *KeyPair, audience string) (*models.SigningKey, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(kp.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key %s: %w", kp.KeyID, err)
+	}
+	encrypted, err := keyCipher.Encrypt(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt signing key %s: %w", kp.KeyID, err)
+	}
+	fingerprint, err := keyFingerprint(kp.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SigningKey{
+		KeyID:               kp.KeyID,
+		Algorithm:           kp.Algorithm,
+		Audience:            audience,
+		Fingerprint:         fingerprint,
+		EncryptedPrivateKey: encrypted,
+		CreatedAt:           kp.CreatedAt,
+		ExpiresAt:           kp.ExpiresAt,
+	}, nil
+}
+
+// decryptSigningKey is the inverse of encryptSigningKey.
+func decryptSigningKey(keyCipher *SecretCipher, sk *models.SigningKey) (*KeyPair, error) {
+	der, err := keyCipher.Decrypt(sk.EncryptedPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt signing key %s: %w", sk.KeyID, err)
+	}
+	privateKey, err := parsePrivateKey(sk.Algorithm, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", sk.KeyID, err)
+	}
+	return &KeyPair{
+		KeyID:      sk.KeyID,
+		Algorithm:  sk.Algorithm,
+		PrivateKey: privateKey,
+		PublicKey:  privateKey.Public(),
+		CreatedAt:  sk.CreatedAt,
+		ExpiresAt:  sk.ExpiresAt,
+		IsActive:   true,
+	}, nil
+}
+
+// keyFingerprint returns the hex SHA-256 of publicKey's PKIX encoding,
+// which identifies a stored key without decrypting it.
+func keyFingerprint(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// samePublicKey reports whether a and b are the same public key.
+func samePublicKey(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
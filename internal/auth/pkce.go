@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// Bounds on the length of a PKCE code_verifier (RFC 7636 §4.1).
+const (
+	minCodeVerifierLength = 43
+	maxCodeVerifierLength = 128
+)
+
+// ErrInvalidCodeVerifier is returned for a code_verifier of the wrong length
+// or with characters outside the unreserved set; the token endpoint answers
+// it with invalid_grant.
+var ErrInvalidCodeVerifier = errors.New("invalid code_verifier")
+
+// ErrInvalidCodeChallenge is returned for a malformed code_challenge.
+var ErrInvalidCodeChallenge = errors.New("invalid code_challenge")
+
+// ValidateCodeVerifier checks verifier is 43 to 128 characters from
+// [A-Za-z0-9-._~], as RFC 7636 §4.1 requires. Anything shorter or drawn
+// from a smaller alphabet may not carry the 256 bits of entropy PKCE relies
+// on.
+func ValidateCodeVerifier(verifier string) error {
+	if len(verifier) < minCodeVerifierLength || len(verifier) > maxCodeVerifierLength {
+		return fmt.Errorf("%w: must be %d to %d characters, got %d", ErrInvalidCodeVerifier, minCodeVerifierLength, maxCodeVerifierLength, len(verifier))
+	}
+	if !unreserved(verifier) {
+		return fmt.Errorf("%w: may only contain A-Z, a-z, 0-9, '-', '.', '_' and '~'", ErrInvalidCodeVerifier)
+	}
+	return nil
+}
+
+// ValidateCodeChallenge checks challenge is well-formed for method: an
+// S256 challenge is the unpadded base64url of a SHA-256 digest, and a plain
+// challenge is itself a valid code_verifier.
+func ValidateCodeChallenge(challenge, method string) error {
+	switch method {
+	case "S256":
+		digest, err := base64.RawURLEncoding.DecodeString(challenge)
+		if err != nil || len(digest) != sha256.Size {
+			return fmt.Errorf("%w: not a base64url-encoded SHA-256 digest", ErrInvalidCodeChallenge)
+		}
+		return nil
+	case "plain":
+		if err := ValidateCodeVerifier(challenge); err != nil {
+			return fmt.Errorf("%w: not a valid plain challenge", ErrInvalidCodeChallenge)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported code_challenge_method %q", ErrInvalidCodeChallenge, method)
+	}
+}
+
+// unreserved reports whether s only contains RFC 3986 unreserved characters.
+func unreserved(s string) bool {
+	for _, c := range s {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
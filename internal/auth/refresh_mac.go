@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+// refreshMACSeparator joins a refresh token's random part and its MAC. It is
+// outside the base64url alphabet, so it cannot occur in either part.
+const refreshMACSeparator = "."
+
+// EnableRefreshTokenMAC makes the generator append an HMAC-SHA256 of each
+// refresh token's random part, keyed with key, so VerifyRefreshToken can
+// reject tampered or guessed tokens without a cache lookup.
+func (tg *TokenGenerator) EnableRefreshTokenMAC(key []byte) {
+	tg.refreshMACKey = key
+}
+
+// AcceptPreviousRefreshTokenMAC keeps refresh tokens signed with the
+// previous key valid until until, so the key can be rotated without ending
+// outstanding sessions. New tokens are always signed with the current key.
+func (tg *TokenGenerator) AcceptPreviousRefreshTokenMAC(key []byte, until time.Time) {
+	tg.previousRefreshMACKey = key
+	tg.previousRefreshMACUntil = until
+}
+
+// VerifyRefreshToken reports whether token carries a valid MAC under the
+// current key, or the previous key during its overlap. It always succeeds
+// while EnableRefreshTokenMAC has not been called.
+func (tg *TokenGenerator) VerifyRefreshToken(token string) bool {
+	if tg.refreshMACKey == nil {
+		return true
+	}
+	random, mac, ok := strings.Cut(token, refreshMACSeparator)
+	if !ok {
+		return false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(mac)
+	if err != nil {
+		return false
+	}
+	if hmac.Equal(got, refreshTokenMAC(tg.refreshMACKey, random)) {
+		return true
+	}
+	return tg.previousRefreshMACKey != nil && time.Now().Before(tg.previousRefreshMACUntil) &&
+		hmac.Equal(got, refreshTokenMAC(tg.previousRefreshMACKey, random))
+}
+
+// signRefreshToken appends the MAC of random when refresh token MACs are
+// enabled.
+func (tg *TokenGenerator) signRefreshToken(random string) string {
+	if tg.refreshMACKey == nil {
+		return random
+	}
+	return random + refreshMACSeparator + base64.RawURLEncoding.EncodeToString(refreshTokenMAC(tg.refreshMACKey, random))
+}
+
+func refreshTokenMAC(key []byte, random string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(random))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"session-service/internal/metrics"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// KeyRotator is the part of KeyManager driven by RunRotationSchedule.
+type KeyRotator interface {
+	PrerollKeys() error
+	RotateKeys(gracePeriod time.Duration) error
+	CleanupExpiredKeys()
+}
+
+// RotationSchedule says how often signing keys are rotated, how long
+// retired keys keep verifying, and how long before each rotation the next
+// keys are published. Preroll must be shorter than Interval.
+type RotationSchedule struct {
+	Interval    time.Duration
+	GracePeriod time.Duration
+	Preroll     time.Duration
+}
+
+// RunRotationSchedule rotates rotator's keys every schedule.Interval until
+// ctx is done. A panic in one run is recovered and counted, and the next
+// run goes ahead as scheduled. If rotator is a KeyPersister, the new keys
+// and the grace expiries of the retired ones are stored after each run.
+func RunRotationSchedule(ctx context.Context, rotator KeyRotator, schedule RotationSchedule, logger *zap.Logger) {
+	// With pre-rolling each interval is split in two: the next keys are
+	// published Preroll before they take over signing.
+	preroll := schedule.Preroll
+	if preroll >= schedule.Interval {
+		preroll = 0
+	}
+
+	for {
+		if !sleepContext(ctx, schedule.Interval-preroll) {
+			return
+		}
+		if preroll > 0 {
+			metrics.RunRecovered("key_preroll", logger, func() {
+				logger.Info("Pre-rolling signing keys", zap.Duration("preroll", preroll))
+				if err := rotator.PrerollKeys(); err != nil {
+					logger.Error("Failed to pre-roll keys", zap.Error(err))
+				}
+				persistKeys(ctx, rotator, logger)
+			})
+			if !sleepContext(ctx, preroll) {
+				return
+			}
+		}
+		metrics.RunRecovered("key_rotation", logger, func() {
+			logger.Info("Rotating signing keys", zap.Duration("interval", schedule.Interval), zap.Duration("grace_period", schedule.GracePeriod))
+			if err := rotator.RotateKeys(schedule.GracePeriod); err != nil {
+				logger.Error("Failed to rotate keys", zap.Error(err))
+			}
+			rotator.CleanupExpiredKeys()
+			persistKeys(ctx, rotator, logger)
+		})
+	}
+}
+
+// persistKeys stores rotator's keys if it is a KeyPersister. A failure is
+// logged; the keys stay in use, but a restart before the next successful
+// run loses them.
+func persistKeys(ctx context.Context, rotator KeyRotator, logger *zap.Logger) {
+	persister, ok := rotator.(KeyPersister)
+	if !ok {
+		return
+	}
+	if err := persister.PersistKeys(ctx); err != nil {
+		logger.Error("Failed to persist signing keys", zap.Error(err))
+	}
+}
+
+// sleepContext waits for d, reporting false if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ParseScope splits an OAuth2 scope parameter into its scopes, in request
+// order and without duplicates. Scopes are space-delimited (RFC 6749,
+// section 3.3); a non-empty altDelimiter, such as "," for legacy clients, is
+// accepted as a delimiter as well. Scopes stay case-sensitive.
+func ParseScope(scope, altDelimiter string) []string {
+	fields := strings.FieldsFunc(scope, func(r rune) bool {
+		return unicode.IsSpace(r) || (altDelimiter != "" && strings.ContainsRune(altDelimiter, r))
+	})
+
+	var scopes []string
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		scopes = append(scopes, field)
+	}
+	return scopes
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"session-service/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SoftwareStatementVerifier verifies software statements (RFC 7591 §2.3):
+// JWTs in which a trusted issuer asserts a client's metadata, so that
+// registration can take those fields from the statement rather than from
+// the client.
+type SoftwareStatementVerifier struct {
+	issuer    string
+	publicKey *rsa.PublicKey
+}
+
+// NewSoftwareStatementVerifier creates a verifier trusting statements from
+// issuer signed with the RSA key in publicKeyPEM.
+func NewSoftwareStatementVerifier(issuer, publicKeyPEM string) (*SoftwareStatementVerifier, error) {
+	if issuer == "" {
+		return nil, fmt.Errorf("software statement issuer is required")
+	}
+	publicKey, err := parseRSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse software statement key: %w", err)
+	}
+	return &SoftwareStatementVerifier{issuer: issuer, publicKey: publicKey}, nil
+}
+
+// Verify checks statement's RS256 signature, issuer and, when present, exp
+// and nbf, and returns the client metadata it asserts.
+func (v *SoftwareStatementVerifier) Verify(statement string) (*models.SoftwareStatement, error) {
+	token, err := jwt.Parse(statement, func(*jwt.Token) (interface{}, error) {
+		return v.publicKey, nil
+	}, jwt.WithValidMethods([]string{AlgRS256}), jwt.WithIssuer(v.issuer))
+	if err != nil {
+		return nil, fmt.Errorf("invalid software statement: %w", err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("invalid software statement claims")
+	}
+	softwareID, _ := claims["software_id"].(string)
+	clientName, _ := claims["client_name"].(string)
+	return &models.SoftwareStatement{
+		SoftwareID:   softwareID,
+		ClientName:   clientName,
+		RedirectURIs: stringsClaim(claims["redirect_uris"]),
+		GrantTypes:   stringsClaim(claims["grant_types"]),
+	}, nil
+}
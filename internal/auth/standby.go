@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrNoStandby is returned by PromoteStandby when no standby key manager is
+// registered.
+var ErrNoStandby = errors.New("no standby key manager registered")
+
+// ErrStandbyIncomplete is returned by PromoteStandby when the standby key
+// manager lacks a key for one of the algorithms being signed with.
+var ErrStandbyIncomplete = errors.New("standby key manager does not cover every signing algorithm")
+
+// SetStandby registers standby, a key manager (e.g. one backed by a new HSM)
+// whose keys are published in JWKS alongside km's but not used for signing
+// until PromoteStandby. Each of standby's signing keys first signs and
+// verifies a probe token, so a broken key source is rejected before any
+// verifier fetches its keys.
+func (km *KeyManager) SetStandby(standby *KeyManager) error {
+	if standby == km {
+		return errors.New("a key manager cannot be its own standby")
+	}
+	if err := standby.probe(); err != nil {
+		return err
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	km.standby = standby
+	return nil
+}
+
+// HasStandby reports whether a standby key manager is registered.
+func (km *KeyManager) HasStandby() bool {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	return km.standby != nil
+}
+
+// PromoteStandby makes the standby key manager's current keys km's signing
+// keys, and its default algorithm km's default, then unregisters it. As with
+// RotateKeys, the keys they replace remain valid for verification for
+// gracePeriod. The standby must have a key for every algorithm km signs
+// with, so that no client pinned to one of them is left without a key;
+// otherwise nothing changes and ErrStandbyIncomplete is returned.
+//
+// Promotion only changes km, in this process; other replicas keep signing
+// with their own keys until they are promoted too.
+func (km *KeyManager) PromoteStandby(gracePeriod time.Duration) error {
+	km.rotating.Store(true)
+	defer km.rotating.Store(false)
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	standby := km.standby
+	if standby == nil {
+		return ErrNoStandby
+	}
+	standby.mu.RLock()
+	defer standby.mu.RUnlock()
+
+	for alg := range km.currentKeyIDs {
+		if _, ok := standby.currentKeyIDs[alg]; !ok {
+			return fmt.Errorf("%w: it has no %s key", ErrStandbyIncomplete, alg)
+		}
+	}
+
+	now := time.Now()
+	for alg := range standby.currentKeyIDs {
+		if current, ok := km.keys[km.currentKeyIDs[alg]]; ok {
+			current.ExpiresAt = now.Add(gracePeriod)
+		}
+		// A pre-rolled key never signed anything, so it goes straight away
+		if next, ok := km.keys[km.nextKeyIDs[alg]]; ok {
+			next.ExpiresAt = now
+		}
+		delete(km.currentKeyIDs, alg)
+		delete(km.nextKeyIDs, alg)
+	}
+
+	for keyID, kp := range standby.keys {
+		if !kp.IsActive || (!kp.ExpiresAt.IsZero() && kp.ExpiresAt.Before(now)) {
+			continue
+		}
+		key := *kp
+		km.keys[keyID] = &key
+	}
+	for alg, keyID := range standby.currentKeyIDs {
+		km.currentKeyIDs[alg] = keyID
+	}
+	for alg, keyID := range standby.nextKeyIDs {
+		km.nextKeyIDs[alg] = keyID
+	}
+	km.defaultAlg = standby.defaultAlg
+	km.standby = nil
+
+	return nil
+}
+
+// probe signs a token with each of km's signing keys and verifies it with the
+// key's public key.
+func (km *KeyManager) probe() error {
+	for _, alg := range km.Algorithms() {
+		key, err := km.GetSigningKey(alg)
+		if err != nil {
+			return err
+		}
+		method := jwt.GetSigningMethod(alg)
+		if method == nil {
+			return fmt.Errorf("unsupported signing algorithm: %s", alg)
+		}
+
+		signed, err := jwt.NewWithClaims(method, jwt.MapClaims{"iat": time.Now().Unix()}).SignedString(key.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("%s key %s cannot sign: %w", alg, key.KeyID, err)
+		}
+		_, err = jwt.Parse(signed, func(*jwt.Token) (interface{}, error) {
+			return key.PublicKey, nil
+		}, jwt.WithValidMethods([]string{alg}))
+		if err != nil {
+			return fmt.Errorf("%s key %s does not verify its own signature: %w", alg, key.KeyID, err)
+		}
+	}
+	return nil
+}
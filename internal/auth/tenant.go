@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// CanonicalTenantID returns the canonical form of a tenant id. Tenant ids are
+// case-insensitive: they are stored lower-case, and path values and token
+// claims are trimmed and lower-cased before any comparison, so "Tenant-A"
+// and "tenant-a" are the same tenant.
+func CanonicalTenantID(tenantID string) string {
+	return strings.ToLower(strings.TrimSpace(tenantID))
+}
+
+// EnableTenantIssuers makes the validator also accept tokens whose iss is
+// "<issuer>/<tenant_id>", as minted by per-tenant issuers. Such tokens belong
+// to the tenant in their iss, and a tid claim, if present, must agree.
+func (tv *TokenValidator) EnableTenantIssuers() {
+	tv.tenantIssuers = true
+}
+
+// TenantID returns the tenant a validated token belongs to: its tenant claim
+// (tid unless renamed by EnableClaimNames) or, for tokens from a per-tenant
+// issuer without one, the tenant in their iss, in canonical form. It returns
+// "" when the token names no tenant. Tenant checks against the request path
+// should always go through here rather than read tid directly.
+func (tv *TokenValidator) TenantID(claims jwt.MapClaims) string {
+	if tid, ok := claims[tv.claimNames.Tenant].(string); ok && tid != "" {
+		return CanonicalTenantID(tid)
+	}
+	iss, _ := claims["iss"].(string)
+	return CanonicalTenantID(tv.issuerTenant(iss))
+}
+
+// validIssuer reports whether iss is the validator's issuer, or one of its
+// per-tenant issuers when those are enabled.
+func (tv *TokenValidator) validIssuer(iss string) bool {
+	return iss == tv.issuer || tv.issuerTenant(iss) != ""
+}
+
+// issuerTenant returns the tenant of a per-tenant issuer, or "" if iss is not
+// one (or per-tenant issuers are disabled).
+func (tv *TokenValidator) issuerTenant(iss string) string {
+	if !tv.tenantIssuers {
+		return ""
+	}
+	tenantID, ok := strings.CutPrefix(iss, strings.TrimSuffix(tv.issuer, "/")+"/")
+	if !ok || tenantID == "" || strings.Contains(tenantID, "/") {
+		return ""
+	}
+	return tenantID
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TenantBootstrapVerifier verifies tenant bootstrap tokens: short-lived JWTs
+// with which provisioning automation authorizes the creation of the tenant
+// named in their tenant_id claim on first use.
+type TenantBootstrapVerifier struct {
+	publicKey *rsa.PublicKey
+}
+
+// NewTenantBootstrapVerifier creates a verifier trusting bootstrap tokens
+// signed with the RSA key in publicKeyPEM.
+func NewTenantBootstrapVerifier(publicKeyPEM string) (*TenantBootstrapVerifier, error) {
+	publicKey, err := parseRSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tenant bootstrap key: %w", err)
+	}
+	return &TenantBootstrapVerifier{publicKey: publicKey}, nil
+}
+
+// Verify checks token's RS256 signature and expiry, which is required, and
+// that it was issued for tenantID.
+func (v *TenantBootstrapVerifier) Verify(token, tenantID string) error {
+	parsed, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) {
+		return v.publicKey, nil
+	}, jwt.WithValidMethods([]string{AlgRS256}), jwt.WithExpirationRequired())
+	if err != nil {
+		return fmt.Errorf("invalid tenant bootstrap token: %w", err)
+	}
+
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return fmt.Errorf("invalid tenant bootstrap token claims")
+	}
+	if claimed, _ := claims["tenant_id"].(string); claimed == "" || CanonicalTenantID(claimed) != CanonicalTenantID(tenantID) {
+		return fmt.Errorf("tenant bootstrap token is not for tenant %q", tenantID)
+	}
+	return nil
+}
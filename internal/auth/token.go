@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"session-service/internal/models"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,32 +16,127 @@ import (
 type TokenGenerator struct {
 	keyManager         *KeyManager
 	issuer             string
-	audience           string
+	audiences          []string
 	accessTokenExpiry  time.Duration
 	refreshTokenLength int
+
+	// secrets decrypts HS256 tenants' signing secrets; set by EnableHMACTenants
+	secrets *SecretCipher
+
+	// claimNames is set by EnableClaimNames, and claimNamesSet with it
+	claimNames    ClaimNames
+	claimNamesSet bool
+
+	// roleCompressionThreshold is set by EnableRoleCompression
+	roleCompressionThreshold int
+
+	// rfc9068 is set by EnableRFC9068
+	rfc9068 bool
+
+	// refreshMACKey is set by EnableRefreshTokenMAC, the previous key and
+	// the end of its overlap by AcceptPreviousRefreshTokenMAC
+	refreshMACKey           []byte
+	previousRefreshMACKey   []byte
+	previousRefreshMACUntil time.Time
 }
 
-// NewTokenGenerator creates a new token generator
+// NewTokenGenerator creates a new token generator. audience may list several
+// audiences separated by commas, which tokens then carry as an array.
 func NewTokenGenerator(keyManager *KeyManager, issuer, audience string, accessTokenExpiry time.Duration, refreshTokenLength int) *TokenGenerator {
 	return &TokenGenerator{
 		keyManager:         keyManager,
 		issuer:             issuer,
-		audience:           audience,
+		audiences:          SplitAudiences(audience),
 		accessTokenExpiry:  accessTokenExpiry,
 		refreshTokenLength: refreshTokenLength,
+		claimNames:         DefaultClaimNames,
 	}
 }
 
+// EnableHMACTenants lets the generator sign tokens for tenants that have an
+// HS256 secret, decrypting the secrets with cipher.
+func (tg *TokenGenerator) EnableHMACTenants(cipher *SecretCipher) {
+	tg.secrets = cipher
+}
+
 // GenerateAccessToken generates a JWT access token using a TokenSubject.
 // All access tokens are user/tenant scoped; there is no client-only fallback.
 func (tg *TokenGenerator) GenerateAccessToken(subject *models.TokenSubject) (string, string, error) {
+	return tg.GenerateAccessTokenWithExpiry(subject, tg.accessTokenExpiry)
+}
+
+// GenerateAccessTokenWithExpiry generates a JWT access token like
+// GenerateAccessToken but with an explicit lifetime, e.g. a per-tenant override.
+// A non-positive expiry falls back to the generator's default.
+func (tg *TokenGenerator) GenerateAccessTokenWithExpiry(subject *models.TokenSubject, expiry time.Duration) (string, string, error) {
+	return tg.GenerateAccessTokenWithAlgorithm(subject, expiry, "")
+}
+
+// GenerateAccessTokenWithAlgorithm generates a JWT access token like
+// GenerateAccessTokenWithExpiry, signed with the current key for alg (e.g. a
+// client's configured algorithm) of the token's audience. An empty alg uses
+// the default algorithm.
+func (tg *TokenGenerator) GenerateAccessTokenWithAlgorithm(subject *models.TokenSubject, expiry time.Duration, alg string) (string, string, error) {
+	claims, jti := tg.accessTokenClaims(subject, expiry)
+	audiences, _ := claims.GetAudience()
+
+	key, err := tg.keyManager.GetSigningKeyForAudiences(alg, audiences)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get signing key: %w", err)
+	}
+	method := jwt.GetSigningMethod(key.Algorithm)
+	if method == nil {
+		return "", "", fmt.Errorf("unsupported signing algorithm: %s", key.Algorithm)
+	}
+
+	token := tg.newAccessToken(method, claims)
+	// Set kid header so verifiers can select the correct key from JWKS when rotation is enabled.
+	token.Header["kid"] = key.KeyID
+
+	tokenString, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return tokenString, jti, nil
+}
+
+// GenerateTenantAccessToken generates a JWT access token for subject in
+// tenant. Tenants with an HS256 secret always get HS256 tokens signed with
+// it; for other tenants it behaves like GenerateAccessTokenWithAlgorithm.
+func (tg *TokenGenerator) GenerateTenantAccessToken(subject *models.TokenSubject, expiry time.Duration, alg string, tenant *models.Tenant) (string, string, error) {
+	secret, err := tenantHMACSecret(tg.secrets, tenant)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get tenant signing secret: %w", err)
+	}
+	if secret == nil {
+		return tg.GenerateAccessTokenWithAlgorithm(subject, expiry, alg)
+	}
+
+	// No kid: the secret is not published, so there is no key to select.
+	claims, jti := tg.accessTokenClaims(subject, expiry)
+	tokenString, err := tg.newAccessToken(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return tokenString, jti, nil
+}
+
+// accessTokenClaims builds the claims of an access token for subject and
+// returns them with the token's jti.
+func (tg *TokenGenerator) accessTokenClaims(subject *models.TokenSubject, expiry time.Duration) (jwt.MapClaims, string) {
+	if expiry <= 0 {
+		expiry = tg.accessTokenExpiry
+	}
+
 	now := time.Now()
 	jti := uuid.New().String()
 
 	claims := jwt.MapClaims{
 		"iss": tg.issuer,
-		"aud": tg.audience,
-		"exp": now.Add(tg.accessTokenExpiry).Unix(),
+		"aud": audienceClaim(tg.audiences),
+		"exp": now.Add(expiry).Unix(),
 		"iat": now.Unix(),
 		"jti": jti,
 	}
@@ -48,35 +144,85 @@ func (tg *TokenGenerator) GenerateAccessToken(subject *models.TokenSubject) (str
 	// subject is required; we assume caller has validated it.
 	claims["sub"] = subject.UserID
 	claims["oid"] = subject.UserID
-	claims["tid"] = subject.TenantID
+	claims[tg.claimNames.Tenant] = subject.TenantID
+	if subject.ExternalTenantID != "" {
+		claims["xtid"] = subject.ExternalTenantID
+	}
 	if len(subject.Roles) > 0 {
-		claims["roles"] = subject.Roles
+		claims[tg.claimNames.Roles] = subject.Roles
 	}
-	if len(subject.Scopes) > 0 {
-		claims["scp"] = subject.Scopes
+	if tg.roleCompressionThreshold > 0 && len(subject.Roles) > tg.roleCompressionThreshold {
+		// Compressing into memory cannot fail; the plain claim stays if it does
+		if compressed, err := CompressRoles(subject.Roles); err == nil {
+			delete(claims, tg.claimNames.Roles)
+			claims[tg.claimNames.Roles+CompressedClaimSuffix] = compressed
+		}
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	// Set kid header so verifiers can select the correct key from JWKS when rotation is enabled.
-	if tg.keyManager != nil {
-		if kid := tg.keyManager.GetCurrentKeyID(); kid != "" {
-			token.Header["kid"] = kid
+	if len(subject.Scopes) > 0 {
+		var scopes interface{} = subject.Scopes
+		if tg.rfc9068 {
+			scopes = strings.Join(subject.Scopes, " ")
 		}
+		claims[tg.scopesClaim()] = scopes
 	}
-
-	tokenString, err := token.SignedString(tg.keyManager.GetPrivateKey())
-	if err != nil {
-		return "", "", fmt.Errorf("failed to sign token: %w", err)
+	if subject.Act != nil {
+		claims["act"] = actorClaim(subject.Act)
+	}
+	if subject.SessionID != "" {
+		claims["sid"] = subject.SessionID
+	}
+	if len(subject.AuthMethods) > 0 {
+		claims["amr"] = subject.AuthMethods
+	}
+	if subject.ClientID != "" {
+		claims["client_id"] = subject.ClientID
+	}
+	if len(subject.Audiences) > 0 {
+		claims["aud"] = audienceClaim(subject.Audiences)
+	}
+	if subject.AuthorizedParty != "" {
+		claims["azp"] = subject.AuthorizedParty
 	}
 
-	return tokenString, jti, nil
+	return claims, jti
 }
 
-// GenerateRefreshToken generates a random refresh token
+// GenerateRefreshToken generates a random refresh token, followed by its MAC
+// when EnableRefreshTokenMAC has been called
 func (tg *TokenGenerator) GenerateRefreshToken() (string, error) {
 	bytes := make([]byte, tg.refreshTokenLength)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", fmt.Errorf("failed to generate refresh token: %w", err)
 	}
-	return base64.URLEncoding.EncodeToString(bytes), nil
+	return tg.signRefreshToken(base64.URLEncoding.EncodeToString(bytes)), nil
+}
+
+// SplitAudiences returns the audiences in a comma-separated list such as
+// JWT_AUDIENCE.
+func SplitAudiences(audience string) []string {
+	var audiences []string
+	for _, aud := range strings.Split(audience, ",") {
+		if aud = strings.TrimSpace(aud); aud != "" {
+			audiences = append(audiences, aud)
+		}
+	}
+	return audiences
+}
+
+// audienceClaim renders audiences as the aud claim: a plain string for a
+// single audience, as verifiers have always received, or an array.
+func audienceClaim(audiences []string) interface{} {
+	if len(audiences) <= 1 {
+		return strings.Join(audiences, "")
+	}
+	return audiences
+}
+
+// actorClaim renders an actor chain as the nested act claim.
+func actorClaim(actor *models.Actor) map[string]interface{} {
+	claim := map[string]interface{}{"sub": actor.Subject}
+	if actor.Act != nil {
+		claim["act"] = actorClaim(actor.Act)
+	}
+	return claim
 }
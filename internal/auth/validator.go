@@ -2,52 +2,175 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"session-service/internal/cache"
+	"session-service/internal/metrics"
+	"session-service/internal/models"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 )
 
 // TokenValidator handles token validation
 type TokenValidator struct {
 	keyManager *KeyManager
 	issuer     string
-	audience   string
+	audiences  []string
 	cache      cache.Cache
+
+	// driftWindow and logger are set by EnableClockDriftWarnings
+	driftWindow time.Duration
+	logger      *zap.Logger
+
+	// leeway is set by EnableClockSkewLeeway
+	leeway time.Duration
+
+	// secrets and loadTenant are set by EnableHMACTenants
+	secrets    *SecretCipher
+	loadTenant TenantLoader
+
+	// revokeOnRoleChange is set by EnableRoleChangeRevocation
+	revokeOnRoleChange bool
+
+	// tenantIssuers is set by EnableTenantIssuers
+	tenantIssuers bool
+
+	// previousAudiences is set by EnablePreviousAudiences
+	previousAudiences []string
+
+	// scopeAudiences and clientAudience are set by EnableScopeAudiences
+	scopeAudiences []string
+	clientAudience bool
+
+	// currentKeyScopes is set by EnableCurrentKeyScopes
+	currentKeyScopes []string
+
+	// claimNames is set by EnableClaimNames, and claimNamesSet with it
+	claimNames    ClaimNames
+	claimNamesSet bool
+
+	// requireAccessTokenType is set by RequireAccessTokenType
+	requireAccessTokenType bool
 }
 
-// NewTokenValidator creates a new token validator
+// ErrRolesChanged is returned for tokens issued before the user's roles last
+// changed; a refresh yields a token with the current roles.
+var ErrRolesChanged = errors.New("token was issued before the user's roles changed")
+
+// ErrTokenRevoked is returned for tokens revoked by jti or issued before the
+// user's revocation cutoff.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// ErrRequiresCurrentKey is returned for tokens carrying a scope that requires
+// the current signing key but signed by a key in its grace period.
+var ErrRequiresCurrentKey = errors.New("token scope requires the current signing key")
+
+// TenantLoader returns a tenant by ID, or nil if it does not exist.
+type TenantLoader func(ctx context.Context, tenantID string) (*models.Tenant, error)
+
+// NewTokenValidator creates a new token validator. audience may list several
+// audiences separated by commas; tokens for any of them are accepted.
 func NewTokenValidator(keyManager *KeyManager, issuer, audience string, cache cache.Cache) *TokenValidator {
 	return &TokenValidator{
 		keyManager: keyManager,
 		issuer:     issuer,
-		audience:   audience,
+		audiences:  SplitAudiences(audience),
 		cache:      cache,
+		claimNames: DefaultClaimNames,
 	}
 }
 
+// EnableClockDriftWarnings makes the validator log a warning and count a
+// metric whenever a token is rejected as expired or not yet valid by no more
+// than window, which usually means the issuer's and verifier's clocks differ.
+// It is purely diagnostic: such tokens are still rejected.
+func (tv *TokenValidator) EnableClockDriftWarnings(window time.Duration, logger *zap.Logger) {
+	tv.driftWindow = window
+	tv.logger = logger
+}
+
+// EnableClockSkewLeeway makes the validator accept tokens up to leeway past
+// their exp or before their nbf, so small clock differences between the
+// issuer and verifiers don't reject valid tokens.
+func (tv *TokenValidator) EnableClockSkewLeeway(leeway time.Duration) {
+	tv.leeway = leeway
+}
+
+// EnableRoleChangeRevocation makes the validator reject access tokens issued
+// before the user's roles last changed (as recorded in the cache), with
+// ErrRolesChanged.
+func (tv *TokenValidator) EnableRoleChangeRevocation() {
+	tv.revokeOnRoleChange = true
+}
+
+// EnableHMACTenants makes the validator accept HS256 tokens, verified with
+// the secret of the tenant in their tid claim. Tenants are read from the
+// cache, falling back to loadTenant. HS256 tokens for tenants without a
+// secret are still rejected.
+func (tv *TokenValidator) EnableHMACTenants(cipher *SecretCipher, loadTenant TenantLoader) {
+	tv.secrets = cipher
+	tv.loadTenant = loadTenant
+}
+
+// EnablePreviousAudiences makes the validator also accept tokens for any of
+// audiences, e.g. the old value while the audience is being migrated. A
+// token is accepted when any of its aud values is accepted.
+func (tv *TokenValidator) EnablePreviousAudiences(audiences []string) {
+	tv.previousAudiences = audiences
+}
+
+// EnableScopeAudiences makes the validator also accept the tokens it issues
+// for scope-mapped audiences: tokens for any of audiences and, with
+// clientAudience, tokens whose audience is their azp client.
+func (tv *TokenValidator) EnableScopeAudiences(audiences []string, clientAudience bool) {
+	tv.scopeAudiences = audiences
+	tv.clientAudience = clientAudience
+}
+
+// EnableCurrentKeyScopes makes the validator reject, with
+// ErrRequiresCurrentKey, tokens that carry any of scopes in their scp claim
+// but were signed by a key that has been rotated out and is only accepted
+// during its grace period.
+func (tv *TokenValidator) EnableCurrentKeyScopes(scopes []string) {
+	tv.currentKeyScopes = scopes
+}
+
 // ValidateToken validates a JWT token
 func (tv *TokenValidator) ValidateToken(ctx context.Context, tokenString string) (jwt.MapClaims, error) {
+	validMethods := []string{AlgRS256, AlgES256, AlgEdDSA}
+	if tv.secrets != nil {
+		validMethods = append(validMethods, AlgHS256)
+	}
+
 	// Parse and validate token
+	var kid string
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		if token.Method.Alg() == AlgHS256 {
+			return tv.tenantSecret(ctx, token)
 		}
+
 		// Require kid so we always pick an explicit key; no fallback.
-		kid, ok := token.Header["kid"].(string)
-		if !ok || kid == "" {
+		kid, _ = token.Header["kid"].(string)
+		if kid == "" {
 			return nil, fmt.Errorf("missing kid in token header")
 		}
-		pub, err := tv.keyManager.GetPublicKeyByID(kid)
+		key, err := tv.keyManager.GetVerificationKey(kid)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get public key for kid %s: %w", kid, err)
 		}
-		return pub, nil
-	}, jwt.WithValidMethods([]string{"RS256"}))
+		// The token's alg must match the key's algorithm, never just any supported one.
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.PublicKey, nil
+	}, jwt.WithValidMethods(validMethods), jwt.WithLeeway(tv.leeway))
 
 	if err != nil {
+		tv.checkClockDrift(token, err)
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
@@ -55,28 +178,56 @@ func (tv *TokenValidator) ValidateToken(ctx context.Context, tokenString string)
 		return nil, fmt.Errorf("token is not valid")
 	}
 
+	if isIDTokenType(token.Header["typ"]) {
+		return nil, fmt.Errorf("ID tokens are not accepted as access tokens")
+	}
+	if tv.requireAccessTokenType && !isAccessTokenType(token.Header["typ"]) {
+		return nil, fmt.Errorf("token is not an access token")
+	}
+
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
 		return nil, fmt.Errorf("invalid token claims")
 	}
 
 	// Validate issuer
-	if iss, ok := claims["iss"].(string); !ok || iss != tv.issuer {
+	iss, ok := claims["iss"].(string)
+	if !ok || !tv.validIssuer(iss) {
 		return nil, fmt.Errorf("invalid issuer")
 	}
+	if issuerTenant := tv.issuerTenant(iss); issuerTenant != "" {
+		if tid, ok := claims[tv.claimNames.Tenant].(string); ok && tid != "" && CanonicalTenantID(tid) != CanonicalTenantID(issuerTenant) {
+			return nil, fmt.Errorf("tid does not match issuer")
+		}
+	}
 
 	// Validate audience
-	if aud, ok := claims["aud"].(string); !ok || aud != tv.audience {
+	if !tv.validAudience(claims) {
 		return nil, fmt.Errorf("invalid audience")
 	}
 
+	// High-assurance scopes are not honoured on tokens from a retiring key
+	if kid != "" && tv.requiresCurrentKey(claims) {
+		if status, ok := tv.keyManager.GetKeyStatus(kid); !ok || status.InGrace {
+			return nil, ErrRequiresCurrentKey
+		}
+	}
+
 	// Check expiration (jwt-go already validates this, but double-check)
 	if exp, ok := claims["exp"].(float64); ok {
-		if time.Now().Unix() > int64(exp) {
+		if time.Now().Add(-tv.leeway).Unix() > int64(exp) {
 			return nil, fmt.Errorf("token has expired")
 		}
 	}
 
+	// Check not-before, so tokens pre-minted for later use are rejected until
+	// then; tokens without nbf are valid straight away
+	if nbf, ok := claims["nbf"].(float64); ok {
+		if time.Now().Add(tv.leeway).Unix() < int64(nbf) {
+			return nil, fmt.Errorf("token is not valid yet")
+		}
+	}
+
 	// Check revocation list
 	if jti, ok := claims["jti"].(string); ok && jti != "" {
 		revoked, err := tv.cache.IsTokenRevoked(ctx, jti)
@@ -84,9 +235,179 @@ func (tv *TokenValidator) ValidateToken(ctx context.Context, tokenString string)
 			return nil, fmt.Errorf("failed to check token revocation: %w", err)
 		}
 		if revoked {
-			return nil, fmt.Errorf("token has been revoked")
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	// Check per-user revocation cutoff (e.g. the user was deleted)
+	if sub, ok := claims["sub"].(string); ok && sub != "" {
+		cutoff, err := tv.cache.GetUserRevocationCutoff(ctx, sub)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check user revocation: %w", err)
+		}
+		if !cutoff.IsZero() {
+			iat, err := claims.GetIssuedAt()
+			if err != nil || iat == nil || !iat.After(cutoff) {
+				return nil, ErrTokenRevoked
+			}
+		}
+	}
+
+	// Check the token still carries the user's current roles
+	if sub, ok := claims["sub"].(string); ok && sub != "" && tv.revokeOnRoleChange {
+		changedAt, err := tv.cache.GetUserRolesChangedAt(ctx, sub)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check user roles change: %w", err)
+		}
+		// iat has whole-second precision, so a token issued in the same
+		// second as the change is accepted rather than rejecting the token
+		// that was just issued with the new roles.
+		if !changedAt.IsZero() {
+			iat, err := claims.GetIssuedAt()
+			if err != nil || iat == nil || iat.Before(changedAt.Truncate(time.Second)) {
+				return nil, ErrRolesChanged
+			}
 		}
 	}
 
 	return claims, nil
 }
+
+// SigningKeyStatus returns the lifecycle state of the key that signed
+// tokenString. It does not verify the token; call ValidateToken first.
+func (tv *TokenValidator) SigningKeyStatus(tokenString string) (*KeyStatus, error) {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, fmt.Errorf("missing kid in token header")
+	}
+
+	status, ok := tv.keyManager.GetKeyStatus(kid)
+	if !ok {
+		return nil, fmt.Errorf("key not found or inactive: %s", kid)
+	}
+	return status, nil
+}
+
+// tenantSecret returns the HS256 verification secret for the tenant named in
+// the token's tid claim. Only that tenant's own secret is ever used, and
+// tenants without one cannot have HS256 tokens.
+func (tv *TokenValidator) tenantSecret(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+	tenantID := tv.TenantID(claims)
+	if tenantID == "" {
+		return nil, fmt.Errorf("missing tenant in %s token", AlgHS256)
+	}
+
+	tenant, err := tv.cache.GetTenant(ctx, tenantID)
+	if err != nil || tenant == nil {
+		tenant, err = tv.loadTenant(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tenant %s: %w", tenantID, err)
+		}
+		if tenant != nil {
+			// Best effort: the token handler caches tenants the same way
+			_ = tv.cache.SetTenant(ctx, tenant, 15*time.Minute)
+		}
+	}
+
+	secret, err := tenantHMACSecret(tv.secrets, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("tenant %s does not sign with %s", tenantID, AlgHS256)
+	}
+	return secret, nil
+}
+
+// requiresCurrentKey reports whether claims' scopes, an array or a
+// space-separated string, names a scope that requires the current key.
+func (tv *TokenValidator) requiresCurrentKey(claims jwt.MapClaims) bool {
+	if len(tv.currentKeyScopes) == 0 {
+		return false
+	}
+
+	// RFC 9068 tokens carry their scopes under "scope" unless the claim
+	// names were changed
+	raw, ok := claims[tv.claimNames.Scopes]
+	if !ok && !tv.claimNamesSet {
+		raw = claims[RFC9068ScopesClaim]
+	}
+	scopes := stringsClaim(raw)
+	if scp, ok := raw.(string); ok {
+		scopes = strings.Fields(scp)
+	}
+	for _, scope := range scopes {
+		if slices.Contains(tv.currentKeyScopes, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// validAudience reports whether claims' aud, a string or an array, names one
+// of the configured audiences or of the previous audiences.
+func (tv *TokenValidator) validAudience(claims jwt.MapClaims) bool {
+	audiences, err := claims.GetAudience()
+	if err != nil {
+		return false
+	}
+	azp, _ := claims["azp"].(string)
+	for _, aud := range audiences {
+		if slices.Contains(tv.audiences, aud) || slices.Contains(tv.previousAudiences, aud) || slices.Contains(tv.scopeAudiences, aud) {
+			return true
+		}
+		if tv.clientAudience && azp != "" && aud == azp {
+			return true
+		}
+	}
+	return false
+}
+
+// checkClockDrift reports a token rejected for exp or nbf by a margin within
+// the drift window. The token's signature was verified before its time claims,
+// so the claims can be trusted here.
+func (tv *TokenValidator) checkClockDrift(token *jwt.Token, err error) {
+	if tv.driftWindow <= 0 || token == nil {
+		return
+	}
+
+	var reason string
+	var skew time.Duration
+	now := time.Now()
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		exp, claimErr := token.Claims.GetExpirationTime()
+		if claimErr != nil || exp == nil {
+			return
+		}
+		reason, skew = "expired", now.Sub(exp.Time)
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		nbf, claimErr := token.Claims.GetNotBefore()
+		if claimErr != nil || nbf == nil {
+			return
+		}
+		reason, skew = "not_yet_valid", nbf.Sub(now)
+	default:
+		return
+	}
+
+	// Only the margin beyond the leeway counts towards the window
+	if skew-tv.leeway > tv.driftWindow {
+		return
+	}
+
+	metrics.ClockDriftSuspected.WithLabelValues(reason).Inc()
+	tv.logger.Warn("Token rejected by a small time margin; possible clock drift",
+		zap.String("reason", reason),
+		zap.Duration("observed_skew", skew),
+		zap.Duration("window", tv.driftWindow))
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// VerificationCache keeps recent successful validation results in memory,
+// keyed by a hash of the token, so repeated verifications of the same token
+// skip signature and revocation checks. Entries live for at most ttl and
+// never past the token's own exp; revocation is only checked when an entry
+// is populated, so ttl bounds how long a revoked token can still verify.
+type VerificationCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]verificationEntry
+	now        func() time.Time
+}
+
+type verificationEntry struct {
+	claims    jwt.MapClaims
+	expiresAt time.Time
+}
+
+// NewVerificationCache creates a cache holding up to maxEntries results for
+// ttl each. It returns nil (caching disabled) when ttl is not positive.
+func NewVerificationCache(ttl time.Duration, maxEntries int) *VerificationCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &VerificationCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]verificationEntry),
+		now:        time.Now,
+	}
+}
+
+// Get returns a copy of the cached claims for token, if present and fresh.
+func (c *VerificationCache) Get(token string) (jwt.MapClaims, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := verificationCacheKey(token)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return copyClaims(entry.claims), true
+}
+
+// Set caches claims for token until the earlier of ttl and the token's exp.
+func (c *VerificationCache) Set(token string, claims jwt.MapClaims) {
+	if c == nil {
+		return
+	}
+	now := c.now()
+	expiresAt := now.Add(c.ttl)
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil && exp.Before(expiresAt) {
+		expiresAt = exp.Time
+	}
+	if !now.Before(expiresAt) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evictExpired(now)
+		if len(c.entries) >= c.maxEntries {
+			return
+		}
+	}
+	c.entries[verificationCacheKey(token)] = verificationEntry{
+		claims:    copyClaims(claims),
+		expiresAt: expiresAt,
+	}
+}
+
+func (c *VerificationCache) evictExpired(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func verificationCacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func copyClaims(claims jwt.MapClaims) jwt.MapClaims {
+	out := make(jwt.MapClaims, len(claims))
+	for k, v := range claims {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"session-service/internal/database"
+	"session-service/internal/models"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Config describes the tenant and client seeded on a fresh deployment.
+type Config struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	// ClientName optionally labels the client in admin listings and audit events.
+	ClientName string
+}
+
+// Enabled reports whether bootstrap seeding is configured.
+func (c Config) Enabled() bool {
+	return c.TenantID != "" || c.ClientID != "" || c.ClientSecret != ""
+}
+
+// Run idempotently creates the configured tenant and a client bound to it,
+// so a fresh deployment can issue tokens without manual SQL. Existing records
+// are left untouched (in particular, an existing client's secret is never
+// overwritten). It does nothing when bootstrap is not configured.
+func Run(ctx context.Context, repo database.Repository, cfg Config, logger *zap.Logger) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cfg.TenantID == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return fmt.Errorf("bootstrap requires BOOTSTRAP_TENANT_ID, BOOTSTRAP_CLIENT_ID and BOOTSTRAP_CLIENT_SECRET")
+	}
+
+	created, err := repo.CreateTenantIfNotExists(ctx, models.Tenant{ID: cfg.TenantID, Name: cfg.TenantID})
+	if err != nil {
+		return fmt.Errorf("failed to create bootstrap tenant: %w", err)
+	}
+	if created {
+		logger.Info("Bootstrap tenant created", zap.String("tenant_id", cfg.TenantID))
+	} else {
+		logger.Info("Bootstrap tenant already exists", zap.String("tenant_id", cfg.TenantID))
+	}
+
+	secretHash, err := bcrypt.GenerateFromPassword([]byte(cfg.ClientSecret), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash bootstrap client secret: %w", err)
+	}
+
+	created, err = repo.CreateClientIfNotExists(ctx, models.Client{
+		ClientID:         cfg.ClientID,
+		ClientSecretHash: string(secretHash),
+		RateLimit:        100,
+		TenantID:         cfg.TenantID,
+		Name:             cfg.ClientName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create bootstrap client: %w", err)
+	}
+	if created {
+		logger.Info("Bootstrap client created", zap.String("client_id", cfg.ClientID), zap.String("tenant_id", cfg.TenantID))
+	} else {
+		logger.Info("Bootstrap client already exists; secret left unchanged", zap.String("client_id", cfg.ClientID))
+	}
+
+	return nil
+}
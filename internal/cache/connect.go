@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// ConnectOptions controls how NewCache establishes its first connection.
+// Once connected, go-redis re-dials dropped connections on its own.
+type ConnectOptions struct {
+	// Retries is the number of connection attempts before giving up.
+	Retries int
+	// Timeout bounds each attempt (dial and ping).
+	Timeout time.Duration
+	// Backoff is the wait after the first failed attempt; it grows linearly.
+	Backoff time.Duration
+}
+
+// PingWithRetry calls ping until it succeeds, retrying with linear backoff so
+// that Redis being briefly unavailable at startup (e.g. during a deploy) does
+// not fail the service. It returns the last error once all attempts fail.
+func PingWithRetry(ctx context.Context, ping func(context.Context) error, opts ConnectOptions, logger *zap.Logger) error {
+	attempts := opts.Retries
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		err = pingOnce(ctx, ping, opts.Timeout)
+		if err == nil {
+			return nil
+		}
+		if i < attempts-1 {
+			waitTime := time.Duration(i+1) * opts.Backoff
+			logger.Warn("Failed to connect to Redis, retrying...", zap.Int("attempt", i+1), zap.Duration("wait", waitTime), zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(waitTime):
+			}
+		}
+	}
+	return fmt.Errorf("failed to connect to Redis after %d attempts: %w", attempts, err)
+}
+
+func pingOnce(ctx context.Context, ping func(context.Context) error, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return ping(ctx)
+}
+
+// reconnectLogger is a go-redis hook that logs failed dials while Redis is
+// unreachable at runtime, and the first successful dial once it is back.
+type reconnectLogger struct {
+	logger *zap.Logger
+	down   atomic.Bool
+}
+
+func (h *reconnectLogger) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			h.down.Store(true)
+			h.logger.Warn("Failed to connect to Redis; go-redis will retry", zap.String("addr", addr), zap.Error(err))
+			return nil, err
+		}
+		if h.down.CompareAndSwap(true, false) {
+			h.logger.Info("Reconnected to Redis", zap.String("addr", addr))
+		}
+		return conn, nil
+	}
+}
+
+func (h *reconnectLogger) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return next
+}
+
+func (h *reconnectLogger) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
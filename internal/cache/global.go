@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"session-service/internal/models"
+	"time"
+)
+
+// GlobalSessionCache keeps session state - refresh tokens, revocations,
+// cookie sessions and the session index - in a store shared by every
+// region, so a session started in one region survives failover to another
+// in an active-active deployment. Everything else, such as cached clients
+// and rate limits, stays in the region-local cache it embeds.
+type GlobalSessionCache struct {
+	Cache
+	global Cache
+}
+
+// NewGlobalSessionCache wraps local so that session state is read from and
+// written to global instead.
+func NewGlobalSessionCache(local, global Cache) Cache {
+	return &GlobalSessionCache{Cache: local, global: global}
+}
+
+// Close closes both the local and the global store.
+func (c *GlobalSessionCache) Close() error {
+	return errors.Join(c.Cache.Close(), c.global.Close())
+}
+
+// Ping checks that both the local and the global store are reachable.
+func (c *GlobalSessionCache) Ping(ctx context.Context) error {
+	if err := c.Cache.Ping(ctx); err != nil {
+		return err
+	}
+	return c.global.Ping(ctx)
+}
+
+func (c *GlobalSessionCache) StoreRefreshToken(ctx context.Context, tokenID string, data *models.RefreshTokenData, ttl time.Duration) error {
+	return c.global.StoreRefreshToken(ctx, tokenID, data, ttl)
+}
+
+func (c *GlobalSessionCache) GetRefreshToken(ctx context.Context, tokenID string) (*models.RefreshTokenData, error) {
+	return c.global.GetRefreshToken(ctx, tokenID)
+}
+
+func (c *GlobalSessionCache) DeleteRefreshToken(ctx context.Context, tokenID string) error {
+	return c.global.DeleteRefreshToken(ctx, tokenID)
+}
+
+func (c *GlobalSessionCache) RevokeToken(ctx context.Context, jti string, ttl time.Duration) error {
+	return c.global.RevokeToken(ctx, jti, ttl)
+}
+
+func (c *GlobalSessionCache) RevokeRefreshToken(ctx context.Context, tokenID string, ttl time.Duration) error {
+	return c.global.RevokeRefreshToken(ctx, tokenID, ttl)
+}
+
+func (c *GlobalSessionCache) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return c.global.IsTokenRevoked(ctx, jti)
+}
+
+func (c *GlobalSessionCache) IsRefreshTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return c.global.IsRefreshTokenRevoked(ctx, tokenID)
+}
+
+func (c *GlobalSessionCache) RecordTokenSource(ctx context.Context, jti, source string, ttl time.Duration) (int64, bool, error) {
+	return c.global.RecordTokenSource(ctx, jti, source, ttl)
+}
+
+func (c *GlobalSessionCache) SetUserRevocationCutoff(ctx context.Context, userID string, cutoff time.Time, ttl time.Duration) error {
+	return c.global.SetUserRevocationCutoff(ctx, userID, cutoff, ttl)
+}
+
+func (c *GlobalSessionCache) GetUserRevocationCutoff(ctx context.Context, userID string) (time.Time, error) {
+	return c.global.GetUserRevocationCutoff(ctx, userID)
+}
+
+func (c *GlobalSessionCache) SetUserRolesChangedAt(ctx context.Context, userID string, changedAt time.Time, ttl time.Duration) error {
+	return c.global.SetUserRolesChangedAt(ctx, userID, changedAt, ttl)
+}
+
+func (c *GlobalSessionCache) GetUserRolesChangedAt(ctx context.Context, userID string) (time.Time, error) {
+	return c.global.GetUserRolesChangedAt(ctx, userID)
+}
+
+func (c *GlobalSessionCache) StoreCookieSession(ctx context.Context, sessionID, refreshToken string, ttl time.Duration) error {
+	return c.global.StoreCookieSession(ctx, sessionID, refreshToken, ttl)
+}
+
+func (c *GlobalSessionCache) GetCookieSession(ctx context.Context, sessionID string) (string, error) {
+	return c.global.GetCookieSession(ctx, sessionID)
+}
+
+func (c *GlobalSessionCache) TrackUserSession(ctx context.Context, userID, sessionID, refreshToken string, ttl time.Duration) error {
+	return c.global.TrackUserSession(ctx, userID, sessionID, refreshToken, ttl)
+}
+
+func (c *GlobalSessionCache) GetUserSessions(ctx context.Context, userID string) ([]models.UserSession, error) {
+	return c.global.GetUserSessions(ctx, userID)
+}
+
+func (c *GlobalSessionCache) RemoveUserSession(ctx context.Context, userID, sessionID string) error {
+	return c.global.RemoveUserSession(ctx, userID, sessionID)
+}
@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"context"
+	"session-service/internal/metrics"
+	"session-service/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// InstrumentedCache decorates a Cache, tracing every call as a child span,
+// recording its latency and logging calls slower than the configured
+// threshold.
+type InstrumentedCache struct {
+	next  Cache
+	timer *metrics.OperationTimer
+}
+
+// NewInstrumentedCache wraps c with latency metrics and slow-operation
+// logging. A zero threshold disables the slow-operation log.
+func NewInstrumentedCache(c Cache, threshold time.Duration, logger *zap.Logger) Cache {
+	return &InstrumentedCache{
+		next:  c,
+		timer: &metrics.OperationTimer{Component: "cache", Threshold: threshold, Logger: logger},
+	}
+}
+
+func (c *InstrumentedCache) Close() error {
+	return c.next.Close()
+}
+
+func (c *InstrumentedCache) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
+
+func (c *InstrumentedCache) GetClient(ctx context.Context, clientID string) (*models.Client, error) {
+	ctx, done := c.timer.Start(ctx, "GetClient")
+	defer done()
+	return c.next.GetClient(ctx, clientID)
+}
+
+func (c *InstrumentedCache) SetClient(ctx context.Context, client *models.Client, ttl time.Duration) error {
+	ctx, done := c.timer.Start(ctx, "SetClient")
+	defer done()
+	return c.next.SetClient(ctx, client, ttl)
+}
+
+func (c *InstrumentedCache) GetTenant(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	ctx, done := c.timer.Start(ctx, "GetTenant")
+	defer done()
+	return c.next.GetTenant(ctx, tenantID)
+}
+
+func (c *InstrumentedCache) SetTenant(ctx context.Context, tenant *models.Tenant, ttl time.Duration) error {
+	ctx, done := c.timer.Start(ctx, "SetTenant")
+	defer done()
+	return c.next.SetTenant(ctx, tenant, ttl)
+}
+
+func (c *InstrumentedCache) GetTenantConfig(ctx context.Context, tenantID string) (*models.TenantConfig, error) {
+	ctx, done := c.timer.Start(ctx, "GetTenantConfig")
+	defer done()
+	return c.next.GetTenantConfig(ctx, tenantID)
+}
+
+func (c *InstrumentedCache) SetTenantConfig(ctx context.Context, tenantConfig *models.TenantConfig, ttl time.Duration) error {
+	ctx, done := c.timer.Start(ctx, "SetTenantConfig")
+	defer done()
+	return c.next.SetTenantConfig(ctx, tenantConfig, ttl)
+}
+
+func (c *InstrumentedCache) DeleteTenantConfig(ctx context.Context, tenantID string) error {
+	ctx, done := c.timer.Start(ctx, "DeleteTenantConfig")
+	defer done()
+	return c.next.DeleteTenantConfig(ctx, tenantID)
+}
+
+func (c *InstrumentedCache) CheckRateLimit(ctx context.Context, clientID string, limit int, window time.Duration) (bool, error) {
+	ctx, done := c.timer.Start(ctx, "CheckRateLimit")
+	defer done()
+	return c.next.CheckRateLimit(ctx, clientID, limit, window)
+}
+
+func (c *InstrumentedCache) GetRateLimitUsage(ctx context.Context, clientID string) (int64, time.Duration, error) {
+	ctx, done := c.timer.Start(ctx, "GetRateLimitUsage")
+	defer done()
+	return c.next.GetRateLimitUsage(ctx, clientID)
+}
+
+func (c *InstrumentedCache) StoreRefreshToken(ctx context.Context, tokenID string, data *models.RefreshTokenData, ttl time.Duration) error {
+	ctx, done := c.timer.Start(ctx, "StoreRefreshToken")
+	defer done()
+	return c.next.StoreRefreshToken(ctx, tokenID, data, ttl)
+}
+
+func (c *InstrumentedCache) GetRefreshToken(ctx context.Context, tokenID string) (*models.RefreshTokenData, error) {
+	ctx, done := c.timer.Start(ctx, "GetRefreshToken")
+	defer done()
+	return c.next.GetRefreshToken(ctx, tokenID)
+}
+
+func (c *InstrumentedCache) DeleteRefreshToken(ctx context.Context, tokenID string) error {
+	ctx, done := c.timer.Start(ctx, "DeleteRefreshToken")
+	defer done()
+	return c.next.DeleteRefreshToken(ctx, tokenID)
+}
+
+func (c *InstrumentedCache) RevokeToken(ctx context.Context, jti string, ttl time.Duration) error {
+	ctx, done := c.timer.Start(ctx, "RevokeToken")
+	defer done()
+	return c.next.RevokeToken(ctx, jti, ttl)
+}
+
+func (c *InstrumentedCache) RevokeRefreshToken(ctx context.Context, tokenID string, ttl time.Duration) error {
+	ctx, done := c.timer.Start(ctx, "RevokeRefreshToken")
+	defer done()
+	return c.next.RevokeRefreshToken(ctx, tokenID, ttl)
+}
+
+func (c *InstrumentedCache) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ctx, done := c.timer.Start(ctx, "IsTokenRevoked")
+	defer done()
+	return c.next.IsTokenRevoked(ctx, jti)
+}
+
+func (c *InstrumentedCache) IsRefreshTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ctx, done := c.timer.Start(ctx, "IsRefreshTokenRevoked")
+	defer done()
+	return c.next.IsRefreshTokenRevoked(ctx, tokenID)
+}
+
+func (c *InstrumentedCache) RecordTokenSource(ctx context.Context, jti, source string, ttl time.Duration) (int64, bool, error) {
+	ctx, done := c.timer.Start(ctx, "RecordTokenSource")
+	defer done()
+	return c.next.RecordTokenSource(ctx, jti, source, ttl)
+}
+
+func (c *InstrumentedCache) SetUserRevocationCutoff(ctx context.Context, userID string, cutoff time.Time, ttl time.Duration) error {
+	ctx, done := c.timer.Start(ctx, "SetUserRevocationCutoff")
+	defer done()
+	return c.next.SetUserRevocationCutoff(ctx, userID, cutoff, ttl)
+}
+
+func (c *InstrumentedCache) GetUserRevocationCutoff(ctx context.Context, userID string) (time.Time, error) {
+	ctx, done := c.timer.Start(ctx, "GetUserRevocationCutoff")
+	defer done()
+	return c.next.GetUserRevocationCutoff(ctx, userID)
+}
+
+func (c *InstrumentedCache) SetUserRolesChangedAt(ctx context.Context, userID string, changedAt time.Time, ttl time.Duration) error {
+	ctx, done := c.timer.Start(ctx, "SetUserRolesChangedAt")
+	defer done()
+	return c.next.SetUserRolesChangedAt(ctx, userID, changedAt, ttl)
+}
+
+func (c *InstrumentedCache) GetUserRolesChangedAt(ctx context.Context, userID string) (time.Time, error) {
+	ctx, done := c.timer.Start(ctx, "GetUserRolesChangedAt")
+	defer done()
+	return c.next.GetUserRolesChangedAt(ctx, userID)
+}
+
+func (c *InstrumentedCache) StoreDeviceCode(ctx context.Context, deviceCode string, data *models.DeviceCodeData, ttl time.Duration) error {
+	ctx, done := c.timer.Start(ctx, "StoreDeviceCode")
+	defer done()
+	return c.next.StoreDeviceCode(ctx, deviceCode, data, ttl)
+}
+
+func (c *InstrumentedCache) GetDeviceCode(ctx context.Context, deviceCode string) (*models.DeviceCodeData, error) {
+	ctx, done := c.timer.Start(ctx, "GetDeviceCode")
+	defer done()
+	return c.next.GetDeviceCode(ctx, deviceCode)
+}
+
+func (c *InstrumentedCache) GetDeviceCodeByUserCode(ctx context.Context, userCode string) (string, error) {
+	ctx, done := c.timer.Start(ctx, "GetDeviceCodeByUserCode")
+	defer done()
+	return c.next.GetDeviceCodeByUserCode(ctx, userCode)
+}
+
+func (c *InstrumentedCache) DeleteDeviceCode(ctx context.Context, deviceCode, userCode string) error {
+	ctx, done := c.timer.Start(ctx, "DeleteDeviceCode")
+	defer done()
+	return c.next.DeleteDeviceCode(ctx, deviceCode, userCode)
+}
+
+func (c *InstrumentedCache) StoreCookieSession(ctx context.Context, sessionID, refreshToken string, ttl time.Duration) error {
+	ctx, done := c.timer.Start(ctx, "StoreCookieSession")
+	defer done()
+	return c.next.StoreCookieSession(ctx, sessionID, refreshToken, ttl)
+}
+
+func (c *InstrumentedCache) GetCookieSession(ctx context.Context, sessionID string) (string, error) {
+	ctx, done := c.timer.Start(ctx, "GetCookieSession")
+	defer done()
+	return c.next.GetCookieSession(ctx, sessionID)
+}
+
+func (c *InstrumentedCache) TrackUserSession(ctx context.Context, userID, sessionID, refreshToken string, ttl time.Duration) error {
+	ctx, done := c.timer.Start(ctx, "TrackUserSession")
+	defer done()
+	return c.next.TrackUserSession(ctx, userID, sessionID, refreshToken, ttl)
+}
+
+func (c *InstrumentedCache) GetUserSessions(ctx context.Context, userID string) ([]models.UserSession, error) {
+	ctx, done := c.timer.Start(ctx, "GetUserSessions")
+	defer done()
+	return c.next.GetUserSessions(ctx, userID)
+}
+
+func (c *InstrumentedCache) RemoveUserSession(ctx context.Context, userID, sessionID string) error {
+	ctx, done := c.timer.Start(ctx, "RemoveUserSession")
+	defer done()
+	return c.next.RemoveUserSession(ctx, userID, sessionID)
+}
@@ -13,9 +13,16 @@ import (
 // Cache defines the interface for cache operations
 type Cache interface {
 	Close() error
+	Ping(ctx context.Context) error
 	GetClient(ctx context.Context, clientID string) (*models.Client, error)
 	SetClient(ctx context.Context, client *models.Client, ttl time.Duration) error
+	GetTenant(ctx context.Context, tenantID string) (*models.Tenant, error)
+	SetTenant(ctx context.Context, tenant *models.Tenant, ttl time.Duration) error
+	GetTenantConfig(ctx context.Context, tenantID string) (*models.TenantConfig, error)
+	SetTenantConfig(ctx context.Context, tenantConfig *models.TenantConfig, ttl time.Duration) error
+	DeleteTenantConfig(ctx context.Context, tenantID string) error
 	CheckRateLimit(ctx context.Context, clientID string, limit int, window time.Duration) (bool, error)
+	GetRateLimitUsage(ctx context.Context, clientID string) (int64, time.Duration, error)
 	StoreRefreshToken(ctx context.Context, tokenID string, data *models.RefreshTokenData, ttl time.Duration) error
 	GetRefreshToken(ctx context.Context, tokenID string) (*models.RefreshTokenData, error)
 	DeleteRefreshToken(ctx context.Context, tokenID string) error
@@ -23,6 +30,20 @@ type Cache interface {
 	RevokeRefreshToken(ctx context.Context, tokenID string, ttl time.Duration) error
 	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
 	IsRefreshTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	RecordTokenSource(ctx context.Context, jti, source string, ttl time.Duration) (int64, bool, error)
+	SetUserRevocationCutoff(ctx context.Context, userID string, cutoff time.Time, ttl time.Duration) error
+	GetUserRevocationCutoff(ctx context.Context, userID string) (time.Time, error)
+	SetUserRolesChangedAt(ctx context.Context, userID string, changedAt time.Time, ttl time.Duration) error
+	GetUserRolesChangedAt(ctx context.Context, userID string) (time.Time, error)
+	StoreDeviceCode(ctx context.Context, deviceCode string, data *models.DeviceCodeData, ttl time.Duration) error
+	GetDeviceCode(ctx context.Context, deviceCode string) (*models.DeviceCodeData, error)
+	GetDeviceCodeByUserCode(ctx context.Context, userCode string) (string, error)
+	DeleteDeviceCode(ctx context.Context, deviceCode, userCode string) error
+	StoreCookieSession(ctx context.Context, sessionID, refreshToken string, ttl time.Duration) error
+	GetCookieSession(ctx context.Context, sessionID string) (string, error)
+	TrackUserSession(ctx context.Context, userID, sessionID, refreshToken string, ttl time.Duration) error
+	GetUserSessions(ctx context.Context, userID string) ([]models.UserSession, error)
+	RemoveUserSession(ctx context.Context, userID, sessionID string) error
 }
 
 // RedisCache handles Redis operations
@@ -32,17 +53,22 @@ type RedisCache struct {
 }
 
 // NewCache creates a new cache instance
-func NewCache(redisURL string, logger *zap.Logger) (Cache, error) {
+func NewCache(redisURL string, connect ConnectOptions, logger *zap.Logger) (Cache, error) {
 	opt, err := redis.ParseURL(redisURL)
 	if err != nil {
 		return nil, err
 	}
+	if connect.Timeout > 0 {
+		opt.DialTimeout = connect.Timeout
+	}
 
 	client := redis.NewClient(opt)
+	client.AddHook(&reconnectLogger{logger: logger})
 
-	// Test the connection
-	ctx := context.Background()
-	if err := client.Ping(ctx).Err(); err != nil {
+	// Test the connection, retrying while Redis comes up
+	ping := func(ctx context.Context) error { return client.Ping(ctx).Err() }
+	if err := PingWithRetry(context.Background(), ping, connect, logger); err != nil {
+		client.Close()
 		return nil, err
 	}
 
@@ -57,6 +83,11 @@ func (c *RedisCache) Close() error {
 	return c.client.Close()
 }
 
+// Ping checks that Redis is reachable
+func (c *RedisCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
 // GetClient retrieves client metadata from cache
 func (c *RedisCache) GetClient(ctx context.Context, clientID string) (*models.Client, error) {
 	key := "client:" + clientID
@@ -94,6 +125,91 @@ func (c *RedisCache) SetClient(ctx context.Context, client *models.Client, ttl t
 	return nil
 }
 
+// GetTenant retrieves tenant metadata from cache
+func (c *RedisCache) GetTenant(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	key := "tenant:" + tenantID
+	data, err := c.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to get tenant from cache", zap.String("tenant_id", tenantID), zap.Error(err))
+		return nil, err
+	}
+
+	var tenant models.Tenant
+	if err := json.Unmarshal([]byte(data), &tenant); err != nil {
+		c.logger.Error("Failed to unmarshal tenant data", zap.Error(err))
+		return nil, err
+	}
+
+	return &tenant, nil
+}
+
+// SetTenant stores tenant metadata in cache
+func (c *RedisCache) SetTenant(ctx context.Context, tenant *models.Tenant, ttl time.Duration) error {
+	key := "tenant:" + tenant.ID
+	data, err := json.Marshal(tenant)
+	if err != nil {
+		return err
+	}
+
+	if err := c.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		c.logger.Error("Failed to set tenant in cache", zap.String("tenant_id", tenant.ID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetTenantConfig retrieves a tenant's policy from cache
+func (c *RedisCache) GetTenantConfig(ctx context.Context, tenantID string) (*models.TenantConfig, error) {
+	key := "tenant_config:" + tenantID
+	data, err := c.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to get tenant config from cache", zap.String("tenant_id", tenantID), zap.Error(err))
+		return nil, err
+	}
+
+	var tenantConfig models.TenantConfig
+	if err := json.Unmarshal([]byte(data), &tenantConfig); err != nil {
+		c.logger.Error("Failed to unmarshal tenant config", zap.Error(err))
+		return nil, err
+	}
+
+	return &tenantConfig, nil
+}
+
+// SetTenantConfig stores a tenant's policy in cache
+func (c *RedisCache) SetTenantConfig(ctx context.Context, tenantConfig *models.TenantConfig, ttl time.Duration) error {
+	key := "tenant_config:" + tenantConfig.TenantID
+	data, err := json.Marshal(tenantConfig)
+	if err != nil {
+		return err
+	}
+
+	if err := c.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		c.logger.Error("Failed to set tenant config in cache", zap.String("tenant_id", tenantConfig.TenantID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// DeleteTenantConfig drops a tenant's cached policy so the next lookup reads
+// the database
+func (c *RedisCache) DeleteTenantConfig(ctx context.Context, tenantID string) error {
+	if err := c.client.Del(ctx, "tenant_config:"+tenantID).Err(); err != nil {
+		c.logger.Error("Failed to delete tenant config from cache", zap.String("tenant_id", tenantID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 // CheckRateLimit checks if the client has exceeded rate limit
 func (c *RedisCache) CheckRateLimit(ctx context.Context, clientID string, limit int, window time.Duration) (bool, error) {
 	key := "rate_limit:" + clientID
@@ -113,6 +229,36 @@ func (c *RedisCache) CheckRateLimit(ctx context.Context, clientID string, limit
 	return count > int64(limit), nil
 }
 
+// GetRateLimitUsage returns how many requests clientID has made in its
+// current rate limit window and how long until the window resets. Both are
+// zero when no window is open.
+func (c *RedisCache) GetRateLimitUsage(ctx context.Context, clientID string) (int64, time.Duration, error) {
+	key := "rate_limit:" + clientID
+	pipe := c.client.Pipeline()
+	countCmd := pipe.Get(ctx, key)
+	ttlCmd := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		c.logger.Error("Failed to get rate limit usage", zap.String("client_id", clientID), zap.Error(err))
+		return 0, 0, err
+	}
+
+	count, err := countCmd.Int64()
+	if err == redis.Nil {
+		return 0, 0, nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to parse rate limit counter", zap.String("client_id", clientID), zap.Error(err))
+		return 0, 0, err
+	}
+
+	// PTTL is negative for a key without an expiry
+	ttl := ttlCmd.Val()
+	if ttl < 0 {
+		ttl = 0
+	}
+	return count, ttl, nil
+}
+
 // StoreRefreshToken stores a refresh token in Redis
 func (c *RedisCache) StoreRefreshToken(ctx context.Context, tokenID string, data *models.RefreshTokenData, ttl time.Duration) error {
 	key := "refresh_token:" + tokenID
@@ -201,3 +347,254 @@ func (c *RedisCache) IsRefreshTokenRevoked(ctx context.Context, tokenID string)
 	}
 	return exists > 0, nil
 }
+
+// RecordTokenSource adds source to the set of sources that have presented the
+// token jti, kept for ttl. It returns the number of distinct sources so far
+// and whether source is new to the set.
+func (c *RedisCache) RecordTokenSource(ctx context.Context, jti, source string, ttl time.Duration) (int64, bool, error) {
+	key := "token_sources:" + jti
+	var added *redis.IntCmd
+	var count *redis.IntCmd
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		added = pipe.SAdd(ctx, key, source)
+		pipe.Expire(ctx, key, ttl)
+		count = pipe.SCard(ctx, key)
+		return nil
+	})
+	if err != nil {
+		c.logger.Error("Failed to record token source", zap.String("jti", jti), zap.Error(err))
+		return 0, false, err
+	}
+	return count.Val(), added.Val() > 0, nil
+}
+
+// SetUserRevocationCutoff revokes every token issued to a user at or before
+// cutoff. ttl should cover the longest lifetime of any outstanding token.
+func (c *RedisCache) SetUserRevocationCutoff(ctx context.Context, userID string, cutoff time.Time, ttl time.Duration) error {
+	key := "revoked:user:" + userID
+	if err := c.client.Set(ctx, key, cutoff.UnixMilli(), ttl).Err(); err != nil {
+		c.logger.Error("Failed to set user revocation cutoff", zap.String("user_id", userID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetUserRevocationCutoff returns the user's revocation cutoff, or the zero
+// time if none is set.
+func (c *RedisCache) GetUserRevocationCutoff(ctx context.Context, userID string) (time.Time, error) {
+	key := "revoked:user:" + userID
+	millis, err := c.client.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to get user revocation cutoff", zap.String("user_id", userID), zap.Error(err))
+		return time.Time{}, err
+	}
+	return time.UnixMilli(millis), nil
+}
+
+// SetUserRolesChangedAt records when a user's roles last changed, so tokens
+// carrying the previous roles can be rejected. ttl should cover the longest
+// lifetime of any outstanding token.
+func (c *RedisCache) SetUserRolesChangedAt(ctx context.Context, userID string, changedAt time.Time, ttl time.Duration) error {
+	key := "roles_changed:user:" + userID
+	if err := c.client.Set(ctx, key, changedAt.UnixMilli(), ttl).Err(); err != nil {
+		c.logger.Error("Failed to set user roles changed time", zap.String("user_id", userID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetUserRolesChangedAt returns when the user's roles last changed, or the
+// zero time if no change is recorded.
+func (c *RedisCache) GetUserRolesChangedAt(ctx context.Context, userID string) (time.Time, error) {
+	key := "roles_changed:user:" + userID
+	millis, err := c.client.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to get user roles changed time", zap.String("user_id", userID), zap.Error(err))
+		return time.Time{}, err
+	}
+	return time.UnixMilli(millis), nil
+}
+
+// StoreDeviceCode stores a device authorization, indexed by both its device
+// code and its user code
+func (c *RedisCache) StoreDeviceCode(ctx context.Context, deviceCode string, data *models.DeviceCodeData, ttl time.Duration) error {
+	codeData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	pipe := c.client.TxPipeline()
+	pipe.Set(ctx, "device_code:"+deviceCode, codeData, ttl)
+	pipe.Set(ctx, "device_user_code:"+data.UserCode, deviceCode, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.logger.Error("Failed to store device code", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// GetDeviceCode retrieves a device authorization by device code
+func (c *RedisCache) GetDeviceCode(ctx context.Context, deviceCode string) (*models.DeviceCodeData, error) {
+	data, err := c.client.Get(ctx, "device_code:"+deviceCode).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to get device code", zap.Error(err))
+		return nil, err
+	}
+
+	var codeData models.DeviceCodeData
+	if err := json.Unmarshal([]byte(data), &codeData); err != nil {
+		c.logger.Error("Failed to unmarshal device code data", zap.Error(err))
+		return nil, err
+	}
+
+	return &codeData, nil
+}
+
+// GetDeviceCodeByUserCode returns the device code for a user code, or "" if
+// there is none
+func (c *RedisCache) GetDeviceCodeByUserCode(ctx context.Context, userCode string) (string, error) {
+	deviceCode, err := c.client.Get(ctx, "device_user_code:"+userCode).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to get device code by user code", zap.Error(err))
+		return "", err
+	}
+	return deviceCode, nil
+}
+
+// DeleteDeviceCode deletes a device authorization and its user code index
+func (c *RedisCache) DeleteDeviceCode(ctx context.Context, deviceCode, userCode string) error {
+	if err := c.client.Del(ctx, "device_code:"+deviceCode, "device_user_code:"+userCode).Err(); err != nil {
+		c.logger.Error("Failed to delete device code", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// StoreCookieSession maps a cookie session ID to the refresh token it
+// currently stands for
+func (c *RedisCache) StoreCookieSession(ctx context.Context, sessionID, refreshToken string, ttl time.Duration) error {
+	if err := c.client.Set(ctx, "cookie_session:"+sessionID, refreshToken, ttl).Err(); err != nil {
+		c.logger.Error("Failed to store cookie session", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetCookieSession returns the refresh token of a cookie session, or "" if
+// there is none
+func (c *RedisCache) GetCookieSession(ctx context.Context, sessionID string) (string, error) {
+	refreshToken, err := c.client.Get(ctx, "cookie_session:"+sessionID).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to get cookie session", zap.Error(err))
+		return "", err
+	}
+	return refreshToken, nil
+}
+
+// TrackUserSession records a session in the user's session index with
+// refreshToken as its current refresh token. A session already in the index
+// keeps the time it started.
+func (c *RedisCache) TrackUserSession(ctx context.Context, userID, sessionID, refreshToken string, ttl time.Duration) error {
+	startedKey, tokensKey := "user_sessions:"+userID, "user_session_tokens:"+userID
+
+	pipe := c.client.TxPipeline()
+	pipe.ZAddNX(ctx, startedKey, redis.Z{Score: float64(time.Now().UnixMilli()), Member: sessionID})
+	pipe.HSet(ctx, tokensKey, sessionID, refreshToken)
+	pipe.Expire(ctx, startedKey, ttl)
+	pipe.Expire(ctx, tokensKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.logger.Error("Failed to track user session", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetUserSessions returns the user's sessions whose current refresh token
+// still exists, oldest first. Sessions whose refresh token expired or was
+// deleted are dropped from the index.
+func (c *RedisCache) GetUserSessions(ctx context.Context, userID string) ([]models.UserSession, error) {
+	startedKey, tokensKey := "user_sessions:"+userID, "user_session_tokens:"+userID
+
+	started, err := c.client.ZRangeWithScores(ctx, startedKey, 0, -1).Result()
+	if err != nil {
+		c.logger.Error("Failed to get user sessions", zap.Error(err))
+		return nil, err
+	}
+	if len(started) == 0 {
+		return nil, nil
+	}
+
+	sessionIDs := make([]string, len(started))
+	for i, z := range started {
+		sessionIDs[i], _ = z.Member.(string)
+	}
+	tokens, err := c.client.HMGet(ctx, tokensKey, sessionIDs...).Result()
+	if err != nil {
+		c.logger.Error("Failed to get user session tokens", zap.Error(err))
+		return nil, err
+	}
+
+	pipe := c.client.Pipeline()
+	exists := make([]*redis.IntCmd, len(sessionIDs))
+	for i := range sessionIDs {
+		if token, _ := tokens[i].(string); token != "" {
+			exists[i] = pipe.Exists(ctx, "refresh_token:"+token)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.logger.Error("Failed to check user session tokens", zap.Error(err))
+		return nil, err
+	}
+
+	var sessions []models.UserSession
+	var stale []string
+	for i, sessionID := range sessionIDs {
+		if exists[i] == nil || exists[i].Val() == 0 {
+			stale = append(stale, sessionID)
+			continue
+		}
+		sessions = append(sessions, models.UserSession{
+			SessionID:    sessionID,
+			RefreshToken: tokens[i].(string),
+			StartedAt:    time.UnixMilli(int64(started[i].Score)),
+		})
+	}
+
+	if len(stale) > 0 {
+		pipe := c.client.TxPipeline()
+		pipe.ZRem(ctx, startedKey, stale)
+		pipe.HDel(ctx, tokensKey, stale...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			c.logger.Warn("Failed to drop ended user sessions", zap.Error(err))
+		}
+	}
+	return sessions, nil
+}
+
+// RemoveUserSession removes a session from the user's session index
+func (c *RedisCache) RemoveUserSession(ctx context.Context, userID, sessionID string) error {
+	pipe := c.client.TxPipeline()
+	pipe.ZRem(ctx, "user_sessions:"+userID, sessionID)
+	pipe.HDel(ctx, "user_session_tokens:"+userID, sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.logger.Error("Failed to remove user session", zap.Error(err))
+		return err
+	}
+	return nil
+}
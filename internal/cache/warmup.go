@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"session-service/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ClientLister is the subset of the repository needed to warm the client cache.
+type ClientLister interface {
+	ListActiveClients(ctx context.Context, limit int) ([]*models.Client, error)
+}
+
+// WarmClients preloads up to max recently used clients into the cache so the
+// first requests after a deploy don't all fall through to the database.
+// Individual cache failures are logged and skipped; it returns the number of
+// clients cached.
+func WarmClients(ctx context.Context, lister ClientLister, c Cache, max int, ttl time.Duration, logger *zap.Logger) (int, error) {
+	if max <= 0 {
+		return 0, nil
+	}
+
+	clients, err := lister.ListActiveClients(ctx, max)
+	if err != nil {
+		return 0, err
+	}
+	if len(clients) > max {
+		clients = clients[:max]
+	}
+
+	warmed := 0
+	for _, client := range clients {
+		if err := c.SetClient(ctx, client, ttl); err != nil {
+			logger.Warn("Failed to warm client cache", zap.String("client_id", client.ClientID), zap.Error(err))
+			continue
+		}
+		warmed++
+	}
+
+	return warmed, nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Postgres SQLSTATE codes the repository classifies.
+// See https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	sqlStateUniqueViolation      = "23505"
+	sqlStateSerializationFailure = "40001"
+)
+
+// sqlStateError is implemented by driver errors that expose a SQLSTATE code
+// (lib/pq's *pq.Error and pgx's *pgconn.PgError both do).
+type sqlStateError interface {
+	SQLState() string
+}
+
+// sqlState returns the SQLSTATE code of err, or "" if err is not a driver error.
+func sqlState(err error) string {
+	var stateErr sqlStateError
+	if errors.As(err, &stateErr) {
+		return stateErr.SQLState()
+	}
+	return ""
+}
+
+// IsUniqueViolation reports whether err is a unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	return sqlState(err) == sqlStateUniqueViolation
+}
+
+// IsSerializationFailure reports whether err is a serialization failure,
+// which is safe to retry by re-running the whole transaction.
+func IsSerializationFailure(err error) bool {
+	return sqlState(err) == sqlStateSerializationFailure
+}
+
+// RetryOnSerializationFailure runs fn up to maxAttempts times, retrying only
+// when it fails with a serialization failure. The wait between attempts grows
+// linearly with backoff. Any other error is returned immediately.
+func RetryOnSerializationFailure(ctx context.Context, maxAttempts int, backoff time.Duration, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err = fn(); err == nil || !IsSerializationFailure(err) {
+			return err
+		}
+		if attempt == maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * backoff):
+		}
+	}
+	return err
+}
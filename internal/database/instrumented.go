@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"session-service/internal/audit"
+	"session-service/internal/metrics"
+	"session-service/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// InstrumentedRepository decorates a Repository, tracing every call as a
+// child span, recording its latency and logging calls slower than the
+// configured threshold.
+type InstrumentedRepository struct {
+	next  Repository
+	timer *metrics.OperationTimer
+}
+
+// NewInstrumentedRepository wraps repo with latency metrics and slow-query
+// logging. A zero threshold disables the slow-query log.
+func NewInstrumentedRepository(repo Repository, threshold time.Duration, logger *zap.Logger) Repository {
+	return &InstrumentedRepository{
+		next:  repo,
+		timer: &metrics.OperationTimer{Component: "database", Threshold: threshold, Logger: logger},
+	}
+}
+
+func (r *InstrumentedRepository) Close() error {
+	return r.next.Close()
+}
+
+func (r *InstrumentedRepository) Ping(ctx context.Context) error {
+	return r.next.Ping(ctx)
+}
+
+func (r *InstrumentedRepository) GetClientByID(ctx context.Context, clientID string) (*models.Client, error) {
+	ctx, done := r.timer.Start(ctx, "GetClientByID")
+	defer done()
+	return r.next.GetClientByID(ctx, clientID)
+}
+
+func (r *InstrumentedRepository) UpdateClientUpdatedAt(ctx context.Context, clientID string) error {
+	ctx, done := r.timer.Start(ctx, "UpdateClientUpdatedAt")
+	defer done()
+	return r.next.UpdateClientUpdatedAt(ctx, clientID)
+}
+
+func (r *InstrumentedRepository) GetAudienceEncryptionKey(ctx context.Context, audience string) (string, error) {
+	ctx, done := r.timer.Start(ctx, "GetAudienceEncryptionKey")
+	defer done()
+	return r.next.GetAudienceEncryptionKey(ctx, audience)
+}
+
+func (r *InstrumentedRepository) ListActiveClients(ctx context.Context, limit int) ([]*models.Client, error) {
+	ctx, done := r.timer.Start(ctx, "ListActiveClients")
+	defer done()
+	return r.next.ListActiveClients(ctx, limit)
+}
+
+func (r *InstrumentedRepository) ListTenantClients(ctx context.Context, tenantID string, limit, offset int) ([]*models.Client, int, error) {
+	ctx, done := r.timer.Start(ctx, "ListTenantClients")
+	defer done()
+	return r.next.ListTenantClients(ctx, tenantID, limit, offset)
+}
+
+func (r *InstrumentedRepository) UpdateClientMetadata(ctx context.Context, tenantID, clientID string, metadata models.ClientMetadata) (*models.Client, error) {
+	ctx, done := r.timer.Start(ctx, "UpdateClientMetadata")
+	defer done()
+	return r.next.UpdateClientMetadata(ctx, tenantID, clientID, metadata)
+}
+
+func (r *InstrumentedRepository) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	ctx, done := r.timer.Start(ctx, "GetUserByID")
+	defer done()
+	return r.next.GetUserByID(ctx, userID)
+}
+
+func (r *InstrumentedRepository) GetUserRoles(ctx context.Context, userID string) ([]string, error) {
+	ctx, done := r.timer.Start(ctx, "GetUserRoles")
+	defer done()
+	return r.next.GetUserRoles(ctx, userID)
+}
+
+func (r *InstrumentedRepository) EnsureTenantExists(ctx context.Context, tenantID string) error {
+	ctx, done := r.timer.Start(ctx, "EnsureTenantExists")
+	defer done()
+	return r.next.EnsureTenantExists(ctx, tenantID)
+}
+
+func (r *InstrumentedRepository) GetTenantByID(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	ctx, done := r.timer.Start(ctx, "GetTenantByID")
+	defer done()
+	return r.next.GetTenantByID(ctx, tenantID)
+}
+
+func (r *InstrumentedRepository) SetTenantHMACSecret(ctx context.Context, tenantID string, encryptedSecret []byte) (bool, error) {
+	ctx, done := r.timer.Start(ctx, "SetTenantHMACSecret")
+	defer done()
+	return r.next.SetTenantHMACSecret(ctx, tenantID, encryptedSecret)
+}
+
+func (r *InstrumentedRepository) GetTenantConfig(ctx context.Context, tenantID string) (*models.TenantConfig, error) {
+	ctx, done := r.timer.Start(ctx, "GetTenantConfig")
+	defer done()
+	return r.next.GetTenantConfig(ctx, tenantID)
+}
+
+func (r *InstrumentedRepository) UpsertTenantConfig(ctx context.Context, tenantConfig models.TenantConfig) (bool, error) {
+	ctx, done := r.timer.Start(ctx, "UpsertTenantConfig")
+	defer done()
+	return r.next.UpsertTenantConfig(ctx, tenantConfig)
+}
+
+func (r *InstrumentedRepository) ListTenantRoles(ctx context.Context, tenantID string) ([]string, error) {
+	ctx, done := r.timer.Start(ctx, "ListTenantRoles")
+	defer done()
+	return r.next.ListTenantRoles(ctx, tenantID)
+}
+
+func (r *InstrumentedRepository) UpsertUserAndRoles(ctx context.Context, user models.User, roles []string) (bool, error) {
+	ctx, done := r.timer.Start(ctx, "UpsertUserAndRoles")
+	defer done()
+	return r.next.UpsertUserAndRoles(ctx, user, roles)
+}
+
+func (r *InstrumentedRepository) DeleteUser(ctx context.Context, tenantID, userID string) (bool, error) {
+	ctx, done := r.timer.Start(ctx, "DeleteUser")
+	defer done()
+	return r.next.DeleteUser(ctx, tenantID, userID)
+}
+
+func (r *InstrumentedRepository) GetUserExport(ctx context.Context, tenantID, userID string) (*models.UserExport, error) {
+	ctx, done := r.timer.Start(ctx, "GetUserExport")
+	defer done()
+	return r.next.GetUserExport(ctx, tenantID, userID)
+}
+
+func (r *InstrumentedRepository) ExportTenantUsers(ctx context.Context, tenantID string, fn func(*models.UserExport) error) error {
+	ctx, done := r.timer.Start(ctx, "ExportTenantUsers")
+	defer done()
+	return r.next.ExportTenantUsers(ctx, tenantID, fn)
+}
+
+func (r *InstrumentedRepository) ListTenantUsers(ctx context.Context, tenantID string, limit, offset int) ([]*models.UserExport, int, error) {
+	ctx, done := r.timer.Start(ctx, "ListTenantUsers")
+	defer done()
+	return r.next.ListTenantUsers(ctx, tenantID, limit, offset)
+}
+
+func (r *InstrumentedRepository) CreateTenantIfNotExists(ctx context.Context, tenant models.Tenant) (bool, error) {
+	ctx, done := r.timer.Start(ctx, "CreateTenantIfNotExists")
+	defer done()
+	return r.next.CreateTenantIfNotExists(ctx, tenant)
+}
+
+func (r *InstrumentedRepository) InsertAuditEvent(ctx context.Context, event audit.Event) error {
+	ctx, done := r.timer.Start(ctx, "InsertAuditEvent")
+	defer done()
+	return r.next.InsertAuditEvent(ctx, event)
+}
+
+func (r *InstrumentedRepository) LoadSigningKeys(ctx context.Context, fingerprint string) ([]*models.SigningKey, error) {
+	ctx, done := r.timer.Start(ctx, "LoadSigningKeys")
+	defer done()
+	return r.next.LoadSigningKeys(ctx, fingerprint)
+}
+
+func (r *InstrumentedRepository) SaveSigningKey(ctx context.Context, key *models.SigningKey) error {
+	ctx, done := r.timer.Start(ctx, "SaveSigningKey")
+	defer done()
+	return r.next.SaveSigningKey(ctx, key)
+}
+
+func (r *InstrumentedRepository) MarkKeyExpired(ctx context.Context, keyID string, expiresAt time.Time) error {
+	ctx, done := r.timer.Start(ctx, "MarkKeyExpired")
+	defer done()
+	return r.next.MarkKeyExpired(ctx, keyID, expiresAt)
+}
+
+func (r *InstrumentedRepository) CreateClientIfNotExists(ctx context.Context, client models.Client) (bool, error) {
+	ctx, done := r.timer.Start(ctx, "CreateClientIfNotExists")
+	defer done()
+	return r.next.CreateClientIfNotExists(ctx, client)
+}
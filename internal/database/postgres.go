@@ -3,10 +3,13 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"session-service/internal/audit"
 	"session-service/internal/models"
 	"time"
 
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 	"gocloud.dev/postgres"
 	_ "gocloud.dev/postgres/awspostgres"
@@ -16,16 +19,44 @@ import (
 // Repository defines the interface for database operations
 type Repository interface {
 	Close() error
+	Ping(ctx context.Context) error
 
 	// Clients
 	GetClientByID(ctx context.Context, clientID string) (*models.Client, error)
 	UpdateClientUpdatedAt(ctx context.Context, clientID string) error
+	GetAudienceEncryptionKey(ctx context.Context, audience string) (string, error)
+	ListActiveClients(ctx context.Context, limit int) ([]*models.Client, error)
+	ListTenantClients(ctx context.Context, tenantID string, limit, offset int) ([]*models.Client, int, error)
+	UpdateClientMetadata(ctx context.Context, tenantID, clientID string, metadata models.ClientMetadata) (*models.Client, error)
 
 	// Tenants & Users
 	GetUserByID(ctx context.Context, userID string) (*models.User, error)
 	GetUserRoles(ctx context.Context, userID string) ([]string, error)
 	EnsureTenantExists(ctx context.Context, tenantID string) error
-	UpsertUserAndRoles(ctx context.Context, user models.User, roles []string) error
+	GetTenantByID(ctx context.Context, tenantID string) (*models.Tenant, error)
+	SetTenantHMACSecret(ctx context.Context, tenantID string, encryptedSecret []byte) (bool, error)
+	GetTenantConfig(ctx context.Context, tenantID string) (*models.TenantConfig, error)
+	UpsertTenantConfig(ctx context.Context, tenantConfig models.TenantConfig) (bool, error)
+	ListTenantRoles(ctx context.Context, tenantID string) ([]string, error)
+	UpsertUserAndRoles(ctx context.Context, user models.User, roles []string) (bool, error)
+	DeleteUser(ctx context.Context, tenantID, userID string) (bool, error)
+
+	// Data export
+	GetUserExport(ctx context.Context, tenantID, userID string) (*models.UserExport, error)
+	ExportTenantUsers(ctx context.Context, tenantID string, fn func(*models.UserExport) error) error
+	ListTenantUsers(ctx context.Context, tenantID string, limit, offset int) ([]*models.UserExport, int, error)
+
+	// Audit
+	InsertAuditEvent(ctx context.Context, event audit.Event) error
+
+	// Signing keys
+	LoadSigningKeys(ctx context.Context, fingerprint string) ([]*models.SigningKey, error)
+	SaveSigningKey(ctx context.Context, key *models.SigningKey) error
+	MarkKeyExpired(ctx context.Context, keyID string, expiresAt time.Time) error
+
+	// Bootstrap
+	CreateTenantIfNotExists(ctx context.Context, tenant models.Tenant) (bool, error)
+	CreateClientIfNotExists(ctx context.Context, client models.Client) (bool, error)
 }
 
 // PostgresRepository handles database operations
@@ -70,10 +101,15 @@ func (r *PostgresRepository) Close() error {
 	return r.db.Close()
 }
 
+// Ping checks that the database is reachable
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
 // GetClientByID retrieves a client by client_id
 func (r *PostgresRepository) GetClientByID(ctx context.Context, clientID string) (*models.Client, error) {
 	query := `
-		SELECT id, client_id, client_secret_hash, rate_limit, tenant_id, user_id, created_at, updated_at
+		SELECT id, client_id, client_secret_hash, rate_limit, tenant_id, user_id, COALESCE(signing_alg, ''), encrypt_access_tokens, COALESCE(role_prefixes, '{}'), COALESCE(allowed_scopes, '{}'), COALESCE(allowed_grant_types, '{}'), COALESCE(grantable_roles, '{}'), verbose_introspection, COALESCE(name, ''), COALESCE(description, ''), created_at, updated_at
 		FROM clients
 		WHERE client_id = $1
 	`
@@ -86,6 +122,15 @@ func (r *PostgresRepository) GetClientByID(ctx context.Context, clientID string)
 		&client.RateLimit,
 		&client.TenantID,
 		&client.UserID,
+		&client.SigningAlg,
+		&client.EncryptAccessTokens,
+		pq.Array(&client.RolePrefixes),
+		pq.Array(&client.AllowedScopes),
+		pq.Array(&client.AllowedGrantTypes),
+		pq.Array(&client.GrantableRoles),
+		&client.VerboseIntrospection,
+		&client.Name,
+		&client.Description,
 		&client.CreatedAt,
 		&client.UpdatedAt,
 	)
@@ -112,10 +157,175 @@ func (r *PostgresRepository) UpdateClientUpdatedAt(ctx context.Context, clientID
 	return nil
 }
 
+// ListActiveClients returns up to limit clients, most recently used first.
+// updated_at is bumped on every token issuance, so it tracks client activity.
+func (r *PostgresRepository) ListActiveClients(ctx context.Context, limit int) ([]*models.Client, error) {
+	query := `
+		SELECT id, client_id, client_secret_hash, rate_limit, tenant_id, user_id, COALESCE(signing_alg, ''), encrypt_access_tokens, COALESCE(role_prefixes, '{}'), COALESCE(allowed_scopes, '{}'), COALESCE(allowed_grant_types, '{}'), COALESCE(grantable_roles, '{}'), verbose_introspection, COALESCE(name, ''), COALESCE(description, ''), created_at, updated_at
+		FROM clients
+		ORDER BY updated_at DESC
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		r.logger.Error("Failed to list active clients", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []*models.Client
+	for rows.Next() {
+		var client models.Client
+		if err := rows.Scan(
+			&client.ID,
+			&client.ClientID,
+			&client.ClientSecretHash,
+			&client.RateLimit,
+			&client.TenantID,
+			&client.UserID,
+			&client.SigningAlg,
+			&client.EncryptAccessTokens,
+			pq.Array(&client.RolePrefixes),
+			pq.Array(&client.AllowedScopes),
+			pq.Array(&client.AllowedGrantTypes),
+			pq.Array(&client.GrantableRoles),
+			&client.VerboseIntrospection,
+			&client.Name,
+			&client.Description,
+			&client.CreatedAt,
+			&client.UpdatedAt,
+		); err != nil {
+			r.logger.Error("Failed to scan client row", zap.Error(err))
+			return nil, err
+		}
+		clients = append(clients, &client)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Failed to iterate client rows", zap.Error(err))
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+// ListTenantClients returns up to limit clients of a tenant, ordered by
+// client_id and skipping the first offset, along with the tenant's total
+// client count.
+func (r *PostgresRepository) ListTenantClients(ctx context.Context, tenantID string, limit, offset int) ([]*models.Client, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM clients WHERE tenant_id = $1`, tenantID).Scan(&total); err != nil {
+		r.logger.Error("Failed to count tenant clients", zap.String("tenant_id", tenantID), zap.Error(err))
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, client_id, rate_limit, tenant_id, COALESCE(allowed_grant_types, '{}'), COALESCE(name, ''), COALESCE(description, ''), created_at, updated_at
+		FROM clients
+		WHERE tenant_id = $1
+		ORDER BY client_id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to list tenant clients", zap.String("tenant_id", tenantID), zap.Error(err))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	clients := []*models.Client{}
+	for rows.Next() {
+		var client models.Client
+		if err := rows.Scan(
+			&client.ID,
+			&client.ClientID,
+			&client.RateLimit,
+			&client.TenantID,
+			pq.Array(&client.AllowedGrantTypes),
+			&client.Name,
+			&client.Description,
+			&client.CreatedAt,
+			&client.UpdatedAt,
+		); err != nil {
+			r.logger.Error("Failed to scan client row", zap.Error(err))
+			return nil, 0, err
+		}
+		clients = append(clients, &client)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Failed to iterate client rows", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return clients, total, nil
+}
+
+// UpdateClientMetadata sets the fields of metadata that are non-nil on a
+// tenant's client; an empty string clears the field. It returns the updated
+// client, or nil if the tenant has no such client.
+func (r *PostgresRepository) UpdateClientMetadata(ctx context.Context, tenantID, clientID string, metadata models.ClientMetadata) (*models.Client, error) {
+	query := `
+		UPDATE clients SET
+			name = CASE WHEN $3 THEN NULLIF($4, '') ELSE name END,
+			description = CASE WHEN $5 THEN NULLIF($6, '') ELSE description END
+		WHERE tenant_id = $1 AND client_id = $2
+		RETURNING id, client_id, rate_limit, tenant_id, COALESCE(allowed_grant_types, '{}'), COALESCE(name, ''), COALESCE(description, ''), created_at, updated_at
+	`
+
+	var name, description string
+	if metadata.Name != nil {
+		name = *metadata.Name
+	}
+	if metadata.Description != nil {
+		description = *metadata.Description
+	}
+
+	var client models.Client
+	err := r.db.QueryRowContext(ctx, query, tenantID, clientID, metadata.Name != nil, name, metadata.Description != nil, description).Scan(
+		&client.ID,
+		&client.ClientID,
+		&client.RateLimit,
+		&client.TenantID,
+		pq.Array(&client.AllowedGrantTypes),
+		&client.Name,
+		&client.Description,
+		&client.CreatedAt,
+		&client.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to update client metadata", zap.String("tenant_id", tenantID), zap.String("client_id", clientID), zap.Error(err))
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+// GetAudienceEncryptionKey returns the PEM public key registered for the
+// resource server behind audience, or "" if none is registered.
+func (r *PostgresRepository) GetAudienceEncryptionKey(ctx context.Context, audience string) (string, error) {
+	query := `SELECT public_key FROM audience_encryption_keys WHERE audience = $1`
+
+	var publicKey string
+	err := r.db.QueryRowContext(ctx, query, audience).Scan(&publicKey)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to get audience encryption key", zap.String("audience", audience), zap.Error(err))
+		return "", err
+	}
+
+	return publicKey, nil
+}
+
 // GetUserByID retrieves a user by ID
 func (r *PostgresRepository) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
 	query := `
-		SELECT id, tenant_id, email, full_name, phone_number, created_at, updated_at
+		SELECT id, tenant_id, email, full_name, phone_number, email_verified, phone_verified, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -128,6 +338,8 @@ func (r *PostgresRepository) GetUserByID(ctx context.Context, userID string) (*m
 		&email,
 		&user.FullName,
 		&user.PhoneNumber,
+		&user.EmailVerified,
+		&user.PhoneVerified,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -182,6 +394,115 @@ func (r *PostgresRepository) GetUserRoles(ctx context.Context, userID string) ([
 	return roles, nil
 }
 
+// ListTenantRoles returns the tenant's role catalog. An empty result means the
+// tenant has no catalog and accepts free-form roles.
+func (r *PostgresRepository) ListTenantRoles(ctx context.Context, tenantID string) ([]string, error) {
+	query := `
+		SELECT role
+		FROM tenant_roles
+		WHERE tenant_id = $1
+		ORDER BY role
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		r.logger.Error("Failed to list tenant roles", zap.String("tenant_id", tenantID), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			r.logger.Error("Failed to scan tenant role", zap.Error(err))
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// SetTenantHMACSecret stores the tenant's encrypted HS256 signing secret, or
+// clears it when encryptedSecret is nil so the tenant goes back to the
+// published keys. It reports whether the tenant exists.
+func (r *PostgresRepository) SetTenantHMACSecret(ctx context.Context, tenantID string, encryptedSecret []byte) (bool, error) {
+	query := `
+		UPDATE tenants
+		SET hmac_secret = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	res, err := r.db.ExecContext(ctx, query, tenantID, encryptedSecret)
+	if err != nil {
+		r.logger.Error("Failed to set tenant HMAC secret", zap.String("tenant_id", tenantID), zap.Error(err))
+		return false, err
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return updated > 0, nil
+}
+
+// GetTenantConfig returns the tenant's policy. A tenant without a
+// tenant_config row gets the defaults, which apply the global configuration.
+func (r *PostgresRepository) GetTenantConfig(ctx context.Context, tenantID string) (*models.TenantConfig, error) {
+	query := `
+		SELECT tenant_id, allowed_grant_types, max_sessions_per_user, updated_at
+		FROM tenant_config
+		WHERE tenant_id = $1
+	`
+
+	var tenantConfig models.TenantConfig
+	err := r.db.QueryRowContext(ctx, query, tenantID).Scan(
+		&tenantConfig.TenantID,
+		pq.Array(&tenantConfig.AllowedGrantTypes),
+		&tenantConfig.MaxSessionsPerUser,
+		&tenantConfig.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return &models.TenantConfig{TenantID: tenantID}, nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to get tenant config", zap.String("tenant_id", tenantID), zap.Error(err))
+		return nil, err
+	}
+
+	return &tenantConfig, nil
+}
+
+// UpsertTenantConfig replaces the tenant's policy. It reports whether the
+// tenant exists; nothing is stored for an unknown tenant.
+func (r *PostgresRepository) UpsertTenantConfig(ctx context.Context, tenantConfig models.TenantConfig) (bool, error) {
+	query := `
+		INSERT INTO tenant_config (tenant_id, allowed_grant_types, max_sessions_per_user, updated_at)
+		SELECT id, $2, $3, CURRENT_TIMESTAMP FROM tenants WHERE id = $1
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			allowed_grant_types = EXCLUDED.allowed_grant_types,
+			max_sessions_per_user = EXCLUDED.max_sessions_per_user,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	res, err := r.db.ExecContext(ctx, query, tenantConfig.TenantID, pq.Array(tenantConfig.AllowedGrantTypes), tenantConfig.MaxSessionsPerUser)
+	if err != nil {
+		r.logger.Error("Failed to upsert tenant config", zap.String("tenant_id", tenantConfig.TenantID), zap.Error(err))
+		return false, err
+	}
+
+	upserted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return upserted > 0, nil
+}
+
 // EnsureTenantExists verifies that a tenant with the given ID exists.
 // It returns sql.ErrNoRows if the tenant does not exist so callers can map
 // this to an appropriate invalid_request-style error.
@@ -205,12 +526,77 @@ func (r *PostgresRepository) EnsureTenantExists(ctx context.Context, tenantID st
 	return nil
 }
 
-// UpsertUserAndRoles upserts a user and, if roles are provided, replaces all
-// role assignments for that user in a single transaction.
-func (r *PostgresRepository) UpsertUserAndRoles(ctx context.Context, user models.User, roles []string) error {
-	tx, err := r.db.BeginTx(ctx, nil)
+// GetTenantByID retrieves a tenant by ID, including any per-tenant token
+// expiry overrides. It returns nil if the tenant does not exist.
+func (r *PostgresRepository) GetTenantByID(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	query := `
+		SELECT id, external_tid, name, access_token_ttl, refresh_token_ttl, hmac_secret, bind_refresh_tokens, created_at, updated_at
+		FROM tenants
+		WHERE id = $1
+	`
+
+	var tenant models.Tenant
+	var externalTID sql.NullString
+	var accessTokenTTL, refreshTokenTTL sql.NullInt64
+	err := r.db.QueryRowContext(ctx, query, tenantID).Scan(
+		&tenant.ID,
+		&externalTID,
+		&tenant.Name,
+		&accessTokenTTL,
+		&refreshTokenTTL,
+		&tenant.EncryptedHMACSecret,
+		&tenant.BindRefreshTokens,
+		&tenant.CreatedAt,
+		&tenant.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
+		r.logger.Error("Failed to get tenant by ID", zap.String("tenant_id", tenantID), zap.Error(err))
+		return nil, err
+	}
+
+	if externalTID.Valid {
+		tenant.ExternalTID = externalTID.String
+	}
+	// TTL overrides are stored in seconds; NULL means use the global config.
+	if accessTokenTTL.Valid {
+		tenant.AccessTokenTTL = time.Duration(accessTokenTTL.Int64) * time.Second
+	}
+	if refreshTokenTTL.Valid {
+		tenant.RefreshTokenTTL = time.Duration(refreshTokenTTL.Int64) * time.Second
+	}
+
+	return &tenant, nil
+}
+
+// UpsertUserAndRoles upserts a user and, if roles are provided, replaces all
+// role assignments for that user in a single transaction. It reports whether
+// the user's set of roles changed. The transaction is serializable, so
+// concurrent upserts of one user cannot interleave their role replacements;
+// the one that loses fails with a serialization failure and is retried a
+// couple of times.
+func (r *PostgresRepository) UpsertUserAndRoles(ctx context.Context, user models.User, roles []string) (bool, error) {
+	attempt := 0
+	var rolesChanged bool
+	err := RetryOnSerializationFailure(ctx, 3, 50*time.Millisecond, func() error {
+		attempt++
+		var err error
+		rolesChanged, err = r.upsertUserAndRoles(ctx, user, roles)
+		if IsSerializationFailure(err) {
+			r.logger.Warn("Serialization failure upserting user", zap.String("user_id", user.ID), zap.Int("attempt", attempt), zap.Error(err))
+		}
 		return err
+	})
+	return rolesChanged, err
+}
+
+func (r *PostgresRepository) upsertUserAndRoles(ctx context.Context, user models.User, roles []string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return false, err
 	}
 	defer func() {
 		if err != nil {
@@ -221,13 +607,15 @@ func (r *PostgresRepository) UpsertUserAndRoles(ctx context.Context, user models
 	}()
 
 	userQuery := `
-		INSERT INTO users (id, tenant_id, email, full_name, phone_number)
-		VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+		INSERT INTO users (id, tenant_id, email, full_name, phone_number, email_verified, phone_verified)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
 		ON CONFLICT (id) DO UPDATE
 		SET tenant_id = EXCLUDED.tenant_id,
 		    email = NULLIF(EXCLUDED.email, ''),
 		    full_name = EXCLUDED.full_name,
-		    phone_number = EXCLUDED.phone_number
+		    phone_number = EXCLUDED.phone_number,
+		    email_verified = EXCLUDED.email_verified,
+		    phone_verified = EXCLUDED.phone_verified
 	`
 
 	// NULLIF in SQL converts empty strings to NULL, so empty email will be stored as NULL
@@ -237,16 +625,26 @@ func (r *PostgresRepository) UpsertUserAndRoles(ctx context.Context, user models
 		user.Email,
 		user.FullName,
 		user.PhoneNumber,
+		user.EmailVerified,
+		user.PhoneVerified,
 	); err != nil {
 		r.logger.Error("Failed to upsert user", zap.String("user_id", user.ID), zap.Error(err))
-		return err
+		return false, err
 	}
 
 	// If roles slice is non-nil, we treat it as authoritative and replace roles.
+	rolesChanged := false
 	if roles != nil {
+		var current []string
+		if current, err = userRolesTx(ctx, tx, user.ID); err != nil {
+			r.logger.Error("Failed to read existing user roles", zap.String("user_id", user.ID), zap.Error(err))
+			return false, err
+		}
+		rolesChanged = !sameRoles(current, roles)
+
 		if _, err = tx.ExecContext(ctx, `DELETE FROM user_roles WHERE user_id = $1`, user.ID); err != nil {
 			r.logger.Error("Failed to delete existing user roles", zap.String("user_id", user.ID), zap.Error(err))
-			return err
+			return false, err
 		}
 
 		if len(roles) > 0 {
@@ -258,7 +656,7 @@ func (r *PostgresRepository) UpsertUserAndRoles(ctx context.Context, user models
 			for _, role := range roles {
 				if _, err = tx.ExecContext(ctx, roleInsert, user.ID, role); err != nil {
 					r.logger.Error("Failed to insert user role", zap.String("user_id", user.ID), zap.String("role", role), zap.Error(err))
-					return err
+					return false, err
 				}
 			}
 		}
@@ -266,8 +664,347 @@ func (r *PostgresRepository) UpsertUserAndRoles(ctx context.Context, user models
 
 	if err = tx.Commit(); err != nil {
 		r.logger.Error("Failed to commit user upsert transaction", zap.String("user_id", user.ID), zap.Error(err))
+		return false, err
+	}
+
+	return rolesChanged, nil
+}
+
+// userRolesTx returns the roles currently assigned to a user within tx.
+func userRolesTx(ctx context.Context, tx *sql.Tx, userID string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT role FROM user_roles WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// sameRoles reports whether a and b contain the same set of roles, ignoring
+// order and duplicates.
+func sameRoles(a, b []string) bool {
+	setA := make(map[string]struct{}, len(a))
+	for _, role := range a {
+		setA[role] = struct{}{}
+	}
+	setB := make(map[string]struct{}, len(b))
+	for _, role := range b {
+		if _, ok := setA[role]; !ok {
+			return false
+		}
+		setB[role] = struct{}{}
+	}
+	return len(setA) == len(setB)
+}
+
+// DeleteUser deletes a user and their role assignments in a single
+// transaction. Clients referencing the user have user_id cleared by the
+// fk_clients_user_id constraint. It returns false if the user does not exist
+// within the given tenant.
+func (r *PostgresRepository) DeleteUser(ctx context.Context, tenantID, userID string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				r.logger.Error("Failed to rollback transaction", zap.Error(rbErr))
+			}
+		}
+	}()
+
+	// Roles cascade on user deletion, but delete them explicitly so the
+	// erasure does not depend on the constraint definition.
+	rolesQuery := `
+		DELETE FROM user_roles
+		WHERE user_id IN (SELECT id FROM users WHERE id = $1 AND tenant_id = $2)
+	`
+	if _, err = tx.ExecContext(ctx, rolesQuery, userID, tenantID); err != nil {
+		r.logger.Error("Failed to delete user roles", zap.String("user_id", userID), zap.Error(err))
+		return false, err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1 AND tenant_id = $2`, userID, tenantID)
+	if err != nil {
+		r.logger.Error("Failed to delete user", zap.String("user_id", userID), zap.Error(err))
+		return false, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit user deletion transaction", zap.String("user_id", userID), zap.Error(err))
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+// userExportQuery joins users with their role assignments, one row per role
+// (or a single row with a NULL role for users without roles), ordered so that
+// all rows for a user are adjacent.
+const userExportQuery = `
+	SELECT u.id, u.tenant_id, u.email, u.full_name, u.phone_number, u.email_verified, u.phone_verified, u.created_at, u.updated_at, ur.role
+	FROM users u
+	LEFT JOIN user_roles ur ON ur.user_id = u.id
+	WHERE u.tenant_id = $1 %s
+	ORDER BY u.id, ur.role
+`
+
+// GetUserExport retrieves a user and their roles for data export.
+// It returns nil if the user does not exist within the given tenant.
+func (r *PostgresRepository) GetUserExport(ctx context.Context, tenantID, userID string) (*models.UserExport, error) {
+	var export *models.UserExport
+	err := r.scanUserExports(ctx, fmt.Sprintf(userExportQuery, "AND u.id = $2"), []interface{}{tenantID, userID}, func(u *models.UserExport) error {
+		export = u
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("Failed to export user", zap.String("tenant_id", tenantID), zap.String("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return export, nil
+}
+
+// ExportTenantUsers streams every user of a tenant, with roles, to fn.
+// Users are delivered one at a time so large tenants are never held in memory.
+func (r *PostgresRepository) ExportTenantUsers(ctx context.Context, tenantID string, fn func(*models.UserExport) error) error {
+	if err := r.scanUserExports(ctx, fmt.Sprintf(userExportQuery, ""), []interface{}{tenantID}, fn); err != nil {
+		r.logger.Error("Failed to export tenant users", zap.String("tenant_id", tenantID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// userPageQuery selects one page of a tenant's users, ordered by ID, with a
+// row per role like userExportQuery.
+const userPageQuery = `
+	SELECT u.id, u.tenant_id, u.email, u.full_name, u.phone_number, u.email_verified, u.phone_verified, u.created_at, u.updated_at, ur.role
+	FROM (
+		SELECT * FROM users
+		WHERE tenant_id = $1
+		ORDER BY id
+		LIMIT $2 OFFSET $3
+	) u
+	LEFT JOIN user_roles ur ON ur.user_id = u.id
+	ORDER BY u.id, ur.role
+`
+
+// ListTenantUsers returns up to limit users of a tenant, with roles, skipping
+// the first offset users by ID, along with the tenant's total user count.
+func (r *PostgresRepository) ListTenantUsers(ctx context.Context, tenantID string, limit, offset int) ([]*models.UserExport, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE tenant_id = $1`, tenantID).Scan(&total); err != nil {
+		r.logger.Error("Failed to count tenant users", zap.String("tenant_id", tenantID), zap.Error(err))
+		return nil, 0, err
+	}
+
+	users := []*models.UserExport{}
+	err := r.scanUserExports(ctx, userPageQuery, []interface{}{tenantID, limit, offset}, func(u *models.UserExport) error {
+		users = append(users, u)
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("Failed to list tenant users", zap.String("tenant_id", tenantID), zap.Error(err))
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// scanUserExports runs a userExportQuery and folds the per-role rows into one
+// UserExport per user, calling fn as each user is completed.
+func (r *PostgresRepository) scanUserExports(ctx context.Context, query string, args []interface{}, fn func(*models.UserExport) error) error {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var current *models.UserExport
+	for rows.Next() {
+		var (
+			u     models.UserExport
+			email sql.NullString
+			role  sql.NullString
+		)
+		if err := rows.Scan(&u.ID, &u.TenantID, &email, &u.FullName, &u.PhoneNumber, &u.EmailVerified, &u.PhoneVerified, &u.CreatedAt, &u.UpdatedAt, &role); err != nil {
+			return err
+		}
+
+		if current == nil || current.ID != u.ID {
+			if current != nil {
+				if err := fn(current); err != nil {
+					return err
+				}
+			}
+			u.Email = email.String
+			u.Roles = []string{}
+			current = &u
+		}
+		if role.Valid {
+			current.Roles = append(current.Roles, role.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
 		return err
 	}
 
+	if current != nil {
+		return fn(current)
+	}
 	return nil
 }
+
+// InsertAuditEvent stores an audit event. An event whose ID is already
+// stored is ignored, so dead-lettered events can be replayed safely.
+func (r *PostgresRepository) InsertAuditEvent(ctx context.Context, event audit.Event) error {
+	var metadata []byte
+	if len(event.Metadata) > 0 {
+		var err error
+		if metadata, err = json.Marshal(event.Metadata); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO audit_events (id, event_type, tenant_id, actor_id, target_id, metadata, occurred_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, event.ID, event.Type, event.TenantID, event.ActorID, event.TargetID, metadata, event.Timestamp)
+	if err != nil {
+		r.logger.Error("Failed to insert audit event", zap.String("audit_id", event.ID), zap.String("audit_type", event.Type), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// LoadSigningKeys returns the stored signing keys that have not expired,
+// and those with the given public key fingerprint, oldest first. Keys
+// stored before fingerprints were recorded are always returned.
+func (r *PostgresRepository) LoadSigningKeys(ctx context.Context, fingerprint string) ([]*models.SigningKey, error) {
+	query := `
+		SELECT kid, algorithm, audience, fingerprint, private_key, created_at, expires_at
+		FROM signing_keys
+		WHERE expires_at IS NULL OR expires_at > NOW() OR fingerprint = $1 OR fingerprint IS NULL
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, fingerprint)
+	if err != nil {
+		r.logger.Error("Failed to load signing keys", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.SigningKey
+	for rows.Next() {
+		var key models.SigningKey
+		var fingerprint sql.NullString
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&key.KeyID, &key.Algorithm, &key.Audience, &fingerprint, &key.EncryptedPrivateKey, &key.CreatedAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		key.Fingerprint = fingerprint.String
+		if expiresAt.Valid {
+			key.ExpiresAt = expiresAt.Time
+		}
+		keys = append(keys, &key)
+	}
+	return keys, rows.Err()
+}
+
+// SaveSigningKey stores a signing key. A key whose kid is already stored is
+// left unchanged; use MarkKeyExpired to retire it.
+func (r *PostgresRepository) SaveSigningKey(ctx context.Context, key *models.SigningKey) error {
+	query := `
+		INSERT INTO signing_keys (kid, algorithm, audience, fingerprint, private_key, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (kid) DO NOTHING
+	`
+
+	var expiresAt sql.NullTime
+	if !key.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: key.ExpiresAt, Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, query, key.KeyID, key.Algorithm, key.Audience, key.Fingerprint, key.EncryptedPrivateKey, key.CreatedAt, expiresAt)
+	if err != nil {
+		r.logger.Error("Failed to save signing key", zap.String("kid", key.KeyID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// MarkKeyExpired sets when a stored signing key stops verifying tokens.
+func (r *PostgresRepository) MarkKeyExpired(ctx context.Context, keyID string, expiresAt time.Time) error {
+	query := `
+		UPDATE signing_keys
+		SET expires_at = $2
+		WHERE kid = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, keyID, expiresAt)
+	if err != nil {
+		r.logger.Error("Failed to mark signing key expired", zap.String("kid", keyID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// CreateTenantIfNotExists inserts the tenant unless one with the same ID
+// already exists. It reports whether the tenant was created.
+func (r *PostgresRepository) CreateTenantIfNotExists(ctx context.Context, tenant models.Tenant) (bool, error) {
+	query := `
+		INSERT INTO tenants (id, name)
+		VALUES ($1, $2)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	res, err := r.db.ExecContext(ctx, query, tenant.ID, tenant.Name)
+	if err != nil {
+		r.logger.Error("Failed to create tenant", zap.String("tenant_id", tenant.ID), zap.Error(err))
+		return false, err
+	}
+
+	created, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return created > 0, nil
+}
+
+// CreateClientIfNotExists inserts the client unless one with the same
+// client_id already exists. It reports whether the client was created.
+func (r *PostgresRepository) CreateClientIfNotExists(ctx context.Context, client models.Client) (bool, error) {
+	query := `
+		INSERT INTO clients (client_id, client_secret_hash, rate_limit, tenant_id, name, description)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''))
+		ON CONFLICT (client_id) DO NOTHING
+	`
+
+	res, err := r.db.ExecContext(ctx, query, client.ClientID, client.ClientSecretHash, client.RateLimit, client.TenantID, client.Name, client.Description)
+	if err != nil {
+		r.logger.Error("Failed to create client", zap.String("client_id", client.ClientID), zap.Error(err))
+		return false, err
+	}
+
+	created, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return created > 0, nil
+}
@@ -0,0 +1,705 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"session-service/internal/audit"
+	"session-service/internal/auth"
+	"session-service/internal/cache"
+	"session-service/internal/config"
+	"session-service/internal/database"
+	"session-service/internal/middleware"
+	"session-service/internal/models"
+	"session-service/pkg/errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// AdminHandler handles tenant administration endpoints.
+// Routes are expected to be protected by middleware.RequireRole.
+type AdminHandler struct {
+	repo   database.Repository
+	cache  cache.Cache
+	config *config.Config
+	audit  audit.Recorder
+	logger *zap.Logger
+
+	// secrets encrypts HS256 tenant secrets; set by EnableHMACTenants
+	secrets *auth.SecretCipher
+
+	// events publishes audit events to event streams; set by EnableEventStream
+	events *audit.Broadcaster
+
+	// keys' standby key source can be promoted, retiring the replaced keys
+	// after promotionGrace; set by EnableStandbyPromotion
+	keys           *auth.KeyManager
+	promotionGrace time.Duration
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(
+	repo database.Repository,
+	cache cache.Cache,
+	config *config.Config,
+	auditRecorder audit.Recorder,
+	logger *zap.Logger,
+) *AdminHandler {
+	return &AdminHandler{
+		repo:   repo,
+		cache:  cache,
+		config: config,
+		audit:  auditRecorder,
+		logger: logger,
+	}
+}
+
+// EnableHMACTenants allows HS256 signing secrets to be issued to tenants,
+// encrypting them at rest with cipher.
+func (h *AdminHandler) EnableHMACTenants(cipher *auth.SecretCipher) {
+	h.secrets = cipher
+}
+
+// HandleExportUser handles GET /{tenant_id}/admin/users/{user_id}/export
+// @Summary     Export a user's data
+// @Description Returns the user record and role assignments held for a user (GDPR data portability). Requires an admin access token for the tenant.
+// @Tags        admin
+// @Produce     application/json
+// @Security    BearerAuth
+// @Param       tenant_id path string true "Tenant ID"
+// @Param       user_id   path string true "User ID"
+// @Success     200  {object}  models.UserExport
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     404  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /{tenant_id}/admin/users/{user_id}/export [get]
+func (h *AdminHandler) HandleExportUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	tenantID := vars["tenant_id"]
+	userID := vars["user_id"]
+	if tenantID == "" || userID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	export, err := h.repo.GetUserExport(ctx, tenantID, userID)
+	if err != nil {
+		h.logger.Error("Failed to export user", zap.String("tenant_id", tenantID), zap.String("user_id", userID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if export == nil {
+		h.sendError(w, errors.ErrNotFound)
+		return
+	}
+
+	h.audit.Record(ctx, audit.Event{
+		Type:     audit.EventUserExport,
+		TenantID: tenantID,
+		ActorID:  actorID(r),
+		TargetID: userID,
+	})
+
+	h.sendJSON(w, http.StatusOK, export)
+}
+
+// HandleListUsers handles GET /{tenant_id}/admin/users
+// @Summary     List the users of a tenant
+// @Description Returns one page of the tenant's users with role assignments, ordered by ID. Pagination links (first, prev, next, last) are also returned in the Link header. Requires an admin access token for the tenant.
+// @Tags        admin
+// @Produce     application/json
+// @Security    BearerAuth
+// @Param       tenant_id path  string true  "Tenant ID"
+// @Param       limit     query int    false "Page size (default 50, max 200)"
+// @Param       offset    query int    false "Number of users to skip"
+// @Success     200  {object}  models.UserListResponse
+// @Failure     400  {object}  map[string]string
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /{tenant_id}/admin/users [get]
+func (h *AdminHandler) HandleListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID := mux.Vars(r)["tenant_id"]
+	if tenantID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	limit, offset, serviceErr := parsePagination(r)
+	if serviceErr != nil {
+		h.sendError(w, serviceErr)
+		return
+	}
+
+	users, total, err := h.repo.ListTenantUsers(ctx, tenantID, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list tenant users", zap.String("tenant_id", tenantID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	h.audit.Record(ctx, audit.Event{
+		Type:     audit.EventTenantUsersList,
+		TenantID: tenantID,
+		ActorID:  actorID(r),
+		Metadata: map[string]string{
+			"limit":      strconv.Itoa(limit),
+			"offset":     strconv.Itoa(offset),
+			"user_count": strconv.Itoa(len(users)),
+		},
+	})
+
+	w.Header().Set("Link", paginationLinks(r, limit, offset, total))
+	h.sendJSON(w, http.StatusOK, &models.UserListResponse{
+		Users:  users,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// HandleExportTenantUsers handles GET /{tenant_id}/admin/users/export
+// @Summary     Export all users of a tenant
+// @Description Streams every user of the tenant with role assignments as newline-delimited JSON. Requires an admin access token for the tenant.
+// @Tags        admin
+// @Produce     application/x-ndjson
+// @Security    BearerAuth
+// @Param       tenant_id path string true "Tenant ID"
+// @Success     200  {object}  models.UserExport "One JSON object per line"
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /{tenant_id}/admin/users/export [get]
+func (h *AdminHandler) HandleExportTenantUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID := mux.Vars(r)["tenant_id"]
+	if tenantID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	// Headers are only committed once the first user is written, so a query
+	// failure before any output can still be reported as a proper error.
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	count := 0
+	err := h.repo.ExportTenantUsers(ctx, tenantID, func(u *models.UserExport) error {
+		if count == 0 {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+		count++
+		if err := enc.Encode(u); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		h.logger.Error("Failed to export tenant users", zap.String("tenant_id", tenantID), zap.Int("exported", count), zap.Error(err))
+		if count == 0 {
+			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+			return
+		}
+		// Some user data has already left the service; still audit it.
+	} else if count == 0 {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	h.audit.Record(ctx, audit.Event{
+		Type:     audit.EventTenantUsersExport,
+		TenantID: tenantID,
+		ActorID:  actorID(r),
+		Metadata: map[string]string{"user_count": strconv.Itoa(count)},
+	})
+}
+
+// HandleDeleteUser handles DELETE /{tenant_id}/admin/users/{user_id}
+// @Summary     Delete a user
+// @Description Deletes the user and their role assignments (GDPR erasure) and revokes all of the user's outstanding access and refresh tokens. Requires an admin access token for the tenant.
+// @Tags        admin
+// @Security    BearerAuth
+// @Param       tenant_id path string true "Tenant ID"
+// @Param       user_id   path string true "User ID"
+// @Success     204
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     404  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /{tenant_id}/admin/users/{user_id} [delete]
+func (h *AdminHandler) HandleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	tenantID := vars["tenant_id"]
+	userID := vars["user_id"]
+	if tenantID == "" || userID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	deleted, err := h.repo.DeleteUser(ctx, tenantID, userID)
+	if err != nil {
+		h.logger.Error("Failed to delete user", zap.String("tenant_id", tenantID), zap.String("user_id", userID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if !deleted {
+		h.sendError(w, errors.ErrNotFound)
+		return
+	}
+
+	// Revoke everything issued to the user so far. The cutoff must outlive
+	// the longest-lived token that could still be outstanding.
+	ttl := h.config.RefreshTokenExpiry
+	if h.config.JWTExpiry > ttl {
+		ttl = h.config.JWTExpiry
+	}
+	if tenant, err := h.repo.GetTenantByID(ctx, tenantID); err != nil {
+		h.logger.Warn("Failed to load tenant token lifetimes; using global expiry for revocation", zap.String("tenant_id", tenantID), zap.Error(err))
+	} else if tenant != nil {
+		if tenant.RefreshTokenTTL > ttl {
+			ttl = tenant.RefreshTokenTTL
+		}
+		if tenant.AccessTokenTTL > ttl {
+			ttl = tenant.AccessTokenTTL
+		}
+	}
+	if err := h.cache.SetUserRevocationCutoff(ctx, userID, time.Now(), ttl); err != nil {
+		// The user is already gone; report the failure so the caller can retry
+		// the revocation rather than assume sessions were terminated.
+		h.logger.Error("Failed to revoke tokens for deleted user", zap.String("tenant_id", tenantID), zap.String("user_id", userID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	h.audit.Record(ctx, audit.Event{
+		Type:     audit.EventUserDelete,
+		TenantID: tenantID,
+		ActorID:  actorID(r),
+		TargetID: userID,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleListClients handles GET /{tenant_id}/admin/clients
+// @Summary     List the clients of a tenant
+// @Description Returns one page of the tenant's clients, ordered by client ID, with their optional name and description. Secrets are never returned. Pagination links are also returned in the Link header. Requires an admin access token for the tenant.
+// @Tags        admin
+// @Produce     application/json
+// @Security    BearerAuth
+// @Param       tenant_id path  string true  "Tenant ID"
+// @Param       limit     query int    false "Page size (default 50, max 200)"
+// @Param       offset    query int    false "Number of clients to skip"
+// @Success     200  {object}  models.ClientListResponse
+// @Failure     400  {object}  map[string]string
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /{tenant_id}/admin/clients [get]
+func (h *AdminHandler) HandleListClients(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID := mux.Vars(r)["tenant_id"]
+	if tenantID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	limit, offset, serviceErr := parsePagination(r)
+	if serviceErr != nil {
+		h.sendError(w, serviceErr)
+		return
+	}
+
+	clients, total, err := h.repo.ListTenantClients(ctx, tenantID, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list tenant clients", zap.String("tenant_id", tenantID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	summaries := make([]*models.ClientSummary, 0, len(clients))
+	for _, client := range clients {
+		summaries = append(summaries, h.clientSummary(client))
+	}
+
+	h.audit.Record(ctx, audit.Event{
+		Type:     audit.EventTenantClientsList,
+		TenantID: tenantID,
+		ActorID:  actorID(r),
+		Metadata: map[string]string{
+			"limit":        strconv.Itoa(limit),
+			"offset":       strconv.Itoa(offset),
+			"client_count": strconv.Itoa(len(clients)),
+		},
+	})
+
+	w.Header().Set("Link", paginationLinks(r, limit, offset, total))
+	h.sendJSON(w, http.StatusOK, &models.ClientListResponse{
+		Clients: summaries,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
+
+// HandleUpdateClient handles PATCH /{tenant_id}/admin/clients/{client_id}
+// @Summary     Update a client's name and description
+// @Description Sets the optional human-readable name and description of a client. Omitted fields are left unchanged and an empty string clears a field. Requires an admin access token for the tenant.
+// @Tags        admin
+// @Accept      application/json
+// @Produce     application/json
+// @Security    BearerAuth
+// @Param       tenant_id path string                true "Tenant ID"
+// @Param       client_id path string                true "Client ID"
+// @Param       request   body models.ClientMetadata true "Client metadata"
+// @Success     200  {object}  models.ClientSummary
+// @Failure     400  {object}  map[string]string
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     404  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /{tenant_id}/admin/clients/{client_id} [patch]
+func (h *AdminHandler) HandleUpdateClient(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	vars := mux.Vars(r)
+	tenantID := vars["tenant_id"]
+	clientID := vars["client_id"]
+	if tenantID == "" || clientID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	var metadata models.ClientMetadata
+	if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "Invalid JSON body"))
+		return
+	}
+	if metadata.Name == nil && metadata.Description == nil {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "name or description is required"))
+		return
+	}
+	if metadata.Name != nil && len(*metadata.Name) > maxClientNameLength {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, fmt.Sprintf("name must be at most %d characters", maxClientNameLength)))
+		return
+	}
+
+	client, err := h.repo.UpdateClientMetadata(ctx, tenantID, clientID, metadata)
+	if err != nil {
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if client == nil {
+		h.sendError(w, errors.ErrNotFound)
+		return
+	}
+
+	h.audit.Record(ctx, audit.Event{
+		Type:     audit.EventClientUpdate,
+		TenantID: tenantID,
+		ActorID:  actorID(r),
+		TargetID: clientID,
+		Metadata: clientAuditMetadata(client),
+	})
+
+	h.sendJSON(w, http.StatusOK, h.clientSummary(client))
+}
+
+// HandleRotateSigningSecret handles POST /{tenant_id}/admin/signing-secret
+// @Summary     Issue an HS256 signing secret
+// @Description Generates a new shared secret and switches the tenant to HS256 tokens signed with it. Tokens signed with a previous secret stop validating immediately. The secret is returned only once. Requires an admin access token for the tenant.
+// @Tags        admin
+// @Produce     application/json
+// @Security    BearerAuth
+// @Param       tenant_id path string true "Tenant ID"
+// @Success     200  {object}  models.TenantSigningSecretResponse
+// @Failure     400  {object}  map[string]string
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     404  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /{tenant_id}/admin/signing-secret [post]
+func (h *AdminHandler) HandleRotateSigningSecret(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID := mux.Vars(r)["tenant_id"]
+	if tenantID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+	if h.secrets == nil {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "HS256 tenants are not enabled; set TENANT_SECRET_KEY"))
+		return
+	}
+
+	secret, err := auth.GenerateHMACSecret()
+	if err != nil {
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	encrypted, err := h.secrets.Encrypt(secret)
+	if err != nil {
+		h.logger.Error("Failed to encrypt tenant signing secret", zap.String("tenant_id", tenantID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	found, err := h.repo.SetTenantHMACSecret(ctx, tenantID, encrypted)
+	if err != nil {
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if !found {
+		h.sendError(w, errors.ErrNotFound)
+		return
+	}
+	h.refreshCachedTenant(ctx, tenantID)
+
+	h.audit.Record(ctx, audit.Event{
+		Type:     audit.EventTenantSigningSecretRotate,
+		TenantID: tenantID,
+		ActorID:  actorID(r),
+	})
+
+	h.sendJSON(w, http.StatusOK, &models.TenantSigningSecretResponse{
+		Algorithm: auth.AlgHS256,
+		Secret:    base64.RawURLEncoding.EncodeToString(secret),
+	})
+}
+
+// HandleDeleteSigningSecret handles DELETE /{tenant_id}/admin/signing-secret
+// @Summary     Remove a tenant's HS256 signing secret
+// @Description Switches the tenant back to tokens signed with the published keys. Outstanding HS256 tokens stop validating immediately. Requires an admin access token for the tenant.
+// @Tags        admin
+// @Security    BearerAuth
+// @Param       tenant_id path string true "Tenant ID"
+// @Success     204
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     404  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /{tenant_id}/admin/signing-secret [delete]
+func (h *AdminHandler) HandleDeleteSigningSecret(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID := mux.Vars(r)["tenant_id"]
+	if tenantID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	found, err := h.repo.SetTenantHMACSecret(ctx, tenantID, nil)
+	if err != nil {
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if !found {
+		h.sendError(w, errors.ErrNotFound)
+		return
+	}
+	h.refreshCachedTenant(ctx, tenantID)
+
+	h.audit.Record(ctx, audit.Event{
+		Type:     audit.EventTenantSigningSecretDelete,
+		TenantID: tenantID,
+		ActorID:  actorID(r),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetTenantConfig handles GET /{tenant_id}/admin/config
+// @Summary     Show a tenant's policy
+// @Description Returns the tenant's policy from the tenant_config table, or the defaults if it has none. Requires TENANT_CONFIG and an admin access token for the tenant.
+// @Tags        admin
+// @Produce     application/json
+// @Security    BearerAuth
+// @Param       tenant_id path string true "Tenant ID"
+// @Success     200  {object}  models.TenantConfig
+// @Failure     400  {object}  map[string]string
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /{tenant_id}/admin/config [get]
+func (h *AdminHandler) HandleGetTenantConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID := mux.Vars(r)["tenant_id"]
+	if tenantID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+	if !h.config.TenantConfig {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "per-tenant configuration is not enabled; set TENANT_CONFIG"))
+		return
+	}
+
+	tenantConfig, err := h.repo.GetTenantConfig(ctx, tenantID)
+	if err != nil {
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	h.audit.Record(ctx, audit.Event{
+		Type:     audit.EventTenantConfigRead,
+		TenantID: tenantID,
+		ActorID:  actorID(r),
+	})
+
+	h.sendJSON(w, http.StatusOK, tenantConfig)
+}
+
+// HandleUpdateTenantConfig handles PUT /{tenant_id}/admin/config
+// @Summary     Replace a tenant's policy
+// @Description Stores the tenant's policy, which takes effect on its next token request. Empty allowed_grant_types allows every enabled grant and a max_sessions_per_user of 0 keeps MAX_SESSIONS_PER_USER. Requires TENANT_CONFIG and an admin access token for the tenant.
+// @Tags        admin
+// @Accept      application/json
+// @Produce     application/json
+// @Security    BearerAuth
+// @Param       tenant_id path string              true "Tenant ID"
+// @Param       request   body models.TenantConfig true "Tenant policy"
+// @Success     200  {object}  models.TenantConfig
+// @Failure     400  {object}  map[string]string
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     404  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /{tenant_id}/admin/config [put]
+func (h *AdminHandler) HandleUpdateTenantConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID := mux.Vars(r)["tenant_id"]
+	if tenantID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+	if !h.config.TenantConfig {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "per-tenant configuration is not enabled; set TENANT_CONFIG"))
+		return
+	}
+
+	var tenantConfig models.TenantConfig
+	if err := json.NewDecoder(r.Body).Decode(&tenantConfig); err != nil {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "Invalid JSON body"))
+		return
+	}
+	for _, grantType := range tenantConfig.AllowedGrantTypes {
+		if !slices.Contains(tenantGrantTypes, grantType) {
+			h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, fmt.Sprintf("unknown grant type %q", grantType)))
+			return
+		}
+	}
+	if tenantConfig.MaxSessionsPerUser < 0 {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "max_sessions_per_user must not be negative"))
+		return
+	}
+	tenantConfig.TenantID = tenantID
+
+	found, err := h.repo.UpsertTenantConfig(ctx, tenantConfig)
+	if err != nil {
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if !found {
+		h.sendError(w, errors.ErrNotFound)
+		return
+	}
+	// The token endpoint reloads the policy on its next lookup
+	if err := h.cache.DeleteTenantConfig(ctx, tenantID); err != nil {
+		h.logger.Warn("Failed to invalidate cached tenant config", zap.String("tenant_id", tenantID), zap.Error(err))
+	}
+
+	h.audit.Record(ctx, audit.Event{
+		Type:     audit.EventTenantConfigUpdate,
+		TenantID: tenantID,
+		ActorID:  actorID(r),
+		Metadata: map[string]string{
+			"allowed_grant_types":   strings.Join(tenantConfig.AllowedGrantTypes, ","),
+			"max_sessions_per_user": strconv.Itoa(tenantConfig.MaxSessionsPerUser),
+		},
+	})
+
+	h.sendJSON(w, http.StatusOK, &tenantConfig)
+}
+
+// refreshCachedTenant replaces the cached tenant record so token issuance and
+// validation pick up a signing change without waiting for the cache to expire.
+func (h *AdminHandler) refreshCachedTenant(ctx context.Context, tenantID string) {
+	tenant, err := h.repo.GetTenantByID(ctx, tenantID)
+	if err != nil || tenant == nil {
+		h.logger.Warn("Failed to reload tenant after signing change", zap.String("tenant_id", tenantID), zap.Error(err))
+		return
+	}
+	if err := h.cache.SetTenant(ctx, tenant, 15*time.Minute); err != nil {
+		h.logger.Warn("Failed to refresh cached tenant", zap.String("tenant_id", tenantID), zap.Error(err))
+	}
+}
+
+// maxClientNameLength matches the clients.name column.
+const maxClientNameLength = 255
+
+func (h *AdminHandler) clientSummary(client *models.Client) *models.ClientSummary {
+	return &models.ClientSummary{
+		ClientID:          client.ClientID,
+		Name:              client.Name,
+		Description:       client.Description,
+		RateLimit:         client.RateLimit,
+		AllowedGrantTypes: client.AllowedGrantTypes,
+		GrantConflict:     grantConflict(h.config, client),
+		CreatedAt:         client.CreatedAt,
+		UpdatedAt:         client.UpdatedAt,
+	}
+}
+
+// clientAuditMetadata labels audit events about a client with its name, so
+// operators can tell clients apart without looking up their IDs.
+func clientAuditMetadata(client *models.Client) map[string]string {
+	if client.Name == "" {
+		return nil
+	}
+	return map[string]string{"client_name": client.Name}
+}
+
+// actorID returns the sub of the authenticated admin, if any.
+func actorID(r *http.Request) string {
+	if claims, ok := middleware.ClaimsFromContext(r.Context()); ok {
+		if sub, ok := claims["sub"].(string); ok {
+			return sub
+		}
+	}
+	return ""
+}
+
+func (h *AdminHandler) sendError(w http.ResponseWriter, err *errors.ServiceError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":             err.Code,
+		"error_description": err.Message,
+	})
+}
+
+func (h *AdminHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"session-service/internal/config"
+	"session-service/internal/models"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// clientGrantAllowed reports whether client may use grantType: its
+// allowed_grant_types must name it, unless empty. Under
+// SEPARATE_PROVISIONING_CLIENTS, provision_user must be named explicitly
+// and a client with a grant conflict may use neither grant.
+func (h *TokenHandler) clientGrantAllowed(client *models.Client, grantType string) bool {
+	if len(client.AllowedGrantTypes) > 0 && !slices.Contains(client.AllowedGrantTypes, grantType) {
+		return false
+	}
+	if !h.config.SeparateProvisioning {
+		return true
+	}
+	if grantType == "provision_user" && !slices.Contains(client.AllowedGrantTypes, grantType) {
+		return false
+	}
+	if (grantType == "provision_user" || grantType == "client_credentials") && grantConflict(h.config, client) {
+		h.logger.Error("Client is allowed both provision_user and client_credentials; add it to MIXED_GRANT_CLIENTS or remove one",
+			zap.String("client_id", client.ClientID))
+		return false
+	}
+	return true
+}
+
+// grantConflict reports whether client may use both provision_user and
+// client_credentials although SEPARATE_PROVISIONING_CLIENTS keeps them
+// apart and MIXED_GRANT_CLIENTS does not exempt it.
+func grantConflict(cfg *config.Config, client *models.Client) bool {
+	if !cfg.SeparateProvisioning || slices.Contains(cfg.MixedGrantClients, client.ClientID) {
+		return false
+	}
+	return slices.Contains(client.AllowedGrantTypes, "provision_user") && slices.Contains(client.AllowedGrantTypes, "client_credentials")
+}
+
+// ungrantableRoles returns the roles client may not assign with
+// provision_user: those outside its grantable_roles, if set, and the
+// privileged roles it does not name.
+func ungrantableRoles(cfg *config.Config, client *models.Client, roles []string) []string {
+	var ungrantable []string
+	for _, role := range roles {
+		if slices.Contains(client.GrantableRoles, role) {
+			continue
+		}
+		if len(client.GrantableRoles) > 0 || privilegedRole(cfg, role) {
+			ungrantable = append(ungrantable, role)
+		}
+	}
+	return ungrantable
+}
+
+// privilegedRole reports whether role is ADMIN_ROLE or one of the
+// PRIVILEGED_ROLES. ADMIN_ROLE is always privileged, so a client can never
+// make its users tenant admins unless its grantable_roles say so.
+func privilegedRole(cfg *config.Config, role string) bool {
+	return (cfg.AdminRole != "" && role == cfg.AdminRole) || slices.Contains(cfg.PrivilegedRoles, role)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"session-service/internal/middleware"
+	"session-service/internal/models"
+	"session-service/pkg/errors"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// isTenantPlaceholder reports whether tenantID is one of
+// CLIENT_TENANT_PLACEHOLDERS, standing for the authenticated client's tenant.
+func (h *TokenHandler) isTenantPlaceholder(tenantID string) bool {
+	return slices.Contains(h.config.ClientTenantPlaceholders, tenantID)
+}
+
+// resolveClientTenant returns the tenant the client of r is bound to. The
+// client is authenticated later by the grant, as for any other tenant.
+func (h *TokenHandler) resolveClientTenant(ctx context.Context, r *http.Request) (string, *errors.ServiceError) {
+	clientID, _, serviceErr := clientCredentials(r)
+	if serviceErr != nil {
+		return "", serviceErr
+	}
+	client, serviceErr := h.lookupClient(ctx, clientID)
+	if serviceErr != nil {
+		return "", serviceErr
+	}
+	if client.TenantID == "" {
+		return "", errors.WithMessage(errors.ErrInvalidRequest, "Client is not bound to a tenant; name the tenant in the path")
+	}
+	return middleware.CanonicalTenantID(client.TenantID), nil
+}
+
+// checkClientTenant rejects a client bound to a tenant other than tenantID
+// while CLIENT_TENANT_PLACEHOLDERS is set. Clients without a tenant may use
+// any tenant.
+func (h *TokenHandler) checkClientTenant(client *models.Client, tenantID string) *errors.ServiceError {
+	if len(h.config.ClientTenantPlaceholders) == 0 || client.TenantID == "" || tenantID == "" {
+		return nil
+	}
+	if middleware.CanonicalTenantID(client.TenantID) != tenantID {
+		h.logger.Warn("Client used with another tenant",
+			zap.String("client_id", client.ClientID),
+			zap.String("client_tenant_id", client.TenantID),
+			zap.String("path_tenant_id", tenantID))
+		return errors.WithMessage(errors.ErrInvalidRequest, "Client is bound to a different tenant")
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"session-service/internal/models"
+	"session-service/pkg/errors"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// cookieSessionPath is the path, under /{tenant_id}, that session cookies are
+// scoped to, so browsers send them to the session refresh endpoint only.
+const cookieSessionPath = "/oauth2/v1.0/session"
+
+// EnableCookieSessions lets browser clients keep their refresh token out of
+// JavaScript. A provision_user request with session_cookie=true gets a
+// Secure, HttpOnly cookie named cookieName holding a random session ID
+// instead of a refresh token; the refresh token is stored server-side under
+// that ID, and HandleSessionRefresh refreshes with the cookie.
+func (h *TokenHandler) EnableCookieSessions(cookieName string) {
+	h.sessionCookieName = cookieName
+}
+
+// HandleSessionRefresh handles POST /{tenant_id}/oauth2/v1.0/session/refresh
+// @Summary     Refresh a cookie session
+// @Description Issues a new access token for the refresh token held by the HttpOnly session cookie set by provision_user with session_cookie=true. The refresh token is rotated server-side and never returned.
+// @Tags        oauth2
+// @Produce     application/json
+// @Param       tenant_id path string true "Tenant ID"
+// @Success     200 {object} models.TokenResponse
+// @Failure     400 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Failure     503 {object} map[string]string
+// @Router      /{tenant_id}/oauth2/v1.0/session/refresh [post]
+func (h *TokenHandler) HandleSessionRefresh(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if h.sessionCookieName == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	tenantID := mux.Vars(r)["tenant_id"]
+	if tenantID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	if h.refuseDuringRotation(w, tenantID) {
+		return
+	}
+
+	cookie, err := r.Cookie(h.sessionCookieName)
+	if err != nil || cookie.Value == "" {
+		h.sendError(w, errors.ErrInvalidRefreshToken)
+		return
+	}
+
+	refreshToken, err := h.cache.GetCookieSession(ctx, cookie.Value)
+	if err != nil {
+		h.logger.Error("Failed to get cookie session", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if refreshToken == "" {
+		// Expired or unknown; have the browser drop the cookie
+		http.SetCookie(w, h.sessionCookie(tenantID, "", -1))
+		h.sendError(w, errors.ErrInvalidRefreshToken)
+		return
+	}
+
+	h.refreshTokens(ctx, w, r, tenantID, refreshToken, cookie.Value)
+}
+
+// wantsCookieSession reports whether the refresh token issued for r should be
+// kept in a cookie session rather than returned.
+func (h *TokenHandler) wantsCookieSession(r *http.Request) bool {
+	return h.sessionCookieName != "" &&
+		r.FormValue("grant_type") == "provision_user" &&
+		r.FormValue("session_cookie") == "true"
+}
+
+// sendCookieSession stores the refresh token of response under sessionID,
+// starting a new session when sessionID is empty, and writes the response
+// with the session cookie in place of the refresh token.
+func (h *TokenHandler) sendCookieSession(ctx context.Context, w http.ResponseWriter, tenantID, sessionID string, response *models.TokenResponse, ttl time.Duration) {
+	if sessionID == "" {
+		var err error
+		sessionID, err = h.tokenGen.GenerateRefreshToken()
+		if err != nil {
+			h.logger.Error("Failed to generate cookie session ID", zap.Error(err))
+			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+			return
+		}
+	}
+
+	if err := h.cache.StoreCookieSession(ctx, sessionID, response.RefreshToken, ttl); err != nil {
+		h.logger.Error("Failed to store cookie session", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	http.SetCookie(w, h.sessionCookie(tenantID, sessionID, int(ttl.Seconds())))
+	response.RefreshToken = ""
+	h.sendJSON(w, http.StatusOK, response)
+}
+
+// sessionCookie builds the session cookie for tenantID; a negative maxAge
+// deletes it.
+func (h *TokenHandler) sessionCookie(tenantID, sessionID string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     h.sessionCookieName,
+		Value:    sessionID,
+		Path:     "/" + tenantID + cookieSessionPath,
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"session-service/internal/middleware"
+	"session-service/pkg/errors"
+
+	"go.uber.org/zap"
+)
+
+// DebugHandler exposes the non-production request recorder to admins.
+// Routes are expected to be protected by middleware.RequireRoleAnyTenant.
+type DebugHandler struct {
+	recorder *middleware.RequestRecorder
+	logger   *zap.Logger
+}
+
+// NewDebugHandler creates a new debug handler
+func NewDebugHandler(recorder *middleware.RequestRecorder, logger *zap.Logger) *DebugHandler {
+	return &DebugHandler{
+		recorder: recorder,
+		logger:   logger,
+	}
+}
+
+// HandleRecordedRequests handles GET /admin/debug/requests
+// @Summary     List recently recorded requests
+// @Description Returns sanitized recent requests for the caller's tenant, oldest first. Only available when the request recorder is enabled outside production.
+// @Tags        admin
+// @Produce     application/json
+// @Security    BearerAuth
+// @Success     200  {array}   middleware.RecordedRequest
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Router      /admin/debug/requests [get]
+func (h *DebugHandler) HandleRecordedRequests(w http.ResponseWriter, r *http.Request) {
+	tenantID := middleware.TenantFromContext(r.Context())
+	if tenantID == "" {
+		h.sendError(w, errors.ErrForbidden)
+		return
+	}
+
+	entries := make([]middleware.RecordedRequest, 0)
+	for _, entry := range h.recorder.Entries() {
+		if entry.TenantID == tenantID {
+			entries = append(entries, entry)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
+
+func (h *DebugHandler) sendError(w http.ResponseWriter, err *errors.ServiceError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":             err.Code,
+		"error_description": err.Message,
+	})
+}
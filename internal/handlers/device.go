@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"session-service/internal/middleware"
+	"session-service/internal/models"
+	"session-service/pkg/errors"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// DeviceCodeGrantType is the grant_type for polling a device authorization (RFC 8628).
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// userCodeAlphabet avoids vowels (no accidental words) and look-alike characters.
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+const userCodeLength = 8
+
+// maxLoginHintLength bounds the login_hint stored with a device code.
+const maxLoginHintLength = 256
+
+// slowDownIncrement is added to a device's polling interval on each slow_down (RFC 8628 §3.5).
+const slowDownIncrement = 5
+
+// HandleDeviceAuthorization handles POST /{tenant_id}/oauth2/v1.0/device_authorization
+// @Summary     Start a device authorization
+// @Description Issues a device_code for the client to poll with and a user_code for the user to approve (RFC 8628)
+// @Tags        oauth2
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       tenant_id     path     string true "Tenant ID"
+// @Param       client_id     formData string true "Client ID"
+// @Param       client_secret formData string true "Client Secret"
+// @Param       login_hint    formData string false "Identifier of the user expected to approve, echoed at approval (DEVICE_LOGIN_HINT)"
+// @Success     200 {object} models.DeviceAuthorizationResponse
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Router      /{tenant_id}/oauth2/v1.0/device_authorization [post]
+func (h *TokenHandler) HandleDeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID := mux.Vars(r)["tenant_id"]
+	if tenantID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	client, serviceErr := h.authenticateClient(ctx, r)
+	if serviceErr != nil {
+		h.sendError(w, serviceErr)
+		return
+	}
+
+	loginHint := ""
+	if h.config.DeviceLoginHint {
+		loginHint = r.FormValue("login_hint")
+		if len(loginHint) > maxLoginHintLength {
+			h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, fmt.Sprintf("login_hint must be at most %d bytes", maxLoginHintLength)))
+			return
+		}
+	}
+
+	if err := h.repo.EnsureTenantExists(ctx, tenantID); err != nil {
+		h.logger.Error("Tenant does not exist for device authorization", zap.String("tenant_id", tenantID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInvalidRequest))
+		return
+	}
+
+	deviceCode, err := h.tokenGen.GenerateRefreshToken()
+	if err != nil {
+		h.logger.Error("Failed to generate device code", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	userCode, err := h.newUserCode(ctx)
+	if err != nil {
+		h.logger.Error("Failed to generate user code", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	interval := int(h.config.DevicePollInterval.Seconds())
+	data := &models.DeviceCodeData{
+		ClientID:  client.ClientID,
+		TenantID:  tenantID,
+		UserCode:  userCode,
+		Interval:  interval,
+		LoginHint: loginHint,
+		ExpiresAt: time.Now().Add(h.config.DeviceCodeExpiry),
+	}
+	if err := h.cache.StoreDeviceCode(ctx, deviceCode, data, h.config.DeviceCodeExpiry); err != nil {
+		h.logger.Error("Failed to store device code", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	verificationURI := h.config.BaseURL + "/" + tenantID + "/oauth2/v1.0/device"
+	displayCode := formatUserCode(userCode)
+	response := &models.DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                displayCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(displayCode),
+		ExpiresIn:               int64(h.config.DeviceCodeExpiry.Seconds()),
+		Interval:                interval,
+	}
+
+	h.sendJSON(w, http.StatusOK, response)
+}
+
+// HandleDeviceApproval handles POST /{tenant_id}/oauth2/v1.0/device
+// @Summary     Approve a device authorization
+// @Description Approves a user_code on behalf of the user in the Bearer access token; the device's next poll receives tokens for that user. The response echoes the device's login_hint, if any
+// @Tags        oauth2
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       tenant_id path     string true "Tenant ID"
+// @Param       user_code formData string true "User code shown on the device"
+// @Success     200 {object} map[string]string
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Router      /{tenant_id}/oauth2/v1.0/device [post]
+func (h *TokenHandler) HandleDeviceApproval(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := mux.Vars(r)["tenant_id"]
+
+	claims, ok := middleware.ClaimsFromContext(ctx)
+	if !ok {
+		h.sendError(w, errors.ErrInvalidToken)
+		return
+	}
+	userID, _ := claims["sub"].(string)
+	if userID == "" {
+		h.sendError(w, errors.ErrForbidden)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	userCode := normalizeUserCode(r.FormValue("user_code"))
+	if userCode == "" {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "user_code is required"))
+		return
+	}
+
+	deviceCode, err := h.cache.GetDeviceCodeByUserCode(ctx, userCode)
+	if err != nil {
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	var data *models.DeviceCodeData
+	if deviceCode != "" {
+		data, err = h.cache.GetDeviceCode(ctx, deviceCode)
+		if err != nil {
+			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+			return
+		}
+	}
+	if data == nil || middleware.CanonicalTenantID(data.TenantID) != tenantID || !time.Now().Before(data.ExpiresAt) {
+		h.sendError(w, errors.WithMessage(errors.ErrNotFound, "Unknown or expired user_code"))
+		return
+	}
+	if data.Subject != nil {
+		h.sendError(w, errors.WithMessage(errors.ErrConflict, "user_code has already been approved"))
+		return
+	}
+
+	user, err := h.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to get user from database", zap.String("user_id", userID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if user == nil || user.TenantID != tenantID {
+		h.sendError(w, errors.ErrForbidden)
+		return
+	}
+
+	roles, err := h.repo.GetUserRoles(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to get user roles", zap.String("user_id", userID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	data.Subject = &models.TokenSubject{
+		UserID:   userID,
+		TenantID: tenantID,
+		Roles:    roles,
+	}
+	if err := h.cache.StoreDeviceCode(ctx, deviceCode, data, time.Until(data.ExpiresAt)); err != nil {
+		h.logger.Error("Failed to store device approval", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	h.logger.Info("Device authorization approved",
+		zap.String("tenant_id", tenantID),
+		zap.String("user_id", userID),
+		zap.String("client_id", data.ClientID))
+	response := map[string]string{"status": "approved"}
+	if data.LoginHint != "" {
+		response["login_hint"] = data.LoginHint
+	}
+	h.sendJSON(w, http.StatusOK, response)
+}
+
+// handleDeviceCode handles grant_type=urn:ietf:params:oauth:grant-type:device_code.
+// It answers authorization_pending until the user code is approved, slow_down
+// when the client polls faster than its interval, and expired_token once the
+// device code lapses. An approved device code is exchanged for tokens once.
+func (h *TokenHandler) handleDeviceCode(ctx context.Context, w http.ResponseWriter, r *http.Request, tenantIDFromPath string) {
+	client, serviceErr := h.authenticateClient(ctx, r)
+	if serviceErr != nil {
+		h.sendError(w, serviceErr)
+		return
+	}
+	if !h.clientGrantAllowed(client, DeviceCodeGrantType) {
+		h.sendError(w, errors.ErrUnauthorizedClient)
+		return
+	}
+
+	deviceCode := r.FormValue("device_code")
+	if deviceCode == "" {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "device_code is required"))
+		return
+	}
+
+	data, err := h.cache.GetDeviceCode(ctx, deviceCode)
+	if err != nil {
+		h.logger.Error("Failed to get device code", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if data == nil {
+		h.sendError(w, errors.ErrExpiredToken)
+		return
+	}
+	if data.ClientID != client.ClientID || middleware.CanonicalTenantID(data.TenantID) != tenantIDFromPath {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidGrant, "device_code was not issued to this client and tenant"))
+		return
+	}
+
+	now := time.Now()
+	if !now.Before(data.ExpiresAt) {
+		if err := h.cache.DeleteDeviceCode(ctx, deviceCode, data.UserCode); err != nil {
+			h.logger.Warn("Failed to delete expired device code", zap.Error(err))
+		}
+		h.sendError(w, errors.ErrExpiredToken)
+		return
+	}
+
+	if data.Subject == nil {
+		pollErr := errors.ErrAuthorizationPending
+		if !data.LastPolledAt.IsZero() && now.Sub(data.LastPolledAt) < time.Duration(data.Interval)*time.Second {
+			data.Interval += slowDownIncrement
+			pollErr = errors.ErrSlowDown
+		}
+		data.LastPolledAt = now
+		if err := h.cache.StoreDeviceCode(ctx, deviceCode, data, data.ExpiresAt.Sub(now)); err != nil {
+			h.logger.Error("Failed to update device code", zap.Error(err))
+			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+			return
+		}
+		h.sendError(w, pollErr)
+		return
+	}
+
+	// Approved: the device code is single-use
+	if err := h.cache.DeleteDeviceCode(ctx, deviceCode, data.UserCode); err != nil {
+		h.logger.Error("Failed to delete device code", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	h.issueTokens(ctx, w, r, client, data.Subject)
+}
+
+// newUserCode returns a random user code not currently in use.
+func (h *TokenHandler) newUserCode(ctx context.Context) (string, error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		code := make([]byte, userCodeLength)
+		for i := range code {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+			if err != nil {
+				return "", err
+			}
+			code[i] = userCodeAlphabet[n.Int64()]
+		}
+
+		existing, err := h.cache.GetDeviceCodeByUserCode(ctx, string(code))
+		if err != nil {
+			return "", err
+		}
+		if existing == "" {
+			return string(code), nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate an unused user code")
+}
+
+// formatUserCode renders a user code as XXXX-XXXX for display.
+func formatUserCode(code string) string {
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
+
+// normalizeUserCode accepts user codes in any case, with or without separators.
+func normalizeUserCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.Join(strings.Fields(code), "")
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"session-service/internal/audit"
+	"session-service/pkg/errors"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// eventStreamHeartbeat is how often an idle event stream sends a comment,
+// so proxies don't close it.
+const eventStreamHeartbeat = 15 * time.Second
+
+// redactedMetadata replaces sensitive audit metadata values in event streams.
+const redactedMetadata = "[REDACTED]"
+
+// sensitiveMetadataKeys mark audit metadata keys whose values are never
+// streamed.
+var sensitiveMetadataKeys = []string{"secret", "token", "password", "key", "assertion", "code"}
+
+// EnableEventStream serves the tenant's audit events published by events on
+// GET /{tenant_id}/admin/events.
+func (h *AdminHandler) EnableEventStream(events *audit.Broadcaster) {
+	h.events = events
+}
+
+// HandleEventStream handles GET /{tenant_id}/admin/events
+// @Summary     Stream a tenant's audit events
+// @Description Streams the tenant's audit events as server-sent events while the connection is open, one "event" per audit event type with the event as JSON data. Sensitive metadata is redacted. Requires ADMIN_EVENT_STREAM and an admin access token for the tenant.
+// @Tags        admin
+// @Produce     text/event-stream
+// @Security    BearerAuth
+// @Param       tenant_id path string true "Tenant ID"
+// @Success     200
+// @Failure     400  {object}  map[string]string
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     404
+// @Failure     500  {object}  map[string]string
+// @Router      /{tenant_id}/admin/events [get]
+func (h *AdminHandler) HandleEventStream(w http.ResponseWriter, r *http.Request) {
+	if h.events == nil {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := r.Context()
+
+	tenantID := mux.Vars(r)["tenant_id"]
+	if tenantID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	events, unsubscribe := h.events.Subscribe(tenantID)
+	defer unsubscribe()
+
+	h.audit.Record(ctx, audit.Event{
+		Type:     audit.EventTenantEventsStream,
+		TenantID: tenantID,
+		ActorID:  actorID(r),
+	})
+
+	// The stream stays open past the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Debug("Failed to lift write deadline for event stream", zap.Error(err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.logger.Error("Event stream cannot be flushed", zap.Error(err))
+		return
+	}
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			data, err := json.Marshal(redactEvent(event))
+			if err != nil {
+				h.logger.Error("Failed to encode audit event for stream", zap.Error(err))
+				continue
+			}
+			if event.ID != "" {
+				fmt.Fprintf(w, "id: %s\n", event.ID)
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			h.logger.Debug("Event stream closed", zap.String("tenant_id", tenantID), zap.Error(err))
+			return
+		}
+	}
+}
+
+// redactEvent returns a copy of event whose sensitive metadata values are
+// replaced, for streaming.
+func redactEvent(event audit.Event) audit.Event {
+	if len(event.Metadata) == 0 {
+		return event
+	}
+
+	metadata := make(map[string]string, len(event.Metadata))
+	for k, v := range event.Metadata {
+		metadata[k] = v
+		for _, sensitive := range sensitiveMetadataKeys {
+			if strings.Contains(strings.ToLower(k), sensitive) {
+				metadata[k] = redactedMetadata
+				break
+			}
+		}
+	}
+	event.Metadata = metadata
+	return event
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"session-service/internal/auth"
+	"session-service/internal/cache"
+	"session-service/internal/database"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HealthHandler serves liveness and readiness probes
+type HealthHandler struct {
+	repo   database.Repository
+	cache  cache.Cache
+	logger *zap.Logger
+
+	// tokenGen and tokenValidator are set by EnableSigningCheck
+	tokenGen       *auth.TokenGenerator
+	tokenValidator *auth.TokenValidator
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(repo database.Repository, cache cache.Cache, logger *zap.Logger) *HealthHandler {
+	return &HealthHandler{
+		repo:   repo,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// EnableSigningCheck makes GET /readyz?deep=true also sign and verify a
+// synthetic access token with tokenGen and tokenValidator.
+func (h *HealthHandler) EnableSigningCheck(tokenGen *auth.TokenGenerator, tokenValidator *auth.TokenValidator) {
+	h.tokenGen = tokenGen
+	h.tokenValidator = tokenValidator
+}
+
+// HandleLiveness handles GET /healthz. It reports OK while the process is serving.
+func (h *HealthHandler) HandleLiveness(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
+// HandleReadiness handles GET /readyz. It reports OK only when the database
+// and Redis are reachable and, for ?deep=true with EnableSigningCheck, when
+// tokens can be signed and verified.
+func (h *HealthHandler) HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	if err := h.repo.Ping(ctx); err != nil {
+		h.logger.Warn("Readiness check failed: database unreachable", zap.Error(err))
+		http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if err := h.cache.Ping(ctx); err != nil {
+		h.logger.Warn("Readiness check failed: cache unreachable", zap.Error(err))
+		http.Error(w, "cache unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if h.tokenGen != nil && r.URL.Query().Get("deep") == "true" {
+		if err := auth.SelfTest(ctx, h.tokenGen, h.tokenValidator); err != nil {
+			h.logger.Error("Readiness check failed: signing round-trip", zap.Error(err))
+			http.Error(w, "signing unavailable", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
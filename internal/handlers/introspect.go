@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/pkg/errors"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// introspectionStatus is the status a verbose introspection answers with for
+// each inactive reason when INTROSPECTION_ERROR_STATUS is set.
+var introspectionStatus = map[string]int{
+	models.IntrospectionReasonInvalid:            http.StatusUnauthorized,
+	models.IntrospectionReasonExpired:            http.StatusUnauthorized,
+	models.IntrospectionReasonRevoked:            http.StatusUnauthorized,
+	models.IntrospectionReasonRolesChanged:       http.StatusUnauthorized,
+	models.IntrospectionReasonRequiresCurrentKey: http.StatusForbidden,
+	models.IntrospectionReasonTenantMismatch:     http.StatusForbidden,
+}
+
+// HandleIntrospect handles POST /{tenant_id}/oauth2/v1.0/introspect
+// @Summary     Introspect an access token
+// @Description Reports whether an access token is active and, if so, its claims (RFC 7662). Inactive tokens are answered with 200 and only active:false, unless the client has verbose_introspection, in which case a reason is added and, with INTROSPECTION_ERROR_STATUS, a 401 or 403 is returned instead.
+// @Tags        oauth2
+// @Accept      application/x-www-form-urlencoded
+// @Produce     application/json
+// @Param       tenant_id     path     string true "Tenant ID"
+// @Param       client_id     formData string true "Client ID"
+// @Param       client_secret formData string true "Client Secret"
+// @Param       token         formData string true "Access token to introspect"
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]string
+// @Failure     401 {object} map[string]interface{}
+// @Failure     403 {object} map[string]interface{}
+// @Failure     500 {object} map[string]string
+// @Router      /{tenant_id}/oauth2/v1.0/introspect [post]
+func (h *TokenHandler) HandleIntrospect(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tenantID := mux.Vars(r)["tenant_id"]
+	if tenantID == "" {
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	client, serviceErr := h.authenticateClient(ctx, r)
+	if serviceErr != nil {
+		h.sendError(w, serviceErr)
+		return
+	}
+
+	token := r.FormValue("token")
+	if token == "" {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "token is required"))
+		return
+	}
+
+	claims, err := h.tokenValidator.ValidateToken(ctx, token)
+	if err != nil {
+		h.logger.Debug("Introspected token is inactive", zap.String("client_id", client.ClientID), zap.Error(err))
+		h.sendInactive(w, client, inactiveReason(err))
+		return
+	}
+	if h.tokenValidator.TenantID(claims) != tenantID {
+		h.sendInactive(w, client, models.IntrospectionReasonTenantMismatch)
+		return
+	}
+
+	response := make(map[string]interface{}, len(claims)+1)
+	for k, v := range claims {
+		response[k] = v
+	}
+	response["active"] = true
+	h.sendJSON(w, http.StatusOK, response)
+}
+
+// sendInactive answers an introspection of an inactive token. Only clients
+// with verbose_introspection learn the reason; everyone else gets the
+// RFC 7662 response.
+func (h *TokenHandler) sendInactive(w http.ResponseWriter, client *models.Client, reason string) {
+	if !client.VerboseIntrospection {
+		h.sendJSON(w, http.StatusOK, map[string]interface{}{"active": false})
+		return
+	}
+
+	status := http.StatusOK
+	if h.config.IntrospectionErrorStatus {
+		status = introspectionStatus[reason]
+	}
+	h.sendJSON(w, status, map[string]interface{}{"active": false, "reason": reason})
+}
+
+// inactiveReason maps a validation error to the reason a verbose
+// introspection reports.
+func inactiveReason(err error) string {
+	switch {
+	case stderrors.Is(err, jwt.ErrTokenExpired):
+		return models.IntrospectionReasonExpired
+	case stderrors.Is(err, auth.ErrTokenRevoked):
+		return models.IntrospectionReasonRevoked
+	case stderrors.Is(err, auth.ErrRolesChanged):
+		return models.IntrospectionReasonRolesChanged
+	case stderrors.Is(err, auth.ErrRequiresCurrentKey):
+		return models.IntrospectionReasonRequiresCurrentKey
+	default:
+		return models.IntrospectionReasonInvalid
+	}
+}
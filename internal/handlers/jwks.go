@@ -2,36 +2,73 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
-	"session-service/internal/auth"
 	"session-service/internal/database"
 	"session-service/pkg/errors"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/lestrrat-go/jwx/v2/jwk"
 	"go.uber.org/zap"
 )
 
+// staleJWKSMaxAge is the Cache-Control max-age of a stale key set, so
+// verifiers fetch a fresh one soon after the source recovers.
+const staleJWKSMaxAge = 60
+
+// KeySetSource provides the public keys the JWKS endpoint serves, e.g. an
+// *auth.KeyManager.
+type KeySetSource interface {
+	PublicKeySet(use, alg, audience string) (jwk.Set, error)
+}
+
 // JWKSHandler handles JWKS endpoint requests
 type JWKSHandler struct {
-	repo       database.Repository
-	keyManager *auth.KeyManager
-	logger     *zap.Logger
+	repo   database.Repository
+	keys   KeySetSource
+	logger *zap.Logger
+
+	// staleIfError is set by EnableStaleIfError; lastGood holds the last
+	// key set served for each use, alg and audience filter
+	staleIfError time.Duration
+	mu           sync.Mutex
+	lastGood     map[string]servedKeySet
+}
+
+// servedKeySet is a marshaled key set and when it was served.
+type servedKeySet struct {
+	data     []byte
+	servedAt time.Time
 }
 
 // NewJWKSHandler creates a new JWKS handler
-func NewJWKSHandler(repo database.Repository, keyManager *auth.KeyManager, logger *zap.Logger) *JWKSHandler {
+func NewJWKSHandler(repo database.Repository, keys KeySetSource, logger *zap.Logger) *JWKSHandler {
 	return &JWKSHandler{
-		repo:       repo,
-		keyManager: keyManager,
-		logger:     logger,
+		repo:   repo,
+		keys:   keys,
+		logger: logger,
 	}
 }
 
+// EnableStaleIfError makes the handler answer with the last key set it
+// served, if no older than maxStale, when the key source fails, so
+// verifiers keep working through transient failures. Stale responses carry
+// a Warning: 111 header and a short max-age.
+func (h *JWKSHandler) EnableStaleIfError(maxStale time.Duration) {
+	h.staleIfError = maxStale
+	h.lastGood = make(map[string]servedKeySet)
+}
+
 // HandleJWKS handles GET /{tenant_id}/discovery/v1.0/keys
 // @Summary     Get JSON Web Key Set (JWKS)
 // @Description Returns the public keys in JWKS format for JWT validation. Supports key rotation with multiple active keys.
 // @Tags        oidc
-// @Param       tenant_id path string true "Tenant ID"
+// @Param       tenant_id path  string true  "Tenant ID"
+// @Param       use       query string false "Only return keys with this use (e.g. sig)"
+// @Param       alg       query string false "Only return keys for this algorithm (e.g. RS256)"
+// @Param       audience  query string false "Return the keys of this audience, if it is signed with its own keys"
 // @Produce     application/json
 // @Success     200  {object}  map[string]interface{} "JWKS response"
 // @Failure     500  {object}  map[string]string
@@ -57,13 +94,24 @@ func (h *JWKSHandler) HandleJWKS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	keySet := h.keyManager.GetJWKSet()
-
-	// Marshal to JSON
-	data, err := json.Marshal(keySet)
+	// Optional filters let constrained clients fetch only the keys they use
+	query := r.URL.Query()
+	use, alg, audience := query.Get("use"), query.Get("alg"), query.Get("audience")
+	data, err := h.keySet(use, alg, audience)
 	if err != nil {
-		h.logger.Error("Failed to marshal JWKS", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		stale, ok := h.staleKeySet(use, alg, audience)
+		if !ok {
+			h.logger.Error("Failed to build JWKS", zap.Error(err))
+			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+			return
+		}
+		h.logger.Warn("Serving stale JWKS after failing to build it",
+			zap.Duration("age", time.Since(stale.servedAt)), zap.Error(err))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", staleJWKSMaxAge))
+		w.Header().Set("Warning", `111 - "Revalidation Failed"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write(stale.data)
 		return
 	}
 
@@ -73,6 +121,41 @@ func (h *JWKSHandler) HandleJWKS(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
+// keySet returns the marshaled key set for use, alg and audience,
+// remembering it as the last good one when EnableStaleIfError has been called.
+func (h *JWKSHandler) keySet(use, alg, audience string) ([]byte, error) {
+	keySet, err := h.keys.PublicKeySet(use, alg, audience)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(keySet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JWKS: %w", err)
+	}
+
+	if h.lastGood != nil {
+		h.mu.Lock()
+		h.lastGood[use+" "+alg+" "+audience] = servedKeySet{data: data, servedAt: time.Now()}
+		h.mu.Unlock()
+	}
+	return data, nil
+}
+
+// staleKeySet returns the last good key set for use, alg and audience if it
+// may still be served.
+func (h *JWKSHandler) staleKeySet(use, alg, audience string) (servedKeySet, bool) {
+	if h.lastGood == nil {
+		return servedKeySet{}, false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stale, ok := h.lastGood[use+" "+alg+" "+audience]
+	if !ok || time.Since(stale.servedAt) > h.staleIfError {
+		return servedKeySet{}, false
+	}
+	return stale, true
+}
+
 func (h *JWKSHandler) sendError(w http.ResponseWriter, err *errors.ServiceError) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(err.Status)
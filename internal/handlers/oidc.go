@@ -3,16 +3,36 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"session-service/internal/auth"
+	"strings"
 
+	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
+// Discovery metadata names of the endpoints a router can advertise.
+const (
+	DiscoveryTokenEndpoint               = "token_endpoint"
+	DiscoveryJWKSURI                     = "jwks_uri"
+	DiscoveryDeviceAuthorizationEndpoint = "device_authorization_endpoint"
+	DiscoveryRevocationEndpoint          = "revocation_endpoint"
+	DiscoveryIntrospectionEndpoint       = "introspection_endpoint"
+	DiscoveryUserinfoEndpoint            = "userinfo_endpoint"
+	DiscoveryEndSessionEndpoint          = "end_session_endpoint"
+)
+
 // OIDCConfiguration represents the OpenID Connect discovery document
 type OIDCConfiguration struct {
 	TokenEndpoint                     string   `json:"token_endpoint"`
 	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
 	JwksURI                           string   `json:"jwks_uri"`
-	ResponseModesSupported            []string `json:"response_modes_supported"`
+	DeviceAuthorizationEndpoint       string   `json:"device_authorization_endpoint,omitempty"`
+	RevocationEndpoint                string   `json:"revocation_endpoint,omitempty"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint,omitempty"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint,omitempty"`
+	EndSessionEndpoint                string   `json:"end_session_endpoint,omitempty"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	ResponseModesSupported            []string `json:"response_modes_supported,omitempty"`
 	SubjectTypesSupported             []string `json:"subject_types_supported"`
 	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
 	ResponseTypesSupported            []string `json:"response_types_supported"`
@@ -22,22 +42,82 @@ type OIDCConfiguration struct {
 	ClaimsSupported                   []string `json:"claims_supported"`
 }
 
+// AuthorizationServerMetadata represents a tenant's OAuth 2.0 Authorization
+// Server Metadata document (RFC 8414)
+type AuthorizationServerMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	JwksURI                           string   `json:"jwks_uri"`
+	DeviceAuthorizationEndpoint       string   `json:"device_authorization_endpoint,omitempty"`
+	RevocationEndpoint                string   `json:"revocation_endpoint,omitempty"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint,omitempty"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// tokenEndpointAuthMethods are the client authentication methods the token
+// endpoint accepts.
+var tokenEndpointAuthMethods = []string{"client_secret_post", "client_secret_basic"}
+
+// responseTypes are the authorization endpoint response types supported.
+// There is no authorization endpoint, so the list is empty; it is still
+// present because discovery requires it.
+var responseTypes = []string{}
+
 // OIDCConfigurationHandler handles OIDC discovery endpoint
 type OIDCConfigurationHandler struct {
 	baseURL string
 	issuer  string
 	logger  *zap.Logger
+
+	// Filled in by the router as it wires routes, so discovery only
+	// advertises what is actually served.
+	endpoints  map[string]string
+	grantTypes []string
+
+	idTokenKeys *auth.KeyManager
 }
 
 // NewOIDCConfigurationHandler creates a new OIDC configuration handler
 func NewOIDCConfigurationHandler(baseURL, issuer string, logger *zap.Logger) *OIDCConfigurationHandler {
 	return &OIDCConfigurationHandler{
-		baseURL: baseURL,
-		issuer:  issuer,
-		logger:  logger,
+		baseURL:   baseURL,
+		issuer:    issuer,
+		logger:    logger,
+		endpoints: make(map[string]string),
 	}
 }
 
+// AdvertiseEndpoint publishes a route under the given discovery metadata name
+// (e.g. DiscoveryTokenEndpoint). Tenant-scoped paths keep their {tenant_id}
+// placeholder for clients to fill in.
+func (h *OIDCConfigurationHandler) AdvertiseEndpoint(name, path string) {
+	h.endpoints[name] = h.baseURL + path
+}
+
+// SetGrantTypes sets the grant types advertised as grant_types_supported.
+func (h *OIDCConfigurationHandler) SetGrantTypes(grantTypes []string) {
+	h.grantTypes = grantTypes
+}
+
+// SetIDTokenSigningKeys sets the key manager ID tokens are signed with. Its
+// default algorithm, which changes when standby keys are promoted, is
+// advertised for ID tokens; without one, RS256 is.
+func (h *OIDCConfigurationHandler) SetIDTokenSigningKeys(keys *auth.KeyManager) {
+	h.idTokenKeys = keys
+}
+
+// idTokenSigningAlg returns the algorithm ID tokens are currently signed with.
+func (h *OIDCConfigurationHandler) idTokenSigningAlg() string {
+	if h.idTokenKeys == nil {
+		return "RS256"
+	}
+	return h.idTokenKeys.DefaultAlgorithm()
+}
+
 // HandleOIDCConfiguration handles GET /.well-known/openid-configuration
 func (h *OIDCConfigurationHandler) HandleOIDCConfiguration(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -46,13 +126,18 @@ func (h *OIDCConfigurationHandler) HandleOIDCConfiguration(w http.ResponseWriter
 	}
 
 	config := OIDCConfiguration{
-		TokenEndpoint:                     h.baseURL + "/oauth2/v1.0/token",
-		TokenEndpointAuthMethodsSupported: []string{"client_secret_post", "client_secret_basic"},
-		JwksURI:                           h.baseURL + "/discovery/v1.0/keys",
-		ResponseModesSupported:            []string{"query", "fragment", "form_post"},
+		TokenEndpoint:                     h.endpoints[DiscoveryTokenEndpoint],
+		TokenEndpointAuthMethodsSupported: tokenEndpointAuthMethods,
+		JwksURI:                           h.endpoints[DiscoveryJWKSURI],
+		DeviceAuthorizationEndpoint:       h.endpoints[DiscoveryDeviceAuthorizationEndpoint],
+		RevocationEndpoint:                h.endpoints[DiscoveryRevocationEndpoint],
+		IntrospectionEndpoint:             h.endpoints[DiscoveryIntrospectionEndpoint],
+		UserinfoEndpoint:                  h.endpoints[DiscoveryUserinfoEndpoint],
+		EndSessionEndpoint:                h.endpoints[DiscoveryEndSessionEndpoint],
+		GrantTypesSupported:               h.grantTypes,
 		SubjectTypesSupported:             []string{"public"},
-		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
-		ResponseTypesSupported:            []string{"code", "token"},
+		IDTokenSigningAlgValuesSupported:  []string{h.idTokenSigningAlg()},
+		ResponseTypesSupported:            responseTypes,
 		ScopesSupported:                   []string{"openid"},
 		Issuer:                            h.issuer,
 		RequestURIParameterSupported:      false,
@@ -66,9 +151,42 @@ func (h *OIDCConfigurationHandler) HandleOIDCConfiguration(w http.ResponseWriter
 		},
 	}
 
-	data, err := json.MarshalIndent(config, "", "  ")
+	h.writeMetadata(w, config)
+}
+
+// HandleAuthorizationServerMetadata handles
+// GET /.well-known/oauth-authorization-server/{tenant_id}, the RFC 8414
+// metadata of a tenant. It advertises the same routes as OIDC discovery,
+// with the tenant filled in.
+func (h *OIDCConfigurationHandler) HandleAuthorizationServerMetadata(w http.ResponseWriter, r *http.Request) {
+	tenantID := mux.Vars(r)["tenant_id"]
+	endpoint := func(name string) string {
+		return strings.ReplaceAll(h.endpoints[name], "{tenant_id}", tenantID)
+	}
+
+	metadata := AuthorizationServerMetadata{
+		Issuer:                            h.issuer,
+		TokenEndpoint:                     endpoint(DiscoveryTokenEndpoint),
+		TokenEndpointAuthMethodsSupported: tokenEndpointAuthMethods,
+		JwksURI:                           endpoint(DiscoveryJWKSURI),
+		DeviceAuthorizationEndpoint:       endpoint(DiscoveryDeviceAuthorizationEndpoint),
+		RevocationEndpoint:                endpoint(DiscoveryRevocationEndpoint),
+		IntrospectionEndpoint:             endpoint(DiscoveryIntrospectionEndpoint),
+		GrantTypesSupported:               h.grantTypes,
+		ResponseTypesSupported:            responseTypes,
+		ScopesSupported:                   []string{"openid"},
+		// There is no authorization endpoint, so no PKCE methods to advertise
+		CodeChallengeMethodsSupported: nil,
+	}
+
+	h.writeMetadata(w, metadata)
+}
+
+// writeMetadata writes a discovery document as cacheable JSON.
+func (h *OIDCConfigurationHandler) writeMetadata(w http.ResponseWriter, document interface{}) {
+	data, err := json.MarshalIndent(document, "", "  ")
 	if err != nil {
-		h.logger.Error("Failed to marshal OIDC configuration", zap.Error(err))
+		h.logger.Error("Failed to marshal discovery metadata", zap.Error(err))
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
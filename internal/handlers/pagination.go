@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"session-service/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPageLimit is the page size of admin list endpoints when the
+	// request does not set limit.
+	DefaultPageLimit = 50
+	// MaxPageLimit caps the limit a request may ask for.
+	MaxPageLimit = 200
+)
+
+// parsePagination reads the limit and offset query parameters of a list
+// request, applying DefaultPageLimit and rejecting values out of range.
+func parsePagination(r *http.Request) (int, int, *errors.ServiceError) {
+	limit, offset := DefaultPageLimit, 0
+
+	query := r.URL.Query()
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > MaxPageLimit {
+			return 0, 0, errors.WithMessage(errors.ErrInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", MaxPageLimit))
+		}
+		limit = n
+	}
+	if value := query.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, 0, errors.WithMessage(errors.ErrInvalidRequest, "offset must not be negative")
+		}
+		offset = n
+	}
+	return limit, offset, nil
+}
+
+// paginationLinks builds an RFC 5988 Link header value with first, prev, next
+// and last links for a page of limit items at offset out of total. The links
+// are relative references to the request URL, keeping its other query
+// parameters; prev and next are omitted on the first and last pages.
+func paginationLinks(r *http.Request, limit, offset, total int) string {
+	lastOffset := 0
+	if total > 0 {
+		lastOffset = (total - 1) / limit * limit
+	}
+
+	links := []string{pageLink(r, "first", limit, 0)}
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		links = append(links, pageLink(r, "prev", limit, prev))
+	}
+	if offset+limit < total {
+		links = append(links, pageLink(r, "next", limit, offset+limit))
+	}
+	links = append(links, pageLink(r, "last", limit, lastOffset))
+	return strings.Join(links, ", ")
+}
+
+func pageLink(r *http.Request, rel string, limit, offset int) string {
+	query := r.URL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	target := *r.URL
+	target.RawQuery = query.Encode()
+	return fmt.Sprintf("<%s>; rel=%q", target.RequestURI(), rel)
+}
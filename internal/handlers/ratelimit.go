@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"session-service/internal/models"
+	"session-service/pkg/errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HandleRateLimit handles GET /{tenant_id}/oauth2/v1.0/ratelimit
+// @Summary     Get the client's rate limit
+// @Description Returns the authenticated client's rate limit, the window it applies to and how many requests remain in the current window, so clients can pace themselves. Checking does not count against the limit. Enabled with RATE_LIMIT_ENDPOINT.
+// @Tags        oauth2
+// @Produce     application/json
+// @Security    BasicAuth
+// @Param       tenant_id path string true "Tenant ID"
+// @Success     200 {object} models.RateLimitResponse
+// @Failure     401 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Router      /{tenant_id}/oauth2/v1.0/ratelimit [get]
+func (h *TokenHandler) HandleRateLimit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !h.config.RateLimitEndpoint {
+		http.NotFound(w, r)
+		return
+	}
+
+	client, serviceErr := h.verifyClient(ctx, r)
+	if serviceErr != nil {
+		h.sendError(w, serviceErr)
+		return
+	}
+
+	count, resetIn, err := h.cache.GetRateLimitUsage(ctx, client.ClientID)
+	if err != nil {
+		h.logger.Error("Failed to get rate limit usage", zap.String("client_id", client.ClientID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	remaining := client.RateLimit - int(count)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	h.sendJSON(w, http.StatusOK, &models.RateLimitResponse{
+		ClientID:      client.ClientID,
+		RateLimit:     client.RateLimit,
+		WindowSeconds: int64(rateLimitWindow.Seconds()),
+		Remaining:     remaining,
+		ResetSeconds:  int64((resetIn + time.Second - 1) / time.Second),
+	})
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"session-service/internal/config"
+	"session-service/internal/models"
+	"session-service/pkg/errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// makeRoomForSession enforces the session limit of tenantID before a new
+// session of userID starts. At the limit it either ends the user's oldest
+// sessions or, with the reject policy, answers SESSION_LIMIT_REACHED. It
+// reports whether the new session may start.
+func (h *TokenHandler) makeRoomForSession(ctx context.Context, w http.ResponseWriter, tenantID, userID string) bool {
+	limit, err := h.sessionLimit(ctx, tenantID)
+	if err != nil {
+		h.logger.Error("Failed to get tenant config", zap.String("tenant_id", tenantID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return false
+	}
+	if limit <= 0 {
+		return true
+	}
+
+	sessions, err := h.cache.GetUserSessions(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to get user sessions", zap.String("user_id", userID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return false
+	}
+	excess := len(sessions) - limit + 1
+	if excess <= 0 {
+		return true
+	}
+
+	if h.config.SessionLimitPolicy == config.SessionLimitReject {
+		h.logger.Info("New session rejected at the session limit",
+			zap.String("user_id", userID),
+			zap.Int("sessions", len(sessions)))
+		h.sendError(w, errors.ErrSessionLimitReached)
+		return false
+	}
+
+	// Sessions are oldest first
+	for _, session := range sessions[:excess] {
+		if err := h.endSession(ctx, userID, session); err != nil {
+			h.logger.Error("Failed to evict session", zap.String("user_id", userID), zap.Error(err))
+			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+			return false
+		}
+		h.logger.Info("Evicted oldest session at the session limit",
+			zap.String("user_id", userID),
+			zap.String("session_id", session.SessionID))
+	}
+	return true
+}
+
+// endSession revokes the current refresh token of session and removes it
+// from the user's session index.
+func (h *TokenHandler) endSession(ctx context.Context, userID string, session models.UserSession) error {
+	if err := h.cache.RevokeRefreshToken(ctx, session.RefreshToken, h.config.RefreshTokenExpiry); err != nil {
+		return err
+	}
+	if err := h.cache.DeleteRefreshToken(ctx, session.RefreshToken); err != nil {
+		return err
+	}
+	return h.cache.RemoveUserSession(ctx, userID, session.SessionID)
+}
+
+// trackSession records refreshToken as the current refresh token of the
+// subject's session while session limits are enforced for its tenant. A
+// failure only leaves the session uncounted, so it is logged rather than
+// returned.
+func (h *TokenHandler) trackSession(ctx context.Context, subject *models.TokenSubject, refreshToken string, ttl time.Duration) {
+	limit, err := h.sessionLimit(ctx, subject.TenantID)
+	if err != nil {
+		h.logger.Warn("Failed to get tenant config", zap.String("tenant_id", subject.TenantID), zap.Error(err))
+		return
+	}
+	if limit <= 0 {
+		return
+	}
+	if err := h.cache.TrackUserSession(ctx, subject.UserID, subject.SessionID, refreshToken, ttl); err != nil {
+		h.logger.Warn("Failed to track user session", zap.String("user_id", subject.UserID), zap.Error(err))
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"session-service/internal/audit"
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/pkg/errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EnableStandbyPromotion serves POST /admin/keys/promote-standby, which
+// promotes keys' standby key source to the active signer. Keys it replaces
+// remain valid for verification for gracePeriod.
+func (h *AdminHandler) EnableStandbyPromotion(keys *auth.KeyManager, gracePeriod time.Duration) {
+	h.keys = keys
+	h.promotionGrace = gracePeriod
+}
+
+// HandlePromoteStandbyKey handles POST /admin/keys/promote-standby
+// @Summary     Promote the standby signing keys
+// @Description Switches token signing to the standby key source configured with JWT_STANDBY_PRIVATE_KEY, whose keys are already published in JWKS. The keys it replaces stay valid for verification for KEY_GRACE_DAYS. The promotion applies to this replica only. Requires OPERATOR_TOKEN as the Bearer credential.
+// @Tags        admin
+// @Produce     application/json
+// @Security    BearerAuth
+// @Success     200  {object}  models.SigningKeyPromotionResponse
+// @Failure     400  {object}  map[string]string
+// @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
+// @Failure     409  {object}  map[string]string
+// @Failure     500  {object}  map[string]string
+// @Router      /admin/keys/promote-standby [post]
+func (h *AdminHandler) HandlePromoteStandbyKey(w http.ResponseWriter, r *http.Request) {
+	if h.keys == nil {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "no standby key source is configured; set JWT_STANDBY_PRIVATE_KEY"))
+		return
+	}
+
+	if err := h.keys.PromoteStandby(h.promotionGrace); err != nil {
+		if stderrors.Is(err, auth.ErrNoStandby) {
+			h.sendError(w, errors.WithMessage(errors.ErrConflict, "the standby key source has already been promoted"))
+			return
+		}
+		if stderrors.Is(err, auth.ErrStandbyIncomplete) {
+			h.sendError(w, errors.WithMessage(errors.ErrConflict, err.Error()))
+			return
+		}
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	if err := h.keys.PersistKeys(r.Context()); err != nil {
+		h.logger.Error("Failed to persist promoted signing keys", zap.Error(err))
+	}
+
+	key, err := h.keys.GetSigningKey("")
+	if err != nil {
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	h.logger.Info("Standby signing keys promoted", zap.String("kid", key.KeyID), zap.String("alg", key.Algorithm))
+
+	h.audit.Record(r.Context(), audit.Event{
+		Type:     audit.EventSigningKeyPromote,
+		ActorID:  "operator",
+		TargetID: key.KeyID,
+	})
+
+	h.sendJSON(w, http.StatusOK, &models.SigningKeyPromotionResponse{
+		KeyID:     key.KeyID,
+		Algorithm: key.Algorithm,
+	})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/pkg/errors"
+
+	"go.uber.org/zap"
+)
+
+// EnableTenantBootstrap lets provision_user create its tenant on first use
+// when the request carries a tenant_bootstrap_token for that tenant which
+// verifier accepts. Without a bootstrap token the tenant must exist.
+func (h *TokenHandler) EnableTenantBootstrap(verifier *auth.TenantBootstrapVerifier) {
+	h.tenantBootstrap = verifier
+}
+
+// ensureProvisioningTenant checks that tenantID exists, first creating it
+// if the request carries a valid tenant bootstrap token.
+func (h *TokenHandler) ensureProvisioningTenant(ctx context.Context, r *http.Request, tenantID string) *errors.ServiceError {
+	bootstrapToken := r.FormValue("tenant_bootstrap_token")
+	if h.tenantBootstrap == nil || bootstrapToken == "" {
+		if err := h.repo.EnsureTenantExists(ctx, tenantID); err != nil {
+			h.logger.Error("Tenant does not exist for token request", zap.String("tenant_id", tenantID), zap.Error(err))
+			return errors.Wrap(err, errors.ErrInvalidRequest)
+		}
+		return nil
+	}
+
+	if err := h.tenantBootstrap.Verify(bootstrapToken, tenantID); err != nil {
+		h.logger.Warn("Rejected tenant bootstrap token", zap.String("tenant_id", tenantID), zap.Error(err))
+		return errors.WithMessage(errors.ErrInvalidRequest, "Invalid tenant_bootstrap_token")
+	}
+
+	created, err := h.repo.CreateTenantIfNotExists(ctx, models.Tenant{ID: tenantID, Name: tenantID})
+	if err != nil {
+		h.logger.Error("Failed to create tenant from bootstrap token", zap.String("tenant_id", tenantID), zap.Error(err))
+		return errors.Wrap(err, errors.ErrInternalServer)
+	}
+	if created {
+		h.logger.Info("Tenant created from bootstrap token", zap.String("tenant_id", tenantID))
+	}
+	return nil
+}
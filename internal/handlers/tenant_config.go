@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"session-service/internal/models"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// tenantGrantTypes are the grant types a tenant's allowed_grant_types may
+// name.
+var tenantGrantTypes = []string{"client_credentials", "provision_user", "refresh_token", DeviceCodeGrantType}
+
+// tenantConfig returns the tenant's policy, checking the cache before the
+// database. Without TENANT_CONFIG, or for a tenant without a tenant_config
+// row, it returns the defaults, which leave the global configuration in
+// effect.
+func (h *TokenHandler) tenantConfig(ctx context.Context, tenantID string) (*models.TenantConfig, error) {
+	if !h.config.TenantConfig {
+		return &models.TenantConfig{TenantID: tenantID}, nil
+	}
+
+	tenantConfig, err := h.cache.GetTenantConfig(ctx, tenantID)
+	if err != nil {
+		h.logger.Error("Failed to get tenant config from cache", zap.Error(err))
+	}
+	if tenantConfig != nil {
+		return tenantConfig, nil
+	}
+
+	tenantConfig, err = h.repo.GetTenantConfig(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.cache.SetTenantConfig(ctx, tenantConfig, 15*time.Minute); err != nil {
+		h.logger.Warn("Failed to cache tenant config", zap.Error(err))
+	}
+
+	return tenantConfig, nil
+}
+
+// grantAllowed reports whether the tenant's policy lets it use grantType.
+func grantAllowed(tenantConfig *models.TenantConfig, grantType string) bool {
+	return len(tenantConfig.AllowedGrantTypes) == 0 || slices.Contains(tenantConfig.AllowedGrantTypes, grantType)
+}
+
+// sessionLimit returns the maximum number of concurrent sessions of a user
+// of tenantID: the tenant's override, or MAX_SESSIONS_PER_USER. 0 is
+// unlimited.
+func (h *TokenHandler) sessionLimit(ctx context.Context, tenantID string) (int, error) {
+	tenantConfig, err := h.tenantConfig(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	if tenantConfig.MaxSessionsPerUser > 0 {
+		return tenantConfig.MaxSessionsPerUser, nil
+	}
+	return h.config.MaxSessionsPerUser, nil
+}
@@ -2,22 +2,33 @@ package handlers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"session-service/internal/auth"
 	"session-service/internal/cache"
 	"session-service/internal/config"
 	"session-service/internal/database"
+	"session-service/internal/middleware"
 	"session-service/internal/models"
 	"session-service/pkg/errors"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// rateLimitWindow is the window over which a client's rate_limit applies.
+const rateLimitWindow = time.Minute
+
 // TokenHandler handles OAuth2 token requests
 type TokenHandler struct {
 	repo           database.Repository
@@ -26,6 +37,21 @@ type TokenHandler struct {
 	tokenValidator *auth.TokenValidator
 	config         *config.Config
 	logger         *zap.Logger
+
+	// rotation and rotationRetryAfter are set by EnableRotationGuard
+	rotation           KeyRotationState
+	rotationRetryAfter time.Duration
+
+	// sessionCookieName is set by EnableCookieSessions
+	sessionCookieName string
+
+	// tenantBootstrap is set by EnableTenantBootstrap
+	tenantBootstrap *auth.TenantBootstrapVerifier
+}
+
+// KeyRotationState reports whether signing keys are being rotated.
+type KeyRotationState interface {
+	Rotating() bool
 }
 
 // NewTokenHandler creates a new token handler
@@ -47,26 +73,43 @@ func NewTokenHandler(
 	}
 }
 
+// EnableRotationGuard makes the token endpoint answer 503 with a Retry-After
+// of retryAfter while rotation reports a key rotation in progress, rather
+// than sign tokens while the key set is being replaced.
+func (h *TokenHandler) EnableRotationGuard(rotation KeyRotationState, retryAfter time.Duration) {
+	h.rotation = rotation
+	h.rotationRetryAfter = retryAfter
+}
+
 // HandleToken handles POST /{tenant_id}/oauth2/v2.0/token
 // @Summary     Get OAuth2 access and refresh tokens
-// @Description Issues access and refresh tokens using client_credentials, provision_user, or refresh_token grant types. Use provision_user for initial login with user details, client_credentials for subsequent authentication of existing users.
+// @Description Issues access and refresh tokens using client_credentials, provision_user, refresh_token, or urn:ietf:params:oauth:grant-type:device_code grant types. Use provision_user for initial login with user details, client_credentials for subsequent authentication of existing users.
 // @Tags        oauth2
 // @Accept      application/x-www-form-urlencoded
 // @Produce     application/json
 // @Param       tenant_id      path     string  true  "Tenant ID"
-// @Param       grant_type     formData string  true  "Grant type: client_credentials, provision_user, or refresh_token"
+// @Param       grant_type     formData string  true  "Grant type: client_credentials, provision_user, refresh_token, or urn:ietf:params:oauth:grant-type:device_code"
 // @Param       client_id      formData string  false "Client ID (required for client_credentials and provision_user)"
 // @Param       client_secret  formData string  false "Client Secret (required for client_credentials and provision_user)"
 // @Param       user_id       formData string  false "User ID (required for client_credentials and provision_user)"
 // @Param       user_full_name formData string  false "User full name (required for provision_user)"
 // @Param       user_phone     formData string  false "User phone (required for provision_user)"
 // @Param       user_email     formData string  false "User email (optional, provision_user only)"
-// @Param       user_roles     formData string  false "Comma-separated user roles (optional, provision_user only)"
+// @Param       user_roles     formData string  false "Comma-separated user roles (optional, provision_user only); each must be grantable by the client"
+// @Param       user_email_verified formData bool false "Whether user_email is verified (optional, provision_user only, default false)"
+// @Param       user_phone_verified formData bool false "Whether user_phone is verified (optional, provision_user only, default false)"
 // @Param       refresh_token  formData string  false "Refresh token (required for refresh_token grant)"
+// @Param       resource       formData string  false "Audience to narrow a refreshed access token to; must have been granted (optional, refresh_token only, with REFRESH_AUDIENCE_BINDING)"
+// @Param       device_code    formData string  false "Device code (required for the device_code grant)"
+// @Param       scope          formData string  false "Space-delimited scopes for the scp claim (optional, client_credentials and provision_user); each must be allowed for the client"
+// @Param       nonce          formData string  false "Value echoed in the ID token's nonce claim (optional, with scope=openid)"
+// @Param       session_cookie formData bool    false "Keep the refresh token in an HttpOnly session cookie instead of the response (optional, provision_user only, requires SESSION_COOKIES)"
 // @Success     200  {object}  models.TokenResponse
 // @Failure     400  {object}  map[string]string
 // @Failure     401  {object}  map[string]string
+// @Failure     403  {object}  map[string]string
 // @Failure     500  {object}  map[string]string
+// @Failure     503  {object}  map[string]string
 // @Router      /{tenant_id}/oauth2/v2.0/token [post]
 func (h *TokenHandler) HandleToken(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -84,75 +127,81 @@ func (h *TokenHandler) HandleToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.refuseDuringRotation(w, tenantIDFromPath) {
+		return
+	}
+
 	if err := r.ParseForm(); err != nil {
 		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
 		return
 	}
 
+	// A placeholder tenant stands for the client's own
+	if h.isTenantPlaceholder(tenantIDFromPath) {
+		tenantID, serviceErr := h.resolveClientTenant(ctx, r)
+		if serviceErr != nil {
+			h.sendError(w, serviceErr)
+			return
+		}
+		tenantIDFromPath = tenantID
+		vars["tenant_id"] = tenantID
+		r = mux.SetURLVars(r, vars)
+	}
+
 	grantType := r.FormValue("grant_type")
 
+	tenantConfig, err := h.tenantConfig(ctx, tenantIDFromPath)
+	if err != nil {
+		h.logger.Error("Failed to get tenant config", zap.String("tenant_id", tenantIDFromPath), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if !grantAllowed(tenantConfig, grantType) {
+		h.sendError(w, errors.ErrUnsupportedGrantType)
+		return
+	}
+
 	switch grantType {
 	case "client_credentials":
 		h.handleClientCredentials(ctx, w, r, tenantIDFromPath)
 	case "provision_user":
+		if h.config.DisableProvisioning {
+			h.sendError(w, errors.ErrUnsupportedGrantType)
+			return
+		}
 		h.handleUserProvisioning(ctx, w, r, tenantIDFromPath)
 	case "refresh_token":
 		h.handleRefreshToken(ctx, w, r, tenantIDFromPath)
+	case DeviceCodeGrantType:
+		h.handleDeviceCode(ctx, w, r, tenantIDFromPath)
 	default:
-		h.sendError(w, errors.ErrInvalidGrant)
+		h.sendError(w, errors.ErrUnsupportedGrantType)
 	}
 }
 
-func (h *TokenHandler) handleClientCredentials(ctx context.Context, w http.ResponseWriter, r *http.Request, tenantIDFromPath string) {
-	clientID := r.FormValue("client_id")
-	clientSecret := r.FormValue("client_secret")
-
-	if clientID == "" || clientSecret == "" {
-		h.sendError(w, errors.ErrInvalidCredentials)
-		return
-	}
-
-	// Check cache first
-	client, err := h.cache.GetClient(ctx, clientID)
-	if err != nil {
-		h.logger.Error("Failed to get client from cache", zap.Error(err))
-	}
-
-	// If not in cache, get from database
-	if client == nil {
-		client, err = h.repo.GetClientByID(ctx, clientID)
-		if err != nil {
-			h.logger.Error("Failed to get client from database", zap.Error(err))
-			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
-			return
-		}
-
-		if client == nil {
-			h.sendError(w, errors.ErrInvalidCredentials)
-			return
-		}
-
-		// Cache the client
-		if err := h.cache.SetClient(ctx, client, 15*time.Minute); err != nil {
-			h.logger.Warn("Failed to cache client", zap.Error(err))
-		}
+// GrantTypes returns the grant types HandleToken accepts, for discovery.
+func (h *TokenHandler) GrantTypes() []string {
+	grantTypes := []string{"client_credentials"}
+	if !h.config.DisableProvisioning {
+		grantTypes = append(grantTypes, "provision_user")
 	}
+	return append(grantTypes, "refresh_token", DeviceCodeGrantType)
+}
 
-	// Verify client secret
-	if err := bcrypt.CompareHashAndPassword([]byte(client.ClientSecretHash), []byte(clientSecret)); err != nil {
-		h.sendError(w, errors.ErrInvalidCredentials)
+func (h *TokenHandler) handleClientCredentials(ctx context.Context, w http.ResponseWriter, r *http.Request, tenantIDFromPath string) {
+	client, serviceErr := h.authenticateClient(ctx, r)
+	if serviceErr != nil {
+		h.sendError(w, serviceErr)
 		return
 	}
-
-	// Check rate limit
-	exceeded, err := h.cache.CheckRateLimit(ctx, clientID, client.RateLimit, time.Minute)
-	if err != nil {
-		h.logger.Error("Rate limit check failed", zap.Error(err))
-		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+	if !h.clientGrantAllowed(client, "client_credentials") {
+		h.sendError(w, errors.ErrUnauthorizedClient)
 		return
 	}
-	if exceeded {
-		h.sendError(w, errors.ErrRateLimitExceeded)
+
+	scopes, serviceErr := h.requestedScopes(r, client)
+	if serviceErr != nil {
+		h.sendError(w, serviceErr)
 		return
 	}
 
@@ -212,109 +261,35 @@ func (h *TokenHandler) handleClientCredentials(ctx context.Context, w http.Respo
 		UserID:   userID,
 		TenantID: tenantID,
 		Roles:    roles,
+		Scopes:   scopes,
 	}
 
-	// Generate tokens
-	accessToken, _, err := h.tokenGen.GenerateAccessToken(subject)
-	if err != nil {
-		h.logger.Error("Failed to generate access token", zap.Error(err))
-		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
-		return
-	}
-
-	refreshToken, err := h.tokenGen.GenerateRefreshToken()
-	if err != nil {
-		h.logger.Error("Failed to generate refresh token", zap.Error(err))
-		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
-		return
-	}
-
-	// Store refresh token, including subject so refresh can recreate claims
-	refreshTokenData := &models.RefreshTokenData{
-		ClientID:  clientID,
-		Subject:   subject,
-		ExpiresAt: time.Now().Add(h.config.RefreshTokenExpiry),
-	}
-	if err := h.cache.StoreRefreshToken(ctx, refreshToken, refreshTokenData, h.config.RefreshTokenExpiry); err != nil {
-		h.logger.Error("Failed to store refresh token", zap.Error(err))
-		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
-		return
-	}
-
-	// Update client updated_at
-	if err := h.repo.UpdateClientUpdatedAt(ctx, clientID); err != nil {
-		h.logger.Warn("Failed to update client updated_at", zap.Error(err))
-	}
-
-	// Send response
-	response := &models.TokenResponse{
-		AccessToken:  accessToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    int64(h.config.JWTExpiry.Seconds()),
-		RefreshToken: refreshToken,
-	}
-
-	h.sendJSON(w, http.StatusOK, response)
+	h.issueTokens(ctx, w, r, client, subject)
 }
 
 func (h *TokenHandler) handleUserProvisioning(ctx context.Context, w http.ResponseWriter, r *http.Request, tenantIDFromPath string) {
-	clientID := r.FormValue("client_id")
-	clientSecret := r.FormValue("client_secret")
-
-	if clientID == "" || clientSecret == "" {
-		h.sendError(w, errors.ErrInvalidCredentials)
+	client, serviceErr := h.authenticateClient(ctx, r)
+	if serviceErr != nil {
+		h.sendError(w, serviceErr)
 		return
 	}
-
-	// Check cache first
-	client, err := h.cache.GetClient(ctx, clientID)
-	if err != nil {
-		h.logger.Error("Failed to get client from cache", zap.Error(err))
-	}
-
-	// If not in cache, get from database
-	if client == nil {
-		client, err = h.repo.GetClientByID(ctx, clientID)
-		if err != nil {
-			h.logger.Error("Failed to get client from database", zap.Error(err))
-			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
-			return
-		}
-
-		if client == nil {
-			h.sendError(w, errors.ErrInvalidCredentials)
-			return
-		}
-
-		// Cache the client
-		if err := h.cache.SetClient(ctx, client, 15*time.Minute); err != nil {
-			h.logger.Warn("Failed to cache client", zap.Error(err))
-		}
-	}
-
-	// Verify client secret
-	if err := bcrypt.CompareHashAndPassword([]byte(client.ClientSecretHash), []byte(clientSecret)); err != nil {
-		h.sendError(w, errors.ErrInvalidCredentials)
+	if !h.clientGrantAllowed(client, "provision_user") {
+		h.sendError(w, errors.ErrUnauthorizedClient)
 		return
 	}
 
-	// Check rate limit
-	exceeded, err := h.cache.CheckRateLimit(ctx, clientID, client.RateLimit, time.Minute)
-	if err != nil {
-		h.logger.Error("Rate limit check failed", zap.Error(err))
-		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
-		return
-	}
-	if exceeded {
-		h.sendError(w, errors.ErrRateLimitExceeded)
+	scopes, serviceErr := h.requestedScopes(r, client)
+	if serviceErr != nil {
+		h.sendError(w, serviceErr)
 		return
 	}
 
-	// Parse user fields
+	// Parse user fields. User details are trimmed, so whitespace-only
+	// values count as missing.
 	userID := r.FormValue("user_id")
-	userFullName := r.FormValue("user_full_name")
-	userPhone := r.FormValue("user_phone")
-	userEmail := r.FormValue("user_email")
+	userFullName := strings.TrimSpace(r.FormValue("user_full_name"))
+	userPhone := strings.TrimSpace(r.FormValue("user_phone"))
+	userEmail := strings.TrimSpace(r.FormValue("user_email"))
 	userRolesRaw := r.FormValue("user_roles")
 
 	// Use tenant_id from path (required)
@@ -336,10 +311,29 @@ func (h *TokenHandler) handleUserProvisioning(ctx context.Context, w http.Respon
 		return
 	}
 
-	// Ensure tenant exists
-	if err := h.repo.EnsureTenantExists(ctx, tenantID); err != nil {
-		h.logger.Error("Tenant does not exist for token request", zap.String("tenant_id", tenantID), zap.Error(err))
-		h.sendError(w, errors.Wrap(err, errors.ErrInvalidRequest))
+	// Bound user details before they reach the database
+	if field := h.overlongProvisioningField(userFullName, userPhone, userEmail); field != "" {
+		h.logger.Warn("Provisioning field exceeds maximum length",
+			zap.String("user_id", userID),
+			zap.String("field", field))
+		h.sendError(w, errors.ErrInvalidRequest)
+		return
+	}
+
+	emailVerified, err := parseOptionalBool(r.FormValue("user_email_verified"))
+	if err != nil {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "user_email_verified must be true or false"))
+		return
+	}
+	phoneVerified, err := parseOptionalBool(r.FormValue("user_phone_verified"))
+	if err != nil {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "user_phone_verified must be true or false"))
+		return
+	}
+
+	// Ensure tenant exists, creating it only for a valid bootstrap token
+	if serviceErr := h.ensureProvisioningTenant(ctx, r, tenantID); serviceErr != nil {
+		h.sendError(w, serviceErr)
 		return
 	}
 
@@ -354,20 +348,55 @@ func (h *TokenHandler) handleUserProvisioning(ctx context.Context, w http.Respon
 		}
 	}
 
+	// Tenants with a role catalog only accept roles from it
+	if len(roles) > 0 {
+		unknown, err := h.unknownTenantRoles(ctx, tenantID, roles)
+		if err != nil {
+			h.logger.Error("Failed to list tenant roles", zap.String("tenant_id", tenantID), zap.Error(err))
+			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+			return
+		}
+		if len(unknown) > 0 {
+			h.logger.Warn("Provisioning rejected unknown roles",
+				zap.String("tenant_id", tenantID),
+				zap.String("user_id", userID),
+				zap.Strings("roles", unknown))
+			h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "Unknown roles for tenant: "+strings.Join(unknown, ", ")))
+			return
+		}
+	}
+
+	// A client cannot hand out roles it was not allowed to grant
+	if ungrantable := ungrantableRoles(h.config, client, roles); len(ungrantable) > 0 {
+		h.logger.Warn("Provisioning rejected roles the client may not grant",
+			zap.String("client_id", client.ClientID),
+			zap.String("tenant_id", tenantID),
+			zap.String("user_id", userID),
+			zap.Strings("roles", ungrantable))
+		h.sendError(w, errors.WithMessage(errors.ErrForbidden, "Client may not grant roles: "+strings.Join(ungrantable, ", ")))
+		return
+	}
+
 	// Upsert user and roles (this will INSERT or UPDATE)
 	user := models.User{
-		ID:          userID,
-		TenantID:    tenantID,
-		Email:       userEmail,
-		FullName:    userFullName,
-		PhoneNumber: userPhone,
+		ID:            userID,
+		TenantID:      tenantID,
+		Email:         userEmail,
+		FullName:      userFullName,
+		PhoneNumber:   userPhone,
+		EmailVerified: emailVerified,
+		PhoneVerified: phoneVerified,
 	}
 
-	if err := h.repo.UpsertUserAndRoles(ctx, user, roles); err != nil {
+	rolesChanged, err := h.repo.UpsertUserAndRoles(ctx, user, roles)
+	if err != nil {
 		h.logger.Error("Failed to upsert user and roles", zap.String("user_id", userID), zap.Error(err))
-		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		h.sendError(w, repositoryError(err))
 		return
 	}
+	if rolesChanged && h.config.RevokeTokensOnRoleChange {
+		h.recordRolesChange(ctx, tenantID, userID)
+	}
 
 	// Get roles (either from provided roles or fetch from DB if roles were nil)
 	if roles == nil {
@@ -383,55 +412,22 @@ func (h *TokenHandler) handleUserProvisioning(ctx context.Context, w http.Respon
 		UserID:   userID,
 		TenantID: tenantID,
 		Roles:    roles,
+		Scopes:   scopes,
 	}
 
-	// Generate tokens
-	accessToken, _, err := h.tokenGen.GenerateAccessToken(subject)
-	if err != nil {
-		h.logger.Error("Failed to generate access token", zap.Error(err))
-		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
-		return
-	}
-
-	refreshToken, err := h.tokenGen.GenerateRefreshToken()
-	if err != nil {
-		h.logger.Error("Failed to generate refresh token", zap.Error(err))
-		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
-		return
-	}
-
-	// Store refresh token, including subject so refresh can recreate claims
-	refreshTokenData := &models.RefreshTokenData{
-		ClientID:  clientID,
-		Subject:   subject,
-		ExpiresAt: time.Now().Add(h.config.RefreshTokenExpiry),
-	}
-	if err := h.cache.StoreRefreshToken(ctx, refreshToken, refreshTokenData, h.config.RefreshTokenExpiry); err != nil {
-		h.logger.Error("Failed to store refresh token", zap.Error(err))
-		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
-		return
-	}
-
-	// Update client updated_at
-	if err := h.repo.UpdateClientUpdatedAt(ctx, clientID); err != nil {
-		h.logger.Warn("Failed to update client updated_at", zap.Error(err))
-	}
-
-	// Send response
-	response := &models.TokenResponse{
-		AccessToken:  accessToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    int64(h.config.JWTExpiry.Seconds()),
-		RefreshToken: refreshToken,
-	}
-
-	h.sendJSON(w, http.StatusOK, response)
+	h.issueTokens(ctx, w, r, client, subject)
 }
 
 func (h *TokenHandler) handleRefreshToken(ctx context.Context, w http.ResponseWriter, r *http.Request, tenantIDFromPath string) {
-	refreshToken := r.FormValue("refresh_token")
+	h.refreshTokens(ctx, w, r, tenantIDFromPath, r.FormValue("refresh_token"), "")
+}
 
-	if refreshToken == "" {
+// refreshTokens exchanges refreshToken for a new token pair and writes the
+// token response. With a cookieSessionID the new refresh token replaces the
+// old one in that cookie session rather than being returned.
+func (h *TokenHandler) refreshTokens(ctx context.Context, w http.ResponseWriter, r *http.Request, tenantIDFromPath, refreshToken, cookieSessionID string) {
+	// A token with a missing or wrong MAC was never issued; don't look it up
+	if refreshToken == "" || !h.tokenGen.VerifyRefreshToken(refreshToken) {
 		h.sendError(w, errors.ErrInvalidRefreshToken)
 		return
 	}
@@ -467,11 +463,25 @@ func (h *TokenHandler) handleRefreshToken(ctx context.Context, w http.ResponseWr
 		return
 	}
 
+	// Check if the session has been idle for too long
+	if h.config.IdleSessionTimeout > 0 {
+		lastUsedAt := tokenData.LastUsedAt
+		if lastUsedAt.IsZero() {
+			lastUsedAt = tokenData.IssuedAt
+		}
+		if time.Since(lastUsedAt) > h.config.IdleSessionTimeout {
+			h.logger.Info("Refresh token rejected after idle session timeout", zap.String("client_id", tokenData.ClientID))
+			h.sendError(w, errors.ErrInvalidRefreshToken)
+			return
+		}
+	}
+
 	clientID := tokenData.ClientID
 	subject := tokenData.Subject
 
-	// Validate that tenant_id from path matches the tenant_id in the refresh token subject
-	if subject == nil || subject.TenantID != tenantIDFromPath {
+	// Validate that tenant_id from path matches the tenant_id in the refresh
+	// token subject, which may predate tenant ids being canonical
+	if subject == nil || middleware.CanonicalTenantID(subject.TenantID) != tenantIDFromPath {
 		h.logger.Error("Tenant ID mismatch between path and refresh token",
 			zap.String("path_tenant_id", tenantIDFromPath),
 			zap.String("token_tenant_id", func() string {
@@ -483,6 +493,36 @@ func (h *TokenHandler) handleRefreshToken(ctx context.Context, w http.ResponseWr
 		h.sendError(w, errors.ErrInvalidRefreshToken)
 		return
 	}
+	subject.TenantID = tenantIDFromPath
+
+	// Reject refresh tokens issued before the user's revocation cutoff (e.g. the user was deleted)
+	cutoff, err := h.cache.GetUserRevocationCutoff(ctx, subject.UserID)
+	if err != nil {
+		h.logger.Error("Failed to check user revocation", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if !cutoff.IsZero() && !tokenData.IssuedAt.After(cutoff) {
+		h.logger.Info("Refresh token revoked by user revocation cutoff", zap.String("user_id", subject.UserID))
+		h.sendError(w, errors.ErrInvalidRefreshToken)
+		return
+	}
+
+	// In strict mode the stored subject is not trusted: the user must still
+	// exist in the database, in the same tenant
+	if h.config.RefreshVerifyUser {
+		user, err := h.repo.GetUserByID(ctx, subject.UserID)
+		if err != nil {
+			h.logger.Error("Failed to get user from database", zap.String("user_id", subject.UserID), zap.Error(err))
+			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+			return
+		}
+		if user == nil || user.TenantID != subject.TenantID {
+			h.logger.Info("Refresh token rejected for a deleted or moved user", zap.String("user_id", subject.UserID))
+			h.sendError(w, errors.ErrInvalidRefreshToken)
+			return
+		}
+	}
 
 	// Get client to check rate limit
 	client, err := h.repo.GetClientByID(ctx, clientID)
@@ -498,7 +538,7 @@ func (h *TokenHandler) handleRefreshToken(ctx context.Context, w http.ResponseWr
 	}
 
 	// Check rate limit
-	exceeded, err := h.cache.CheckRateLimit(ctx, clientID, client.RateLimit, time.Minute)
+	exceeded, err := h.cache.CheckRateLimit(ctx, clientID, client.RateLimit, rateLimitWindow)
 	if err != nil {
 		h.logger.Error("Rate limit check failed", zap.Error(err))
 		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
@@ -509,6 +549,82 @@ func (h *TokenHandler) handleRefreshToken(ctx context.Context, w http.ResponseWr
 		return
 	}
 
+	// The client's allowed scopes may have been narrowed since the session began
+	var droppedScopes []string
+	subject.Scopes, droppedScopes = filterScopes(subject.Scopes, client.AllowedScopes)
+	if len(droppedScopes) > 0 {
+		h.logger.Info("Dropped scopes no longer allowed for client from refreshed token",
+			zap.String("client_id", clientID),
+			zap.String("user_id", subject.UserID),
+			zap.Strings("dropped_scopes", droppedScopes))
+	}
+
+	tenant, err := h.getTenant(ctx, subject.TenantID)
+	if err != nil {
+		h.logger.Error("Failed to get tenant", zap.String("tenant_id", subject.TenantID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	// Bound refresh tokens only work from the device they were issued to
+	fingerprint := h.requestFingerprint(r)
+	if tenant != nil && tenant.BindRefreshTokens && tokenData.Fingerprint != fingerprint {
+		h.logger.Warn("Refresh token used from a different device or network",
+			zap.String("tenant_id", subject.TenantID),
+			zap.String("client_id", clientID))
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRefreshToken, "Refresh token is bound to a different device or network"))
+		return
+	}
+
+	// Tokens issued before a role change must not carry the old roles forward
+	if h.config.RevokeTokensOnRoleChange {
+		changedAt, err := h.cache.GetUserRolesChangedAt(ctx, subject.UserID)
+		if err != nil {
+			h.logger.Error("Failed to check user roles change", zap.Error(err))
+			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+			return
+		}
+		if changedAt.After(tokenData.IssuedAt) {
+			roles, err := h.repo.GetUserRoles(ctx, subject.UserID)
+			if err != nil {
+				h.logger.Error("Failed to get user roles", zap.String("user_id", subject.UserID), zap.Error(err))
+				h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+				return
+			}
+			subject.Roles = filterRoles(roles, client.RolePrefixes)
+		}
+	}
+
+	// With audience binding, refreshed tokens stay within the audiences the
+	// session was granted, narrowed by any resource parameters
+	var audiences []string
+	if h.config.RefreshAudienceBinding {
+		if serviceErr := h.checkAudienceCount(len(r.Form["resource"])); serviceErr != nil {
+			h.sendError(w, serviceErr)
+			return
+		}
+		var serviceErr *errors.ServiceError
+		audiences, serviceErr = refreshAudiences(r.Form["resource"], tokenData.Audiences, auth.SplitAudiences(h.config.JWTAudience))
+		if serviceErr != nil {
+			h.logger.Info("Refresh requested an audience the session was not granted",
+				zap.String("client_id", clientID),
+				zap.Strings("resources", r.Form["resource"]))
+			h.sendError(w, serviceErr)
+			return
+		}
+	}
+
+	// Never let the access token outlive the refresh token it was issued
+	// from. With under a second left, no access token can be issued at all,
+	// so the refresh token is treated as expired.
+	accessTTL, refreshTTL := h.tokenLifetimes(tenant, tokenData.GrantType)
+	remaining := time.Until(tokenData.ExpiresAt).Truncate(time.Second)
+	if remaining < time.Second {
+		h.sendError(w, errors.ErrInvalidRefreshToken)
+		return
+	}
+	accessTTL = min(accessTTL, remaining)
+
 	// Revoke old refresh token
 	if err := h.cache.RevokeRefreshToken(ctx, refreshToken, h.config.RefreshTokenExpiry); err != nil {
 		h.logger.Warn("Failed to revoke old refresh token", zap.Error(err))
@@ -524,7 +640,14 @@ func (h *TokenHandler) handleRefreshToken(ctx context.Context, w http.ResponseWr
 		return
 	}
 
-	accessToken, _, err := h.tokenGen.GenerateAccessToken(subject)
+	h.applyTenantClaims(subject, tenant)
+
+	// Refresh tokens issued before session IDs existed start a session here
+	if subject.SessionID == "" {
+		subject.SessionID = uuid.New().String()
+	}
+
+	accessToken, accessJTI, err := h.issueAccessToken(ctx, client, tenant, subject, audiences, accessTTL)
 	if err != nil {
 		h.logger.Error("Failed to generate access token", zap.Error(err))
 		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
@@ -538,36 +661,608 @@ func (h *TokenHandler) handleRefreshToken(ctx context.Context, w http.ResponseWr
 		return
 	}
 
+	// A narrowed audience only applies to this access token; the session
+	// keeps its original grant
+	grantedAudiences := subject.Audiences
+	if h.config.RefreshAudienceBinding {
+		grantedAudiences = tokenData.Audiences
+	}
+
 	// Store new refresh token
+	now := time.Now()
 	newRefreshTokenData := &models.RefreshTokenData{
-		ClientID:  clientID,
-		Subject:   subject, // Preserve subject for future refreshes
-		ExpiresAt: time.Now().Add(h.config.RefreshTokenExpiry),
+		ClientID:       clientID,
+		Subject:        subject, // Preserve subject (and session ID) for future refreshes
+		IssuedAt:       now,
+		ExpiresAt:      now.Add(refreshTTL),
+		LastUsedAt:     now,
+		Fingerprint:    fingerprint,
+		AccessTokenJTI: accessJTI,
+		GrantType:      tokenData.GrantType,
+		Audiences:      grantedAudiences,
 	}
-	if err := h.cache.StoreRefreshToken(ctx, newRefreshToken, newRefreshTokenData, h.config.RefreshTokenExpiry); err != nil {
+	if err := h.cache.StoreRefreshToken(ctx, newRefreshToken, newRefreshTokenData, refreshTTL); err != nil {
 		h.logger.Error("Failed to store refresh token", zap.Error(err))
 		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
 		return
 	}
+	h.trackSession(ctx, subject, newRefreshToken, refreshTTL)
 
 	// Send response
 	response := &models.TokenResponse{
 		AccessToken:  accessToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    int64(h.config.JWTExpiry.Seconds()),
+		ExpiresIn:    int64(accessTTL.Seconds()),
 		RefreshToken: newRefreshToken,
 	}
 
+	if cookieSessionID != "" {
+		h.sendCookieSession(ctx, w, subject.TenantID, cookieSessionID, response, refreshTTL)
+		return
+	}
 	h.sendJSON(w, http.StatusOK, response)
 }
 
+// authenticateClient authenticates the client of r with verifyClient and
+// applies its rate limit.
+func (h *TokenHandler) authenticateClient(ctx context.Context, r *http.Request) (*models.Client, *errors.ServiceError) {
+	client, serviceErr := h.verifyClient(ctx, r)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if serviceErr := h.checkClientTenant(client, mux.Vars(r)["tenant_id"]); serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	// Check rate limit
+	exceeded, err := h.cache.CheckRateLimit(ctx, client.ClientID, client.RateLimit, rateLimitWindow)
+	if err != nil {
+		h.logger.Error("Rate limit check failed", zap.Error(err))
+		return nil, errors.Wrap(err, errors.ErrInternalServer)
+	}
+	if exceeded {
+		return nil, errors.ErrRateLimitExceeded
+	}
+
+	return client, nil
+}
+
+// verifyClient looks up the client of r (cache first, then database) and
+// verifies its secret, without counting the request against its rate limit.
+func (h *TokenHandler) verifyClient(ctx context.Context, r *http.Request) (*models.Client, *errors.ServiceError) {
+	clientID, clientSecret, serviceErr := clientCredentials(r)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	client, serviceErr := h.lookupClient(ctx, clientID)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	// Verify client secret
+	if err := bcrypt.CompareHashAndPassword([]byte(client.ClientSecretHash), []byte(clientSecret)); err != nil {
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	return client, nil
+}
+
+// lookupClient returns the client clientID, checking the cache before the
+// database, without authenticating it.
+func (h *TokenHandler) lookupClient(ctx context.Context, clientID string) (*models.Client, *errors.ServiceError) {
+	// Check cache first
+	client, err := h.cache.GetClient(ctx, clientID)
+	if err != nil {
+		h.logger.Error("Failed to get client from cache", zap.Error(err))
+	}
+	if client != nil {
+		return client, nil
+	}
+
+	// If not in cache, get from database
+	client, err = h.repo.GetClientByID(ctx, clientID)
+	if err != nil {
+		h.logger.Error("Failed to get client from database", zap.Error(err))
+		return nil, errors.Wrap(err, errors.ErrInternalServer)
+	}
+
+	if client == nil {
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	// Cache the client
+	if err := h.cache.SetClient(ctx, client, 15*time.Minute); err != nil {
+		h.logger.Warn("Failed to cache client", zap.Error(err))
+	}
+	return client, nil
+}
+
+// issueTokens issues an access and refresh token pair for subject on behalf
+// of client, applying the tenant's claims and token lifetimes, and writes the
+// token response.
+func (h *TokenHandler) issueTokens(ctx context.Context, w http.ResponseWriter, r *http.Request, client *models.Client, subject *models.TokenSubject) {
+	subject.Roles = filterRoles(subject.Roles, client.RolePrefixes)
+	subject.Scopes, _ = filterScopes(subject.Scopes, client.AllowedScopes)
+
+	// The grant type picks the session's amr and access token lifetime,
+	// which refreshed tokens keep
+	grantType := r.FormValue("grant_type")
+	if amr := h.config.GrantAMR[grantType]; amr != "" {
+		subject.AuthMethods = []string{amr}
+	}
+
+	tenant, err := h.getTenant(ctx, subject.TenantID)
+	if err != nil {
+		h.logger.Error("Failed to get tenant", zap.String("tenant_id", subject.TenantID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	h.applyTenantClaims(subject, tenant)
+	accessTTL, refreshTTL := h.tokenLifetimes(tenant, grantType)
+
+	if !h.makeRoomForSession(ctx, w, subject.TenantID, subject.UserID) {
+		return
+	}
+
+	// Each new session gets an ID that every token refreshed from it carries as sid
+	subject.SessionID = uuid.New().String()
+
+	// Generate tokens
+	accessToken, accessJTI, err := h.issueAccessToken(ctx, client, tenant, subject, nil, accessTTL)
+	if err != nil {
+		h.logger.Error("Failed to generate access token", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	var idToken string
+	if idTokenGrant(grantType) && slices.Contains(subject.Scopes, "openid") {
+		idToken, err = h.tokenGen.GenerateIDToken(subject, client.ClientID, r.FormValue("nonce"))
+		if err != nil {
+			h.logger.Error("Failed to generate ID token", zap.Error(err))
+			h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+			return
+		}
+	}
+
+	refreshToken, err := h.tokenGen.GenerateRefreshToken()
+	if err != nil {
+		h.logger.Error("Failed to generate refresh token", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+
+	// Store refresh token, including subject so refresh can recreate claims
+	now := time.Now()
+	refreshTokenData := &models.RefreshTokenData{
+		ClientID:       client.ClientID,
+		Subject:        subject,
+		IssuedAt:       now,
+		ExpiresAt:      now.Add(refreshTTL),
+		LastUsedAt:     now,
+		Fingerprint:    h.requestFingerprint(r),
+		AccessTokenJTI: accessJTI,
+		GrantType:      grantType,
+		Audiences:      subject.Audiences,
+	}
+	if err := h.cache.StoreRefreshToken(ctx, refreshToken, refreshTokenData, refreshTTL); err != nil {
+		h.logger.Error("Failed to store refresh token", zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	h.trackSession(ctx, subject, refreshToken, refreshTTL)
+
+	// Update client updated_at
+	if err := h.repo.UpdateClientUpdatedAt(ctx, client.ClientID); err != nil {
+		h.logger.Warn("Failed to update client updated_at", zap.Error(err))
+	}
+
+	// Send response
+	response := &models.TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTTL.Seconds()),
+		RefreshToken: refreshToken,
+		IDToken:      idToken,
+	}
+
+	if h.wantsCookieSession(r) {
+		h.sendCookieSession(ctx, w, subject.TenantID, "", response, refreshTTL)
+		return
+	}
+	h.sendJSON(w, http.StatusOK, response)
+}
+
+// idTokenGrant reports whether grantType authenticates the user, so its
+// token response can carry an ID token.
+func idTokenGrant(grantType string) bool {
+	return grantType == "provision_user" || grantType == "client_credentials"
+}
+
+// refuseDuringRotation answers 503 with a Retry-After and reports true while
+// the rotation guard is enabled and signing keys are being rotated.
+func (h *TokenHandler) refuseDuringRotation(w http.ResponseWriter, tenantID string) bool {
+	if h.rotation == nil || !h.rotation.Rotating() {
+		return false
+	}
+	h.logger.Info("Token request refused during signing key rotation", zap.String("tenant_id", tenantID))
+	w.Header().Set("Retry-After", retryAfterSeconds(h.rotationRetryAfter))
+	h.sendError(w, errors.ErrTemporarilyUnavailable)
+	return true
+}
+
+// clientCredentials returns the client ID and secret of r, sent either with
+// HTTP Basic authentication (client_secret_basic) or as form fields
+// (client_secret_post). Using both at once is rejected, as is a malformed
+// Authorization header.
+func clientCredentials(r *http.Request) (string, string, *errors.ServiceError) {
+	if !middleware.HasAuthorization(r) {
+		clientID, clientSecret := r.FormValue("client_id"), r.FormValue("client_secret")
+		if clientID == "" || clientSecret == "" {
+			return "", "", errors.ErrInvalidCredentials
+		}
+		return clientID, clientSecret, nil
+	}
+
+	clientID, clientSecret, ok := middleware.BasicCredentials(r)
+	if !ok {
+		return "", "", errors.WithMessage(errors.ErrInvalidCredentials, "Malformed Basic authorization header")
+	}
+	if r.FormValue("client_secret") != "" {
+		return "", "", errors.WithMessage(errors.ErrInvalidRequest, "Client credentials must be sent in only one way")
+	}
+	if formID := r.FormValue("client_id"); formID != "" && formID != clientID {
+		return "", "", errors.WithMessage(errors.ErrInvalidRequest, "client_id does not match the Authorization header")
+	}
+	return clientID, clientSecret, nil
+}
+
+// retryAfterSeconds formats d for a Retry-After header: whole seconds,
+// rounded up, and at least 1.
+func retryAfterSeconds(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
+
+// parseOptionalBool parses a boolean form value; an empty value is false.
+func parseOptionalBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// filterRoles returns the roles that start with one of prefixes, or all
+// roles when no prefixes are configured. The user's full role set is
+// unaffected; only the token carries fewer roles.
+func filterRoles(roles, prefixes []string) []string {
+	if len(prefixes) == 0 {
+		return roles
+	}
+
+	filtered := make([]string, 0, len(roles))
+	for _, role := range roles {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(role, prefix) {
+				filtered = append(filtered, role)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// requestedScopes returns the space-delimited scopes requested by the scope
+// parameter of r. Requests for scopes the client does not allow are rejected
+// with INVALID_SCOPE, naming the rejected scopes when ScopeErrorDetails is
+// set; the client's allowed set is never disclosed. Scopes mapping to more
+// audiences than MAX_AUDIENCES are rejected with INVALID_REQUEST.
+func (h *TokenHandler) requestedScopes(r *http.Request, client *models.Client) ([]string, *errors.ServiceError) {
+	scopes, rejected := filterScopes(auth.ParseScope(r.FormValue("scope"), h.config.ScopeAltDelimiter), client.AllowedScopes)
+	if len(rejected) == 0 {
+		audiences, _ := scopeAudiences(h.config.ScopeAudiences, scopes, client.ClientID)
+		if serviceErr := h.checkAudienceCount(len(audiences)); serviceErr != nil {
+			return nil, serviceErr
+		}
+		return scopes, nil
+	}
+
+	h.logger.Info("Token request asked for scopes not allowed for client",
+		zap.String("client_id", client.ClientID),
+		zap.Strings("rejected_scopes", rejected))
+	if !h.config.ScopeErrorDetails {
+		return nil, errors.ErrInvalidScope
+	}
+	serviceErr := errors.WithMessage(errors.ErrInvalidScope, "Scope not allowed for this client: "+strings.Join(rejected, " "))
+	return nil, errors.WithField(serviceErr, "rejected_scopes", rejected)
+}
+
+// filterScopes splits scopes into those in allowed and those that are not.
+// Every scope is kept when allowed is empty.
+func filterScopes(scopes, allowed []string) (kept, dropped []string) {
+	if len(allowed) == 0 {
+		return scopes, nil
+	}
+
+	for _, scope := range scopes {
+		if slices.Contains(allowed, scope) {
+			kept = append(kept, scope)
+		} else {
+			dropped = append(dropped, scope)
+		}
+	}
+	return kept, dropped
+}
+
+// scopeAudiences returns the audiences SCOPE_AUDIENCES maps scopes to, in
+// the order first requested, with ClientIDAudience replaced by clientID.
+// The authorized party is clientID when it is one of them. No mapped scope
+// returns nil, for the default audience.
+func scopeAudiences(mapping map[string]string, scopes []string, clientID string) (audiences []string, authorizedParty string) {
+	for _, scope := range scopes {
+		audience, ok := mapping[scope]
+		if !ok {
+			continue
+		}
+		if audience == config.ClientIDAudience {
+			audience, authorizedParty = clientID, clientID
+		}
+		if !slices.Contains(audiences, audience) {
+			audiences = append(audiences, audience)
+		}
+	}
+	return audiences, authorizedParty
+}
+
+// checkAudienceCount rejects a request for n audiences, through mapped
+// scopes or resource parameters, beyond MAX_AUDIENCES.
+func (h *TokenHandler) checkAudienceCount(n int) *errors.ServiceError {
+	if h.config.MaxAudiences > 0 && n > h.config.MaxAudiences {
+		return errors.WithMessage(errors.ErrInvalidRequest, fmt.Sprintf("At most %d audiences may be requested", h.config.MaxAudiences))
+	}
+	return nil
+}
+
+// refreshAudiences returns the audiences of a token refreshed from a session
+// granted granted (the default audiences if empty): all of them, or the
+// requested resources if each of them was granted.
+func refreshAudiences(resources, granted, defaultAudiences []string) ([]string, *errors.ServiceError) {
+	if len(granted) == 0 {
+		granted = defaultAudiences
+	}
+	if len(resources) == 0 {
+		return granted, nil
+	}
+
+	var audiences []string
+	for _, resource := range resources {
+		if !slices.Contains(granted, resource) {
+			return nil, errors.ErrInvalidTarget
+		}
+		if !slices.Contains(audiences, resource) {
+			audiences = append(audiences, resource)
+		}
+	}
+	return audiences, nil
+}
+
+// requestFingerprint identifies the device a request came from: a hash of
+// the client IP (behind trusted proxies) and the optional X-Device-ID
+// header. Only the hash is stored, so refresh tokens hold no IP addresses.
+func (h *TokenHandler) requestFingerprint(r *http.Request) string {
+	ip := middleware.ClientIP(r, h.config.TrustedProxies)
+	sum := sha256.Sum256([]byte(ip + "\n" + r.Header.Get("X-Device-ID")))
+	return hex.EncodeToString(sum[:])
+}
+
+// getTenant returns the tenant record, checking the cache before the database.
+// It returns nil if the tenant does not exist.
+func (h *TokenHandler) getTenant(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	tenant, err := h.cache.GetTenant(ctx, tenantID)
+	if err != nil {
+		h.logger.Error("Failed to get tenant from cache", zap.Error(err))
+	}
+	if tenant != nil {
+		return tenant, nil
+	}
+
+	tenant, err = h.repo.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenant != nil {
+		if err := h.cache.SetTenant(ctx, tenant, 15*time.Minute); err != nil {
+			h.logger.Warn("Failed to cache tenant", zap.Error(err))
+		}
+	}
+
+	return tenant, nil
+}
+
+// recordRolesChange marks the user's roles as changed now, so the validator
+// rejects access tokens carrying the previous roles. The mark must outlive the
+// longest-lived token the tenant could still have outstanding. A failure is
+// logged rather than failing provisioning, which has already been committed.
+func (h *TokenHandler) recordRolesChange(ctx context.Context, tenantID, userID string) {
+	tenant, err := h.getTenant(ctx, tenantID)
+	if err != nil {
+		h.logger.Warn("Failed to load tenant token lifetimes; using global expiry for role change", zap.String("tenant_id", tenantID), zap.Error(err))
+	}
+	accessTTL, refreshTTL := h.tokenLifetimes(tenant, "")
+	ttl := refreshTTL
+	if accessTTL > ttl {
+		ttl = accessTTL
+	}
+	for _, expiry := range h.config.GrantExpiry {
+		ttl = max(ttl, expiry)
+	}
+
+	if err := h.cache.SetUserRolesChangedAt(ctx, userID, time.Now(), ttl); err != nil {
+		h.logger.Error("Failed to record user roles change; tokens with the previous roles stay valid", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// applyTenantClaims sets tenant-derived claim values on the subject. The
+// external tenant ID is refreshed from the tenant record on every issuance so
+// refreshed tokens pick up changes.
+func (h *TokenHandler) applyTenantClaims(subject *models.TokenSubject, tenant *models.Tenant) {
+	subject.ExternalTenantID = ""
+	if h.config.IncludeExternalTID && tenant != nil {
+		subject.ExternalTenantID = tenant.ExternalTID
+	}
+}
+
+// tokenLifetimes returns the access and refresh token lifetimes for a tenant
+// and the grant that started the session. Per-tenant overrides from the
+// tenants table take precedence, then the grant's configured expiry; unset
+// values (or a nil tenant) fall back to the global JWTExpiry and
+// RefreshTokenExpiry.
+func (h *TokenHandler) tokenLifetimes(tenant *models.Tenant, grantType string) (time.Duration, time.Duration) {
+	accessTTL := h.config.JWTExpiry
+	refreshTTL := h.config.RefreshTokenExpiry
+
+	if expiry, ok := h.config.GrantExpiry[grantType]; ok {
+		accessTTL = expiry
+	}
+
+	if tenant != nil {
+		if tenant.AccessTokenTTL > 0 {
+			accessTTL = tenant.AccessTokenTTL
+		}
+		if tenant.RefreshTokenTTL > 0 {
+			refreshTTL = tenant.RefreshTokenTTL
+		}
+	}
+
+	return accessTTL, refreshTTL
+}
+
+// overlongProvisioningField returns the name of the first provisioning field
+// longer than its configured maximum, or "" if all fields are within bounds.
+func (h *TokenHandler) overlongProvisioningField(fullName, phone, email string) string {
+	fields := []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"user_full_name", fullName, h.config.MaxFullNameLength},
+		{"user_phone", phone, h.config.MaxPhoneLength},
+		{"user_email", email, h.config.MaxEmailLength},
+	}
+	for _, f := range fields {
+		if f.max > 0 && utf8.RuneCountInString(f.value) > f.max {
+			return f.name
+		}
+	}
+	return ""
+}
+
+// issueAccessToken signs an access token with the client's algorithm (or the
+// tenant's HS256 secret, if it has one), naming the client in client_id when
+// enabled, and, for clients that opted in,
+// encrypts it for the audience's resource server. The token is for
+// audiences, or for those of its scopes if nil. It returns the token and
+// its jti.
+func (h *TokenHandler) issueAccessToken(ctx context.Context, client *models.Client, tenant *models.Tenant, subject *models.TokenSubject, audiences []string, ttl time.Duration) (string, string, error) {
+	// Set on every issuance, like the tenant claims, so a subject stored with
+	// a refresh token does not keep client_id after the claim is disabled,
+	// nor audiences for scopes it no longer carries.
+	subject.ClientID = ""
+	if h.config.IncludeClientID {
+		subject.ClientID = client.ClientID
+	}
+	if audiences == nil {
+		subject.Audiences, subject.AuthorizedParty = scopeAudiences(h.config.ScopeAudiences, subject.Scopes, client.ClientID)
+	} else {
+		subject.Audiences, subject.AuthorizedParty = audiences, ""
+		if slices.Contains(audiences, client.ClientID) {
+			subject.AuthorizedParty = client.ClientID
+		}
+	}
+
+	accessToken, jti, err := h.tokenGen.GenerateTenantAccessToken(subject, ttl, client.SigningAlg, tenant)
+	if err != nil {
+		return "", "", err
+	}
+	if !client.EncryptAccessTokens {
+		return accessToken, jti, nil
+	}
+
+	// The token is encrypted for the resource server of its own audience,
+	// which may come from its scopes or a refresh's resource. A JWE has a
+	// single recipient, so a token for several audiences is refused rather
+	// than made unreadable to all but one of them.
+	tokenAudiences := subject.Audiences
+	if len(tokenAudiences) == 0 {
+		tokenAudiences = auth.SplitAudiences(h.config.JWTAudience)
+	}
+	if len(tokenAudiences) != 1 {
+		return "", "", fmt.Errorf("cannot encrypt a token for %d audiences; it needs exactly one", len(tokenAudiences))
+	}
+	audience := tokenAudiences[0]
+	publicKey, err := h.repo.GetAudienceEncryptionKey(ctx, audience)
+	if err != nil {
+		return "", "", err
+	}
+	if publicKey == "" {
+		return "", "", fmt.Errorf("no encryption key registered for audience %q", audience)
+	}
+	encrypted, err := auth.EncryptToken(accessToken, publicKey)
+	if err != nil {
+		return "", "", err
+	}
+	return encrypted, jti, nil
+}
+
+// unknownTenantRoles returns the roles not in the tenant's role catalog. It
+// returns nil when the tenant has no catalog (free-form roles).
+func (h *TokenHandler) unknownTenantRoles(ctx context.Context, tenantID string, roles []string) ([]string, error) {
+	catalog, err := h.repo.ListTenantRoles(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(catalog) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]bool, len(catalog))
+	for _, role := range catalog {
+		known[role] = true
+	}
+
+	var unknown []string
+	for _, role := range roles {
+		if !known[role] {
+			unknown = append(unknown, role)
+		}
+	}
+	return unknown, nil
+}
+
+// repositoryError maps a repository write error to a ServiceError so that
+// conflicts surface as 409 rather than as a server error.
+func repositoryError(err error) *errors.ServiceError {
+	if database.IsUniqueViolation(err) {
+		return errors.Wrap(err, errors.ErrConflict)
+	}
+	return errors.Wrap(err, errors.ErrInternalServer)
+}
+
 func (h *TokenHandler) sendError(w http.ResponseWriter, err *errors.ServiceError) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(err.Status)
-	json.NewEncoder(w).Encode(map[string]string{
+	response := map[string]interface{}{
 		"error":             err.Code,
 		"error_description": err.Message,
-	})
+	}
+	for key, value := range err.Fields {
+		response[key] = value
+	}
+	json.NewEncoder(w).Encode(response)
 }
 
 func (h *TokenHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
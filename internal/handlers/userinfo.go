@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"session-service/internal/middleware"
+	"session-service/internal/models"
+	"session-service/pkg/errors"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// HandleUserInfo handles GET /{tenant_id}/oauth2/v1.0/userinfo
+// @Summary     Get the authenticated user's profile
+// @Description Returns the profile of the user in the Bearer access token, including whether their email and phone number are verified (OIDC userinfo). Profile data is never put in tokens.
+// @Tags        oauth2
+// @Produce     application/json
+// @Security    BearerAuth
+// @Param       tenant_id path string true "Tenant ID"
+// @Success     200 {object} models.UserInfoResponse
+// @Failure     401 {object} map[string]string
+// @Failure     403 {object} map[string]string
+// @Failure     404 {object} map[string]string
+// @Failure     500 {object} map[string]string
+// @Router      /{tenant_id}/oauth2/v1.0/userinfo [get]
+func (h *TokenHandler) HandleUserInfo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := mux.Vars(r)["tenant_id"]
+
+	claims, ok := middleware.ClaimsFromContext(ctx)
+	if !ok {
+		h.sendError(w, errors.ErrInvalidToken)
+		return
+	}
+	userID, _ := claims["sub"].(string)
+	if userID == "" {
+		h.sendError(w, errors.ErrForbidden)
+		return
+	}
+
+	user, err := h.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to get user from database", zap.String("user_id", userID), zap.Error(err))
+		h.sendError(w, errors.Wrap(err, errors.ErrInternalServer))
+		return
+	}
+	if user == nil || user.TenantID != tenantID {
+		h.sendError(w, errors.ErrNotFound)
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, &models.UserInfoResponse{
+		Subject:             user.ID,
+		Name:                user.FullName,
+		Email:               user.Email,
+		EmailVerified:       user.EmailVerified,
+		PhoneNumber:         user.PhoneNumber,
+		PhoneNumberVerified: user.PhoneVerified,
+	})
+}
@@ -2,32 +2,63 @@ package handlers
 
 import (
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"session-service/internal/auth"
+	"session-service/internal/cache"
+	"session-service/internal/metrics"
+	"session-service/internal/middleware"
 	"session-service/internal/models"
 	"session-service/pkg/errors"
+	"slices"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
 // VerifyHandler handles token verification requests
 type VerifyHandler struct {
-	validator *auth.TokenValidator
-	logger    *zap.Logger
+	validator        *auth.TokenValidator
+	resultCache      *auth.VerificationCache
+	includeKeyStatus bool
+	logger           *zap.Logger
+
+	// Set by EnableTokenSourceTracking
+	sources         cache.Cache
+	sourceThreshold int64
+	rejectSources   bool
+	trustedProxies  []*net.IPNet
 }
 
-// NewVerifyHandler creates a new verify handler
-func NewVerifyHandler(validator *auth.TokenValidator, logger *zap.Logger) *VerifyHandler {
+// NewVerifyHandler creates a new verify handler. When includeKeyStatus is set,
+// valid responses also report the signing key's kid and grace status. A nil
+// resultCache disables caching of successful validations.
+func NewVerifyHandler(validator *auth.TokenValidator, resultCache *auth.VerificationCache, includeKeyStatus bool, logger *zap.Logger) *VerifyHandler {
 	return &VerifyHandler{
-		validator: validator,
-		logger:    logger,
+		validator:        validator,
+		resultCache:      resultCache,
+		includeKeyStatus: includeKeyStatus,
+		logger:           logger,
 	}
 }
 
+// EnableTokenSourceTracking records the client IPs that present each token's
+// jti for verification. When a token has been presented from more than
+// threshold distinct IPs, a warning is logged and a metric counted; with
+// reject set, the token is also reported invalid from then on.
+func (h *VerifyHandler) EnableTokenSourceTracking(sources cache.Cache, threshold int, reject bool, trustedProxies []*net.IPNet) {
+	h.sources = sources
+	h.sourceThreshold = int64(threshold)
+	h.rejectSources = reject
+	h.trustedProxies = trustedProxies
+}
+
 // HandleVerify handles POST /{tenant_id}/oauth2/v1.0/verify
 // @Summary     Verify JWT token
-// @Description Validates a JWT access token and returns its claims if valid
+// @Description Validates a JWT access token and returns its claims if valid. The token may be sent in the body or as a Bearer Authorization header.
 // @Tags        oauth2
 // @Param       tenant_id path string true "Tenant ID"
 // @Accept      application/json
@@ -54,39 +85,93 @@ func (h *VerifyHandler) HandleVerify(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.VerifyRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !(err == io.EOF && middleware.HasAuthorization(r)) {
 		h.sendError(w, errors.Wrap(err, errors.ErrInvalidToken))
 		return
 	}
 
+	// The token may instead be sent as "Authorization: Bearer <token>"
+	if req.Token == "" && middleware.HasAuthorization(r) {
+		token, ok := middleware.BearerToken(r)
+		if !ok {
+			h.sendError(w, errors.WithMessage(errors.ErrInvalidToken, "Malformed Bearer authorization header"))
+			return
+		}
+		req.Token = token
+	}
+
 	if req.Token == "" {
 		h.sendError(w, errors.ErrInvalidToken)
 		return
 	}
+	if req.MaxAge != nil && *req.MaxAge < 0 {
+		h.sendError(w, errors.WithMessage(errors.ErrInvalidRequest, "max_age must not be negative"))
+		return
+	}
 
-	// Validate token
-	claims, err := h.validator.ValidateToken(ctx, req.Token)
-	if err != nil {
-		h.logger.Debug("Token validation failed", zap.Error(err))
+	// Validate token, reusing a recent successful result when cached
+	claims, cached := h.resultCache.Get(req.Token)
+	if !cached {
+		var err error
+		claims, err = h.validator.ValidateToken(ctx, req.Token)
+		if err != nil {
+			h.logger.Debug("Token validation failed", zap.Error(err))
+			response := &models.VerifyResponse{
+				Valid:   false,
+				Message: err.Error(),
+			}
+			switch err {
+			case auth.ErrRolesChanged:
+				response.Reason = models.VerifyReasonRolesChanged
+			case auth.ErrRequiresCurrentKey:
+				response.Reason = models.VerifyReasonRequiresCurrentKey
+			}
+			h.sendResponse(w, http.StatusOK, response)
+			return
+		}
+		h.resultCache.Set(req.Token, claims)
+	}
+
+	// Validate that tenant_id in path matches the token's tenant
+	if tokenTenantID := h.validator.TenantID(claims); tokenTenantID != tenantIDFromPath {
+		h.logger.Debug("Tenant ID mismatch",
+			zap.String("path_tenant_id", tenantIDFromPath),
+			zap.String("token_tenant_id", tokenTenantID))
 		h.sendResponse(w, http.StatusOK, &models.VerifyResponse{
 			Valid:   false,
-			Message: err.Error(),
+			Message: "tenant_id in path does not match token tenant_id",
 		})
 		return
 	}
 
-	// Validate that tenant_id in path matches tenant_id in token claims
-	if tid, ok := claims["tid"].(string); ok {
-		if tid != tenantIDFromPath {
-			h.logger.Debug("Tenant ID mismatch",
-				zap.String("path_tenant_id", tenantIDFromPath),
-				zap.String("token_tenant_id", tid))
-			h.sendResponse(w, http.StatusOK, &models.VerifyResponse{
-				Valid:   false,
-				Message: "tenant_id in path does not match token tenant_id",
-			})
-			return
-		}
+	// Surface tokens presented from suspiciously many places
+	if h.sources != nil && h.tooManySources(r, claims) && h.rejectSources {
+		h.sendResponse(w, http.StatusOK, &models.VerifyResponse{
+			Valid:   false,
+			Message: "token has been presented from too many sources",
+			Reason:  models.VerifyReasonTooManySources,
+		})
+		return
+	}
+
+	// Step-up checks: the authentication must be recent enough
+	if req.MaxAge != nil && !authenticatedWithin(claims, *req.MaxAge) {
+		h.sendResponse(w, http.StatusOK, &models.VerifyResponse{
+			Valid:   false,
+			Message: "token authentication is older than max_age",
+			Reason:  models.VerifyReasonStale,
+		})
+		return
+	}
+
+	// The resource server asking must be one of the token's audiences
+	if req.ExpectedAudience != "" && !hasAudience(claims, req.ExpectedAudience) {
+		h.sendResponse(w, http.StatusOK, &models.VerifyResponse{
+			Valid:   false,
+			Message: "expected_audience is not among the token's audiences",
+			Reason:  models.VerifyReasonWrongAudience,
+		})
+		return
 	}
 
 	// Convert claims to map[string]interface{}
@@ -95,10 +180,95 @@ func (h *VerifyHandler) HandleVerify(w http.ResponseWriter, r *http.Request) {
 		claimsMap[k] = v
 	}
 
-	h.sendResponse(w, http.StatusOK, &models.VerifyResponse{
+	response := &models.VerifyResponse{
 		Valid:  true,
 		Claims: claimsMap,
-	})
+	}
+	if h.includeKeyStatus {
+		response.SigningKey = h.signingKeyStatus(req.Token)
+	}
+
+	h.sendResponse(w, http.StatusOK, response)
+}
+
+// tooManySources records the caller as a source of the token and reports
+// whether the token has now been seen from more than the allowed number of
+// sources. The warning and metric fire once per new source past the limit.
+// Tracking is best-effort: cache failures never fail verification.
+func (h *VerifyHandler) tooManySources(r *http.Request, claims jwt.MapClaims) bool {
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return false
+	}
+	ttl := time.Minute
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		ttl = time.Until(exp.Time)
+	}
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+
+	source := middleware.ClientIP(r, h.trustedProxies)
+	count, added, err := h.sources.RecordTokenSource(r.Context(), jti, source, ttl)
+	if err != nil {
+		h.logger.Warn("Failed to record token source", zap.String("jti", jti), zap.Error(err))
+		return false
+	}
+	if count <= h.sourceThreshold {
+		return false
+	}
+	if added {
+		metrics.TokenSourceAnomalies.Inc()
+		h.logger.Warn("Access token presented from many sources; possible token theft",
+			zap.String("jti", jti),
+			zap.String("tid", h.validator.TenantID(claims)),
+			zap.Any("sub", claims["sub"]),
+			zap.String("source", source),
+			zap.Int64("distinct_sources", count))
+	}
+	return true
+}
+
+// authenticatedWithin reports whether the token's auth_time, or its iat when
+// it has no auth_time, is no more than maxAge seconds ago. Tokens with
+// neither claim cannot prove freshness and fail.
+func authenticatedWithin(claims jwt.MapClaims, maxAge int64) bool {
+	authTime, ok := claims["auth_time"].(float64)
+	if !ok {
+		iat, err := claims.GetIssuedAt()
+		if err != nil || iat == nil {
+			return false
+		}
+		authTime = float64(iat.Unix())
+	}
+	return time.Now().Unix()-int64(authTime) <= maxAge
+}
+
+// hasAudience reports whether audience is among the token's aud.
+func hasAudience(claims jwt.MapClaims, audience string) bool {
+	audiences, err := claims.GetAudience()
+	return err == nil && slices.Contains(audiences, audience)
+}
+
+// signingKeyStatus reports the grace status of the key that signed token.
+// The token has already been validated, so a lookup failure only means the
+// key was retired in the meantime; the status is omitted in that case.
+func (h *VerifyHandler) signingKeyStatus(token string) *models.SigningKeyStatus {
+	status, err := h.validator.SigningKeyStatus(token)
+	if err != nil {
+		h.logger.Debug("Failed to look up signing key status", zap.Error(err))
+		return nil
+	}
+
+	keyStatus := &models.SigningKeyStatus{
+		KeyID:   status.KeyID,
+		InGrace: status.InGrace,
+	}
+	if !status.ExpiresAt.IsZero() {
+		expiresAt := status.ExpiresAt
+		keyStatus.ExpiresAt = &expiresAt
+	}
+	return keyStatus
 }
 
 func (h *VerifyHandler) sendError(w http.ResponseWriter, err *errors.ServiceError) {
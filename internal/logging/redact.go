@@ -0,0 +1,136 @@
+// Package logging provides zap helpers shared by the service's loggers.
+package logging
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted replaces sensitive values in log entries.
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedKeys are the field and claim names whose values are redacted
+// unless LOG_REDACT_KEYS names others.
+var DefaultRedactedKeys = []string{
+	"email", "phone", "phone_number", "name", "given_name", "family_name",
+	"user_email", "user_phone", "user_full_name",
+	"token", "access_token", "refresh_token", "id_token", "client_secret", "password",
+}
+
+// jwtPattern matches compact JWS and JWE serializations, whose header always
+// starts with the base64url encoding of `{"`.
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_.-]*`)
+
+// redactingCore redacts sensitive values before passing entries on to the
+// wrapped core.
+type redactingCore struct {
+	zapcore.Core
+	keys map[string]struct{}
+}
+
+// NewRedactingCore wraps core so that fields, and keys of map fields such as
+// token claims, named in keys (case-insensitively) have their values
+// replaced, and raw JWTs are removed from messages, strings and errors.
+// Install it with zap.WrapCore so every log call is covered.
+func NewRedactingCore(core zapcore.Core, keys []string) zapcore.Core {
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = struct{}{}
+	}
+	return &redactingCore{Core: core, keys: set}
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redactFields(fields)), keys: c.keys}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = redactTokens(entry.Message)
+	return c.Core.Write(entry, c.redactFields(fields))
+}
+
+// redactFields returns a copy of fields with sensitive values replaced.
+func (c *redactingCore) redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		redacted[i] = c.redactField(field)
+	}
+	return redacted
+}
+
+func (c *redactingCore) redactField(field zapcore.Field) zapcore.Field {
+	if c.sensitive(field.Key) {
+		return zapcore.Field{Key: field.Key, Type: zapcore.StringType, String: Redacted}
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		field.String = redactTokens(field.String)
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok {
+			if message := redactTokens(err.Error()); message != err.Error() {
+				return zapcore.Field{Key: field.Key, Type: zapcore.StringType, String: message}
+			}
+		}
+	case zapcore.ReflectType:
+		field.Interface = c.redactValue(field.Interface)
+	}
+	return field
+}
+
+// redactValue redacts the sensitive entries of string-keyed maps, such as
+// token claims, recursively. Other values are returned as they are.
+func (c *redactingCore) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return redactTokens(v)
+	case nil:
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		redacted := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			if c.sensitive(key) {
+				redacted[key] = Redacted
+				continue
+			}
+			redacted[key] = c.redactValue(iter.Value().Interface())
+		}
+		return redacted
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8:
+		redacted := make([]interface{}, rv.Len())
+		for i := range redacted {
+			redacted[i] = c.redactValue(rv.Index(i).Interface())
+		}
+		return redacted
+	}
+	return value
+}
+
+func (c *redactingCore) sensitive(key string) bool {
+	_, ok := c.keys[strings.ToLower(key)]
+	return ok
+}
+
+// redactTokens replaces any JWT in s.
+func redactTokens(s string) string {
+	if !strings.Contains(s, "eyJ") {
+		return s
+	}
+	return jwtPattern.ReplaceAllString(s, Redacted)
+}
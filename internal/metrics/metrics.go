@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"context"
+	"session-service/internal/tracing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Registry holds all service metrics. It is served on /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// OperationDuration records the latency of repository and cache operations.
+	OperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "session_service",
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of database and cache operations.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14), // 1ms .. ~8s
+	}, []string{"component", "operation"})
+
+	// SlowOperations counts operations that exceeded the slow-op threshold.
+	SlowOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "session_service",
+		Name:      "slow_operations_total",
+		Help:      "Database and cache operations slower than SLOW_OP_THRESHOLD.",
+	}, []string{"component", "operation"})
+
+	// ClockDriftSuspected counts tokens rejected as expired or not yet valid by
+	// a margin small enough to suggest clock drift rather than a stale token.
+	ClockDriftSuspected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "session_service",
+		Name:      "clock_drift_suspected_total",
+		Help:      "Tokens rejected for exp/nbf within CLOCK_DRIFT_WARN_WINDOW of being valid.",
+	}, []string{"reason"})
+
+	// TokenSourceAnomalies counts access tokens presented to /verify from
+	// more distinct sources than JTI_SOURCE_WARN_THRESHOLD, a sign of theft.
+	TokenSourceAnomalies = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "session_service",
+		Name:      "token_source_anomalies_total",
+		Help:      "Access tokens verified from more distinct sources than JTI_SOURCE_WARN_THRESHOLD.",
+	})
+
+	// RequestsShed counts requests refused with 503 because
+	// MAX_CONCURRENT_REQUESTS were already in flight.
+	RequestsShed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "session_service",
+		Name:      "requests_shed_total",
+		Help:      "Requests refused because MAX_CONCURRENT_REQUESTS were already in flight.",
+	})
+
+	// BackgroundPanics counts panics recovered in background tasks such as
+	// key rotation.
+	BackgroundPanics = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "session_service",
+		Name:      "background_panics_total",
+		Help:      "Panics recovered in background tasks, which carry on with their next run.",
+	}, []string{"task"})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		OperationDuration,
+		SlowOperations,
+		ClockDriftSuspected,
+		TokenSourceAnomalies,
+		RequestsShed,
+		BackgroundPanics,
+	)
+}
+
+// OperationTimer records operation latencies for one component (e.g.
+// "database" or "cache") and logs operations slower than Threshold.
+type OperationTimer struct {
+	Component string
+	// Threshold above which an operation is logged as slow; zero disables
+	// slow-op logging but latencies are still recorded.
+	Threshold time.Duration
+	Logger    *zap.Logger
+}
+
+// Start begins operation as a child span of ctx. It returns the span's
+// context, to pass on to the operation, and a func that ends the span and
+// records the duration as Observe does. Use it as
+//
+//	ctx, done := timer.Start(ctx, "GetClientByID")
+//	defer done()
+func (t *OperationTimer) Start(ctx context.Context, operation string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, t.Component+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("component", t.Component)))
+
+	return ctx, func() {
+		span.End()
+		t.Observe(operation, start)
+	}
+}
+
+// Observe records the duration of operation since start. Use it as
+// `defer timer.Observe("GetClientByID", time.Now())`.
+func (t *OperationTimer) Observe(operation string, start time.Time) {
+	duration := time.Since(start)
+	OperationDuration.WithLabelValues(t.Component, operation).Observe(duration.Seconds())
+
+	if t.Threshold > 0 && duration > t.Threshold {
+		SlowOperations.WithLabelValues(t.Component, operation).Inc()
+		t.Logger.Warn("Slow operation",
+			zap.String("component", t.Component),
+			zap.String("operation", operation),
+			zap.Duration("duration", duration),
+			zap.Duration("threshold", t.Threshold))
+	}
+}
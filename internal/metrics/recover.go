@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+)
+
+// RunRecovered runs one iteration of the background task, recovering a
+// panic so that it cannot take the process down. A recovered panic is
+// logged with its stack and counted in BackgroundPanics, and RunRecovered
+// returns false.
+func RunRecovered(task string, logger *zap.Logger, fn func()) (ok bool) {
+	defer func() {
+		if p := recover(); p != nil {
+			BackgroundPanics.WithLabelValues(task).Inc()
+			logger.Error("Recovered panic in background task",
+				zap.String("task", task),
+				zap.Error(fmt.Errorf("panic: %v", p)),
+				zap.ByteString("stack", debug.Stack()))
+			ok = false
+		}
+	}()
+
+	fn()
+	return true
+}
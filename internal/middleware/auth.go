@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"session-service/internal/auth"
+	"session-service/pkg/errors"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type claimsContextKey struct{}
+
+type tenantContextKey struct{}
+
+// ClaimsFromContext returns the validated token claims stored by RequireRole.
+func ClaimsFromContext(ctx context.Context) (jwt.MapClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(jwt.MapClaims)
+	return claims, ok
+}
+
+// TenantFromContext returns the tenant of the validated token stored by
+// RequireRole, whatever claim the token carries it in.
+func TenantFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenantID
+}
+
+// RequireRole creates a middleware that only admits requests carrying a valid
+// Bearer access token whose tid matches the tenant_id in the path and whose
+// roles claim contains the given role. The validated claims are stored in the
+// request context for downstream handlers.
+func RequireRole(validator *auth.TokenValidator, role string, logger *zap.Logger) func(http.Handler) http.Handler {
+	return requireRole(validator, role, true, logger)
+}
+
+// RequireRoleAnyTenant is like RequireRole for routes without a tenant_id in
+// the path. The token's tid is not checked, so handlers must scope what they
+// return to the tid in the claims.
+func RequireRoleAnyTenant(validator *auth.TokenValidator, role string, logger *zap.Logger) func(http.Handler) http.Handler {
+	return requireRole(validator, role, false, logger)
+}
+
+// RequireTenantToken admits any request carrying a valid Bearer access token
+// whose tid matches the tenant_id in the path, regardless of its roles.
+func RequireTenantToken(validator *auth.TokenValidator, logger *zap.Logger) func(http.Handler) http.Handler {
+	return requireRole(validator, "", true, logger)
+}
+
+// RequireOperatorToken admits requests whose Bearer credential is the
+// operator token, for service-wide operations that no tenant's token may
+// perform however it was issued. With an empty operatorToken every request
+// is forbidden.
+func RequireOperatorToken(operatorToken string, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if operatorToken == "" {
+				sendAuthError(w, errors.WithMessage(errors.ErrForbidden, "operator endpoints are disabled; set OPERATOR_TOKEN"))
+				return
+			}
+
+			token, ok := BearerToken(r)
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(operatorToken)) != 1 {
+				logger.Warn("Operator request with an invalid token", zap.String("path", r.URL.Path))
+				sendAuthError(w, errors.ErrInvalidToken)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func requireRole(validator *auth.TokenValidator, role string, matchPathTenant bool, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := BearerToken(r)
+			if !ok {
+				sendAuthError(w, errors.ErrInvalidToken)
+				return
+			}
+
+			claims, err := validator.ValidateToken(r.Context(), token)
+			if err != nil {
+				logger.Debug("Admin token validation failed", zap.Error(err))
+				sendAuthError(w, errors.ErrInvalidToken)
+				return
+			}
+
+			tid := validator.TenantID(claims)
+			if tid == "" {
+				sendAuthError(w, errors.ErrForbidden)
+				return
+			}
+			if matchPathTenant {
+				if tenantID := mux.Vars(r)["tenant_id"]; tid != tenantID {
+					logger.Warn("Admin token tenant does not match path",
+						zap.String("path_tenant_id", tenantID),
+						zap.String("token_tenant_id", tid))
+					sendAuthError(w, errors.ErrForbidden)
+					return
+				}
+			}
+
+			if role != "" && !slices.Contains(validator.Roles(claims), role) {
+				logger.Warn("Admin request missing required role",
+					zap.String("tenant_id", tid),
+					zap.Any("sub", claims["sub"]),
+					zap.String("required_role", role))
+				sendAuthError(w, errors.ErrForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), claimsContextKey{}, claims)
+			ctx = context.WithValue(ctx, tenantContextKey{}, tid)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func sendAuthError(w http.ResponseWriter, err *errors.ServiceError) {
+	w.Header().Set("Content-Type", "application/json")
+	if err.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":             err.Code,
+		"error_description": err.Message,
+	})
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MaxAuthorizationHeaderLength bounds the Authorization headers we attempt to
+// parse. Anything longer is treated as malformed rather than decoded.
+const MaxAuthorizationHeaderLength = 8192
+
+// HasAuthorization reports whether r carries a non-empty Authorization header.
+func HasAuthorization(r *http.Request) bool {
+	return strings.TrimSpace(r.Header.Get("Authorization")) != ""
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer <token>"
+// header. The scheme is case-insensitive; ok is false when the header is
+// missing, uses another scheme or is malformed.
+func BearerToken(r *http.Request) (token string, ok bool) {
+	return authorizationCredentials(r, "Bearer")
+}
+
+// BasicCredentials extracts the client ID and secret from an
+// "Authorization: Basic <base64(id:secret)>" header. Per RFC 6749 section
+// 2.3.1 both values are form-urlencoded before being joined. ok is false when
+// the header is missing, uses another scheme, is not valid base64, lacks the
+// colon separator or has an empty ID or secret.
+func BasicCredentials(r *http.Request) (clientID, clientSecret string, ok bool) {
+	credentials, ok := authorizationCredentials(r, "Basic")
+	if !ok {
+		return "", "", false
+	}
+
+	decoded, err := base64.StdEncoding.Strict().DecodeString(credentials)
+	if err != nil {
+		return "", "", false
+	}
+	rawID, rawSecret, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return "", "", false
+	}
+
+	clientID, err = url.QueryUnescape(rawID)
+	if err != nil {
+		return "", "", false
+	}
+	clientSecret, err = url.QueryUnescape(rawSecret)
+	if err != nil {
+		return "", "", false
+	}
+	if clientID == "" || clientSecret == "" {
+		return "", "", false
+	}
+	return clientID, clientSecret, true
+}
+
+// authorizationCredentials returns the credentials of r's Authorization
+// header if it is exactly "<scheme> <credentials>": the scheme matched
+// case-insensitively, one space, and credentials without further whitespace.
+// Surrounding whitespace is tolerated.
+func authorizationCredentials(r *http.Request, scheme string) (string, bool) {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if header == "" || len(header) > MaxAuthorizationHeaderLength {
+		return "", false
+	}
+
+	prefix, credentials, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(prefix, scheme) {
+		return "", false
+	}
+	if credentials == "" || strings.ContainsAny(credentials, " \t\r\n") {
+		return "", false
+	}
+	return credentials, true
+}
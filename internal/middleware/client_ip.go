@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP address of the client that sent r. X-Forwarded-For
+// is only believed when the request came from a trusted proxy: entries are
+// read right to left, skipping trusted proxies, and the first untrusted
+// address is the client. Without trusted proxies the peer address is used.
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+
+	if !isTrustedProxy(net.ParseIP(peer), trustedProxies) {
+		return peer
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		ip := net.ParseIP(hop)
+		if ip == nil {
+			// Anything further left was written by an unknown party
+			break
+		}
+		if !isTrustedProxy(ip, trustedProxies) {
+			return ip.String()
+		}
+		peer = ip.String()
+	}
+	return peer
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"session-service/internal/metrics"
+	"session-service/pkg/errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LoadShedding limits the requests served concurrently to maxInFlight. A
+// request arriving when the limit is reached waits up to acquireTimeout for a
+// slot and is otherwise refused with 503 and Retry-After, so a traffic spike
+// is turned away at the edge instead of overloading the database and Redis.
+func LoadShedding(maxInFlight int, acquireTimeout time.Duration, logger *zap.Logger) func(http.Handler) http.Handler {
+	slots := make(chan struct{}, maxInFlight)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case slots <- struct{}{}:
+			default:
+				timer := time.NewTimer(acquireTimeout)
+				select {
+				case slots <- struct{}{}:
+					timer.Stop()
+				case <-timer.C:
+					metrics.RequestsShed.Inc()
+					logger.Debug("Request shed; too many requests in flight",
+						zap.String("path", r.URL.Path),
+						zap.Int("max_in_flight", maxInFlight))
+					sendShedResponse(w)
+					return
+				case <-r.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
+			defer func() { <-slots }()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func sendShedResponse(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", "1")
+	w.WriteHeader(errors.ErrTemporarilyUnavailable.Status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":             errors.ErrTemporarilyUnavailable.Code,
+		"error_description": errors.ErrTemporarilyUnavailable.Message,
+	})
+}
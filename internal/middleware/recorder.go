@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxRecordedBodyBytes bounds how much of a request body the recorder reads.
+const maxRecordedBodyBytes = 64 << 10
+
+// redactedValue replaces sensitive parameter values in recorded requests.
+const redactedValue = "[REDACTED]"
+
+// recordableParams are the request parameters whose values may be recorded.
+// Every other parameter is kept by name only, so secrets, tokens and PII can
+// never end up in the buffer, even for parameters added later.
+var recordableParams = map[string]bool{
+	"grant_type": true,
+	"client_id":  true,
+	"user_id":    true,
+	"user_roles": true,
+	"scope":      true,
+	"audience":   true,
+	"resource":   true,
+}
+
+// RecordedRequest is a sanitized record of one request and its outcome.
+type RecordedRequest struct {
+	Time      time.Time         `json:"time"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	TenantID  string            `json:"tenant_id,omitempty"`
+	GrantType string            `json:"grant_type,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+	Status    int               `json:"status"`
+	ErrorCode string            `json:"error_code,omitempty"`
+}
+
+// RequestRecorder keeps the most recent requests in a fixed-size ring buffer
+// so support can reproduce a client's failing call. Parameter values outside
+// recordableParams are redacted before anything is stored, and successful
+// response bodies are never kept.
+// It is meant for non-production debugging only.
+type RequestRecorder struct {
+	mu      sync.Mutex
+	entries []RecordedRequest
+	next    int
+	full    bool
+}
+
+// NewRequestRecorder creates a recorder that keeps the last size requests.
+func NewRequestRecorder(size int) *RequestRecorder {
+	if size <= 0 {
+		size = 1
+	}
+	return &RequestRecorder{entries: make([]RecordedRequest, size)}
+}
+
+// Middleware records every request passing through it.
+func (rr *RequestRecorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := RecordedRequest{
+			Time:     time.Now(),
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			TenantID: mux.Vars(r)["tenant_id"],
+		}
+
+		// Read the body for sanitizing, then hand the handler an identical copy.
+		if r.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxRecordedBodyBytes))
+			if err == nil {
+				r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+				entry.Params = sanitizeBody(r.Header.Get("Content-Type"), body)
+			}
+		}
+		for key, values := range r.URL.Query() {
+			if entry.Params == nil {
+				entry.Params = make(map[string]string)
+			}
+			entry.Params[key] = sanitizeParam(key, strings.Join(values, ","))
+		}
+		entry.GrantType = entry.Params["grant_type"]
+
+		capture := &recordingWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(capture, r)
+
+		entry.Status = capture.statusCode
+		if capture.statusCode >= http.StatusBadRequest {
+			var errBody struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(capture.errBody.Bytes(), &errBody) == nil {
+				entry.ErrorCode = errBody.Error
+			}
+		}
+
+		rr.add(entry)
+	})
+}
+
+// Entries returns the recorded requests, oldest first.
+func (rr *RequestRecorder) Entries() []RecordedRequest {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	if !rr.full {
+		return append([]RecordedRequest(nil), rr.entries[:rr.next]...)
+	}
+	out := make([]RecordedRequest, 0, len(rr.entries))
+	out = append(out, rr.entries[rr.next:]...)
+	return append(out, rr.entries[:rr.next]...)
+}
+
+func (rr *RequestRecorder) add(entry RecordedRequest) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.entries[rr.next] = entry
+	rr.next = (rr.next + 1) % len(rr.entries)
+	if rr.next == 0 {
+		rr.full = true
+	}
+}
+
+// sanitizeBody extracts top-level parameters from a form or JSON body,
+// redacting sensitive values. Other bodies are not recorded.
+func sanitizeBody(contentType string, body []byte) map[string]string {
+	if len(body) == 0 {
+		return nil
+	}
+
+	params := make(map[string]string)
+	if strings.HasPrefix(contentType, "application/json") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil
+		}
+		for key, value := range fields {
+			s, _ := value.(string)
+			params[key] = sanitizeParam(key, s)
+		}
+		return params
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil
+	}
+	for key, v := range values {
+		params[key] = sanitizeParam(key, strings.Join(v, ","))
+	}
+	return params
+}
+
+func sanitizeParam(key, value string) string {
+	if recordableParams[key] {
+		return value
+	}
+	return redactedValue
+}
+
+// recordingWriter captures the status code and, for error responses, the
+// body so the error code can be recorded. Successful bodies (which carry
+// tokens) are never buffered.
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	errBody    bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	w.statusCode = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.statusCode >= http.StatusBadRequest && w.errBody.Len() < maxRecordedBodyBytes {
+		w.errBody.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
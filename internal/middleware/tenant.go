@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"session-service/internal/auth"
+	"session-service/pkg/errors"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// CanonicalTenantID returns the canonical form of a tenant id; see
+// auth.CanonicalTenantID.
+func CanonicalTenantID(tenantID string) string {
+	return auth.CanonicalTenantID(tenantID)
+}
+
+// NormalizeTenantID rewrites the tenant_id route variable to its canonical
+// form so every handler sees the same tenant id regardless of how the client
+// cased it.
+func NormalizeTenantID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		if tenantID, ok := vars["tenant_id"]; ok {
+			if canonical := CanonicalTenantID(tenantID); canonical != tenantID {
+				normalized := make(map[string]string, len(vars))
+				for k, v := range vars {
+					normalized[k] = v
+				}
+				normalized["tenant_id"] = canonical
+				r = mux.SetURLVars(r, normalized)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ValidateTenantID rejects requests whose tenant_id route variable does not
+// fully match pattern with ErrInvalidRequest, before the id reaches any
+// database lookup or token claim. It must run after NormalizeTenantID. A nil
+// pattern disables the check.
+func ValidateTenantID(pattern *regexp.Regexp) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if pattern == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tenantID, ok := mux.Vars(r)["tenant_id"]; ok && !pattern.MatchString(tenantID) {
+				err := errors.WithMessage(errors.ErrInvalidRequest, "Malformed tenant_id")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(err.Status)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error":             err.Code,
+					"error_description": err.Message,
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TrailingSlashFallback returns a not-found handler that retries unmatched
+// paths without their trailing slash, so "/{tenant_id}/oauth2/v2.0/token/" is
+// served like the canonical path. The request is re-routed in place rather
+// than redirected because clients commonly downgrade a redirected POST to a
+// GET. Paths that still don't match get a plain 404.
+func TrailingSlashFallback(router http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trimmed := strings.TrimRight(r.URL.Path, "/")
+		if trimmed == r.URL.Path || trimmed == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = trimmed
+		r2.URL.RawPath = ""
+		router.ServeHTTP(w, r2)
+	})
+}
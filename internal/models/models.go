@@ -4,22 +4,74 @@ import "time"
 
 // Tenant represents a tenant in the database
 type Tenant struct {
-	ID          string    `db:"id"`
-	ExternalTID string    `db:"external_tid"`
-	Name        string    `db:"name"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID          string `db:"id"`
+	ExternalTID string `db:"external_tid"`
+	Name        string `db:"name"`
+	// AccessTokenTTL and RefreshTokenTTL override the global token expiries
+	// for this tenant. Zero means no override (use the global config).
+	AccessTokenTTL  time.Duration `db:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `db:"refresh_token_ttl"`
+	// EncryptedHMACSecret, when set, makes the tenant's tokens HS256-signed
+	// with this shared secret instead of the published asymmetric keys. It
+	// is encrypted with TENANT_SECRET_KEY.
+	EncryptedHMACSecret []byte `db:"hmac_secret"`
+	// BindRefreshTokens restricts refresh tokens to the client IP and device
+	// they were issued to.
+	BindRefreshTokens bool      `db:"bind_refresh_tokens"`
+	CreatedAt         time.Time `db:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at"`
+}
+
+// TenantConfig is a tenant's policy from the tenant_config table. Its zero
+// value, used for tenants without a row, applies the global configuration.
+type TenantConfig struct {
+	TenantID string `db:"tenant_id" json:"tenant_id"`
+	// AllowedGrantTypes limits the grants the tenant's token endpoint
+	// accepts. Empty means every enabled grant.
+	AllowedGrantTypes []string `db:"allowed_grant_types" json:"allowed_grant_types"`
+	// MaxSessionsPerUser, when positive, replaces MAX_SESSIONS_PER_USER for
+	// the tenant's users.
+	MaxSessionsPerUser int       `db:"max_sessions_per_user" json:"max_sessions_per_user"`
+	UpdatedAt          time.Time `db:"updated_at" json:"updated_at,omitzero"`
 }
 
 // User represents a user in the database (opaque IDs, no PII in tokens)
 type User struct {
-	ID          string    `db:"id"`
-	TenantID    string    `db:"tenant_id"`
-	Email       string    `db:"email"`        // PII, never put in tokens
-	FullName    string    `db:"full_name"`   // PII, never put in tokens
-	PhoneNumber string    `db:"phone_number"`// PII, never put in tokens
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID          string `db:"id"`
+	TenantID    string `db:"tenant_id"`
+	Email       string `db:"email"`        // PII, never put in tokens
+	FullName    string `db:"full_name"`    // PII, never put in tokens
+	PhoneNumber string `db:"phone_number"` // PII, never put in tokens
+	// EmailVerified and PhoneVerified record whether the provisioning client
+	// has verified the email address and phone number.
+	EmailVerified bool      `db:"email_verified"`
+	PhoneVerified bool      `db:"phone_verified"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+// UserExport is the portable representation of everything stored for a user
+// (record plus role assignments), returned by the admin export endpoints.
+type UserExport struct {
+	ID            string    `json:"id"`
+	TenantID      string    `json:"tenant_id"`
+	Email         string    `json:"email,omitempty"`
+	FullName      string    `json:"full_name"`
+	PhoneNumber   string    `json:"phone_number"`
+	EmailVerified bool      `json:"email_verified"`
+	PhoneVerified bool      `json:"phone_verified"`
+	Roles         []string  `json:"roles"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// UserListResponse is one page of a tenant's users. The same pagination is
+// also exposed through the response's Link header.
+type UserListResponse struct {
+	Users  []*UserExport `json:"users"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
 }
 
 // UserRole represents a role assignment for a user within a tenant
@@ -30,14 +82,96 @@ type UserRole struct {
 
 // Client represents a client in the database
 type Client struct {
-	ID               int64     `db:"id"`
-	ClientID         string    `db:"client_id"`
-	ClientSecretHash string    `db:"client_secret_hash"`
-	RateLimit        int       `db:"rate_limit"`
-	TenantID         string    `db:"tenant_id"`
-	UserID           string    `db:"user_id"`
-	CreatedAt        time.Time `db:"created_at"`
-	UpdatedAt        time.Time `db:"updated_at"`
+	ID               int64  `db:"id"`
+	ClientID         string `db:"client_id"`
+	ClientSecretHash string `db:"client_secret_hash"`
+	RateLimit        int    `db:"rate_limit"`
+	TenantID         string `db:"tenant_id"`
+	UserID           string `db:"user_id"`
+	SigningAlg       string `db:"signing_alg"` // empty means the default algorithm
+	// EncryptAccessTokens wraps the client's access tokens in a JWE for the
+	// audience's registered resource server key.
+	EncryptAccessTokens bool `db:"encrypt_access_tokens"`
+	// RolePrefixes limits the roles claim in the client's tokens to roles
+	// starting with one of these prefixes. Empty means all roles.
+	RolePrefixes []string `db:"role_prefixes"`
+	// AllowedScopes limits the scp claim in the client's tokens to these
+	// scopes, including tokens reissued on refresh. Empty means all scopes.
+	AllowedScopes []string `db:"allowed_scopes"`
+	// AllowedGrantTypes limits the token endpoint grants the client may use.
+	// Empty means every grant the tenant allows.
+	AllowedGrantTypes []string `db:"allowed_grant_types"`
+	// GrantableRoles limits the roles the client may assign with
+	// provision_user. Empty means every role except ADMIN_ROLE and
+	// PRIVILEGED_ROLES.
+	GrantableRoles []string `db:"grantable_roles"`
+	// VerboseIntrospection lets the client see why an introspected token is
+	// inactive, beyond the RFC 7662 active:false.
+	VerboseIntrospection bool `db:"verbose_introspection"`
+	// Name and Description are optional labels for operators; empty if unset.
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// SigningKey is a token signing key as stored in signing_keys, so it
+// survives restarts. The private key is PKCS8-encoded and encrypted with
+// SIGNING_KEY_ENCRYPTION_KEY.
+type SigningKey struct {
+	KeyID     string `db:"kid"`
+	Algorithm string `db:"algorithm"`
+	// Audience is set for keys signing only that audience's tokens; empty
+	// for the keys published in the main JWKS.
+	Audience string `db:"audience"`
+	// Fingerprint is the hex SHA-256 of the PKIX-encoded public key.
+	Fingerprint         string    `db:"fingerprint"`
+	EncryptedPrivateKey []byte    `db:"private_key"`
+	CreatedAt           time.Time `db:"created_at"`
+	// ExpiresAt is zero until the key is rotated out.
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// SoftwareStatement is the client metadata asserted by a verified software
+// statement (RFC 7591 §2.3). Fields the statement does not assert are empty.
+type SoftwareStatement struct {
+	SoftwareID   string   `json:"software_id,omitempty"`
+	ClientName   string   `json:"client_name,omitempty"`
+	RedirectURIs []string `json:"redirect_uris,omitempty"`
+	GrantTypes   []string `json:"grant_types,omitempty"`
+}
+
+// ClientMetadata is the body of an admin client update. Omitted fields are
+// left unchanged; an empty string clears the field.
+type ClientMetadata struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// ClientSummary describes a client in admin responses, without its secret.
+type ClientSummary struct {
+	ClientID    string `json:"client_id"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	RateLimit   int    `json:"rate_limit"`
+	// AllowedGrantTypes is the client's grant allowlist; omitted if it may
+	// use every grant.
+	AllowedGrantTypes []string `json:"allowed_grant_types,omitempty"`
+	// GrantConflict marks a client that may use both provision_user and
+	// client_credentials although SEPARATE_PROVISIONING_CLIENTS is set; it
+	// can use neither until one is removed or the client is exempted.
+	GrantConflict bool      `json:"grant_conflict,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ClientListResponse is one page of a tenant's clients. The same pagination
+// is also exposed through the response's Link header.
+type ClientListResponse struct {
+	Clients []*ClientSummary `json:"clients"`
+	Total   int              `json:"total"`
+	Limit   int              `json:"limit"`
+	Offset  int              `json:"offset"`
 }
 
 // TokenResponse represents the OAuth2 token response
@@ -46,6 +180,8 @@ type TokenResponse struct {
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int64  `json:"expires_in"`
 	RefreshToken string `json:"refresh_token,omitempty"`
+	// IDToken is only issued for the openid scope.
+	IDToken string `json:"id_token,omitempty"`
 }
 
 // TokenRequest represents the OAuth2 token request
@@ -60,29 +196,170 @@ type TokenRequest struct {
 // It carries the original client and subject so refresh tokens can issue
 // the same user/tenant-scoped access tokens without re-reading from DB.
 type RefreshTokenData struct {
-	ClientID string        `json:"client_id"`
-	Subject  *TokenSubject `json:"subject,omitempty"`
-	ExpiresAt time.Time    `json:"expires_at"`
+	ClientID  string        `json:"client_id"`
+	Subject   *TokenSubject `json:"subject,omitempty"`
+	IssuedAt  time.Time     `json:"issued_at"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	// LastUsedAt is when the session was last active (issued or refreshed);
+	// it drives the idle-session timeout.
+	LastUsedAt time.Time `json:"last_used_at"`
+	// Fingerprint is a hash of the client IP and device ID the token was
+	// issued to, checked on refresh for tenants that bind refresh tokens.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// AccessTokenJTI is the jti of the access token most recently issued
+	// alongside this refresh token, for correlating the two when auditing.
+	AccessTokenJTI string `json:"access_token_jti,omitempty"`
+	// GrantType is the grant that started the session; it picks the
+	// lifetime of access tokens refreshed from it.
+	GrantType string `json:"grant_type,omitempty"`
+	// Audiences are the audiences the session was granted; empty means the
+	// default audience. Refreshes may narrow them but never widen them.
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+// UserSession is an entry of a user's session index: a refresh token
+// session and the refresh token it is currently on.
+type UserSession struct {
+	SessionID    string
+	RefreshToken string
+	StartedAt    time.Time
+}
+
+// DeviceCodeData represents a pending device authorization stored in Redis.
+// Subject is set once a user approves the user code.
+type DeviceCodeData struct {
+	ClientID     string        `json:"client_id"`
+	TenantID     string        `json:"tenant_id"`
+	UserCode     string        `json:"user_code"`
+	Interval     int           `json:"interval"` // minimum seconds between polls
+	LastPolledAt time.Time     `json:"last_polled_at"`
+	Subject      *TokenSubject `json:"subject,omitempty"`
+	LoginHint    string        `json:"login_hint,omitempty"` // from the device, for the approval step
+	ExpiresAt    time.Time     `json:"expires_at"`
+}
+
+// DeviceAuthorizationResponse represents the device authorization response (RFC 8628)
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// RateLimitResponse reports a client's rate limit and its use of the current
+// window.
+type RateLimitResponse struct {
+	ClientID      string `json:"client_id"`
+	RateLimit     int    `json:"rate_limit"`
+	WindowSeconds int64  `json:"window_seconds"`
+	Remaining     int    `json:"remaining"`
+	ResetSeconds  int64  `json:"reset_seconds"` // until the window restarts; 0 when none is open
+}
+
+// UserInfoResponse represents the OIDC userinfo response for the user in
+// the presented access token.
+type UserInfoResponse struct {
+	Subject             string `json:"sub"`
+	Name                string `json:"name"`
+	Email               string `json:"email,omitempty"`
+	EmailVerified       bool   `json:"email_verified"`
+	PhoneNumber         string `json:"phone_number"`
+	PhoneNumberVerified bool   `json:"phone_number_verified"`
+}
+
+// TenantSigningSecretResponse returns a newly generated HS256 tenant secret.
+// It is shown only once; the service keeps just the encrypted value.
+type TenantSigningSecretResponse struct {
+	Algorithm string `json:"alg"`
+	Secret    string `json:"secret"` // base64url, unpadded
+}
+
+// SigningKeyPromotionResponse describes the signing key that became current
+// when the standby key source was promoted.
+type SigningKeyPromotionResponse struct {
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
 }
 
 // TokenSubject represents the identity and authorization context for a token
 // It is used to construct minimal, non-PII JWT claims (sub, tid, roles, scp, etc.).
 type TokenSubject struct {
-	UserID   string   // maps to sub / oid
-	TenantID string   // maps to tid
-	Roles    []string // roles claim
-	Scopes   []string // scp claim
+	UserID           string   // maps to sub / oid
+	TenantID         string   // maps to tid
+	ExternalTenantID string   // maps to xtid (only set when enabled)
+	Roles            []string // roles claim
+	Scopes           []string // scp claim
+	Act              *Actor   // act claim (RFC 8693), set only for delegated tokens
+	SessionID        string   // maps to sid; stable across refreshes of one session
+	AuthMethods      []string // amr claim; how the session was authenticated
+	ClientID         string   // client_id claim (RFC 9068); only set when enabled
+	Audiences        []string // aud claim; empty means the issuer's default audience
+	AuthorizedParty  string   // azp claim; the client named by a client_id audience
+}
+
+// Actor identifies the party acting on behalf of a token's subject. Act
+// nests prior actors when delegation is chained, most recent actor outermost.
+type Actor struct {
+	Subject string `json:"sub"`
+	Act     *Actor `json:"act,omitempty"`
 }
 
 // VerifyRequest represents a token verification request
 type VerifyRequest struct {
 	Token string `json:"token"`
+	// MaxAge, when set, additionally requires the token's auth_time (or iat
+	// if absent) to be at most this many seconds old.
+	MaxAge *int64 `json:"max_age,omitempty"`
+	// ExpectedAudience, when set, additionally requires this value to be
+	// among the token's aud, so a resource server can check that the token
+	// was meant for it.
+	ExpectedAudience string `json:"expected_audience,omitempty"`
 }
 
+// VerifyReasonStale marks a valid token rejected for exceeding max_age.
+const VerifyReasonStale = "stale"
+
+// VerifyReasonWrongAudience marks a valid token rejected because
+// expected_audience is not among its audiences.
+const VerifyReasonWrongAudience = "wrong_audience"
+
+// VerifyReasonRolesChanged marks a token issued before the user's roles
+// changed; refreshing it yields a token with the current roles.
+const VerifyReasonRolesChanged = "roles_changed"
+
+// VerifyReasonTooManySources marks a token rejected because it was presented
+// from more distinct sources than allowed (JTI_SOURCE_REJECT).
+const VerifyReasonTooManySources = "too_many_sources"
+
+// VerifyReasonRequiresCurrentKey marks a token rejected because it carries a
+// scope in JWT_CURRENT_KEY_SCOPES but was signed by a key in its grace period.
+const VerifyReasonRequiresCurrentKey = "requires_current_key"
+
+// Reasons a verbose introspection gives for an inactive token.
+const (
+	IntrospectionReasonInvalid            = "invalid"
+	IntrospectionReasonExpired            = "expired"
+	IntrospectionReasonRevoked            = "revoked"
+	IntrospectionReasonRolesChanged       = "roles_changed"
+	IntrospectionReasonRequiresCurrentKey = "requires_current_key"
+	IntrospectionReasonTenantMismatch     = "tenant_mismatch"
+)
+
 // VerifyResponse represents a token verification response
 type VerifyResponse struct {
-	Valid   bool                   `json:"valid"`
-	Claims  map[string]interface{} `json:"claims,omitempty"`
-	Message string                 `json:"message,omitempty"`
+	Valid      bool                   `json:"valid"`
+	Claims     map[string]interface{} `json:"claims,omitempty"`
+	Message    string                 `json:"message,omitempty"`
+	Reason     string                 `json:"reason,omitempty"` // machine-readable cause of valid=false, e.g. "stale"
+	SigningKey *SigningKeyStatus      `json:"signing_key,omitempty"`
 }
 
+// SigningKeyStatus tells resource servers whether the key that signed a token
+// is being retired, so they can re-fetch JWKS ahead of time.
+type SigningKeyStatus struct {
+	KeyID     string     `json:"kid"`
+	InGrace   bool       `json:"in_grace"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// corsMiddleware adds CORS headers to every routed response and answers
+// preflight requests for routes that accept OPTIONS. Since mux only runs
+// middleware for matched routes, unknown paths still 404. Browsers only read
+// the allowed methods from preflight responses, so other requests skip the
+// route walk that finds them.
+func corsMiddleware(router *mux.Router) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				setCORSHeaders(w, allowedMethods(router, r))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			setCORSHeaders(w, nil)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// methodNotAllowedHandler handles requests whose path matches a route but
+// whose method does not. Preflight requests are answered with the methods the
+// path does support instead of being refused with 405.
+func methodNotAllowedHandler(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods := allowedMethods(router, r)
+		if r.Method == http.MethodOptions {
+			setCORSHeaders(w, methods)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+}
+
+// setCORSHeaders sets the CORS response headers, with methods as the allowed
+// methods unless nil.
+func setCORSHeaders(w http.ResponseWriter, methods []string) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if methods != nil {
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	}
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+}
+
+// allowedMethods returns the methods of every route matching r's path, with
+// OPTIONS always included for preflight.
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	methods := []string{}
+	seen := map[string]bool{http.MethodOptions: true}
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		routeMethods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range routeMethods {
+			if seen[method] {
+				continue
+			}
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			var match mux.RouteMatch
+			if route.Match(probe, &match) {
+				seen[method] = true
+				methods = append(methods, method)
+			}
+		}
+		return nil
+	})
+	return append(methods, http.MethodOptions)
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"net/http"
+	"regexp"
+	"session-service/internal/handlers"
+	"session-service/internal/metrics"
+	"session-service/internal/middleware"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	httpSwagger "github.com/swaggo/http-swagger"
+	"go.uber.org/zap"
+)
+
+// Routes advertised in OIDC discovery.
+const (
+	tokenPath               = "/{tenant_id}/oauth2/v2.0/token"
+	deviceAuthorizationPath = "/{tenant_id}/oauth2/v1.0/device_authorization"
+	jwksPath                = "/{tenant_id}/discovery/v1.0/keys"
+	userinfoPath            = "/{tenant_id}/oauth2/v1.0/userinfo"
+	introspectionPath       = "/{tenant_id}/oauth2/v1.0/introspect"
+)
+
+// SetupRouter configures and returns the HTTP router with all routes and middleware.
+// When separateAdmin is set, the operational surface (metrics, health probes,
+// admin and debug endpoints) is left out; serve it with SetupAdminRouter on
+// the admin port instead.
+func SetupRouter(
+	tokenHandler *handlers.TokenHandler,
+	verifyHandler *handlers.VerifyHandler,
+	jwksHandler *handlers.JWKSHandler,
+	oidcHandler *handlers.OIDCConfigurationHandler,
+	adminHandler *handlers.AdminHandler,
+	healthHandler *handlers.HealthHandler,
+	adminAuth func(http.Handler) http.Handler,
+	userAuth func(http.Handler) http.Handler,
+	debugHandler *handlers.DebugHandler,
+	recorder *middleware.RequestRecorder,
+	debugAuth func(http.Handler) http.Handler,
+	operatorAuth func(http.Handler) http.Handler,
+	tenantIDPattern *regexp.Regexp,
+	separateAdmin bool,
+	logger *zap.Logger,
+) *mux.Router {
+	router := mux.NewRouter()
+
+	// Add CORS middleware. Preflights to existing paths whose routes don't
+	// list OPTIONS are answered by the method-not-allowed handler.
+	router.Use(corsMiddleware(router))
+	router.MethodNotAllowedHandler = methodNotAllowedHandler(router)
+
+	// Add tracing and logging middleware
+	router.Use(middleware.Tracing())
+	router.Use(middleware.LoggingMiddleware(logger))
+
+	// Tenant ids are case-insensitive; handlers only ever see the canonical form,
+	// and malformed ids are rejected before any lookup
+	router.Use(middleware.NormalizeTenantID)
+	router.Use(middleware.ValidateTenantID(tenantIDPattern))
+
+	// Debug request recorder (non-production only; nil when disabled)
+	if recorder != nil {
+		router.Use(recorder.Middleware)
+	}
+
+	if !separateAdmin {
+		registerOperationalRoutes(router, adminHandler, healthHandler, adminAuth, debugHandler, recorder, debugAuth, operatorAuth)
+	}
+
+	// OIDC Discovery (not tenant-scoped)
+	router.HandleFunc("/.well-known/openid-configuration", oidcHandler.HandleOIDCConfiguration).Methods("GET", "OPTIONS")
+	// RFC 8414 metadata, per tenant, listing that tenant's endpoints
+	router.HandleFunc("/.well-known/oauth-authorization-server/{tenant_id}", oidcHandler.HandleAuthorizationServerMetadata).Methods("GET", "OPTIONS")
+
+	// OAuth2 endpoints (tenant-scoped), advertised in discovery as they are wired
+	router.HandleFunc(tokenPath, tokenHandler.HandleToken).Methods("POST", "OPTIONS")
+	oidcHandler.AdvertiseEndpoint(handlers.DiscoveryTokenEndpoint, tokenPath)
+	oidcHandler.SetGrantTypes(tokenHandler.GrantTypes())
+	router.HandleFunc(deviceAuthorizationPath, tokenHandler.HandleDeviceAuthorization).Methods("POST", "OPTIONS")
+	oidcHandler.AdvertiseEndpoint(handlers.DiscoveryDeviceAuthorizationEndpoint, deviceAuthorizationPath)
+	router.Handle("/{tenant_id}/oauth2/v1.0/device", userAuth(http.HandlerFunc(tokenHandler.HandleDeviceApproval))).Methods("POST")
+	router.HandleFunc("/{tenant_id}/oauth2/v1.0/session/refresh", tokenHandler.HandleSessionRefresh).Methods("POST")
+	router.HandleFunc("/{tenant_id}/oauth2/v1.0/ratelimit", tokenHandler.HandleRateLimit).Methods("GET")
+	router.Handle(userinfoPath, userAuth(http.HandlerFunc(tokenHandler.HandleUserInfo))).Methods("GET", "POST")
+	oidcHandler.AdvertiseEndpoint(handlers.DiscoveryUserinfoEndpoint, userinfoPath)
+	router.HandleFunc(introspectionPath, tokenHandler.HandleIntrospect).Methods("POST", "OPTIONS")
+	oidcHandler.AdvertiseEndpoint(handlers.DiscoveryIntrospectionEndpoint, introspectionPath)
+	router.HandleFunc(jwksPath, jwksHandler.HandleJWKS).Methods("GET", "OPTIONS")
+	oidcHandler.AdvertiseEndpoint(handlers.DiscoveryJWKSURI, jwksPath)
+
+	// Verify Token (tenant-scoped)
+	router.HandleFunc("/{tenant_id}/oauth2/v1.0/verify", verifyHandler.HandleVerify).Methods("POST", "OPTIONS")
+
+	// Health check (tenant-scoped)
+	// @Summary     Health check endpoint
+	// @Description Returns OK if the service is running
+	// @Tags        health
+	// @Produce     text/plain
+	// @Success     200  {string}  string  "OK"
+	// @Router      /{tenant_id}/health [get]
+	router.HandleFunc("/{tenant_id}/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	}).Methods("GET")
+
+	// Swagger documentation
+	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+
+	// Serve "/path/" like "/path" instead of 404ing
+	router.NotFoundHandler = middleware.TrailingSlashFallback(router)
+
+	return router
+}
+
+// SetupAdminRouter configures the router for the internal admin port: metrics,
+// health probes, and the admin and debug endpoints.
+func SetupAdminRouter(
+	adminHandler *handlers.AdminHandler,
+	healthHandler *handlers.HealthHandler,
+	adminAuth func(http.Handler) http.Handler,
+	debugHandler *handlers.DebugHandler,
+	recorder *middleware.RequestRecorder,
+	debugAuth func(http.Handler) http.Handler,
+	operatorAuth func(http.Handler) http.Handler,
+	tenantIDPattern *regexp.Regexp,
+	logger *zap.Logger,
+) *mux.Router {
+	router := mux.NewRouter()
+
+	router.Use(middleware.Tracing())
+	router.Use(middleware.LoggingMiddleware(logger))
+	router.Use(middleware.NormalizeTenantID)
+	router.Use(middleware.ValidateTenantID(tenantIDPattern))
+
+	registerOperationalRoutes(router, adminHandler, healthHandler, adminAuth, debugHandler, recorder, debugAuth, operatorAuth)
+
+	router.NotFoundHandler = middleware.TrailingSlashFallback(router)
+
+	return router
+}
+
+// registerOperationalRoutes adds metrics, health probes, and the admin and
+// debug endpoints to router.
+func registerOperationalRoutes(
+	router *mux.Router,
+	adminHandler *handlers.AdminHandler,
+	healthHandler *handlers.HealthHandler,
+	adminAuth func(http.Handler) http.Handler,
+	debugHandler *handlers.DebugHandler,
+	recorder *middleware.RequestRecorder,
+	debugAuth func(http.Handler) http.Handler,
+	operatorAuth func(http.Handler) http.Handler,
+) {
+	// Debug request recorder endpoint (only when recording is enabled)
+	if recorder != nil {
+		debug := router.PathPrefix("/admin/debug").Subrouter()
+		debug.Use(debugAuth)
+		debug.HandleFunc("/requests", debugHandler.HandleRecordedRequests).Methods("GET")
+	}
+
+	// Signing keys are shared by every tenant, so no tenant's token may
+	// change them, whatever its roles
+	keys := router.PathPrefix("/admin/keys").Subrouter()
+	keys.Use(operatorAuth)
+	keys.HandleFunc("/promote-standby", adminHandler.HandlePromoteStandbyKey).Methods("POST")
+
+	// Prometheus metrics and probes (not tenant-scoped)
+	router.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})).Methods("GET")
+	router.HandleFunc("/healthz", healthHandler.HandleLiveness).Methods("GET")
+	router.HandleFunc("/readyz", healthHandler.HandleReadiness).Methods("GET")
+
+	// Admin endpoints (tenant-scoped, require an access token with the admin role)
+	admin := router.PathPrefix("/{tenant_id}/admin").Subrouter()
+	admin.Use(adminAuth)
+	admin.HandleFunc("/users", adminHandler.HandleListUsers).Methods("GET")
+	admin.HandleFunc("/users/export", adminHandler.HandleExportTenantUsers).Methods("GET")
+	admin.HandleFunc("/users/{user_id}/export", adminHandler.HandleExportUser).Methods("GET")
+	admin.HandleFunc("/users/{user_id}", adminHandler.HandleDeleteUser).Methods("DELETE")
+	admin.HandleFunc("/clients", adminHandler.HandleListClients).Methods("GET")
+	admin.HandleFunc("/clients/{client_id}", adminHandler.HandleUpdateClient).Methods("PATCH")
+	admin.HandleFunc("/events", adminHandler.HandleEventStream).Methods("GET")
+	admin.HandleFunc("/config", adminHandler.HandleGetTenantConfig).Methods("GET")
+	admin.HandleFunc("/config", adminHandler.HandleUpdateTenantConfig).Methods("PUT")
+	admin.HandleFunc("/signing-secret", adminHandler.HandleRotateSigningSecret).Methods("POST")
+	admin.HandleFunc("/signing-secret", adminHandler.HandleDeleteSigningSecret).Methods("DELETE")
+}
@@ -76,4 +76,218 @@ BEGIN
     END IF;
 END$$;
 
+-- -------------------------------
+-- Per-tenant token expiry overrides
+-- -------------------------------
+
+-- Lifetimes in seconds; NULL means the global JWT_EXPIRY / REFRESH_TOKEN_EXPIRY apply.
+ALTER TABLE tenants
+    ADD COLUMN IF NOT EXISTS access_token_ttl INTEGER CHECK (access_token_ttl > 0),
+    ADD COLUMN IF NOT EXISTS refresh_token_ttl INTEGER CHECK (refresh_token_ttl > 0);
+
+-- -------------------------------
+-- Per-client signing algorithm
+-- -------------------------------
+
+-- NULL means tokens for the client are signed with the default algorithm (RS256).
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS signing_alg VARCHAR(16);
+
+-- Re-created so databases that allowed only RS256 and ES256 accept EdDSA too.
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_signing_alg_check;
+ALTER TABLE clients
+    ADD CONSTRAINT clients_signing_alg_check CHECK (signing_alg IN ('RS256', 'ES256', 'EdDSA'));
+
+-- -------------------------------
+-- Tenant role catalog
+-- -------------------------------
+
+-- When a tenant has rows here, provisioning rejects roles outside the catalog.
+-- Tenants without rows accept free-form roles.
+CREATE TABLE IF NOT EXISTS tenant_roles (
+    tenant_id VARCHAR(255) NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    role VARCHAR(100) NOT NULL,
+    PRIMARY KEY (tenant_id, role)
+);
+
+-- -------------------------------
+-- Encrypted access tokens (JWE)
+-- -------------------------------
+
+-- Resource server public keys (RSA, PEM) used to encrypt access tokens for an audience.
+CREATE TABLE IF NOT EXISTS audience_encryption_keys (
+    audience VARCHAR(255) PRIMARY KEY,
+    public_key TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS encrypt_access_tokens BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- -------------------------------
+-- Per-client role filtering
+-- -------------------------------
+-- Only roles starting with one of these prefixes (e.g. 'billing:') are put in
+-- the client's tokens. NULL or empty means all of the user's roles.
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS role_prefixes TEXT[];
+
+-- -------------------------------
+-- HS256 tenants
+-- -------------------------------
+-- Shared secret (AES-GCM encrypted with TENANT_SECRET_KEY) for tenants whose
+-- tokens are HS256-signed. NULL means the tenant uses the published keys.
+ALTER TABLE tenants
+    ADD COLUMN IF NOT EXISTS hmac_secret BYTEA;
+
+-- -------------------------------
+-- Refresh token binding
+-- -------------------------------
+-- When set, refresh tokens only work from the client IP and device
+-- (X-Device-ID) they were issued to.
+ALTER TABLE tenants
+    ADD COLUMN IF NOT EXISTS bind_refresh_tokens BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- -------------------------------
+-- Verified contact details
+-- -------------------------------
+-- Whether the provisioning client verified the user's email and phone.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS phone_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- -------------------------------
+-- Client metadata
+-- -------------------------------
+-- Optional human-readable name and description shown in admin listings and
+-- audit events.
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS name VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS description TEXT;
+
+-- -------------------------------
+-- Client scope allowlist
+-- -------------------------------
+-- Only these scopes are put in the client's tokens; scopes removed here are
+-- dropped from sessions on their next refresh. NULL or empty means all scopes.
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS allowed_scopes TEXT[];
+
+-- -------------------------------
+-- Audit events
+-- -------------------------------
+-- Admin audit events, written when AUDIT_PERSIST is enabled. The ID is
+-- assigned by the service so replays from the dead-letter are idempotent.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id UUID PRIMARY KEY,
+    event_type VARCHAR(255) NOT NULL,
+    tenant_id VARCHAR(255) NOT NULL,
+    actor_id VARCHAR(255),
+    target_id VARCHAR(255),
+    metadata JSONB,
+    occurred_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_tenant_occurred_at ON audit_events(tenant_id, occurred_at);
+
+-- -------------------------------
+-- Verbose introspection
+-- -------------------------------
+-- Lets trusted internal clients see why an introspected token is inactive.
+-- Other clients get the plain RFC 7662 active:false.
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS verbose_introspection BOOLEAN NOT NULL DEFAULT FALSE;
 
+-- -------------------------------
+-- Per-tenant configuration
+-- -------------------------------
+-- Tenant policy that overrides the global configuration. Tenants without a
+-- row use the global configuration.
+CREATE TABLE IF NOT EXISTS tenant_config (
+    tenant_id VARCHAR(255) PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    -- NULL or empty means every enabled grant type
+    allowed_grant_types TEXT[],
+    -- 0 means MAX_SESSIONS_PER_USER applies
+    max_sessions_per_user INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- -------------------------------
+-- Client grant allowlist
+-- -------------------------------
+-- Token endpoint grants the client may use, on top of the tenant's
+-- allowed_grant_types. NULL or empty means every grant the tenant allows.
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS allowed_grant_types TEXT[];
+
+-- -------------------------------
+-- Client grantable roles
+-- -------------------------------
+-- Roles the client may assign with provision_user. NULL or empty means every
+-- role except ADMIN_ROLE and the PRIVILEGED_ROLES, which must be listed here
+-- explicitly.
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS grantable_roles TEXT[];
+
+-- -------------------------------
+-- Signing keys
+-- -------------------------------
+-- Token signing keys, written when SIGNING_KEY_PERSIST is enabled so that
+-- kids and rotated keys' grace periods survive restarts. private_key is the
+-- PKCS8 key encrypted with SIGNING_KEY_ENCRYPTION_KEY. expires_at is NULL
+-- until the key is rotated out; audience is empty for the main JWKS keys.
+CREATE TABLE IF NOT EXISTS signing_keys (
+    kid VARCHAR(255) PRIMARY KEY,
+    algorithm VARCHAR(16) NOT NULL,
+    audience VARCHAR(255) NOT NULL DEFAULT '',
+    private_key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ
+);
+
+-- fingerprint is the hex SHA-256 of the PKIX public key; it finds the
+-- configured key's rows at startup without loading every expired key.
+ALTER TABLE signing_keys
+    ADD COLUMN IF NOT EXISTS fingerprint VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_signing_keys_fingerprint ON signing_keys(fingerprint);
+
+-- -------------------------------
+-- Canonical tenant ids
+-- -------------------------------
+-- Tenant ids are case-insensitive and looked up in lower case, so ids stored
+-- before that are rewritten in lower case, along with every reference to
+-- them; foreign keys to tenants cascade the update. Tenants whose ids differ
+-- only in case must be merged by hand first, or the migration stops.
+DO $$
+DECLARE
+    fk RECORD;
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(lower_id, ', ') INTO duplicates
+    FROM (SELECT lower(id) AS lower_id FROM tenants GROUP BY lower(id) HAVING COUNT(*) > 1) d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'tenant ids differ only in case: %; merge those tenants first', duplicates;
+    END IF;
+
+    FOR fk IN
+        SELECT conrelid::regclass AS tbl, conname, pg_get_constraintdef(oid) AS def
+        FROM pg_constraint
+        WHERE contype = 'f' AND confrelid = 'tenants'::regclass AND confupdtype <> 'c'
+    LOOP
+        EXECUTE format('ALTER TABLE %s DROP CONSTRAINT %I', fk.tbl, fk.conname);
+        EXECUTE format('ALTER TABLE %s ADD CONSTRAINT %I %s ON UPDATE CASCADE', fk.tbl, fk.conname, fk.def);
+    END LOOP;
+
+    UPDATE tenants SET id = lower(id) WHERE id <> lower(id);
+    UPDATE audit_events SET tenant_id = lower(tenant_id) WHERE tenant_id <> lower(tenant_id);
+
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.table_constraints
+        WHERE constraint_name = 'chk_tenants_id_lower_case'
+          AND table_name = 'tenants'
+    ) THEN
+        ALTER TABLE tenants
+            ADD CONSTRAINT chk_tenants_id_lower_case CHECK (id = lower(id));
+    END IF;
+END$$;
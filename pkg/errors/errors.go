@@ -34,6 +34,22 @@ var (
 		Status:  400,
 	}
 
+	// ErrUnsupportedGrantType is returned for grant types the token endpoint
+	// does not serve, including ones disabled by configuration.
+	ErrUnsupportedGrantType = &ServiceError{
+		Code:    "UNSUPPORTED_GRANT_TYPE",
+		Message: "Unsupported grant type",
+		Status:  400,
+	}
+
+	// ErrUnauthorizedClient is returned when an authenticated client is not
+	// allowed the grant type it requested.
+	ErrUnauthorizedClient = &ServiceError{
+		Code:    "UNAUTHORIZED_CLIENT",
+		Message: "Client is not authorized to use this grant type",
+		Status:  400,
+	}
+
 	// ErrInvalidRequest is used for syntactically invalid requests (missing or
 	// malformed parameters) where a 400 response is appropriate.
 	ErrInvalidRequest = &ServiceError{
@@ -48,6 +64,79 @@ var (
 		Status:  401,
 	}
 
+	// ErrInvalidScope is returned when a token request asks for a scope the
+	// client is not allowed.
+	ErrInvalidScope = &ServiceError{
+		Code:    "INVALID_SCOPE",
+		Message: "Requested scope is not allowed for this client",
+		Status:  400,
+	}
+
+	// ErrInvalidTarget is returned when a token request names a resource
+	// (RFC 8707) that was not granted.
+	ErrInvalidTarget = &ServiceError{
+		Code:    "INVALID_TARGET",
+		Message: "Requested resource was not granted",
+		Status:  400,
+	}
+
+	ErrForbidden = &ServiceError{
+		Code:    "FORBIDDEN",
+		Message: "Insufficient privileges",
+		Status:  403,
+	}
+
+	ErrNotFound = &ServiceError{
+		Code:    "NOT_FOUND",
+		Message: "Resource not found",
+		Status:  404,
+	}
+
+	// ErrConflict is used when a write collides with existing data (e.g. a
+	// unique constraint violation).
+	ErrConflict = &ServiceError{
+		Code:    "CONFLICT",
+		Message: "Resource conflicts with existing data",
+		Status:  409,
+	}
+
+	// Device authorization grant (RFC 8628) polling errors. These use the
+	// RFC's lowercase codes because device clients branch on them.
+	ErrAuthorizationPending = &ServiceError{
+		Code:    "authorization_pending",
+		Message: "The user has not yet approved the device",
+		Status:  400,
+	}
+
+	ErrSlowDown = &ServiceError{
+		Code:    "slow_down",
+		Message: "Polling too frequently; increase the interval by 5 seconds",
+		Status:  400,
+	}
+
+	ErrExpiredToken = &ServiceError{
+		Code:    "expired_token",
+		Message: "The device code has expired",
+		Status:  400,
+	}
+
+	// ErrTemporarilyUnavailable is returned while the service briefly cannot
+	// serve a request (e.g. during signing key rotation); clients should
+	// retry after the response's Retry-After.
+	ErrTemporarilyUnavailable = &ServiceError{
+		Code:    "TEMPORARILY_UNAVAILABLE",
+		Message: "Service temporarily unavailable; retry shortly",
+		Status:  503,
+	}
+
+	// ErrSessionLimitReached is returned when a user already has the maximum
+	// number of sessions and the session limit policy rejects new ones.
+	ErrSessionLimitReached = &ServiceError{
+		Code:    "SESSION_LIMIT_REACHED",
+		Message: "Maximum number of active sessions reached",
+		Status:  403,
+	}
+
 	ErrInternalServer = &ServiceError{
 		Code:    "INTERNAL_SERVER_ERROR",
 		Message: "Internal server error",
@@ -61,6 +150,8 @@ type ServiceError struct {
 	Message string
 	Status  int
 	Err     error
+	// Fields are extra machine-readable members of the error response.
+	Fields map[string]interface{}
 }
 
 func (e *ServiceError) Error() string {
@@ -81,6 +172,36 @@ func Wrap(err error, serviceErr *ServiceError) *ServiceError {
 		Message: serviceErr.Message,
 		Status:  serviceErr.Status,
 		Err:     err,
+		Fields:  serviceErr.Fields,
+	}
+}
+
+// WithMessage returns a copy of serviceErr with a more specific message,
+// e.g. naming the offending parameter values.
+func WithMessage(serviceErr *ServiceError, message string) *ServiceError {
+	return &ServiceError{
+		Code:    serviceErr.Code,
+		Message: message,
+		Status:  serviceErr.Status,
+		Err:     serviceErr.Err,
+		Fields:  serviceErr.Fields,
 	}
 }
 
+// WithField returns a copy of serviceErr whose response also carries key
+// set to value.
+func WithField(serviceErr *ServiceError, key string, value interface{}) *ServiceError {
+	fields := make(map[string]interface{}, len(serviceErr.Fields)+1)
+	for k, v := range serviceErr.Fields {
+		fields[k] = v
+	}
+	fields[key] = value
+
+	return &ServiceError{
+		Code:    serviceErr.Code,
+		Message: serviceErr.Message,
+		Status:  serviceErr.Status,
+		Err:     serviceErr.Err,
+		Fields:  fields,
+	}
+}
@@ -0,0 +1,64 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"session-service/internal/audit"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newBroadcaster(buffer int) (*audit.Broadcaster, *mocks.MockAuditRecorder) {
+	next := new(mocks.MockAuditRecorder)
+	next.On("Record", mock.Anything, mock.Anything).Return()
+	return audit.NewBroadcaster(next, buffer), next
+}
+
+func TestBroadcaster_PublishesToTenantSubscribers(t *testing.T) {
+	broadcaster, next := newBroadcaster(4)
+	events, unsubscribe := broadcaster.Subscribe("tenant-abc")
+	defer unsubscribe()
+	other, unsubscribeOther := broadcaster.Subscribe("tenant-xyz")
+	defer unsubscribeOther()
+
+	broadcaster.Record(context.Background(), deleteEvent("user-1"))
+
+	next.AssertCalled(t, "Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool { return e.TargetID == "user-1" }))
+	require.Len(t, events, 1)
+	event := <-events
+	assert.Equal(t, "user-1", event.TargetID)
+	assert.False(t, event.Timestamp.IsZero())
+	assert.Empty(t, other, "other tenants' subscribers see nothing")
+}
+
+func TestBroadcaster_SlowSubscriberMissesEvents(t *testing.T) {
+	broadcaster, next := newBroadcaster(1)
+	events, unsubscribe := broadcaster.Subscribe("tenant-abc")
+	defer unsubscribe()
+
+	// Nobody reads; the second event is dropped instead of blocking
+	broadcaster.Record(context.Background(), deleteEvent("user-1"))
+	broadcaster.Record(context.Background(), deleteEvent("user-2"))
+
+	next.AssertNumberOfCalls(t, "Record", 2)
+	require.Len(t, events, 1)
+	assert.Equal(t, "user-1", (<-events).TargetID)
+}
+
+func TestBroadcaster_Unsubscribe(t *testing.T) {
+	broadcaster, _ := newBroadcaster(4)
+	events, unsubscribe := broadcaster.Subscribe("tenant-abc")
+	require.Equal(t, 1, broadcaster.Subscribers("tenant-abc"))
+
+	unsubscribe()
+	unsubscribe()
+
+	assert.Equal(t, 0, broadcaster.Subscribers("tenant-abc"))
+	_, open := <-events
+	assert.False(t, open)
+	broadcaster.Record(context.Background(), deleteEvent("user-1"))
+}
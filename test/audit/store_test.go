@@ -0,0 +1,148 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"session-service/internal/audit"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var errDBDown = errors.New("db down")
+
+func newStoreRecorder(t *testing.T, deadLetter *audit.DeadLetter) (*audit.StoreRecorder, *mocks.MockRepository, *observer.ObservedLogs) {
+	t.Helper()
+
+	store := new(mocks.MockRepository)
+	next := new(mocks.MockAuditRecorder)
+	next.On("Record", mock.Anything, mock.Anything).Return()
+	core, logs := observer.New(zapcore.InfoLevel)
+	return audit.NewStoreRecorder(store, next, deadLetter, zap.New(core)), store, logs
+}
+
+func deleteEvent(userID string) audit.Event {
+	return audit.Event{Type: audit.EventUserDelete, TenantID: "tenant-abc", ActorID: "admin-1", TargetID: userID}
+}
+
+func TestStoreRecorder_FailedWriteRoutedToDeadLetterAndReplayed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.dlq")
+	recorder, store, _ := newStoreRecorder(t, audit.NewDeadLetter(path))
+
+	var failed audit.Event
+	store.On("InsertAuditEvent", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { failed = args.Get(1).(audit.Event) }).
+		Return(errDBDown).Once()
+	recorder.Record(context.Background(), deleteEvent("user-123"))
+
+	require.FileExists(t, path)
+	assert.NotEmpty(t, failed.ID)
+	assert.False(t, failed.Timestamp.IsZero())
+
+	// Still down: the event stays dead-lettered
+	store.On("InsertAuditEvent", mock.Anything, mock.Anything).Return(errDBDown).Once()
+	assert.Equal(t, 0, recorder.Replay(context.Background()))
+	require.FileExists(t, path)
+
+	// Recovered: the same event, with the same ID, is stored and the file removed
+	store.On("InsertAuditEvent", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.ID == failed.ID && e.Type == audit.EventUserDelete && e.TargetID == "user-123" && e.Timestamp.Equal(failed.Timestamp)
+	})).Return(nil).Once()
+	assert.Equal(t, 1, recorder.Replay(context.Background()))
+	assert.NoFileExists(t, path)
+	store.AssertExpectations(t)
+}
+
+func TestStoreRecorder_StoredEventNotDeadLettered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.dlq")
+	recorder, store, _ := newStoreRecorder(t, audit.NewDeadLetter(path))
+	store.On("InsertAuditEvent", mock.Anything, mock.Anything).Return(nil)
+
+	recorder.Record(context.Background(), deleteEvent("user-123"))
+
+	assert.NoFileExists(t, path)
+}
+
+func TestDeadLetter_ReplayStopsAtFirstFailureKeepingOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.dlq")
+	deadLetter := audit.NewDeadLetter(path)
+	for _, userID := range []string{"user-1", "user-2", "user-3"} {
+		event := deleteEvent(userID)
+		event.ID = userID
+		require.NoError(t, deadLetter.Append(event))
+	}
+
+	store := new(mocks.MockRepository)
+	store.On("InsertAuditEvent", mock.Anything, mock.MatchedBy(func(e audit.Event) bool { return e.ID == "user-1" })).Return(nil).Once()
+	store.On("InsertAuditEvent", mock.Anything, mock.MatchedBy(func(e audit.Event) bool { return e.ID == "user-2" })).Return(errDBDown).Once()
+
+	replayed, err := deadLetter.Replay(context.Background(), store)
+	assert.ErrorIs(t, err, errDBDown)
+	assert.Equal(t, 1, replayed)
+	store.AssertNotCalled(t, "InsertAuditEvent", mock.Anything, mock.MatchedBy(func(e audit.Event) bool { return e.ID == "user-3" }))
+
+	var order []string
+	store = new(mocks.MockRepository)
+	store.On("InsertAuditEvent", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { order = append(order, args.Get(1).(audit.Event).ID) }).
+		Return(nil)
+	replayed, err = deadLetter.Replay(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	assert.Equal(t, []string{"user-2", "user-3"}, order)
+	assert.NoFileExists(t, path)
+}
+
+func TestDeadLetter_KeepsUndecodableLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.dlq")
+	require.NoError(t, os.WriteFile(path, []byte("not json\n"), 0600))
+	deadLetter := audit.NewDeadLetter(path)
+	require.NoError(t, deadLetter.Append(deleteEvent("user-123")))
+
+	store := new(mocks.MockRepository)
+	store.On("InsertAuditEvent", mock.Anything, mock.Anything).Return(nil).Once()
+
+	replayed, err := deadLetter.Replay(context.Background(), store)
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, replayed)
+	data, readErr := os.ReadFile(path)
+	require.NoError(t, readErr)
+	assert.Equal(t, "not json\n", string(data))
+}
+
+func TestStoreRecorder_WithoutDeadLetterLogsLostEvent(t *testing.T) {
+	recorder, store, logs := newStoreRecorder(t, nil)
+	store.On("InsertAuditEvent", mock.Anything, mock.Anything).Return(errDBDown)
+
+	recorder.Record(context.Background(), deleteEvent("user-123"))
+
+	assert.Equal(t, 1, logs.FilterMessage("Failed to persist audit event; event lost").Len())
+}
+
+func TestStoreRecorder_RunReplaysInBackground(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.dlq")
+	deadLetter := audit.NewDeadLetter(path)
+	require.NoError(t, deadLetter.Append(deleteEvent("user-123")))
+	recorder, store, _ := newStoreRecorder(t, deadLetter)
+	store.On("InsertAuditEvent", mock.Anything, mock.Anything).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go recorder.Run(ctx, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+}
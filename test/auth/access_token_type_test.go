@@ -0,0 +1,131 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var rfc9068ClaimNames = auth.ClaimNames{Roles: "roles", Scopes: "scope", Tenant: "tid"}
+
+func TestRFC9068_TypHeaderAndScopeString(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tg.EnableClaimNames(rfc9068ClaimNames)
+	tg.EnableRFC9068()
+
+	token, _, err := tg.GenerateAccessToken(&models.TokenSubject{
+		UserID:   "user-123",
+		TenantID: "tenant-abc",
+		Roles:    []string{"reader", "writer"},
+		Scopes:   []string{"sessions:read", "sessions:write"},
+	})
+	require.NoError(t, err)
+
+	parsed := verifyAgainstJWKS(t, km, token)
+	assert.Equal(t, auth.AccessTokenType, parsed.Header["typ"])
+	claims := parsed.Claims.(jwt.MapClaims)
+	assert.Equal(t, "sessions:read sessions:write", claims["scope"])
+	assert.Equal(t, []interface{}{"reader", "writer"}, claims["roles"])
+	assert.NotContains(t, claims, "scp")
+}
+
+func TestRFC9068_ScopeClaimWithoutClaimNames(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tg.EnableRFC9068()
+
+	token, _, err := tg.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Scopes: []string{"read", "write"}})
+	require.NoError(t, err)
+
+	claims := verifyAgainstJWKS(t, km, token).Claims.(jwt.MapClaims)
+	assert.Equal(t, "read write", claims[auth.RFC9068ScopesClaim])
+	assert.NotContains(t, claims, "scp", "a space-delimited string is never put under the Azure AD scp claim")
+}
+
+func TestRFC9068_ExplicitScopesClaim(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tg.EnableClaimNames(auth.ClaimNames{Roles: "roles", Scopes: "scp", Tenant: "tid"})
+	tg.EnableRFC9068()
+
+	token, _, err := tg.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Scopes: []string{"read", "write"}})
+	require.NoError(t, err)
+
+	claims := verifyAgainstJWKS(t, km, token).Claims.(jwt.MapClaims)
+	assert.Equal(t, "read write", claims["scp"])
+	assert.NotContains(t, claims, auth.RFC9068ScopesClaim)
+}
+
+func TestRFC9068_DisabledByDefault(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+
+	token, _, err := tg.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Scopes: []string{"read"}})
+	require.NoError(t, err)
+
+	parsed := verifyAgainstJWKS(t, km, token)
+	assert.Equal(t, "JWT", parsed.Header["typ"])
+	assert.Equal(t, []interface{}{"read"}, parsed.Claims.(jwt.MapClaims)["scp"])
+}
+
+func TestRequireAccessTokenType(t *testing.T) {
+	km := createTestKeyManager(t)
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	cacheMock.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	tv := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+	tv.RequireAccessTokenType()
+
+	sign := func(t *testing.T, typ string) string {
+		t.Helper()
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss": "issuer",
+			"aud": "audience",
+			"sub": "user-123",
+			"tid": "tenant-abc",
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = km.GetCurrentKeyID()
+		if typ == "" {
+			delete(token.Header, "typ")
+		} else {
+			token.Header["typ"] = typ
+		}
+		signed, err := token.SignedString(km.GetPrivateKey())
+		require.NoError(t, err)
+		return signed
+	}
+
+	tests := []struct {
+		typ       string
+		wantValid bool
+	}{
+		{typ: "at+jwt", wantValid: true},
+		{typ: "application/at+JWT", wantValid: true},
+		{typ: "JWT", wantValid: false},
+		{typ: "", wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			_, err := tv.ValidateToken(context.Background(), sign(t, tt.typ))
+
+			if tt.wantValid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "not an access token")
+			}
+		})
+	}
+}
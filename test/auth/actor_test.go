@@ -0,0 +1,54 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseUnverified(t *testing.T, tokenString string) jwt.MapClaims {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(tokenString, claims)
+	require.NoError(t, err)
+	return claims
+}
+
+func TestGenerateAccessToken_DelegatedTokenCarriesActChain(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+
+	// The gateway acts for the user on behalf of a service
+	subject := &models.TokenSubject{
+		UserID:   "user-123",
+		TenantID: "tenant-abc",
+		Act:      &models.Actor{Subject: "gateway", Act: &models.Actor{Subject: "batch-service"}},
+	}
+
+	token, _, err := tg.GenerateAccessToken(subject)
+	require.NoError(t, err)
+
+	claims := parseUnverified(t, token)
+	assert.Equal(t, "user-123", claims["sub"])
+	assert.Equal(t, map[string]interface{}{
+		"sub": "gateway",
+		"act": map[string]interface{}{"sub": "batch-service"},
+	}, claims["act"])
+}
+
+func TestGenerateAccessToken_NormalTokenOmitsAct(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+
+	token, _, err := tg.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	_, ok := parseUnverified(t, token)["act"]
+	assert.False(t, ok)
+}
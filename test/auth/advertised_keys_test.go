@@ -0,0 +1,77 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxAdvertisedKeys_DropsOlderGraceKeys(t *testing.T) {
+	km := createTestKeyManager(t)
+	km.SetMaxAdvertisedKeys(2)
+	generator := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	cacheMock.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+
+	oldestKid := km.GetCurrentKeyID()
+	oldToken, _, err := generator.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	require.NoError(t, km.RotateKeys(time.Hour))
+	previousKid := km.GetCurrentKeyID()
+	require.NoError(t, km.RotateKeys(time.Hour))
+	currentKid := km.GetCurrentKeyID()
+
+	assert.ElementsMatch(t, []string{currentKid, previousKid}, jwksKeyIDs(t, km))
+
+	// The oldest grace key is no longer advertised but still verifies
+	_, err = validator.ValidateToken(context.Background(), oldToken)
+	assert.NoError(t, err)
+	status, ok := km.GetKeyStatus(oldestKid)
+	require.True(t, ok)
+	assert.True(t, status.InGrace)
+}
+
+func TestMaxAdvertisedKeys_PrerolledKeyAfterPreviousKey(t *testing.T) {
+	km := createTestKeyManager(t)
+	require.NoError(t, km.RotateKeys(time.Hour))
+	previousKid := km.GetCurrentKeyID()
+	require.NoError(t, km.RotateKeys(time.Hour))
+	currentKid := km.GetCurrentKeyID()
+	require.NoError(t, km.PrerollKeys())
+	require.Len(t, jwksKeyIDs(t, km), 4, "unlimited by default")
+
+	km.SetMaxAdvertisedKeys(3)
+	kids := jwksKeyIDs(t, km)
+	require.Len(t, kids, 4, "the pre-rolled key is not counted against the cap")
+	assert.Contains(t, kids, currentKid)
+	assert.Contains(t, kids, previousKid)
+}
+
+func TestMaxAdvertisedKeys_PrerolledKeyAlwaysAdvertised(t *testing.T) {
+	km := createTestKeyManager(t)
+	km.SetMaxAdvertisedKeys(2)
+	require.NoError(t, km.RotateKeys(time.Hour))
+	previousKid := km.GetCurrentKeyID()
+	require.NoError(t, km.RotateKeys(time.Hour))
+	currentKid := km.GetCurrentKeyID()
+	require.NoError(t, km.PrerollKeys())
+
+	kids := jwksKeyIDs(t, km)
+	require.Len(t, kids, 3)
+	assert.Contains(t, kids, currentKid)
+	assert.Contains(t, kids, previousKid)
+
+	require.NoError(t, km.RotateKeys(time.Hour))
+	assert.Contains(t, kids, km.GetCurrentKeyID(), "the key that takes over was published before it signed")
+}
@@ -0,0 +1,135 @@
+package auth_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// verifyAgainstJWKS verifies tokenString using only the key manager's published JWKS.
+func verifyAgainstJWKS(t *testing.T, km *auth.KeyManager, tokenString string) *jwt.Token {
+	t.Helper()
+
+	set := km.GetJWKSet()
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := set.LookupKeyID(kid)
+		if !ok {
+			return nil, fmt.Errorf("kid %s not in JWKS", kid)
+		}
+		if key.Algorithm().String() != token.Method.Alg() {
+			return nil, fmt.Errorf("alg mismatch")
+		}
+		var raw interface{}
+		if err := key.Raw(&raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	})
+	require.NoError(t, err)
+	return token
+}
+
+func TestMultipleSigningAlgorithms_PerClientSelection(t *testing.T) {
+	km := createTestKeyManager(t)
+	require.NoError(t, km.AddAlgorithm(auth.AlgES256))
+	assert.Equal(t, []string{auth.AlgES256, auth.AlgRS256}, km.Algorithms())
+
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	subject := &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}
+
+	legacyClient := &models.Client{ClientID: "legacy-client"}
+	newClient := &models.Client{ClientID: "new-client", SigningAlg: auth.AlgES256}
+
+	legacyToken, _, err := tg.GenerateAccessTokenWithAlgorithm(subject, time.Hour, legacyClient.SigningAlg)
+	require.NoError(t, err)
+	newToken, _, err := tg.GenerateAccessTokenWithAlgorithm(subject, time.Hour, newClient.SigningAlg)
+	require.NoError(t, err)
+
+	legacy := verifyAgainstJWKS(t, km, legacyToken)
+	modern := verifyAgainstJWKS(t, km, newToken)
+	assert.Equal(t, auth.AlgRS256, legacy.Method.Alg())
+	assert.Equal(t, auth.AlgES256, modern.Method.Alg())
+	assert.NotEqual(t, legacy.Header["kid"], modern.Header["kid"])
+
+	mockCache := new(mocks.MockCache)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	tv := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+
+	_, err = tv.ValidateToken(context.Background(), legacyToken)
+	assert.NoError(t, err)
+	_, err = tv.ValidateToken(context.Background(), newToken)
+	assert.NoError(t, err)
+}
+
+func TestMultipleSigningAlgorithms_UnknownAlgorithmFails(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+
+	_, _, err := tg.GenerateAccessTokenWithAlgorithm(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}, time.Hour, auth.AlgES256)
+	assert.Error(t, err)
+}
+
+func TestMultipleSigningAlgorithms_RotationRotatesEveryAlgorithm(t *testing.T) {
+	km := createTestKeyManager(t)
+	require.NoError(t, km.AddAlgorithm(auth.AlgES256))
+
+	rsaBefore, err := km.GetSigningKey(auth.AlgRS256)
+	require.NoError(t, err)
+	ecBefore, err := km.GetSigningKey(auth.AlgES256)
+	require.NoError(t, err)
+
+	require.NoError(t, km.RotateKeys(time.Hour))
+
+	rsaAfter, err := km.GetSigningKey(auth.AlgRS256)
+	require.NoError(t, err)
+	ecAfter, err := km.GetSigningKey(auth.AlgES256)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, rsaBefore.KeyID, rsaAfter.KeyID)
+	assert.NotEqual(t, ecBefore.KeyID, ecAfter.KeyID)
+	assert.Equal(t, auth.AlgES256, ecAfter.Algorithm)
+	assert.Equal(t, 4, km.GetJWKSet().Len())
+}
+
+func TestValidateToken_RejectsAlgorithmNotMatchingKey(t *testing.T) {
+	km := createTestKeyManager(t)
+	require.NoError(t, km.AddAlgorithm(auth.AlgES256))
+	ecKey, err := km.GetSigningKey(auth.AlgES256)
+	require.NoError(t, err)
+
+	// An ES256 signature presented under the RS256 key's kid must not verify.
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": "issuer",
+		"aud": "audience",
+		"sub": "user-123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = km.GetCurrentKeyID()
+	signed, err := token.SignedString(ecKey.PrivateKey)
+	require.NoError(t, err)
+
+	tv := auth.NewTokenValidator(km, "issuer", "audience", new(mocks.MockCache))
+	_, err = tv.ValidateToken(context.Background(), signed)
+	assert.Error(t, err)
+}
+
+func TestKeyManager_RotatingClearedAfterRotation(t *testing.T) {
+	km := createTestKeyManager(t)
+	assert.False(t, km.Rotating())
+
+	require.NoError(t, km.RotateKeys(time.Hour))
+
+	assert.False(t, km.Rotating(), "the rotation flag must clear as soon as RotateKeys returns")
+}
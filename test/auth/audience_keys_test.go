@@ -0,0 +1,109 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// verifyAgainstAudienceJWKS verifies tokenString using only audience's JWKS.
+func verifyAgainstAudienceJWKS(km *auth.KeyManager, audience, tokenString string) (*jwt.Token, error) {
+	set := km.GetJWKSetForAudience("", "", audience)
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := set.LookupKeyID(kid)
+		if !ok {
+			return nil, assert.AnError
+		}
+		var raw interface{}
+		if err := key.Raw(&raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	})
+}
+
+func TestAudienceKeys_SignedAndPublishedPerAudience(t *testing.T) {
+	km := createTestKeyManager(t)
+	require.NoError(t, km.AddAudienceKeys("https://payments.example.com"))
+	require.NoError(t, km.AddAudienceKeys("https://orders.example.com"))
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+
+	issue := func(audience string) string {
+		token, _, err := tg.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Audiences: []string{audience}})
+		require.NoError(t, err)
+		return token
+	}
+	payments, orders, api := issue("https://payments.example.com"), issue("https://orders.example.com"), issue("audience")
+
+	paymentsToken, err := verifyAgainstAudienceJWKS(km, "https://payments.example.com", payments)
+	require.NoError(t, err)
+	ordersToken, err := verifyAgainstAudienceJWKS(km, "https://orders.example.com", orders)
+	require.NoError(t, err)
+	apiToken, err := verifyAgainstAudienceJWKS(km, "", api)
+	require.NoError(t, err)
+	assert.NotEqual(t, paymentsToken.Header["kid"], ordersToken.Header["kid"])
+	assert.NotEqual(t, paymentsToken.Header["kid"], apiToken.Header["kid"])
+	assert.Equal(t, km.GetCurrentKeyID(), apiToken.Header["kid"])
+
+	// No audience's keys verify another audience's tokens
+	_, err = verifyAgainstAudienceJWKS(km, "https://orders.example.com", payments)
+	assert.Error(t, err)
+	_, err = verifyAgainstAudienceJWKS(km, "", payments)
+	assert.Error(t, err)
+	_, err = verifyAgainstAudienceJWKS(km, "https://payments.example.com", api)
+	assert.Error(t, err)
+
+	mockCache := new(mocks.MockCache)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	tv := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+	tv.EnablePreviousAudiences([]string{"https://payments.example.com", "https://orders.example.com"})
+	for _, token := range []string{payments, orders, api} {
+		_, err := tv.ValidateToken(context.Background(), token)
+		assert.NoError(t, err)
+	}
+}
+
+func TestAudienceKeys_MixedAudiencesRejected(t *testing.T) {
+	km := createTestKeyManager(t)
+	require.NoError(t, km.AddAudienceKeys("https://payments.example.com"))
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+
+	_, _, err := tg.GenerateAccessToken(&models.TokenSubject{
+		UserID:    "user-123",
+		TenantID:  "tenant-abc",
+		Audiences: []string{"https://payments.example.com", "audience"},
+	})
+	assert.Error(t, err)
+}
+
+func TestAudienceKeys_RotateWithKeyManager(t *testing.T) {
+	km := createTestKeyManager(t)
+	require.NoError(t, km.AddAlgorithm(auth.AlgES256))
+	require.NoError(t, km.AddAudienceKeys("https://payments.example.com"))
+
+	before, err := km.GetSigningKeyForAudiences(auth.AlgES256, []string{"https://payments.example.com"})
+	require.NoError(t, err)
+
+	require.NoError(t, km.RotateKeys(time.Hour))
+
+	after, err := km.GetSigningKeyForAudiences(auth.AlgES256, []string{"https://payments.example.com"})
+	require.NoError(t, err)
+	assert.NotEqual(t, before.KeyID, after.KeyID)
+	assert.Equal(t, auth.AlgES256, after.Algorithm)
+
+	status, ok := km.GetKeyStatus(before.KeyID)
+	require.True(t, ok)
+	assert.True(t, status.InGrace)
+	assert.Equal(t, 4, km.GetJWKSetForAudience("", "", "https://payments.example.com").Len())
+}
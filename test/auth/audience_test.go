@@ -0,0 +1,151 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateToken_PreviousAudiences(t *testing.T) {
+	km := createTestKeyManager(t)
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	cacheMock.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+
+	sign := func(t *testing.T, aud interface{}) string {
+		t.Helper()
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss": "issuer",
+			"aud": aud,
+			"sub": "user-123",
+			"tid": "tenant-abc",
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = km.GetCurrentKeyID()
+		signed, err := token.SignedString(km.GetPrivateKey())
+		require.NoError(t, err)
+		return signed
+	}
+
+	tests := []struct {
+		name      string
+		aud       interface{}
+		previous  []string
+		wantValid bool
+	}{
+		{name: "current audience", aud: "new-api", wantValid: true},
+		{name: "previous audience not configured", aud: "old-api", wantValid: false},
+		{name: "previous audience", aud: "old-api", previous: []string{"old-api"}, wantValid: true},
+		{name: "array with current audience", aud: []string{"other-api", "new-api"}, wantValid: true},
+		{name: "array with previous audience", aud: []string{"old-api", "other-api"}, previous: []string{"old-api"}, wantValid: true},
+		{name: "unknown audience", aud: []string{"other-api"}, previous: []string{"old-api"}, wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := auth.NewTokenValidator(km, "issuer", "new-api", cacheMock)
+			if tt.previous != nil {
+				validator.EnablePreviousAudiences(tt.previous)
+			}
+
+			_, err := validator.ValidateToken(context.Background(), sign(t, tt.aud))
+
+			if tt.wantValid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "invalid audience")
+			}
+		})
+	}
+}
+
+func TestValidateToken_ScopeAudiences(t *testing.T) {
+	km := createTestKeyManager(t)
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	cacheMock.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	generator := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+	validator.EnableScopeAudiences([]string{"https://payments.example.com"}, true)
+
+	tests := []struct {
+		name            string
+		audiences       []string
+		authorizedParty string
+		wantValid       bool
+	}{
+		{name: "scope audience", audiences: []string{"https://payments.example.com"}, wantValid: true},
+		{name: "client audience", audiences: []string{"my-client"}, authorizedParty: "my-client", wantValid: true},
+		{name: "client audience without azp", audiences: []string{"my-client"}, wantValid: false},
+		{name: "client audience for another azp", audiences: []string{"my-client"}, authorizedParty: "other-client", wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _, err := generator.GenerateAccessToken(&models.TokenSubject{
+				UserID:          "user-123",
+				TenantID:        "tenant-abc",
+				Audiences:       tt.audiences,
+				AuthorizedParty: tt.authorizedParty,
+			})
+			require.NoError(t, err)
+
+			_, err = validator.ValidateToken(context.Background(), token)
+
+			if tt.wantValid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "invalid audience")
+			}
+		})
+	}
+}
+
+func TestAudienceList(t *testing.T) {
+	km := createTestKeyManager(t)
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	cacheMock.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	subject := &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}
+
+	single, _, err := auth.NewTokenGenerator(km, "issuer", "api", time.Hour, 32).GenerateAccessToken(subject)
+	require.NoError(t, err)
+	assert.Equal(t, "api", verifyAgainstJWKS(t, km, single).Claims.(jwt.MapClaims)["aud"])
+
+	multiple, _, err := auth.NewTokenGenerator(km, "issuer", "api, internal-bff", time.Hour, 32).GenerateAccessToken(subject)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"api", "internal-bff"}, verifyAgainstJWKS(t, km, multiple).Claims.(jwt.MapClaims)["aud"])
+
+	tests := []struct {
+		name      string
+		audience  string
+		wantValid bool
+	}{
+		{name: "first audience", audience: "api", wantValid: true},
+		{name: "second audience", audience: "internal-bff", wantValid: true},
+		{name: "validator with a list", audience: "other-api,internal-bff", wantValid: true},
+		{name: "no shared audience", audience: "other-api", wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := auth.NewTokenValidator(km, "issuer", tt.audience, cacheMock).ValidateToken(context.Background(), multiple)
+
+			if tt.wantValid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "invalid audience")
+			}
+		})
+	}
+}
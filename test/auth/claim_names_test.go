@@ -0,0 +1,66 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var customClaimNames = auth.ClaimNames{Roles: "groups", Scopes: "scope", Tenant: "https://example.com/tenant"}
+
+func TestClaimNames_EmitsAndReadsMappedClaims(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tg.EnableClaimNames(customClaimNames)
+
+	mockCache := new(mocks.MockCache)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	tv := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+	tv.EnableClaimNames(customClaimNames)
+
+	token, _, err := tg.GenerateAccessToken(&models.TokenSubject{
+		UserID:   "user-123",
+		TenantID: "tenant-abc",
+		Roles:    []string{"tenant-admin", "reader"},
+		Scopes:   []string{"read"},
+	})
+	require.NoError(t, err)
+
+	claims := verifyAgainstJWKS(t, km, token).Claims.(jwt.MapClaims)
+	assert.Equal(t, []interface{}{"tenant-admin", "reader"}, claims["groups"])
+	assert.Equal(t, []interface{}{"read"}, claims["scope"])
+	assert.Equal(t, "tenant-abc", claims["https://example.com/tenant"])
+	for _, name := range []string{"roles", "scp", "tid"} {
+		assert.NotContains(t, claims, name)
+	}
+
+	validated, err := tv.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tenant-admin", "reader"}, tv.Roles(validated))
+	assert.Equal(t, "tenant-abc", tv.TenantID(validated))
+}
+
+func TestClaimNames_DefaultsUnchanged(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tv := auth.NewTokenValidator(km, "issuer", "audience", new(mocks.MockCache))
+
+	token, _, err := tg.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Roles: []string{"reader"}})
+	require.NoError(t, err)
+
+	claims := verifyAgainstJWKS(t, km, token).Claims.(jwt.MapClaims)
+	assert.Equal(t, []interface{}{"reader"}, claims["roles"])
+	assert.Equal(t, "tenant-abc", claims["tid"])
+	assert.Equal(t, []string{"reader"}, tv.Roles(claims))
+	assert.Equal(t, "tenant-abc", tv.TenantID(claims))
+}
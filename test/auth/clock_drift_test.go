@@ -0,0 +1,98 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/metrics"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// signWithTimes signs a token with the key manager's current key and the given exp/nbf.
+func signWithTimes(t *testing.T, km *auth.KeyManager, exp, nbf time.Time) string {
+	t.Helper()
+
+	claims := jwt.MapClaims{
+		"iss": "issuer",
+		"aud": "audience",
+		"iat": time.Now().Unix(),
+		"exp": exp.Unix(),
+		"nbf": nbf.Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = km.GetCurrentKeyID()
+	signed, err := token.SignedString(km.GetPrivateKey())
+	require.NoError(t, err)
+	return signed
+}
+
+func newDriftValidator(t *testing.T) (*auth.KeyManager, *auth.TokenValidator, *observer.ObservedLogs) {
+	t.Helper()
+
+	km := createTestKeyManager(t)
+	core, logs := observer.New(zapcore.WarnLevel)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", &mocks.MockCache{})
+	validator.EnableClockDriftWarnings(30*time.Second, zap.New(core))
+	return km, validator, logs
+}
+
+func TestValidateToken_ClockDriftWarnings(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		exp, nbf time.Time
+		reason   string
+		warns    bool
+	}{
+		{"just expired", now.Add(-5 * time.Second), now.Add(-time.Hour), "expired", true},
+		{"long expired", now.Add(-time.Hour), now.Add(-2 * time.Hour), "expired", false},
+		{"just not yet valid", now.Add(time.Hour), now.Add(5 * time.Second), "not_yet_valid", true},
+		{"far from valid", now.Add(2 * time.Hour), now.Add(time.Hour), "not_yet_valid", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, validator, logs := newDriftValidator(t)
+			before := testutil.ToFloat64(metrics.ClockDriftSuspected.WithLabelValues(tt.reason))
+
+			_, err := validator.ValidateToken(context.Background(), signWithTimes(t, km, tt.exp, tt.nbf))
+			require.Error(t, err, "acceptance must not change")
+
+			after := testutil.ToFloat64(metrics.ClockDriftSuspected.WithLabelValues(tt.reason))
+			if !tt.warns {
+				assert.Equal(t, 0, logs.Len())
+				assert.Equal(t, before, after)
+				return
+			}
+
+			require.Equal(t, 1, logs.Len())
+			entry := logs.All()[0]
+			assert.Equal(t, tt.reason, entry.ContextMap()["reason"])
+			skew := entry.ContextMap()["observed_skew"].(time.Duration)
+			assert.InDelta(t, 5*time.Second, skew, float64(2*time.Second))
+			assert.Equal(t, before+1, after)
+		})
+	}
+}
+
+func TestValidateToken_ClockDriftWarningsDisabledByDefault(t *testing.T) {
+	km := createTestKeyManager(t)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", &mocks.MockCache{})
+	before := testutil.ToFloat64(metrics.ClockDriftSuspected.WithLabelValues("expired"))
+
+	_, err := validator.ValidateToken(context.Background(), signWithTimes(t, km, time.Now().Add(-5*time.Second), time.Now().Add(-time.Hour)))
+
+	assert.Error(t, err)
+	assert.Equal(t, before, testutil.ToFloat64(metrics.ClockDriftSuspected.WithLabelValues("expired")))
+}
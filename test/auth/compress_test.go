@@ -0,0 +1,84 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func manyRoles(n int) []string {
+	roles := make([]string, n)
+	for i := range roles {
+		roles[i] = fmt.Sprintf("projects:%04d:contributor", i)
+	}
+	return roles
+}
+
+func TestCompressRoles_RoundTrip(t *testing.T) {
+	roles := manyRoles(500)
+
+	compressed, err := auth.CompressRoles(roles)
+	require.NoError(t, err)
+	plain, err := json.Marshal(roles)
+	require.NoError(t, err)
+	assert.Less(t, len(compressed), len(plain)/2)
+
+	decompressed, err := auth.DecompressRoles(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, roles, decompressed)
+}
+
+func TestDecompressRoles_RejectsInvalidClaims(t *testing.T) {
+	for _, claim := range []string{"not base64!", "bm90IGRlZmxhdGU"} {
+		_, err := auth.DecompressRoles(claim)
+		assert.Error(t, err, claim)
+	}
+}
+
+func TestRoleCompression_ValidatorReadsCompressedRoles(t *testing.T) {
+	km := createTestKeyManager(t)
+	generator := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	generator.EnableRoleCompression(100)
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	cacheMock.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+
+	roles := manyRoles(300)
+	token, _, err := generator.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Roles: roles})
+	require.NoError(t, err)
+
+	claims, err := validator.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.NotContains(t, claims, "roles")
+	assert.Contains(t, claims, "roles_z")
+	assert.Equal(t, roles, validator.Roles(claims))
+}
+
+func TestRoleCompression_SmallRoleSetsStayPlain(t *testing.T) {
+	km := createTestKeyManager(t)
+	generator := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	generator.EnableRoleCompression(100)
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	cacheMock.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+
+	token, _, err := generator.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Roles: manyRoles(100)})
+	require.NoError(t, err)
+
+	claims, err := validator.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.NotContains(t, claims, "roles_z")
+	assert.Len(t, validator.Roles(claims), 100)
+}
@@ -0,0 +1,58 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateToken_CurrentKeyScopes(t *testing.T) {
+	km := createTestKeyManager(t)
+	generator := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	cacheMock.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+	validator.EnableCurrentKeyScopes([]string{"payments.write"})
+
+	issue := func(t *testing.T, scopes ...string) string {
+		t.Helper()
+		token, _, err := generator.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Scopes: scopes})
+		require.NoError(t, err)
+		return token
+	}
+
+	graceNormal := issue(t, "profile.read")
+	graceProtected := issue(t, "profile.read", "payments.write")
+	require.NoError(t, km.RotateKeys(time.Hour))
+	currentProtected := issue(t, "payments.write")
+
+	t.Run("grace key, normal scope", func(t *testing.T) {
+		_, err := validator.ValidateToken(context.Background(), graceNormal)
+		assert.NoError(t, err)
+	})
+
+	t.Run("grace key, protected scope", func(t *testing.T) {
+		_, err := validator.ValidateToken(context.Background(), graceProtected)
+		assert.ErrorIs(t, err, auth.ErrRequiresCurrentKey)
+	})
+
+	t.Run("current key, protected scope", func(t *testing.T) {
+		_, err := validator.ValidateToken(context.Background(), currentProtected)
+		assert.NoError(t, err)
+	})
+
+	t.Run("grace key, protected scope, policy disabled", func(t *testing.T) {
+		unrestricted := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+		_, err := unrestricted.ValidateToken(context.Background(), graceProtected)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,162 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSecretCipher(t *testing.T) *auth.SecretCipher {
+	t.Helper()
+	cipher, err := auth.NewSecretCipher(bytes.Repeat([]byte{0x42}, 32))
+	require.NoError(t, err)
+	return cipher
+}
+
+// hmacTenant returns tenant-abc configured with secret, encrypted by cipher.
+func hmacTenant(t *testing.T, cipher *auth.SecretCipher, secret []byte) *models.Tenant {
+	t.Helper()
+	encrypted, err := cipher.Encrypt(secret)
+	require.NoError(t, err)
+	return &models.Tenant{ID: "tenant-abc", EncryptedHMACSecret: encrypted}
+}
+
+func noTenantLoader(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	return nil, nil
+}
+
+func TestSecretCipher_RoundTrip(t *testing.T) {
+	cipher := newTestSecretCipher(t)
+
+	encrypted, err := cipher.Encrypt([]byte("shared-secret"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "shared-secret")
+
+	decrypted, err := cipher.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "shared-secret", string(decrypted))
+
+	other, err := auth.NewSecretCipher(bytes.Repeat([]byte{0x24}, 32))
+	require.NoError(t, err)
+	_, err = other.Decrypt(encrypted)
+	assert.Error(t, err, "a different key must not decrypt the secret")
+}
+
+func TestNewSecretCipher_RejectsShortKey(t *testing.T) {
+	_, err := auth.NewSecretCipher([]byte("too-short"))
+	assert.Error(t, err)
+}
+
+func TestHMACTenant_IssueAndValidate(t *testing.T) {
+	km := createTestKeyManager(t)
+	cipher := newTestSecretCipher(t)
+	secret, err := auth.GenerateHMACSecret()
+	require.NoError(t, err)
+	tenant := hmacTenant(t, cipher, secret)
+
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tg.EnableHMACTenants(cipher)
+	subject := &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}
+
+	token, _, err := tg.GenerateTenantAccessToken(subject, time.Hour, "", tenant)
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, auth.AlgHS256, parsed.Method.Alg())
+	assert.NotContains(t, parsed.Header, "kid", "HMAC secrets are not published, so there is no kid")
+
+	// The tenant holding the secret can verify the token itself
+	_, err = jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return secret, nil })
+	require.NoError(t, err)
+
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("GetTenant", mock.Anything, "tenant-abc").Return(tenant, nil)
+	cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	cacheMock.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+	validator.EnableHMACTenants(cipher, noTenantLoader)
+
+	claims, err := validator.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-abc", claims["tid"])
+
+	// The JWKS only ever carries the asymmetric keys
+	for _, alg := range []string{"", auth.AlgHS256} {
+		set := km.GetJWKSetFiltered("", alg)
+		for i := 0; i < set.Len(); i++ {
+			key, _ := set.Key(i)
+			assert.NotEqual(t, auth.AlgHS256, key.Algorithm().String())
+		}
+	}
+}
+
+func TestHMACTenant_OtherTenantsKeepAsymmetricTokens(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tg.EnableHMACTenants(newTestSecretCipher(t))
+
+	token, _, err := tg.GenerateTenantAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-xyz"}, time.Hour, "", &models.Tenant{ID: "tenant-xyz"})
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, auth.AlgRS256, parsed.Method.Alg())
+}
+
+func TestHMACTenant_RejectsHS256ForTenantWithoutSecret(t *testing.T) {
+	km := createTestKeyManager(t)
+	cipher := newTestSecretCipher(t)
+
+	claims := jwt.MapClaims{
+		"iss": "issuer",
+		"aud": "audience",
+		"sub": "user-123",
+		"tid": "tenant-xyz",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("guessed-secret"))
+	require.NoError(t, err)
+
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("GetTenant", mock.Anything, "tenant-xyz").Return(&models.Tenant{ID: "tenant-xyz"}, nil)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+	validator.EnableHMACTenants(cipher, noTenantLoader)
+
+	_, err = validator.ValidateToken(context.Background(), forged)
+	assert.Error(t, err)
+}
+
+func TestHMACTenant_DisabledValidatorRejectsHS256(t *testing.T) {
+	km := createTestKeyManager(t)
+	cipher := newTestSecretCipher(t)
+	tenant := hmacTenant(t, cipher, []byte("shared-secret"))
+
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tg.EnableHMACTenants(cipher)
+	token, _, err := tg.GenerateTenantAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}, time.Hour, "", tenant)
+	require.NoError(t, err)
+
+	validator := auth.NewTokenValidator(km, "issuer", "audience", &mocks.MockCache{})
+	_, err = validator.ValidateToken(context.Background(), token)
+	assert.Error(t, err)
+}
+
+func TestHMACTenant_GeneratorWithoutCipherFails(t *testing.T) {
+	km := createTestKeyManager(t)
+	tenant := hmacTenant(t, newTestSecretCipher(t), []byte("shared-secret"))
+
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	_, _, err := tg.GenerateTenantAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}, time.Hour, "", tenant)
+	assert.Error(t, err, "an HS256 tenant must never silently fall back to the shared keys")
+}
@@ -0,0 +1,55 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateIDToken_VerifiesAgainstJWKS(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+
+	idToken, err := tg.GenerateIDToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Roles: []string{"admin"}}, "my-client", "abc")
+	require.NoError(t, err)
+
+	parsed := verifyAgainstJWKS(t, km, idToken)
+	assert.Equal(t, auth.IDTokenType, parsed.Header["typ"])
+	claims := parsed.Claims.(jwt.MapClaims)
+	assert.Equal(t, "issuer", claims["iss"])
+	assert.Equal(t, "my-client", claims["aud"])
+	assert.Equal(t, "abc", claims["nonce"])
+	assert.Equal(t, claims["iat"], claims["auth_time"])
+	assert.NotContains(t, claims, "roles")
+}
+
+func TestGenerateIDToken_NotAcceptedAsAccessToken(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "my-client", time.Hour, 32)
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	cacheMock.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	// The validator's audience is the client's ID, which the ID token has
+	// as its aud
+	tv := auth.NewTokenValidator(km, "issuer", "my-client", cacheMock)
+	subject := &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}
+
+	idToken, err := tg.GenerateIDToken(subject, "my-client", "")
+	require.NoError(t, err)
+	_, err = tv.ValidateToken(context.Background(), idToken)
+	assert.Error(t, err)
+
+	accessToken, _, err := tg.GenerateAccessToken(subject)
+	require.NoError(t, err)
+	_, err = tv.ValidateToken(context.Background(), accessToken)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,48 @@
+package auth_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+
+	"github.com/lestrrat-go/jwx/v2/jwe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptToken_DecryptsToSignedJWT(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	signed, _, err := tg.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Roles: []string{"reader"}})
+	require.NoError(t, err)
+
+	// The resource server's key pair; we only ever see the public half.
+	rsPrivPEM, rsPubPEM := generateTestPEMKeys(t)
+
+	encrypted, err := auth.EncryptToken(signed, rsPubPEM)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(encrypted, "."), 5, "compact JWE has five parts")
+	assert.NotContains(t, encrypted, strings.Split(signed, ".")[1], "claims must not be readable")
+
+	msg, err := jwe.Parse([]byte(encrypted))
+	require.NoError(t, err)
+	assert.Equal(t, "JWT", msg.ProtectedHeaders().ContentType())
+
+	block, _ := pem.Decode([]byte(rsPrivPEM))
+	rsKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	require.NoError(t, err)
+
+	decrypted, err := jwe.Decrypt([]byte(encrypted), jwe.WithKey(auth.TokenKeyEncryption, rsKey))
+	require.NoError(t, err)
+	assert.Equal(t, signed, string(decrypted))
+}
+
+func TestEncryptToken_InvalidKey(t *testing.T) {
+	_, err := auth.EncryptToken("a.b.c", "not a pem key")
+	assert.Error(t, err)
+}
@@ -0,0 +1,273 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"sync"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/helpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memKeyStore is an in-memory auth.KeyStore.
+type memKeyStore struct {
+	mu   sync.Mutex
+	keys map[string]models.SigningKey
+}
+
+func newMemKeyStore() *memKeyStore {
+	return &memKeyStore{keys: make(map[string]models.SigningKey)}
+}
+
+func (s *memKeyStore) LoadSigningKeys(_ context.Context, fingerprint string) ([]*models.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []*models.SigningKey
+	for _, key := range s.keys {
+		key := key
+		if key.ExpiresAt.IsZero() || key.ExpiresAt.After(time.Now()) || key.Fingerprint == fingerprint {
+			keys = append(keys, &key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *memKeyStore) SaveSigningKey(_ context.Context, key *models.SigningKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[key.KeyID]; !ok {
+		s.keys[key.KeyID] = *key
+	}
+	return nil
+}
+
+func (s *memKeyStore) MarkKeyExpired(_ context.Context, keyID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.keys[keyID]
+	key.ExpiresAt = expiresAt
+	s.keys[keyID] = key
+	return nil
+}
+
+func (s *memKeyStore) get(keyID string) (models.SigningKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[keyID]
+	return key, ok
+}
+
+func newTestKeyCipher(t *testing.T) *auth.SecretCipher {
+	t.Helper()
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	keyCipher, err := auth.NewSecretCipher(key)
+	require.NoError(t, err)
+	return keyCipher
+}
+
+// restartKeyManager creates a key manager from the configured PEM pair and
+// restores it from store, as the service does at startup.
+func restartKeyManager(t *testing.T, privPEM, pubPEM string, store auth.KeyStore, keyCipher *auth.SecretCipher) *auth.KeyManager {
+	t.Helper()
+	km, err := auth.NewKeyManager(privPEM, pubPEM)
+	require.NoError(t, err)
+	require.NoError(t, km.EnableKeyPersistence(context.Background(), store, keyCipher, time.Hour))
+	return km
+}
+
+func TestKeyPersistence_SeedKeyIDSurvivesRestart(t *testing.T) {
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	store, keyCipher := newMemKeyStore(), newTestKeyCipher(t)
+
+	first := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+	stored, ok := store.get(first.GetCurrentKeyID())
+	require.True(t, ok, "the seed key is stored on first start")
+	assert.Equal(t, auth.AlgRS256, stored.Algorithm)
+	assert.True(t, stored.ExpiresAt.IsZero())
+
+	second := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+	assert.Equal(t, first.GetCurrentKeyID(), second.GetCurrentKeyID())
+	assert.Equal(t, []string{first.GetCurrentKeyID()}, jwksKeyIDs(t, second))
+}
+
+func TestKeyPersistence_RotatedKeysSurviveRestart(t *testing.T) {
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	store, keyCipher := newMemKeyStore(), newTestKeyCipher(t)
+	subject := &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}
+
+	before := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+	seedKeyID := before.GetCurrentKeyID()
+	oldToken, _, err := auth.NewTokenGenerator(before, "issuer", "audience", time.Hour, 32).GenerateAccessToken(subject)
+	require.NoError(t, err)
+	require.NoError(t, before.RotateKeys(time.Hour))
+	require.NoError(t, before.PersistKeys(context.Background()))
+	rotatedKeyID := before.GetCurrentKeyID()
+	newToken, _, err := auth.NewTokenGenerator(before, "issuer", "audience", time.Hour, 32).GenerateAccessToken(subject)
+	require.NoError(t, err)
+
+	stored, ok := store.get(seedKeyID)
+	require.True(t, ok)
+	assert.False(t, stored.ExpiresAt.IsZero(), "the retired key's grace expiry is stored")
+
+	after := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+
+	assert.Equal(t, rotatedKeyID, after.GetCurrentKeyID(), "the rotated key keeps signing rather than the configured one")
+	assert.ElementsMatch(t, []string{seedKeyID, rotatedKeyID}, jwksKeyIDs(t, after))
+	status, ok := after.GetKeyStatus(seedKeyID)
+	require.True(t, ok)
+	assert.True(t, status.InGrace)
+	assert.WithinDuration(t, stored.ExpiresAt, status.ExpiresAt, time.Second)
+	verifyAgainstJWKS(t, after, oldToken)
+	verifyAgainstJWKS(t, after, newToken)
+}
+
+func TestKeyPersistence_RestartAfterSeedKeyExpired(t *testing.T) {
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	store, keyCipher := newMemKeyStore(), newTestKeyCipher(t)
+
+	before := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+	seedKeyID := before.GetCurrentKeyID()
+	require.NoError(t, before.RotateKeys(time.Millisecond))
+	require.NoError(t, before.PersistKeys(context.Background()))
+	rotatedKeyID := before.GetCurrentKeyID()
+	time.Sleep(5 * time.Millisecond)
+
+	after := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+
+	assert.Equal(t, rotatedKeyID, after.GetCurrentKeyID(), "the expired seed key does not sign again")
+	assert.Equal(t, []string{rotatedKeyID}, jwksKeyIDs(t, after))
+	_, ok := after.GetKeyStatus(seedKeyID)
+	assert.False(t, ok)
+	stored, ok := store.get(rotatedKeyID)
+	require.True(t, ok)
+	assert.True(t, stored.ExpiresAt.IsZero(), "the rotated key is not retired")
+}
+
+func TestKeyPersistence_RetiredSeedKeyNotCurrentAgain(t *testing.T) {
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	store, keyCipher := newMemKeyStore(), newTestKeyCipher(t)
+
+	before := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+	seedKeyID := before.GetCurrentKeyID()
+	seedKey := before.GetPrivateKey()
+	require.NoError(t, before.RotateKeys(time.Millisecond))
+	require.NoError(t, before.PersistKeys(context.Background()))
+	// The key that took over has since expired as well
+	require.NoError(t, store.MarkKeyExpired(context.Background(), before.GetCurrentKeyID(), time.Now().Add(-time.Second)))
+	time.Sleep(5 * time.Millisecond)
+
+	after := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+
+	assert.NotEqual(t, seedKeyID, after.GetCurrentKeyID())
+	assert.False(t, seedKey.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(after.GetPrivateKey().Public()),
+		"the retired seed key does not sign again")
+	stored, ok := store.get(after.GetCurrentKeyID())
+	require.True(t, ok, "the replacement key is stored")
+	assert.True(t, stored.ExpiresAt.IsZero())
+	assert.NotEmpty(t, stored.Fingerprint)
+
+	again := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+	assert.Equal(t, after.GetCurrentKeyID(), again.GetCurrentKeyID())
+}
+
+func TestKeyPersistence_PrerolledKeyRestoredAsNext(t *testing.T) {
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	store, keyCipher := newMemKeyStore(), newTestKeyCipher(t)
+
+	before := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+	currentKeyID := before.GetCurrentKeyID()
+	require.NoError(t, before.PrerollKeys())
+	require.NoError(t, before.PersistKeys(context.Background()))
+	keyIDs := jwksKeyIDs(t, before)
+	require.Len(t, keyIDs, 2)
+
+	after := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+	assert.Equal(t, currentKeyID, after.GetCurrentKeyID())
+	assert.ElementsMatch(t, keyIDs, jwksKeyIDs(t, after))
+
+	require.NoError(t, after.RotateKeys(time.Hour))
+	assert.Contains(t, keyIDs, after.GetCurrentKeyID(), "the restored pre-rolled key takes over at the rotation")
+	assert.NotEqual(t, currentKeyID, after.GetCurrentKeyID())
+}
+
+func TestKeyPersistence_ReplacedConfiguredKey(t *testing.T) {
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	store, keyCipher := newMemKeyStore(), newTestKeyCipher(t)
+	before := restartKeyManager(t, privPEM, pubPEM, store, keyCipher)
+	oldKeyID := before.GetCurrentKeyID()
+
+	newPrivPEM, newPubPEM := helpers.GenerateTestPEMKeys(t)
+	after := restartKeyManager(t, newPrivPEM, newPubPEM, store, keyCipher)
+
+	assert.NotEqual(t, oldKeyID, after.GetCurrentKeyID(), "the newly configured key signs")
+	status, ok := after.GetKeyStatus(oldKeyID)
+	require.True(t, ok)
+	assert.True(t, status.InGrace)
+	stored, ok := store.get(oldKeyID)
+	require.True(t, ok)
+	assert.False(t, stored.ExpiresAt.IsZero())
+	_, ok = store.get(after.GetCurrentKeyID())
+	assert.True(t, ok)
+}
+
+func TestKeyPersistence_AudienceKeysSurviveRestart(t *testing.T) {
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	store, keyCipher := newMemKeyStore(), newTestKeyCipher(t)
+	start := func() *auth.KeyManager {
+		km, err := auth.NewKeyManager(privPEM, pubPEM)
+		require.NoError(t, err)
+		require.NoError(t, km.AddAudienceKeys("payments"))
+		require.NoError(t, km.EnableKeyPersistence(context.Background(), store, keyCipher, time.Hour))
+		return km
+	}
+
+	before := start()
+	key, err := before.GetSigningKeyForAudiences("", []string{"payments"})
+	require.NoError(t, err)
+	stored, ok := store.get(key.KeyID)
+	require.True(t, ok)
+	assert.Equal(t, "payments", stored.Audience)
+
+	after := start()
+	restored, err := after.GetSigningKeyForAudiences("", []string{"payments"})
+	require.NoError(t, err)
+	assert.Equal(t, key.KeyID, restored.KeyID)
+	assert.Equal(t, before.GetCurrentKeyID(), after.GetCurrentKeyID())
+}
+
+func TestKeyPersistence_PrivateKeysEncrypted(t *testing.T) {
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	store := newMemKeyStore()
+	km := restartKeyManager(t, privPEM, pubPEM, store, newTestKeyCipher(t))
+
+	stored, ok := store.get(km.GetCurrentKeyID())
+	require.True(t, ok)
+	der, err := x509.MarshalPKCS8PrivateKey(km.GetPrivateKey())
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(stored.EncryptedPrivateKey, der[len(der)-64:]))
+
+	other, err := auth.NewKeyManager(privPEM, pubPEM)
+	require.NoError(t, err)
+	assert.Error(t, other.EnableKeyPersistence(context.Background(), store, newTestKeyCipher(t), time.Hour),
+		"keys stored with another encryption key cannot be restored")
+}
+
+func TestKeyPersistence_PersistWithoutStoreIsNoop(t *testing.T) {
+	km := createTestKeyManager(t)
+
+	assert.NoError(t, km.PersistKeys(context.Background()))
+}
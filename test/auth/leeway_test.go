@@ -0,0 +1,49 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateToken_ClockSkewLeeway(t *testing.T) {
+	km := createTestKeyManager(t)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", &mocks.MockCache{})
+	validator.EnableClockSkewLeeway(time.Minute)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		exp, nbf time.Time
+		valid    bool
+	}{
+		{"expired within leeway", now.Add(-5 * time.Second), now.Add(-time.Hour), true},
+		{"expired beyond leeway", now.Add(-2 * time.Minute), now.Add(-time.Hour), false},
+		{"not yet valid within leeway", now.Add(time.Hour), now.Add(5 * time.Second), true},
+		{"not yet valid beyond leeway", now.Add(time.Hour), now.Add(2 * time.Minute), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validator.ValidateToken(context.Background(), signWithTimes(t, km, tt.exp, tt.nbf))
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateToken_NoLeewayByDefault(t *testing.T) {
+	km := createTestKeyManager(t)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", &mocks.MockCache{})
+
+	_, err := validator.ValidateToken(context.Background(), signWithTimes(t, km, time.Now().Add(-5*time.Second), time.Now().Add(-time.Hour)))
+	assert.Error(t, err)
+}
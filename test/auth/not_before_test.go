@@ -0,0 +1,43 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateToken_FutureNotBeforeRejected(t *testing.T) {
+	km := createTestKeyManager(t)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", &mocks.MockCache{})
+
+	// Pre-minted for a job scheduled in an hour
+	token := signWithTimes(t, km, time.Now().Add(2*time.Hour), time.Now().Add(time.Hour))
+
+	_, err := validator.ValidateToken(context.Background(), token)
+	assert.Error(t, err)
+}
+
+func TestValidateToken_MissingNotBeforeAccepted(t *testing.T) {
+	km := createTestKeyManager(t)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", &mocks.MockCache{})
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": "issuer",
+		"aud": "audience",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = km.GetCurrentKeyID()
+	signed, err := token.SignedString(km.GetPrivateKey())
+	require.NoError(t, err)
+
+	_, err = validator.ValidateToken(context.Background(), signed)
+	assert.NoError(t, err)
+}
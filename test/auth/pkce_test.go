@@ -0,0 +1,73 @@
+package auth_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"session-service/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCodeVerifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string
+		valid    bool
+	}{
+		{"RFC 7636 example", "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", true},
+		{"shortest", strings.Repeat("a", 43), true},
+		{"longest", strings.Repeat("~", 128), true},
+		{"too short", strings.Repeat("a", 42), false},
+		{"too long", strings.Repeat("a", 129), false},
+		{"empty", "", false},
+		{"space", strings.Repeat("a", 42) + " ", false},
+		{"plus and slash", strings.Repeat("a", 41) + "+/", false},
+		{"padding", strings.Repeat("a", 42) + "=", false},
+		{"non-ASCII", strings.Repeat("a", 42) + "é", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := auth.ValidateCodeVerifier(tt.verifier)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, auth.ErrInvalidCodeVerifier)
+			}
+		})
+	}
+}
+
+func TestValidateCodeChallenge(t *testing.T) {
+	digest := sha256.Sum256([]byte("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+	s256 := base64.RawURLEncoding.EncodeToString(digest[:])
+
+	tests := []struct {
+		name      string
+		challenge string
+		method    string
+		valid     bool
+	}{
+		{"S256", s256, "S256", true},
+		{"S256 padded", s256 + "=", "S256", false},
+		{"S256 truncated", s256[:40], "S256", false},
+		{"S256 standard alphabet", base64.StdEncoding.EncodeToString(digest[:]), "S256", false},
+		{"plain", "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", "plain", true},
+		{"plain too short", "abc", "plain", false},
+		{"unknown method", s256, "S512", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := auth.ValidateCodeChallenge(tt.challenge, tt.method)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, auth.ErrInvalidCodeChallenge)
+			}
+		})
+	}
+}
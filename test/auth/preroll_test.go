@@ -0,0 +1,77 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksKeyIDs returns the kids the key manager publishes.
+func jwksKeyIDs(t *testing.T, km *auth.KeyManager) []string {
+	t.Helper()
+
+	set := km.GetJWKSet()
+	kids := make([]string, 0, set.Len())
+	for i := 0; i < set.Len(); i++ {
+		key, ok := set.Key(i)
+		require.True(t, ok)
+		kids = append(kids, key.KeyID())
+	}
+	return kids
+}
+
+func TestPrerollKeys_PublishesNextKeyBeforeItSigns(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	subject := &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}
+	currentKid := km.GetCurrentKeyID()
+
+	require.NoError(t, km.PrerollKeys())
+
+	kids := jwksKeyIDs(t, km)
+	require.Len(t, kids, 2)
+	assert.Contains(t, kids, currentKid)
+	var nextKid string
+	for _, kid := range kids {
+		if kid != currentKid {
+			nextKid = kid
+		}
+	}
+
+	assert.Equal(t, currentKid, km.GetCurrentKeyID(), "the current key keeps signing until the rotation")
+	token, _, err := tg.GenerateAccessToken(subject)
+	require.NoError(t, err)
+	assert.Equal(t, currentKid, verifyAgainstJWKS(t, km, token).Header["kid"])
+
+	require.NoError(t, km.RotateKeys(time.Hour))
+
+	assert.Equal(t, nextKid, km.GetCurrentKeyID(), "the rotation promotes the pre-rolled key")
+	token, _, err = tg.GenerateAccessToken(subject)
+	require.NoError(t, err)
+	assert.Equal(t, nextKid, verifyAgainstJWKS(t, km, token).Header["kid"])
+
+	status, ok := km.GetKeyStatus(currentKid)
+	require.True(t, ok)
+	assert.True(t, status.InGrace)
+	assert.ElementsMatch(t, []string{currentKid, nextKid}, jwksKeyIDs(t, km))
+}
+
+func TestPrerollKeys_IdempotentUntilRotation(t *testing.T) {
+	km := createTestKeyManager(t)
+	require.NoError(t, km.AddAlgorithm(auth.AlgES256))
+
+	require.NoError(t, km.PrerollKeys())
+	require.NoError(t, km.PrerollKeys())
+	assert.Equal(t, 4, km.GetJWKSet().Len(), "one pre-rolled key per algorithm")
+
+	require.NoError(t, km.RotateKeys(time.Hour))
+	assert.Equal(t, 4, km.GetJWKSet().Len(), "the rotation must not generate further keys")
+
+	require.NoError(t, km.PrerollKeys())
+	assert.Equal(t, 6, km.GetJWKSet().Len())
+}
@@ -0,0 +1,82 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var refreshMACKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestRefreshTokenMAC_RoundTrip(t *testing.T) {
+	tg := auth.NewTokenGenerator(createTestKeyManager(t), "issuer", "audience", time.Hour, 32)
+	tg.EnableRefreshTokenMAC(refreshMACKey)
+
+	token, err := tg.GenerateRefreshToken()
+	require.NoError(t, err)
+
+	assert.True(t, tg.VerifyRefreshToken(token))
+
+	random, mac, ok := strings.Cut(token, ".")
+	require.True(t, ok, "token carries a MAC")
+
+	// Flip the first character of the random part, keeping the MAC
+	tampered := "A" + random[1:]
+	if random[0] == 'A' {
+		tampered = "B" + random[1:]
+	}
+	assert.False(t, tg.VerifyRefreshToken(tampered+"."+mac), "tampered random part")
+	assert.False(t, tg.VerifyRefreshToken(random), "MAC stripped")
+	assert.False(t, tg.VerifyRefreshToken(random+".bm90LWEtbWFj"), "forged MAC")
+	assert.False(t, tg.VerifyRefreshToken(random+".!!"), "MAC not base64")
+
+	other := auth.NewTokenGenerator(createTestKeyManager(t), "issuer", "audience", time.Hour, 32)
+	other.EnableRefreshTokenMAC([]byte("fedcba9876543210fedcba9876543210"))
+	assert.False(t, other.VerifyRefreshToken(token), "other key")
+}
+
+func TestRefreshTokenMAC_DisabledAcceptsPlainTokens(t *testing.T) {
+	tg := auth.NewTokenGenerator(createTestKeyManager(t), "issuer", "audience", time.Hour, 32)
+
+	token, err := tg.GenerateRefreshToken()
+	require.NoError(t, err)
+
+	assert.NotContains(t, token, ".")
+	assert.True(t, tg.VerifyRefreshToken(token))
+}
+
+func TestRefreshTokenMAC_PreviousKeyOverlap(t *testing.T) {
+	previousKey := []byte("fedcba9876543210fedcba9876543210")
+	before := auth.NewTokenGenerator(createTestKeyManager(t), "issuer", "audience", time.Hour, 32)
+	before.EnableRefreshTokenMAC(previousKey)
+	oldToken, err := before.GenerateRefreshToken()
+	require.NoError(t, err)
+
+	rotated := func(until time.Time) *auth.TokenGenerator {
+		tg := auth.NewTokenGenerator(createTestKeyManager(t), "issuer", "audience", time.Hour, 32)
+		tg.EnableRefreshTokenMAC(refreshMACKey)
+		tg.AcceptPreviousRefreshTokenMAC(previousKey, until)
+		return tg
+	}
+
+	t.Run("during overlap", func(t *testing.T) {
+		tg := rotated(time.Now().Add(time.Hour))
+		assert.True(t, tg.VerifyRefreshToken(oldToken))
+
+		// New tokens use the current key only
+		newToken, err := tg.GenerateRefreshToken()
+		require.NoError(t, err)
+		assert.True(t, tg.VerifyRefreshToken(newToken))
+		assert.False(t, before.VerifyRefreshToken(newToken))
+	})
+
+	t.Run("after overlap", func(t *testing.T) {
+		tg := rotated(time.Now().Add(-time.Second))
+		assert.False(t, tg.VerifyRefreshToken(oldToken))
+	})
+}
@@ -0,0 +1,63 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateToken_RoleChangeRevocation(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	token, _, err := tg.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Roles: []string{"reader"}})
+	require.NoError(t, err)
+
+	newValidator := func(changedAt time.Time, enabled bool) (*auth.TokenValidator, *mocks.MockCache) {
+		cacheMock := &mocks.MockCache{}
+		cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+		cacheMock.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+		cacheMock.On("GetUserRolesChangedAt", mock.Anything, "user-123").Return(changedAt, nil)
+		validator := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+		if enabled {
+			validator.EnableRoleChangeRevocation()
+		}
+		return validator, cacheMock
+	}
+
+	t.Run("roles changed after issuance", func(t *testing.T) {
+		validator, _ := newValidator(time.Now().Add(2*time.Second), true)
+
+		_, err := validator.ValidateToken(context.Background(), token)
+		assert.Equal(t, auth.ErrRolesChanged, err)
+	})
+
+	t.Run("roles changed before issuance", func(t *testing.T) {
+		validator, _ := newValidator(time.Now().Add(-time.Minute), true)
+
+		_, err := validator.ValidateToken(context.Background(), token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("no role change recorded", func(t *testing.T) {
+		validator, _ := newValidator(time.Time{}, true)
+
+		_, err := validator.ValidateToken(context.Background(), token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		validator, cacheMock := newValidator(time.Now().Add(2*time.Second), false)
+
+		_, err := validator.ValidateToken(context.Background(), token)
+		assert.NoError(t, err)
+		cacheMock.AssertNotCalled(t, "GetUserRolesChangedAt", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,82 @@
+package auth_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// panickingRotator panics in its first rotation, like a nil key would.
+type panickingRotator struct {
+	rotations atomic.Int32
+	cleanups  atomic.Int32
+}
+
+func (r *panickingRotator) PrerollKeys() error { return nil }
+
+func (r *panickingRotator) RotateKeys(time.Duration) error {
+	if r.rotations.Add(1) == 1 {
+		panic("runtime error: invalid memory address or nil pointer dereference")
+	}
+	return nil
+}
+
+func (r *panickingRotator) CleanupExpiredKeys() { r.cleanups.Add(1) }
+
+func TestRunRotationSchedule_RecoversFromPanickingRotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rotator := &panickingRotator{}
+	panics := testutil.ToFloat64(metrics.BackgroundPanics.WithLabelValues("key_rotation"))
+
+	done := make(chan struct{})
+	go func() {
+		auth.RunRotationSchedule(ctx, rotator, auth.RotationSchedule{Interval: 5 * time.Millisecond, GracePeriod: time.Hour}, zap.NewNop())
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return rotator.cleanups.Load() >= 2 }, time.Second, time.Millisecond,
+		"the schedule keeps rotating after the panic")
+	assert.GreaterOrEqual(t, rotator.rotations.Load(), int32(3))
+	assert.Equal(t, panics+1, testutil.ToFloat64(metrics.BackgroundPanics.WithLabelValues("key_rotation")))
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the schedule did not stop when its context was cancelled")
+	}
+}
+
+// persistingRotator counts the runs it is asked to persist.
+type persistingRotator struct {
+	panickingRotator
+	persisted atomic.Int32
+}
+
+func (r *persistingRotator) PersistKeys(context.Context) error {
+	r.persisted.Add(1)
+	return nil
+}
+
+func TestRunRotationSchedule_PersistsKeys(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rotator := &persistingRotator{}
+	rotator.rotations.Store(1)
+
+	go auth.RunRotationSchedule(ctx, rotator, auth.RotationSchedule{Interval: 10 * time.Millisecond, GracePeriod: time.Hour, Preroll: 5 * time.Millisecond}, zap.NewNop())
+
+	assert.Eventually(t, func() bool { return rotator.cleanups.Load() >= 2 }, time.Second, time.Millisecond)
+	cancel()
+	assert.GreaterOrEqual(t, rotator.persisted.Load(), 2*rotator.cleanups.Load()-1,
+		"keys are persisted after every pre-roll and rotation")
+}
@@ -0,0 +1,33 @@
+package auth_test
+
+import (
+	"testing"
+
+	"session-service/internal/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		name         string
+		scope        string
+		altDelimiter string
+		want         []string
+	}{
+		{"space-delimited", "read write admin", "", []string{"read", "write", "admin"}},
+		{"comma-delimited when enabled", "read,write, admin", ",", []string{"read", "write", "admin"}},
+		{"comma-delimited when disabled", "read,write", "", []string{"read,write"}},
+		{"duplicates keep first position", "write read write read", "", []string{"write", "read"}},
+		{"whitespace-heavy", "  read \t\n write   ", "", []string{"read", "write"}},
+		{"empty entries between delimiters", ",read,,write,", ",", []string{"read", "write"}},
+		{"case-sensitive", "Read read", "", []string{"Read", "read"}},
+		{"empty", "   ", ",", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, auth.ParseScope(tt.scope, tt.altDelimiter))
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateKeys_SeedKeyGetsGraceExpiry(t *testing.T) {
+	km := createTestKeyManager(t)
+	seedKeyID := km.GetCurrentKeyID()
+
+	status, ok := km.GetKeyStatus(seedKeyID)
+	require.True(t, ok)
+	assert.True(t, status.ExpiresAt.IsZero(), "the current key has no expiry")
+
+	require.NoError(t, km.RotateKeys(time.Hour))
+
+	status, ok = km.GetKeyStatus(seedKeyID)
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), status.ExpiresAt, time.Minute)
+	assert.True(t, status.InGrace)
+	assert.Contains(t, jwksKeyIDs(t, km), seedKeyID)
+}
+
+func TestRotateKeys_SeedKeyCleanedAfterGrace(t *testing.T) {
+	km := createTestKeyManager(t)
+	seedKeyID := km.GetCurrentKeyID()
+
+	require.NoError(t, km.RotateKeys(10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+
+	assert.NotContains(t, jwksKeyIDs(t, km), seedKeyID)
+	_, err := km.GetVerificationKey(seedKeyID)
+	assert.Error(t, err)
+
+	km.CleanupExpiredKeys()
+
+	_, ok := km.GetKeyStatus(seedKeyID)
+	assert.False(t, ok)
+	assert.Equal(t, []string{km.GetCurrentKeyID()}, jwksKeyIDs(t, km))
+}
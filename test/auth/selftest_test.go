@@ -0,0 +1,69 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfTest_GoodKeysPass(t *testing.T) {
+	km := createTestKeyManager(t)
+	require.NoError(t, km.AddAlgorithm(auth.AlgES256))
+	cacheMock := &mocks.MockCache{} // no expectations: the self-test must not reach the cache
+
+	generator := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+	validator.EnableRoleChangeRevocation()
+
+	assert.NoError(t, auth.SelfTest(context.Background(), generator, validator))
+}
+
+func TestSelfTest_BrokenConfigurationFails(t *testing.T) {
+	privPEM, _ := generateTestPEMKeys(t)
+	_, otherPubPEM := generateTestPEMKeys(t)
+	mismatched, err := auth.NewKeyManager(privPEM, otherPubPEM)
+	require.NoError(t, err)
+	km := createTestKeyManager(t)
+
+	tests := []struct {
+		name      string
+		generator *auth.TokenGenerator
+		validator *auth.TokenValidator
+		wantErr   string
+	}{
+		{
+			name:      "public key does not match private key",
+			generator: auth.NewTokenGenerator(mismatched, "issuer", "audience", time.Hour, 32),
+			validator: auth.NewTokenValidator(mismatched, "issuer", "audience", &mocks.MockCache{}),
+			wantErr:   "verification error",
+		},
+		{
+			name:      "validator has other keys",
+			generator: auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32),
+			validator: auth.NewTokenValidator(createTestKeyManager(t), "issuer", "audience", &mocks.MockCache{}),
+			wantErr:   "failed to get public key",
+		},
+		{
+			name:      "audience mismatch",
+			generator: auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32),
+			validator: auth.NewTokenValidator(km, "issuer", "other-audience", &mocks.MockCache{}),
+			wantErr:   "invalid audience",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := auth.SelfTest(context.Background(), tt.generator, tt.validator)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "RS256")
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// generatePKCS8PEMKeys generates a key pair for alg and returns it as a PKCS8
+// private key and a PKIX public key, both PEM-encoded.
+func generatePKCS8PEMKeys(t *testing.T, alg string) (string, string) {
+	t.Helper()
+
+	var privateKey crypto.Signer
+	var err error
+	switch alg {
+	case auth.AlgRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	case auth.AlgES256:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case auth.AlgEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	}
+	require.NoError(t, err)
+
+	privBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	pubBytes, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	require.NoError(t, err)
+
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privBytes})
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes})
+	return string(privPEM), string(pubPEM)
+}
+
+func TestKeyManagerWithAlgorithm_RoundTrip(t *testing.T) {
+	tests := []struct {
+		alg string
+		kty string
+		crv string
+	}{
+		{alg: auth.AlgRS256, kty: "RSA"},
+		{alg: auth.AlgES256, kty: "EC", crv: "P-256"},
+		{alg: auth.AlgEdDSA, kty: "OKP", crv: "Ed25519"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			privPEM, pubPEM := generatePKCS8PEMKeys(t, tt.alg)
+			km, err := auth.NewKeyManagerWithAlgorithm(tt.alg, privPEM, pubPEM)
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.alg}, km.Algorithms())
+
+			tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+			token, _, err := tg.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+			require.NoError(t, err)
+
+			parsed := verifyAgainstJWKS(t, km, token)
+			assert.Equal(t, tt.alg, parsed.Method.Alg())
+
+			body, err := json.Marshal(km.GetJWKSet())
+			require.NoError(t, err)
+			var jwks struct {
+				Keys []map[string]interface{} `json:"keys"`
+			}
+			require.NoError(t, json.Unmarshal(body, &jwks))
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.kty, jwks.Keys[0]["kty"])
+			if tt.crv != "" {
+				assert.Equal(t, tt.crv, jwks.Keys[0]["crv"])
+			}
+
+			mockCache := new(mocks.MockCache)
+			mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+			mockCache.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+			tv := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+			claims, err := tv.ValidateToken(context.Background(), token)
+			require.NoError(t, err)
+			assert.Equal(t, "user-123", claims["sub"])
+		})
+	}
+}
+
+func TestKeyManagerWithAlgorithm_RotationKeepsAlgorithm(t *testing.T) {
+	privPEM, pubPEM := generatePKCS8PEMKeys(t, auth.AlgEdDSA)
+	km, err := auth.NewKeyManagerWithAlgorithm(auth.AlgEdDSA, privPEM, pubPEM)
+	require.NoError(t, err)
+	before := km.GetCurrentKeyID()
+
+	require.NoError(t, km.RotateKeys(time.Hour))
+
+	key, err := km.GetSigningKey("")
+	require.NoError(t, err)
+	assert.NotEqual(t, before, key.KeyID)
+	assert.Equal(t, auth.AlgEdDSA, key.Algorithm)
+}
+
+func TestKeyManagerWithAlgorithm_SEC1ECKey(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	privBytes, err := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(t, err)
+	pubBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+
+	_, err = auth.NewKeyManagerWithAlgorithm(auth.AlgES256,
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privBytes})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes})))
+	assert.NoError(t, err)
+}
+
+func TestKeyManagerWithAlgorithm_KeyOfWrongType(t *testing.T) {
+	privPEM, pubPEM := generatePKCS8PEMKeys(t, auth.AlgRS256)
+
+	_, err := auth.NewKeyManagerWithAlgorithm(auth.AlgES256, privPEM, pubPEM)
+	assert.Error(t, err)
+	_, err = auth.NewKeyManagerWithAlgorithm(auth.AlgEdDSA, privPEM, pubPEM)
+	assert.Error(t, err)
+	_, err = auth.NewKeyManagerWithAlgorithm("PS256", privPEM, pubPEM)
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signStatement signs claims as a software statement with privPEM.
+func signStatement(t *testing.T, privPEM string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privPEM))
+	require.NoError(t, err)
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestSoftwareStatement_ExtractsAssertedMetadata(t *testing.T) {
+	privPEM, pubPEM := generateTestPEMKeys(t)
+	verifier, err := auth.NewSoftwareStatementVerifier("https://registry.example.com", pubPEM)
+	require.NoError(t, err)
+
+	statement, err := verifier.Verify(signStatement(t, privPEM, jwt.MapClaims{
+		"iss":           "https://registry.example.com",
+		"iat":           time.Now().Unix(),
+		"software_id":   "4NRB1-0XZABZI9E6-5SM3R",
+		"client_name":   "Example Statement-based Client",
+		"redirect_uris": []string{"https://client.example.net/callback"},
+		"grant_types":   []string{"client_credentials", "refresh_token"},
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "4NRB1-0XZABZI9E6-5SM3R", statement.SoftwareID)
+	assert.Equal(t, "Example Statement-based Client", statement.ClientName)
+	assert.Equal(t, []string{"https://client.example.net/callback"}, statement.RedirectURIs)
+	assert.Equal(t, []string{"client_credentials", "refresh_token"}, statement.GrantTypes)
+}
+
+func TestSoftwareStatement_RejectsUntrustedStatements(t *testing.T) {
+	privPEM, pubPEM := generateTestPEMKeys(t)
+	otherPrivPEM, _ := generateTestPEMKeys(t)
+	verifier, err := auth.NewSoftwareStatementVerifier("https://registry.example.com", pubPEM)
+	require.NoError(t, err)
+
+	valid := jwt.MapClaims{"iss": "https://registry.example.com", "grant_types": []string{"client_credentials"}}
+	with := func(key string, value interface{}) jwt.MapClaims {
+		claims := jwt.MapClaims{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		claims[key] = value
+		return claims
+	}
+
+	hmacSigned, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid).SignedString([]byte(pubPEM))
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"other issuer":  signStatement(t, privPEM, with("iss", "https://evil.example.com")),
+		"no issuer":     signStatement(t, privPEM, with("iss", "")),
+		"untrusted key": signStatement(t, otherPrivPEM, valid),
+		"expired":       signStatement(t, privPEM, with("exp", time.Now().Add(-time.Minute).Unix())),
+		"HMAC with key": hmacSigned,
+		"not a JWT":     "not-a-jwt",
+		"empty":         "",
+	}
+	for name, statement := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := verifier.Verify(statement)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewSoftwareStatementVerifier_RequiresIssuerAndKey(t *testing.T) {
+	_, pubPEM := generateTestPEMKeys(t)
+
+	_, err := auth.NewSoftwareStatementVerifier("", pubPEM)
+	assert.Error(t, err)
+	_, err = auth.NewSoftwareStatementVerifier("https://registry.example.com", "not a key")
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// createStandbyKeyManager creates an ES256 key manager to register as a
+// standby. Like the service, it also gets an RS256 key so that it covers
+// the algorithm of createTestKeyManager.
+func createStandbyKeyManager(t *testing.T) *auth.KeyManager {
+	t.Helper()
+	privPEM, pubPEM := generatePKCS8PEMKeys(t, auth.AlgES256)
+	standby, err := auth.NewKeyManagerWithAlgorithm(auth.AlgES256, privPEM, pubPEM)
+	require.NoError(t, err)
+	require.NoError(t, standby.AddAlgorithm(auth.AlgRS256))
+	return standby
+}
+
+// standbyKeyIDs returns the kids of standby's current keys.
+func standbyKeyIDs(t *testing.T, standby *auth.KeyManager) []string {
+	t.Helper()
+	var keyIDs []string
+	for _, alg := range standby.Algorithms() {
+		key, err := standby.GetSigningKey(alg)
+		require.NoError(t, err)
+		keyIDs = append(keyIDs, key.KeyID)
+	}
+	return keyIDs
+}
+
+func TestStandby_KeysPublishedButNotUsedForSigning(t *testing.T) {
+	km := createTestKeyManager(t)
+	standby := createStandbyKeyManager(t)
+	require.NoError(t, km.SetStandby(standby))
+
+	assert.ElementsMatch(t, append(standbyKeyIDs(t, standby), km.GetCurrentKeyID()), jwksKeyIDs(t, km))
+	ecKeys := km.GetJWKSetFiltered("", auth.AlgES256)
+	assert.Equal(t, 1, ecKeys.Len())
+	_, ok := ecKeys.LookupKeyID(standby.GetCurrentKeyID())
+	assert.True(t, ok)
+
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	token, _, err := tg.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+	parsed := verifyAgainstJWKS(t, km, token)
+	assert.Equal(t, km.GetCurrentKeyID(), parsed.Header["kid"])
+	assert.Equal(t, auth.AlgRS256, parsed.Method.Alg())
+}
+
+func TestStandby_PromotionSwitchesSigningKey(t *testing.T) {
+	km := createTestKeyManager(t)
+	standby := createStandbyKeyManager(t)
+	require.NoError(t, km.SetStandby(standby))
+
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	subject := &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}
+	oldKeyID := km.GetCurrentKeyID()
+	oldToken, _, err := tg.GenerateAccessToken(subject)
+	require.NoError(t, err)
+
+	require.NoError(t, km.PromoteStandby(time.Hour))
+
+	assert.False(t, km.HasStandby())
+	assert.Equal(t, standby.GetCurrentKeyID(), km.GetCurrentKeyID())
+	assert.ElementsMatch(t, append(standbyKeyIDs(t, standby), oldKeyID), jwksKeyIDs(t, km))
+	status, ok := km.GetKeyStatus(oldKeyID)
+	require.True(t, ok)
+	assert.True(t, status.InGrace)
+
+	newToken, _, err := tg.GenerateAccessToken(subject)
+	require.NoError(t, err)
+	parsed := verifyAgainstJWKS(t, km, newToken)
+	assert.Equal(t, standby.GetCurrentKeyID(), parsed.Header["kid"])
+	assert.Equal(t, auth.AlgES256, parsed.Method.Alg())
+
+	mockCache := new(mocks.MockCache)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+	tv := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+	_, err = tv.ValidateToken(context.Background(), oldToken)
+	assert.NoError(t, err, "tokens from the replaced key stay valid during the grace period")
+	_, err = tv.ValidateToken(context.Background(), newToken)
+	assert.NoError(t, err)
+}
+
+func TestStandby_PromotedKeysRotate(t *testing.T) {
+	km := createTestKeyManager(t)
+	standby := createStandbyKeyManager(t)
+	require.NoError(t, km.SetStandby(standby))
+	require.NoError(t, km.PromoteStandby(time.Hour))
+	promoted := km.GetCurrentKeyID()
+
+	require.NoError(t, km.RotateKeys(time.Hour))
+
+	key, err := km.GetSigningKey("")
+	require.NoError(t, err)
+	assert.NotEqual(t, promoted, key.KeyID)
+	assert.Equal(t, auth.AlgES256, key.Algorithm)
+}
+
+func TestStandby_PromotionReplacesEveryAlgorithm(t *testing.T) {
+	km := createTestKeyManager(t)
+	standby := createStandbyKeyManager(t)
+	require.NoError(t, km.SetStandby(standby))
+	oldKeyID := km.GetCurrentKeyID()
+
+	require.NoError(t, km.PromoteStandby(time.Hour))
+
+	assert.Equal(t, auth.AlgES256, km.DefaultAlgorithm())
+	rsaKey, err := km.GetSigningKey(auth.AlgRS256)
+	require.NoError(t, err, "clients pinned to RS256 still get a key")
+	standbyRSAKey, err := standby.GetSigningKey(auth.AlgRS256)
+	require.NoError(t, err)
+	assert.Equal(t, standbyRSAKey.KeyID, rsaKey.KeyID)
+	status, ok := km.GetKeyStatus(oldKeyID)
+	require.True(t, ok)
+	assert.True(t, status.InGrace)
+}
+
+func TestStandby_PromotionRefusedWithoutEveryAlgorithm(t *testing.T) {
+	km := createTestKeyManager(t)
+	privPEM, pubPEM := generatePKCS8PEMKeys(t, auth.AlgES256)
+	standby, err := auth.NewKeyManagerWithAlgorithm(auth.AlgES256, privPEM, pubPEM)
+	require.NoError(t, err)
+	require.NoError(t, km.SetStandby(standby))
+	currentKeyID := km.GetCurrentKeyID()
+
+	assert.ErrorIs(t, km.PromoteStandby(time.Hour), auth.ErrStandbyIncomplete)
+
+	assert.True(t, km.HasStandby())
+	assert.Equal(t, auth.AlgRS256, km.DefaultAlgorithm())
+	assert.Equal(t, currentKeyID, km.GetCurrentKeyID())
+	status, ok := km.GetKeyStatus(currentKeyID)
+	require.True(t, ok)
+	assert.False(t, status.InGrace)
+}
+
+func TestStandby_PromoteWithoutStandby(t *testing.T) {
+	km := createTestKeyManager(t)
+
+	assert.ErrorIs(t, km.PromoteStandby(time.Hour), auth.ErrNoStandby)
+}
+
+func TestStandby_MismatchedKeyPairRejected(t *testing.T) {
+	privPEM, _ := generatePKCS8PEMKeys(t, auth.AlgES256)
+	_, otherPubPEM := generatePKCS8PEMKeys(t, auth.AlgES256)
+	standby, err := auth.NewKeyManagerWithAlgorithm(auth.AlgES256, privPEM, otherPubPEM)
+	require.NoError(t, err)
+
+	km := createTestKeyManager(t)
+	assert.Error(t, km.SetStandby(standby))
+	assert.False(t, km.HasStandby())
+	assert.Equal(t, 1, km.GetJWKSet().Len())
+}
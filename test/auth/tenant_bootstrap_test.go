@@ -0,0 +1,41 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantBootstrapVerifier(t *testing.T) {
+	privPEM, pubPEM := generateTestPEMKeys(t)
+	otherPrivPEM, _ := generateTestPEMKeys(t)
+	verifier, err := auth.NewTenantBootstrapVerifier(pubPEM)
+	require.NoError(t, err)
+
+	exp := time.Now().Add(time.Minute).Unix()
+	assert.NoError(t, verifier.Verify(signStatement(t, privPEM, jwt.MapClaims{"tenant_id": "tenant-new", "exp": exp}), "tenant-new"))
+
+	tests := map[string]string{
+		"other tenant":  signStatement(t, privPEM, jwt.MapClaims{"tenant_id": "tenant-other", "exp": exp}),
+		"no tenant":     signStatement(t, privPEM, jwt.MapClaims{"exp": exp}),
+		"no expiry":     signStatement(t, privPEM, jwt.MapClaims{"tenant_id": "tenant-new"}),
+		"expired":       signStatement(t, privPEM, jwt.MapClaims{"tenant_id": "tenant-new", "exp": time.Now().Add(-time.Minute).Unix()}),
+		"untrusted key": signStatement(t, otherPrivPEM, jwt.MapClaims{"tenant_id": "tenant-new", "exp": exp}),
+		"not a JWT":     "not-a-jwt",
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, verifier.Verify(token, "tenant-new"))
+		})
+	}
+}
+
+func TestNewTenantBootstrapVerifier_RequiresKey(t *testing.T) {
+	_, err := auth.NewTenantBootstrapVerifier("not a key")
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantID(t *testing.T) {
+	km := createTestKeyManager(t)
+	cacheMock := &mocks.MockCache{}
+	cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	cacheMock.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+
+	issue := func(t *testing.T, issuer, tenantID string) string {
+		t.Helper()
+		token, _, err := auth.NewTokenGenerator(km, issuer, "audience", time.Hour, 32).
+			GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: tenantID})
+		require.NoError(t, err)
+		return token
+	}
+	newValidator := func(tenantIssuers bool) *auth.TokenValidator {
+		validator := auth.NewTokenValidator(km, "https://auth.example.com", "audience", cacheMock)
+		if tenantIssuers {
+			validator.EnableTenantIssuers()
+		}
+		return validator
+	}
+
+	tests := []struct {
+		name          string
+		issuer        string
+		tid           string
+		tenantIssuers bool
+		wantTenant    string
+		wantErr       bool
+	}{
+		{name: "tid", issuer: "https://auth.example.com", tid: "tenant-abc", wantTenant: "tenant-abc"},
+		{name: "tid with tenant issuers enabled", issuer: "https://auth.example.com", tid: "tenant-abc", tenantIssuers: true, wantTenant: "tenant-abc"},
+		{name: "no tenant", issuer: "https://auth.example.com", wantTenant: ""},
+		{name: "issuer", issuer: "https://auth.example.com/tenant-abc", tenantIssuers: true, wantTenant: "tenant-abc"},
+		{name: "issuer and matching tid", issuer: "https://auth.example.com/tenant-abc", tid: "tenant-abc", tenantIssuers: true, wantTenant: "tenant-abc"},
+		{name: "mixed-case tid", issuer: "https://auth.example.com", tid: "Tenant-ABC", wantTenant: "tenant-abc"},
+		{name: "mixed-case issuer and tid", issuer: "https://auth.example.com/Tenant-ABC", tid: "tenant-abc", tenantIssuers: true, wantTenant: "tenant-abc"},
+		{name: "issuer and different tid", issuer: "https://auth.example.com/tenant-abc", tid: "tenant-xyz", tenantIssuers: true, wantErr: true},
+		{name: "tenant issuer while disabled", issuer: "https://auth.example.com/tenant-abc", wantErr: true},
+		{name: "nested issuer path", issuer: "https://auth.example.com/tenant-abc/extra", tenantIssuers: true, wantErr: true},
+		{name: "foreign issuer", issuer: "https://other.example.com/tenant-abc", tenantIssuers: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := newValidator(tt.tenantIssuers)
+
+			claims, err := validator.ValidateToken(context.Background(), issue(t, tt.issuer, tt.tid))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTenant, validator.TenantID(claims))
+		})
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"time"
 
 	"session-service/internal/auth"
+	"session-service/internal/models"
 	"session-service/test/mocks"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestValidateToken_MissingKidFails(t *testing.T) {
@@ -43,3 +45,35 @@ func TestValidateToken_MissingKidFails(t *testing.T) {
 }
 
 
+
+func TestValidateToken_UserRevocationCutoff(t *testing.T) {
+	km := createTestKeyManager(t)
+	tg := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+
+	token, _, err := tg.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	if err != nil {
+		t.Fatalf("GenerateAccessToken() error = %v", err)
+	}
+
+	t.Run("no cutoff", func(t *testing.T) {
+		cacheMock := &mocks.MockCache{}
+		cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+		cacheMock.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+		validator := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+
+		if _, err := validator.ValidateToken(context.Background(), token); err != nil {
+			t.Fatalf("expected token to validate, got %v", err)
+		}
+	})
+
+	t.Run("issued before cutoff", func(t *testing.T) {
+		cacheMock := &mocks.MockCache{}
+		cacheMock.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+		cacheMock.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Now(), nil)
+		validator := auth.NewTokenValidator(km, "issuer", "audience", cacheMock)
+
+		if _, err := validator.ValidateToken(context.Background(), token); err == nil {
+			t.Fatal("expected token issued before the user's revocation cutoff to be rejected")
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package bootstrap_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"session-service/internal/bootstrap"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func bootstrapConfig() bootstrap.Config {
+	return bootstrap.Config{
+		TenantID:     "acme",
+		ClientID:     "bootstrap-client",
+		ClientSecret: "bootstrap-secret",
+	}
+}
+
+func clientWithSecret(secret string) interface{} {
+	return mock.MatchedBy(func(c models.Client) bool {
+		return c.ClientID == "bootstrap-client" &&
+			c.TenantID == "acme" &&
+			bcrypt.CompareHashAndPassword([]byte(c.ClientSecretHash), []byte(secret)) == nil
+	})
+}
+
+func TestRun_NotConfigured(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+
+	err := bootstrap.Run(context.Background(), mockRepo, bootstrap.Config{}, zap.NewNop())
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "CreateTenantIfNotExists", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "CreateClientIfNotExists", mock.Anything, mock.Anything)
+}
+
+func TestRun_PartialConfig(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	cfg := bootstrapConfig()
+	cfg.ClientSecret = ""
+
+	err := bootstrap.Run(context.Background(), mockRepo, cfg, zap.NewNop())
+
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "CreateTenantIfNotExists", mock.Anything, mock.Anything)
+}
+
+func TestRun_CreatesTenantAndClient(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("CreateTenantIfNotExists", mock.Anything, models.Tenant{ID: "acme", Name: "acme"}).Return(true, nil).Once()
+	mockRepo.On("CreateClientIfNotExists", mock.Anything, clientWithSecret("bootstrap-secret")).Return(true, nil).Once()
+
+	err := bootstrap.Run(context.Background(), mockRepo, bootstrapConfig(), zap.NewNop())
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRun_IdempotentOnRestart(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("CreateTenantIfNotExists", mock.Anything, mock.Anything).Return(true, nil).Once()
+	mockRepo.On("CreateClientIfNotExists", mock.Anything, mock.Anything).Return(true, nil).Once()
+	mockRepo.On("CreateTenantIfNotExists", mock.Anything, mock.Anything).Return(false, nil).Once()
+	mockRepo.On("CreateClientIfNotExists", mock.Anything, mock.Anything).Return(false, nil).Once()
+
+	assert.NoError(t, bootstrap.Run(context.Background(), mockRepo, bootstrapConfig(), zap.NewNop()))
+	assert.NoError(t, bootstrap.Run(context.Background(), mockRepo, bootstrapConfig(), zap.NewNop()))
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNumberOfCalls(t, "CreateTenantIfNotExists", 2)
+	mockRepo.AssertNumberOfCalls(t, "CreateClientIfNotExists", 2)
+}
+
+func TestRun_ClientName(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	cfg := bootstrapConfig()
+	cfg.ClientName = "Bootstrap client"
+	mockRepo.On("CreateTenantIfNotExists", mock.Anything, mock.Anything).Return(true, nil)
+	mockRepo.On("CreateClientIfNotExists", mock.Anything, mock.MatchedBy(func(c models.Client) bool {
+		return c.ClientID == "bootstrap-client" && c.Name == "Bootstrap client"
+	})).Return(true, nil).Once()
+
+	err := bootstrap.Run(context.Background(), mockRepo, cfg, zap.NewNop())
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRun_TenantError(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("CreateTenantIfNotExists", mock.Anything, mock.Anything).Return(false, errors.New("db down"))
+
+	err := bootstrap.Run(context.Background(), mockRepo, bootstrapConfig(), zap.NewNop())
+
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "CreateClientIfNotExists", mock.Anything, mock.Anything)
+}
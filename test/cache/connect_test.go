@@ -0,0 +1,78 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"session-service/internal/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// flakyPing fails the first failures calls, then succeeds.
+type flakyPing struct {
+	failures int
+	calls    int
+}
+
+func (p *flakyPing) ping(ctx context.Context) error {
+	p.calls++
+	if p.calls <= p.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestPingWithRetry_SucceedsAfterTransientFailures(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	stub := &flakyPing{failures: 2}
+
+	err := cache.PingWithRetry(context.Background(), stub.ping, cache.ConnectOptions{Retries: 5, Timeout: time.Second, Backoff: time.Millisecond}, zap.New(core))
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, stub.calls)
+	assert.Equal(t, 2, logs.FilterMessage("Failed to connect to Redis, retrying...").Len())
+}
+
+func TestPingWithRetry_GivesUpAfterRetries(t *testing.T) {
+	stub := &flakyPing{failures: 10}
+
+	err := cache.PingWithRetry(context.Background(), stub.ping, cache.ConnectOptions{Retries: 3, Timeout: time.Second, Backoff: time.Millisecond}, zap.NewNop())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+	assert.Contains(t, err.Error(), "connection refused")
+	assert.Equal(t, 3, stub.calls)
+}
+
+func TestPingWithRetry_AppliesTimeoutPerAttempt(t *testing.T) {
+	hanging := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	start := time.Now()
+	err := cache.PingWithRetry(context.Background(), hanging, cache.ConnectOptions{Retries: 2, Timeout: 20 * time.Millisecond, Backoff: time.Millisecond}, zap.NewNop())
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestPingWithRetry_StopsWhenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stub := &flakyPing{failures: 10}
+	ping := func(ctx context.Context) error {
+		cancel()
+		return stub.ping(ctx)
+	}
+
+	err := cache.PingWithRetry(ctx, ping, cache.ConnectOptions{Retries: 5, Backoff: time.Hour}, zap.NewNop())
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, stub.calls)
+}
@@ -0,0 +1,95 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"session-service/internal/cache"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sharedStore is an in-memory stand-in for the global Redis, holding refresh
+// tokens and revoked jtis. Other calls fail as unexpected mock calls.
+type sharedStore struct {
+	mocks.MockCache
+
+	mu      sync.Mutex
+	tokens  map[string]*models.RefreshTokenData
+	revoked map[string]bool
+}
+
+func newSharedStore() *sharedStore {
+	return &sharedStore{tokens: make(map[string]*models.RefreshTokenData), revoked: make(map[string]bool)}
+}
+
+func (s *sharedStore) StoreRefreshToken(ctx context.Context, tokenID string, data *models.RefreshTokenData, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[tokenID] = data
+	return nil
+}
+
+func (s *sharedStore) GetRefreshToken(ctx context.Context, tokenID string) (*models.RefreshTokenData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[tokenID], nil
+}
+
+func (s *sharedStore) RevokeToken(ctx context.Context, jti string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[jti] = true
+	return nil
+}
+
+func (s *sharedStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revoked[jti], nil
+}
+
+func TestGlobalSessionCache_SharesSessionStateAcrossRegions(t *testing.T) {
+	ctx := context.Background()
+	global := newSharedStore()
+	// The region-local caches have no expectations, so any session call
+	// reaching them fails the test
+	regionA := cache.NewGlobalSessionCache(new(mocks.MockCache), global)
+	regionB := cache.NewGlobalSessionCache(new(mocks.MockCache), global)
+
+	data := &models.RefreshTokenData{ClientID: "test-client", Subject: &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}}
+	require.NoError(t, regionA.StoreRefreshToken(ctx, "refresh-1", data, time.Hour))
+	require.NoError(t, regionA.RevokeToken(ctx, "jti-1", time.Hour))
+
+	got, err := regionB.GetRefreshToken(ctx, "refresh-1")
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	revoked, err := regionB.IsTokenRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestGlobalSessionCache_KeepsOtherDataLocal(t *testing.T) {
+	ctx := context.Background()
+	local := new(mocks.MockCache)
+	global := new(mocks.MockCache)
+	c := cache.NewGlobalSessionCache(local, global)
+
+	client := &models.Client{ClientID: "test-client"}
+	local.On("GetClient", ctx, "test-client").Return(client, nil)
+	local.On("CheckRateLimit", ctx, "test-client", 100, time.Minute).Return(false, nil)
+
+	got, err := c.GetClient(ctx, "test-client")
+	require.NoError(t, err)
+	assert.Equal(t, client, got)
+	_, err = c.CheckRateLimit(ctx, "test-client", 100, time.Minute)
+	require.NoError(t, err)
+
+	local.AssertExpectations(t)
+	global.AssertNotCalled(t, "GetClient")
+}
@@ -0,0 +1,48 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/cache"
+	"session-service/internal/metrics"
+	"session-service/test/mocks"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestInstrumentedCache_LogsSlowOperations(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	stub := new(mocks.MockCache)
+	stub.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).
+		After(30*time.Millisecond).
+		Return(false, nil)
+
+	c := cache.NewInstrumentedCache(stub, 10*time.Millisecond, zap.New(core))
+	before := testutil.ToFloat64(metrics.SlowOperations.WithLabelValues("cache", "CheckRateLimit"))
+
+	exceeded, err := c.CheckRateLimit(context.Background(), "test-client", 100, time.Minute)
+
+	require.NoError(t, err)
+	assert.False(t, exceeded)
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.SlowOperations.WithLabelValues("cache", "CheckRateLimit")))
+	require.Equal(t, 1, logs.FilterMessage("Slow operation").Len())
+}
+
+func TestInstrumentedCache_ZeroThresholdDisablesSlowLog(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	stub := new(mocks.MockCache)
+	stub.On("IsTokenRevoked", mock.Anything, "jti-1").After(5*time.Millisecond).Return(false, nil)
+
+	c := cache.NewInstrumentedCache(stub, 0, zap.New(core))
+	_, err := c.IsTokenRevoked(context.Background(), "jti-1")
+
+	require.NoError(t, err)
+	assert.Zero(t, logs.Len())
+}
@@ -0,0 +1,76 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"session-service/internal/cache"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestWarmClients_PopulatesCache(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+
+	clients := []*models.Client{{ClientID: "client-a"}, {ClientID: "client-b"}}
+	mockRepo.On("ListActiveClients", mock.Anything, 10).Return(clients, nil)
+	mockCache.On("SetClient", mock.Anything, clients[0], 15*time.Minute).Return(nil)
+	mockCache.On("SetClient", mock.Anything, clients[1], 15*time.Minute).Return(nil)
+
+	warmed, err := cache.WarmClients(context.Background(), mockRepo, mockCache, 10, 15*time.Minute, zap.NewNop())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, warmed)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+}
+
+func TestWarmClients_RespectsMaxCount(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+
+	// A lister that ignores the limit must still not overfill the cache
+	clients := []*models.Client{{ClientID: "client-a"}, {ClientID: "client-b"}, {ClientID: "client-c"}}
+	mockRepo.On("ListActiveClients", mock.Anything, 2).Return(clients, nil)
+	mockCache.On("SetClient", mock.Anything, mock.AnythingOfType("*models.Client"), time.Minute).Return(nil)
+
+	warmed, err := cache.WarmClients(context.Background(), mockRepo, mockCache, 2, time.Minute, zap.NewNop())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, warmed)
+	mockCache.AssertNumberOfCalls(t, "SetClient", 2)
+	mockCache.AssertNotCalled(t, "SetClient", mock.Anything, clients[2], time.Minute)
+}
+
+func TestWarmClients_SkipsCacheFailures(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+
+	clients := []*models.Client{{ClientID: "client-a"}, {ClientID: "client-b"}}
+	mockRepo.On("ListActiveClients", mock.Anything, 5).Return(clients, nil)
+	mockCache.On("SetClient", mock.Anything, clients[0], time.Minute).Return(errors.New("redis down"))
+	mockCache.On("SetClient", mock.Anything, clients[1], time.Minute).Return(nil)
+
+	warmed, err := cache.WarmClients(context.Background(), mockRepo, mockCache, 5, time.Minute, zap.NewNop())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, warmed)
+}
+
+func TestWarmClients_DisabledWithNonPositiveMax(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+
+	warmed, err := cache.WarmClients(context.Background(), mockRepo, mockCache, 0, time.Minute, zap.NewNop())
+
+	assert.NoError(t, err)
+	assert.Zero(t, warmed)
+	mockRepo.AssertNotCalled(t, "ListActiveClients", mock.Anything, mock.Anything)
+}
@@ -89,6 +89,356 @@ func TestLoad(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "invalid tenant id pattern",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":   privKey,
+				"JWT_PUBLIC_KEY":    pubKey,
+				"TENANT_ID_PATTERN": "[a-z",
+			},
+			wantErr: true,
+		},
+		{
+			name: "multi-character scope delimiter",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":     privKey,
+				"JWT_PUBLIC_KEY":      pubKey,
+				"SCOPE_ALT_DELIMITER": ",;",
+			},
+			wantErr: true,
+		},
+		{
+			name: "previous refresh token HMAC key without an overlap end",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":                 privKey,
+				"JWT_PUBLIC_KEY":                  pubKey,
+				"REFRESH_TOKEN_HMAC_KEY":          "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+				"REFRESH_TOKEN_HMAC_PREVIOUS_KEY": "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=",
+			},
+			wantErr: true,
+		},
+		{
+			name: "previous refresh token HMAC key without a current key",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":                       privKey,
+				"JWT_PUBLIC_KEY":                        pubKey,
+				"REFRESH_TOKEN_HMAC_PREVIOUS_KEY":       "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=",
+				"REFRESH_TOKEN_HMAC_PREVIOUS_KEY_UNTIL": "2030-01-31T00:00:00Z",
+			},
+			wantErr: true,
+		},
+		{
+			name: "short refresh token HMAC key",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":        privKey,
+				"JWT_PUBLIC_KEY":         pubKey,
+				"REFRESH_TOKEN_HMAC_KEY": "c2hvcnQ=",
+			},
+			wantErr: true,
+		},
+		{
+			name: "single advertised key",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":     privKey,
+				"JWT_PUBLIC_KEY":      pubKey,
+				"MAX_ADVERTISED_KEYS": "1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "tenant bootstrap key not PEM",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":             privKey,
+				"JWT_PUBLIC_KEY":              pubKey,
+				"TENANT_BOOTSTRAP_PUBLIC_KEY": "not-a-key",
+			},
+			wantErr: true,
+		},
+		{
+			name: "scope audience without audience",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY": privKey,
+				"JWT_PUBLIC_KEY":  pubKey,
+				"SCOPE_AUDIENCES": "openid",
+			},
+			wantErr: true,
+		},
+		{
+			name: "mixed grant clients without separate provisioning",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":     privKey,
+				"JWT_PUBLIC_KEY":      pubKey,
+				"MIXED_GRANT_CLIENTS": "legacy-app",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative roles compression threshold",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":                 privKey,
+				"JWT_PUBLIC_KEY":                  pubKey,
+				"JWT_ROLES_COMPRESSION_THRESHOLD": "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "at+jwt typ required without RFC 9068 tokens",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":    privKey,
+				"JWT_PUBLIC_KEY":     pubKey,
+				"JWT_REQUIRE_AT_TYP": "true",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative JWKS stale-if-error window",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":     privKey,
+				"JWT_PUBLIC_KEY":      pubKey,
+				"JWKS_STALE_IF_ERROR": "-1m",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported JWT signing algorithm",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY": privKey,
+				"JWT_PUBLIC_KEY":  pubKey,
+				"JWT_SIGNING_ALG": "HS256",
+			},
+			wantErr: true,
+		},
+		{
+			name: "standby private key without public key",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":         privKey,
+				"JWT_PUBLIC_KEY":          pubKey,
+				"JWT_STANDBY_PRIVATE_KEY": privKey,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative clock skew leeway",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":       privKey,
+				"JWT_PUBLIC_KEY":        pubKey,
+				"JWT_CLOCK_SKEW_LEEWAY": "-1s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "signing key persistence without an encryption key",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":     privKey,
+				"JWT_PUBLIC_KEY":      pubKey,
+				"SIGNING_KEY_PERSIST": "true",
+			},
+			wantErr: true,
+		},
+		{
+			name: "short operator token",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY": privKey,
+				"JWT_PUBLIC_KEY":  pubKey,
+				"OPERATOR_TOKEN":  "too-short",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative audience cap",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY": privKey,
+				"JWT_PUBLIC_KEY":  pubKey,
+				"MAX_AUDIENCES":   "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "tenant secret key of the wrong length",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":   privKey,
+				"JWT_PUBLIC_KEY":    pubKey,
+				"TENANT_SECRET_KEY": "c2hvcnQ=",
+			},
+			wantErr: true,
+		},
+		{
+			name: "grant expiry longer than refresh expiry",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":      privKey,
+				"JWT_PUBLIC_KEY":       pubKey,
+				"REFRESH_TOKEN_EXPIRY": "24h",
+				"JWT_EXPIRY_BY_GRANT":  "provision_user=48h",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown session limit policy",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":       privKey,
+				"JWT_PUBLIC_KEY":        pubKey,
+				"MAX_SESSIONS_PER_USER": "5",
+				"SESSION_LIMIT_POLICY":  "evict_newest",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid trusted proxy",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY": privKey,
+				"JWT_PUBLIC_KEY":  pubKey,
+				"TRUSTED_PROXIES": "10.0.0.0/8,not-an-ip",
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero redis connect retries",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":       privKey,
+				"JWT_PUBLIC_KEY":        pubKey,
+				"REDIS_CONNECT_RETRIES": "0",
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-positive redis connect timeout",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":       privKey,
+				"JWT_PUBLIC_KEY":        pubKey,
+				"REDIS_CONNECT_TIMEOUT": "0s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative jti source threshold",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":           privKey,
+				"JWT_PUBLIC_KEY":            pubKey,
+				"JTI_SOURCE_WARN_THRESHOLD": "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "audit dead-letter without persistence",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":        privKey,
+				"JWT_PUBLIC_KEY":         pubKey,
+				"AUDIT_DEAD_LETTER_PATH": "/var/lib/session-service/audit.dlq",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid amr mapping",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":    privKey,
+				"JWT_PUBLIC_KEY":     pubKey,
+				"TOKEN_AMR_BY_GRANT": "provision_user",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid session cookie name",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":     privKey,
+				"JWT_PUBLIC_KEY":      pubKey,
+				"SESSION_COOKIES":     "true",
+				"SESSION_COOKIE_NAME": "my session",
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-positive audit replay interval",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":       privKey,
+				"JWT_PUBLIC_KEY":        pubKey,
+				"AUDIT_PERSIST":         "true",
+				"AUDIT_REPLAY_INTERVAL": "0s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative compression min size",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":      privKey,
+				"JWT_PUBLIC_KEY":       pubKey,
+				"COMPRESSION_MIN_SIZE": "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported compression encoding",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":       privKey,
+				"JWT_PUBLIC_KEY":        pubKey,
+				"COMPRESSION_ENCODINGS": "br,deflate",
+			},
+			wantErr: true,
+		},
+		{
+			name: "roles claim renamed to a reserved claim",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY": privKey,
+				"JWT_PUBLIC_KEY":  pubKey,
+				"JWT_ROLES_CLAIM": "sub",
+			},
+			wantErr: true,
+		},
+		{
+			name: "scopes and tenant claims renamed to the same claim",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":  privKey,
+				"JWT_PUBLIC_KEY":   pubKey,
+				"JWT_SCOPES_CLAIM": "tid",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative key preroll",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY": privKey,
+				"JWT_PUBLIC_KEY":  pubKey,
+				"KEY_PREROLL":     "-1h",
+			},
+			wantErr: true,
+		},
+		{
+			name: "key preroll not shorter than the rotation interval",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":   privKey,
+				"JWT_PUBLIC_KEY":    pubKey,
+				"KEY_ROTATION_DAYS": "1",
+				"KEY_PREROLL":       "24h",
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-positive key rotation retry-after",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":          privKey,
+				"JWT_PUBLIC_KEY":           pubKey,
+				"KEY_ROTATION_RETRY_AFTER": "0s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative max concurrent requests",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":         privKey,
+				"JWT_PUBLIC_KEY":          pubKey,
+				"MAX_CONCURRENT_REQUESTS": "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "access expiry longer than refresh expiry",
+			env: map[string]string{
+				"JWT_PRIVATE_KEY":      privKey,
+				"JWT_PUBLIC_KEY":       pubKey,
+				"JWT_EXPIRY":           "48h",
+				"REFRESH_TOKEN_EXPIRY": "24h",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,71 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"session-service/internal/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePgError mimics a driver error exposing a SQLSTATE code.
+type fakePgError struct {
+	code string
+}
+
+func (e *fakePgError) Error() string    { return "pq: " + e.code }
+func (e *fakePgError) SQLState() string { return e.code }
+
+func TestIsUniqueViolation(t *testing.T) {
+	assert.True(t, database.IsUniqueViolation(&fakePgError{code: "23505"}))
+	assert.True(t, database.IsUniqueViolation(fmt.Errorf("insert user: %w", &fakePgError{code: "23505"})))
+	assert.False(t, database.IsUniqueViolation(&fakePgError{code: "40001"}))
+	assert.False(t, database.IsUniqueViolation(errors.New("boom")))
+	assert.False(t, database.IsUniqueViolation(nil))
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	assert.True(t, database.IsSerializationFailure(&fakePgError{code: "40001"}))
+	assert.False(t, database.IsSerializationFailure(&fakePgError{code: "23505"}))
+	assert.False(t, database.IsSerializationFailure(errors.New("boom")))
+}
+
+func TestRetryOnSerializationFailure_RetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	err := database.RetryOnSerializationFailure(context.Background(), 3, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return &fakePgError{code: "40001"}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryOnSerializationFailure_GivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	err := database.RetryOnSerializationFailure(context.Background(), 3, time.Millisecond, func() error {
+		calls++
+		return &fakePgError{code: "40001"}
+	})
+
+	assert.True(t, database.IsSerializationFailure(err))
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryOnSerializationFailure_DoesNotRetryOtherErrors(t *testing.T) {
+	calls := 0
+	err := database.RetryOnSerializationFailure(context.Background(), 3, time.Millisecond, func() error {
+		calls++
+		return &fakePgError{code: "23505"}
+	})
+
+	assert.True(t, database.IsUniqueViolation(err))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,55 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"session-service/internal/database"
+	"session-service/internal/metrics"
+	"session-service/test/mocks"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestInstrumentedRepository_LogsSlowQueries(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	stub := new(mocks.MockRepository)
+	stub.On("GetUserRoles", mock.Anything, "user-123").
+		After(30*time.Millisecond).
+		Return([]string{"reader"}, nil)
+
+	repo := database.NewInstrumentedRepository(stub, 10*time.Millisecond, zap.New(core))
+	before := testutil.ToFloat64(metrics.SlowOperations.WithLabelValues("database", "GetUserRoles"))
+
+	roles, err := repo.GetUserRoles(context.Background(), "user-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reader"}, roles)
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.SlowOperations.WithLabelValues("database", "GetUserRoles")))
+
+	entries := logs.FilterMessage("Slow operation").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "database", entries[0].ContextMap()["component"])
+	assert.Equal(t, "GetUserRoles", entries[0].ContextMap()["operation"])
+}
+
+func TestInstrumentedRepository_FastQueriesAreNotLogged(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	stub := new(mocks.MockRepository)
+	stub.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+
+	repo := database.NewInstrumentedRepository(stub, time.Second, zap.New(core))
+	before := testutil.ToFloat64(metrics.SlowOperations.WithLabelValues("database", "EnsureTenantExists"))
+
+	require.NoError(t, repo.EnsureTenantExists(context.Background(), "tenant-abc"))
+
+	assert.Zero(t, logs.Len())
+	assert.Equal(t, before, testutil.ToFloat64(metrics.SlowOperations.WithLabelValues("database", "EnsureTenantExists")))
+	stub.AssertExpectations(t)
+}
@@ -0,0 +1,225 @@
+package handlers_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/audit"
+	"session-service/internal/auth"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleExportUser(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockAudit := new(mocks.MockAuditRecorder)
+	handler := handlers.NewAdminHandler(mockRepo, new(mocks.MockCache), &config.Config{}, mockAudit, zap.NewNop())
+
+	tenantID := "tenant-abc"
+	userID := "user-123"
+	export := &models.UserExport{
+		ID:          userID,
+		TenantID:    tenantID,
+		Email:       "jane@example.com",
+		FullName:    "Jane Doe",
+		PhoneNumber: "+15550100",
+		Roles:       []string{"reader", "tenant-admin"},
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+		UpdatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+
+	mockRepo.On("GetUserExport", mock.Anything, tenantID, userID).Return(export, nil)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.Type == audit.EventUserExport && e.TenantID == tenantID && e.TargetID == userID
+	})).Return()
+
+	req := httptest.NewRequest("GET", "/"+tenantID+"/admin/users/"+userID+"/export", nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": tenantID, "user_id": userID})
+	rr := httptest.NewRecorder()
+
+	handler.HandleExportUser(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var got models.UserExport
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, userID, got.ID)
+	assert.Equal(t, tenantID, got.TenantID)
+	assert.Equal(t, "Jane Doe", got.FullName)
+	assert.Equal(t, "jane@example.com", got.Email)
+	assert.Equal(t, []string{"reader", "tenant-admin"}, got.Roles)
+
+	mockRepo.AssertExpectations(t)
+	mockAudit.AssertExpectations(t)
+}
+
+func TestHandleExportUser_NotFound(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockAudit := new(mocks.MockAuditRecorder)
+	handler := handlers.NewAdminHandler(mockRepo, new(mocks.MockCache), &config.Config{}, mockAudit, zap.NewNop())
+
+	mockRepo.On("GetUserExport", mock.Anything, "tenant-abc", "missing").Return(nil, nil)
+
+	req := httptest.NewRequest("GET", "/tenant-abc/admin/users/missing/export", nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc", "user_id": "missing"})
+	rr := httptest.NewRecorder()
+
+	handler.HandleExportUser(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockAudit.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+}
+
+func TestHandleExportTenantUsers_StreamsNDJSON(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockAudit := new(mocks.MockAuditRecorder)
+	handler := handlers.NewAdminHandler(mockRepo, new(mocks.MockCache), &config.Config{}, mockAudit, zap.NewNop())
+
+	users := []*models.UserExport{
+		{ID: "user-1", TenantID: "tenant-abc", Roles: []string{"reader"}},
+		{ID: "user-2", TenantID: "tenant-abc", Roles: []string{}},
+	}
+	mockRepo.On("ExportTenantUsers", mock.Anything, "tenant-abc", mock.Anything).Return(users, nil)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.Type == audit.EventTenantUsersExport && e.Metadata["user_count"] == "2"
+	})).Return()
+
+	req := httptest.NewRequest("GET", "/tenant-abc/admin/users/export", nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+
+	handler.HandleExportTenantUsers(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+	var got []models.UserExport
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var u models.UserExport
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &u))
+		got = append(got, u)
+	}
+	require.Len(t, got, 2)
+	assert.Equal(t, "user-1", got[0].ID)
+	assert.Equal(t, []string{"reader"}, got[0].Roles)
+
+	mockAudit.AssertExpectations(t)
+}
+
+func TestHandleDeleteUser_RevokesTokens(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+	mockAudit := new(mocks.MockAuditRecorder)
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler := handlers.NewAdminHandler(mockRepo, mockCache, cfg, mockAudit, zap.NewNop())
+
+	tenantID := "tenant-abc"
+	userID := "user-123"
+
+	mockRepo.On("DeleteUser", mock.Anything, tenantID, userID).Return(true, nil)
+	mockRepo.On("GetTenantByID", mock.Anything, tenantID).Return(&models.Tenant{ID: tenantID, RefreshTokenTTL: 48 * time.Hour}, nil)
+	mockCache.On("SetUserRevocationCutoff", mock.Anything, userID, mock.AnythingOfType("time.Time"), 48*time.Hour).Return(nil)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.Type == audit.EventUserDelete && e.TargetID == userID
+	})).Return()
+
+	req := httptest.NewRequest("DELETE", "/"+tenantID+"/admin/users/"+userID, nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": tenantID, "user_id": userID})
+	rr := httptest.NewRecorder()
+
+	handler.HandleDeleteUser(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockRepo.AssertExpectations(t)
+	mockCache.AssertExpectations(t)
+	mockAudit.AssertExpectations(t)
+}
+
+func TestHandleDeleteUser_NotFound(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+	handler := handlers.NewAdminHandler(mockRepo, mockCache, &config.Config{}, new(mocks.MockAuditRecorder), zap.NewNop())
+
+	mockRepo.On("DeleteUser", mock.Anything, "tenant-abc", "missing").Return(false, nil)
+
+	req := httptest.NewRequest("DELETE", "/tenant-abc/admin/users/missing", nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc", "user_id": "missing"})
+	rr := httptest.NewRecorder()
+
+	handler.HandleDeleteUser(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockCache.AssertNotCalled(t, "SetUserRevocationCutoff", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRotateSigningSecret(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+	mockAudit := new(mocks.MockAuditRecorder)
+	handler := handlers.NewAdminHandler(mockRepo, mockCache, &config.Config{}, mockAudit, zap.NewNop())
+	cipher, err := auth.NewSecretCipher(bytes.Repeat([]byte{0x42}, 32))
+	require.NoError(t, err)
+	handler.EnableHMACTenants(cipher)
+
+	var stored []byte
+	mockRepo.On("SetTenantHMACSecret", mock.Anything, "tenant-abc", mock.AnythingOfType("[]uint8")).
+		Run(func(args mock.Arguments) { stored = args.Get(2).([]byte) }).
+		Return(true, nil)
+	tenant := &models.Tenant{ID: "tenant-abc"}
+	mockRepo.On("GetTenantByID", mock.Anything, "tenant-abc").Return(tenant, nil)
+	mockCache.On("SetTenant", mock.Anything, tenant, 15*time.Minute).Return(nil)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.Type == audit.EventTenantSigningSecretRotate && e.TenantID == "tenant-abc"
+	})).Return()
+
+	req := httptest.NewRequest("POST", "/tenant-abc/admin/signing-secret", nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+
+	handler.HandleRotateSigningSecret(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var response models.TenantSigningSecretResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "HS256", response.Algorithm)
+
+	// Only the encrypted secret is stored, and it decrypts to what was returned
+	secret, err := base64.RawURLEncoding.DecodeString(response.Secret)
+	require.NoError(t, err)
+	assert.Len(t, secret, auth.HMACSecretLength)
+	assert.NotEqual(t, secret, stored)
+	decrypted, err := cipher.Decrypt(stored)
+	require.NoError(t, err)
+	assert.Equal(t, secret, decrypted)
+	mockCache.AssertExpectations(t)
+	mockAudit.AssertExpectations(t)
+}
+
+func TestHandleRotateSigningSecret_NotEnabled(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	handler := handlers.NewAdminHandler(mockRepo, new(mocks.MockCache), &config.Config{}, new(mocks.MockAuditRecorder), zap.NewNop())
+
+	req := httptest.NewRequest("POST", "/tenant-abc/admin/signing-secret", nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+
+	handler.HandleRotateSigningSecret(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockRepo.AssertNotCalled(t, "SetTenantHMACSecret", mock.Anything, mock.Anything, mock.Anything)
+}
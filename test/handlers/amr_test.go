@@ -0,0 +1,123 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func amrTestConfig() *config.Config {
+	return &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		GrantAMR: map[string]string{
+			"provision_user":             "pwd",
+			"client_credentials":         "client",
+			handlers.DeviceCodeGrantType: "device",
+		},
+	}
+}
+
+// issueForGrant obtains tokens for user-123 in tenant-abc with grantType and
+// returns the access token's claims and the stored refresh token data.
+func issueForGrant(t *testing.T, cfg *config.Config, grantType string) (map[string]interface{}, *models.RefreshTokenData) {
+	t.Helper()
+
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+
+	var stored *models.RefreshTokenData
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(*models.RefreshTokenData) }).
+		Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+
+	var req *http.Request
+	switch grantType {
+	case "provision_user":
+		mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+		req = newProvisionRequest("tenant-abc", nil)
+	case "client_credentials":
+		mockRepo.On("GetUserByID", mock.Anything, "user-123").Return(&models.User{ID: "user-123", TenantID: "tenant-abc"}, nil)
+		form := url.Values{}
+		form.Set("grant_type", "client_credentials")
+		form.Set("client_id", "test-client")
+		form.Set("client_secret", "test-secret")
+		form.Set("user_id", "user-123")
+		req = httptest.NewRequest("POST", "/tenant-abc/oauth2/v2.0/token", nil)
+		req.PostForm = form
+		req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	case handlers.DeviceCodeGrantType:
+		data := pendingDeviceCode(time.Now().Add(-10 * time.Second))
+		data.Subject = &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}
+		mockCache.On("GetDeviceCode", mock.Anything, "device-code").Return(data, nil)
+		mockCache.On("DeleteDeviceCode", mock.Anything, "device-code", "BCDFGHJK").Return(nil)
+		req = newDevicePollRequest("device-code")
+	default:
+		t.Fatalf("unsupported grant type %q", grantType)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return unverifiedClaims(t, response.AccessToken), stored
+}
+
+func TestHandleToken_AMRFromGrantType(t *testing.T) {
+	tests := []struct {
+		grantType string
+		wantAMR   string
+	}{
+		{grantType: "provision_user", wantAMR: "pwd"},
+		{grantType: "client_credentials", wantAMR: "client"},
+		{grantType: handlers.DeviceCodeGrantType, wantAMR: "device"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.grantType, func(t *testing.T) {
+			claims, stored := issueForGrant(t, amrTestConfig(), tt.grantType)
+
+			assert.Equal(t, []interface{}{tt.wantAMR}, claims["amr"])
+			require.NotNil(t, stored)
+			assert.Equal(t, []string{tt.wantAMR}, stored.Subject.AuthMethods, "refreshes must carry the session's amr forward")
+		})
+	}
+}
+
+func TestHandleToken_NoAMRForUnmappedGrant(t *testing.T) {
+	cfg := amrTestConfig()
+	delete(cfg.GrantAMR, "client_credentials")
+
+	claims, _ := issueForGrant(t, cfg, "client_credentials")
+
+	assert.NotContains(t, claims, "amr")
+}
+
+func TestHandleRefreshToken_KeepsSessionAMR(t *testing.T) {
+	claims, _ := refreshAndCapture(t, &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", AuthMethods: []string{"pwd"}},
+		IssuedAt:  time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	assert.Equal(t, []interface{}{"pwd"}, claims["amr"])
+}
@@ -0,0 +1,173 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newBasicAuthTokenRequest builds a client_credentials request for tenant-abc
+// with the given Authorization header and extra form fields.
+func newBasicAuthTokenRequest(authorization string, form url.Values) *http.Request {
+	form.Set("grant_type", "client_credentials")
+	form.Set("user_id", "user-123")
+	req := httptest.NewRequest("POST", "/tenant-abc/oauth2/v2.0/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", authorization)
+	return mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+}
+
+func TestHandleToken_ClientSecretBasic(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("GetUserByID", mock.Anything, "user-123").Return(&models.User{ID: "user-123", TenantID: "tenant-abc"}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.Anything, mock.Anything, cfg.RefreshTokenExpiry).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	authorization := "basic " + base64.StdEncoding.EncodeToString([]byte("test-client:test-secret"))
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newBasicAuthTokenRequest(authorization, url.Values{}))
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func TestHandleToken_MalformedAuthorizationHeader(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		form          url.Values
+		wantStatus    int
+		wantCode      string
+	}{
+		{"bad base64", "Basic %%%", url.Values{}, http.StatusUnauthorized, "INVALID_CREDENTIALS"},
+		{"missing colon", "Basic " + base64.StdEncoding.EncodeToString([]byte("test-client")), url.Values{}, http.StatusUnauthorized, "INVALID_CREDENTIALS"},
+		{"wrong scheme", "Bearer some-token", url.Values{}, http.StatusUnauthorized, "INVALID_CREDENTIALS"},
+		{"empty credentials", "Basic ", url.Values{}, http.StatusUnauthorized, "INVALID_CREDENTIALS"},
+		{
+			"header and form secret together",
+			"Basic " + base64.StdEncoding.EncodeToString([]byte("test-client:test-secret")),
+			url.Values{"client_secret": {"test-secret"}},
+			http.StatusBadRequest, "INVALID_REQUEST",
+		},
+		{
+			"form client_id disagrees with header",
+			"Basic " + base64.StdEncoding.EncodeToString([]byte("test-client:test-secret")),
+			url.Values{"client_id": {"other-client"}},
+			http.StatusBadRequest, "INVALID_REQUEST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+			handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+			rr := httptest.NewRecorder()
+			handler.HandleToken(rr, newBasicAuthTokenRequest(tt.authorization, tt.form))
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantCode, body["error"])
+			mockCache.AssertNotCalled(t, "GetClient", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "GetClientByID", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleVerify_BearerAuthorizationHeader(t *testing.T) {
+	_, tokenGen, tokenValidator := newVerifyTestSetup(t)
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantValid     bool
+	}{
+		{"valid bearer token", "Bearer " + token, http.StatusOK, true},
+		{"case-insensitive scheme", "BEARER " + token, http.StatusOK, true},
+		{"wrong scheme", "Basic " + token, http.StatusUnauthorized, false},
+		{"empty token", "Bearer ", http.StatusUnauthorized, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tenant-abc/oauth2/v1.0/verify", nil)
+			req.Header.Set("Authorization", tt.authorization)
+			req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+			rr := httptest.NewRecorder()
+
+			handler.HandleVerify(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code, rr.Body.String())
+			if tt.wantStatus == http.StatusOK {
+				var response models.VerifyResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Equal(t, tt.wantValid, response.Valid)
+			} else {
+				assert.Contains(t, rr.Body.String(), "INVALID_TOKEN")
+			}
+		})
+	}
+}
+
+func TestHandleVerify_BodyTokenTakesPrecedenceOverHeader(t *testing.T) {
+	_, tokenGen, tokenValidator := newVerifyTestSetup(t)
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+
+	bodyToken, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-body", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+	headerToken, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-header", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{"valid header token", "Bearer " + headerToken},
+		{"malformed header", "Basic " + headerToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(models.VerifyRequest{Token: bodyToken})
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/tenant-abc/oauth2/v1.0/verify", bytes.NewReader(body))
+			req.Header.Set("Authorization", tt.authorization)
+			req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+			rr := httptest.NewRecorder()
+
+			handler.HandleVerify(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			var response models.VerifyResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.True(t, response.Valid, response.Message)
+			assert.Equal(t, "user-body", response.Claims["sub"])
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"session-service/internal/audit"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// requestGrant sends a grantType request for "user-123" from "test-client",
+// whose allowed_grant_types are allowedGrantTypes.
+func requestGrant(t *testing.T, cfg *config.Config, allowedGrantTypes []string, grantType string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	cfg.JWTExpiry, cfg.RefreshTokenExpiry = time.Hour, 24*time.Hour
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte("test-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := &models.Client{ClientID: "test-client", ClientSecretHash: string(hashedSecret), RateLimit: 100, AllowedGrantTypes: allowedGrantTypes}
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserByID", mock.Anything, "user-123").Return(&models.User{ID: "user-123", TenantID: "tenant-abc"}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+
+	req := newProvisionRequest("tenant-abc", nil)
+	if grantType == "client_credentials" {
+		form := url.Values{}
+		form.Set("grant_type", "client_credentials")
+		form.Set("client_id", "test-client")
+		form.Set("client_secret", "test-secret")
+		form.Set("user_id", "user-123")
+		req = httptest.NewRequest("POST", "/tenant-abc/oauth2/v2.0/token", nil)
+		req.PostForm = form
+		req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	}
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, req)
+	return rr
+}
+
+func TestHandleToken_ClientGrantAllowlist(t *testing.T) {
+	rr := requestGrant(t, &config.Config{}, []string{"provision_user", "refresh_token"}, "client_credentials")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "UNAUTHORIZED_CLIENT")
+
+	rr = requestGrant(t, &config.Config{}, []string{"provision_user", "refresh_token"}, "provision_user")
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func TestHandleToken_NoClientAllowlistAllowsEveryGrant(t *testing.T) {
+	for _, grantType := range []string{"provision_user", "client_credentials"} {
+		t.Run(grantType, func(t *testing.T) {
+			rr := requestGrant(t, &config.Config{}, nil, grantType)
+
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+func TestSeparateProvisioning_RuntimeClientRejectedFromProvisioning(t *testing.T) {
+	tests := []struct {
+		name              string
+		allowedGrantTypes []string
+	}{
+		{name: "runtime allowlist", allowedGrantTypes: []string{"client_credentials", "refresh_token"}},
+		{name: "no allowlist", allowedGrantTypes: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{SeparateProvisioning: true}
+
+			rr := requestGrant(t, cfg, tt.allowedGrantTypes, "provision_user")
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), "UNAUTHORIZED_CLIENT")
+
+			rr = requestGrant(t, cfg, tt.allowedGrantTypes, "client_credentials")
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+func TestSeparateProvisioning_ProvisioningClientAllowed(t *testing.T) {
+	rr := requestGrant(t, &config.Config{SeparateProvisioning: true}, []string{"provision_user", "refresh_token"}, "provision_user")
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func TestSeparateProvisioning_MixedClientRejectedUnlessExempt(t *testing.T) {
+	mixed := []string{"provision_user", "client_credentials"}
+
+	for _, grantType := range mixed {
+		t.Run(grantType, func(t *testing.T) {
+			rr := requestGrant(t, &config.Config{SeparateProvisioning: true}, mixed, grantType)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), "UNAUTHORIZED_CLIENT")
+
+			rr = requestGrant(t, &config.Config{SeparateProvisioning: true, MixedGrantClients: []string{"test-client"}}, mixed, grantType)
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		})
+	}
+}
+
+func TestHandleListClients_ShowsGrantConflict(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockAudit := new(mocks.MockAuditRecorder)
+	handler := handlers.NewAdminHandler(mockRepo, new(mocks.MockCache), &config.Config{SeparateProvisioning: true}, mockAudit, zap.NewNop())
+	mockRepo.On("ListTenantClients", mock.Anything, "tenant-abc", handlers.DefaultPageLimit, 0).Return([]*models.Client{
+		{ClientID: "signup", TenantID: "tenant-abc", AllowedGrantTypes: []string{"provision_user"}},
+		{ClientID: "mixed", TenantID: "tenant-abc", AllowedGrantTypes: []string{"provision_user", "client_credentials"}},
+	}, 2, nil)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.Type == audit.EventTenantClientsList
+	})).Return()
+
+	req := httptest.NewRequest("GET", "/tenant-abc/admin/clients", nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+	handler.HandleListClients(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp models.ClientListResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	require.Len(t, resp.Clients, 2)
+	assert.Equal(t, []string{"provision_user"}, resp.Clients[0].AllowedGrantTypes)
+	assert.False(t, resp.Clients[0].GrantConflict)
+	assert.True(t, resp.Clients[1].GrantConflict)
+}
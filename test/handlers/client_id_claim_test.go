@@ -0,0 +1,48 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleToken_ClientIDClaim(t *testing.T) {
+	for _, grantType := range []string{"provision_user", "client_credentials"} {
+		t.Run(grantType, func(t *testing.T) {
+			cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, IncludeClientID: true}
+
+			claims, _ := issueForGrant(t, cfg, grantType)
+
+			assert.Equal(t, "test-client", claims["client_id"])
+		})
+	}
+}
+
+func TestHandleToken_NoClientIDClaimWhenDisabled(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+
+	claims, stored := issueForGrant(t, cfg, "client_credentials")
+
+	assert.NotContains(t, claims, "client_id")
+	require.NotNil(t, stored)
+	assert.Empty(t, stored.Subject.ClientID)
+}
+
+func TestHandleRefreshToken_DropsClientIDClaimWhenDisabled(t *testing.T) {
+	// The session started while the claim was enabled
+	claims, stored := refreshAndCapture(t, &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", ClientID: "test-client"},
+		IssuedAt:  time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	assert.NotContains(t, claims, "client_id")
+	require.NotNil(t, stored)
+	assert.Empty(t, stored.Subject.ClientID)
+}
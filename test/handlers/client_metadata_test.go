@@ -0,0 +1,105 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"session-service/internal/audit"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newClientAdminHandler() (*handlers.AdminHandler, *mocks.MockRepository, *mocks.MockAuditRecorder) {
+	mockRepo := new(mocks.MockRepository)
+	mockAudit := new(mocks.MockAuditRecorder)
+	handler := handlers.NewAdminHandler(mockRepo, new(mocks.MockCache), &config.Config{}, mockAudit, zap.NewNop())
+	return handler, mockRepo, mockAudit
+}
+
+func updateClient(handler *handlers.AdminHandler, clientID, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("PATCH", "/tenant-abc/admin/clients/"+clientID, strings.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc", "client_id": clientID})
+	rr := httptest.NewRecorder()
+	handler.HandleUpdateClient(rr, req)
+	return rr
+}
+
+func TestHandleUpdateClient_NameRoundTripsThroughList(t *testing.T) {
+	handler, mockRepo, mockAudit := newClientAdminHandler()
+	name := "Billing backend"
+	updated := &models.Client{
+		ClientID:         "billing",
+		ClientSecretHash: "$2a$10$secret",
+		TenantID:         "tenant-abc",
+		RateLimit:        100,
+		Name:             name,
+	}
+	mockRepo.On("UpdateClientMetadata", mock.Anything, "tenant-abc", "billing", models.ClientMetadata{Name: &name}).Return(updated, nil)
+	mockRepo.On("ListTenantClients", mock.Anything, "tenant-abc", handlers.DefaultPageLimit, 0).Return([]*models.Client{updated}, 1, nil)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.Type == audit.EventClientUpdate && e.TargetID == "billing" && e.Metadata["client_name"] == name
+	})).Return().Once()
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.Type == audit.EventTenantClientsList && e.TenantID == "tenant-abc"
+	})).Return().Once()
+
+	rr := updateClient(handler, "billing", `{"name":"Billing backend"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var summary models.ClientSummary
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&summary))
+	assert.Equal(t, name, summary.Name)
+
+	req := httptest.NewRequest("GET", "/tenant-abc/admin/clients", nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr = httptest.NewRecorder()
+	handler.HandleListClients(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "$2a$10$secret")
+	var resp models.ClientListResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	require.Len(t, resp.Clients, 1)
+	assert.Equal(t, "billing", resp.Clients[0].ClientID)
+	assert.Equal(t, name, resp.Clients[0].Name)
+	assert.Equal(t, 1, resp.Total)
+	mockAudit.AssertExpectations(t)
+}
+
+func TestHandleUpdateClient_Rejections(t *testing.T) {
+	handler, mockRepo, mockAudit := newClientAdminHandler()
+	mockRepo.On("UpdateClientMetadata", mock.Anything, "tenant-abc", "missing", mock.Anything).Return(nil, nil)
+
+	assert.Equal(t, http.StatusBadRequest, updateClient(handler, "billing", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, updateClient(handler, "billing", `not json`).Code)
+	assert.Equal(t, http.StatusBadRequest, updateClient(handler, "billing", `{"name":"`+strings.Repeat("x", 256)+`"}`).Code)
+	assert.Equal(t, http.StatusNotFound, updateClient(handler, "missing", `{"description":"gone"}`).Code)
+	mockAudit.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+}
+
+func TestHandleUpdateClient_UnnamedClientAuditedWithoutName(t *testing.T) {
+	handler, mockRepo, mockAudit := newClientAdminHandler()
+	cleared := ""
+	mockRepo.On("UpdateClientMetadata", mock.Anything, "tenant-abc", "billing", models.ClientMetadata{Name: &cleared}).
+		Return(&models.Client{ClientID: "billing", TenantID: "tenant-abc"}, nil)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		_, named := e.Metadata["client_name"]
+		return e.Type == audit.EventClientUpdate && !named
+	})).Return().Once()
+
+	rr := updateClient(handler, "billing", `{"name":""}`)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), `"name"`)
+	mockAudit.AssertExpectations(t)
+}
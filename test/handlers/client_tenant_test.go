@@ -0,0 +1,103 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// expectBoundClient sets up the cache so "test-client", bound to tenantID,
+// authenticates and is within its rate limit.
+func expectBoundClient(t *testing.T, mockCache *mocks.MockCache, tenantID string) {
+	t.Helper()
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte("test-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := &models.Client{ClientID: "test-client", ClientSecretHash: string(hashedSecret), RateLimit: 100, TenantID: tenantID}
+
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+}
+
+func clientTenantTestConfig() *config.Config {
+	return &config.Config{
+		JWTExpiry:                time.Hour,
+		RefreshTokenExpiry:       24 * time.Hour,
+		ClientTenantPlaceholders: []string{"me", "default"},
+	}
+}
+
+func TestClientTenantPlaceholder_ResolvesToClientTenant(t *testing.T) {
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, clientTenantTestConfig())
+	expectBoundClient(t, mockCache, "tenant-abc")
+
+	var stored models.User
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(models.User) }).
+		Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), 24*time.Hour).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("me", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "tenant-abc", stored.TenantID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestClientTenantPlaceholder_Rejections(t *testing.T) {
+	tests := []struct {
+		name         string
+		pathTenant   string
+		clientTenant string
+		wantMessage  string
+	}{
+		{"explicit mismatched tenant", "tenant-xyz", "tenant-abc", "Client is bound to a different tenant"},
+		{"placeholder for unbound client", "me", "", "Client is not bound to a tenant; name the tenant in the path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo, mockCache := newRefreshTestHandler(t, clientTenantTestConfig())
+			expectBoundClient(t, mockCache, tt.clientTenant)
+
+			rr := httptest.NewRecorder()
+			handler.HandleToken(rr, newProvisionRequest(tt.pathTenant, nil))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, "INVALID_REQUEST", body["error"])
+			assert.Equal(t, tt.wantMessage, body["error_description"])
+			mockRepo.AssertNotCalled(t, "EnsureTenantExists", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "UpsertUserAndRoles", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestClientTenantPlaceholder_DisabledByDefault(t *testing.T) {
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour})
+	expectBoundClient(t, mockCache, "tenant-abc")
+	mockRepo.On("EnsureTenantExists", mock.Anything, "me").Return(assert.AnError)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("me", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockRepo.AssertCalled(t, "EnsureTenantExists", mock.Anything, "me")
+}
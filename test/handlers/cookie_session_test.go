@@ -0,0 +1,181 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newCookieSessionHandler(t *testing.T) (*handlers.TokenHandler, *mocks.MockRepository, *mocks.MockCache, *config.Config) {
+	t.Helper()
+
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	handler.EnableCookieSessions("sid")
+	return handler, mockRepo, mockCache, cfg
+}
+
+func newSessionRefreshRequest(tenantID string, cookie *http.Cookie) *http.Request {
+	req := httptest.NewRequest("POST", "/"+tenantID+"/oauth2/v1.0/session/refresh", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	return mux.SetURLVars(req, map[string]string{"tenant_id": tenantID})
+}
+
+func sessionCookie(t *testing.T, rr *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+	return cookies[0]
+}
+
+func TestHandleUserProvisioning_SetsSessionCookie(t *testing.T) {
+	handler, mockRepo, mockCache, cfg := newCookieSessionHandler(t)
+
+	var refreshToken, sessionID string
+	expectAuthenticatedClient(t, mockCache)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) { refreshToken = args.String(1) }).
+		Return(nil)
+	mockCache.On("StoreCookieSession", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) {
+			sessionID = args.String(1)
+			assert.Equal(t, refreshToken, args.String(2))
+		}).
+		Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"session_cookie": "true"}))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.AccessToken)
+	assert.Empty(t, response.RefreshToken, "the refresh token must not reach the browser")
+
+	cookie := sessionCookie(t, rr)
+	assert.Equal(t, "sid", cookie.Name)
+	assert.Equal(t, sessionID, cookie.Value)
+	assert.NotEqual(t, refreshToken, cookie.Value)
+	assert.Equal(t, "/tenant-abc/oauth2/v1.0/session", cookie.Path)
+	assert.True(t, cookie.HttpOnly)
+	assert.True(t, cookie.Secure)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+	assert.Equal(t, int(cfg.RefreshTokenExpiry.Seconds()), cookie.MaxAge)
+}
+
+func TestHandleUserProvisioning_NoSessionCookieUnlessRequested(t *testing.T) {
+	handler, mockRepo, mockCache, cfg := newCookieSessionHandler(t)
+
+	expectAuthenticatedClient(t, mockCache)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.RefreshToken)
+	assert.Empty(t, rr.Result().Cookies())
+	mockCache.AssertNotCalled(t, "StoreCookieSession", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleSessionRefresh_IssuesNewTokens(t *testing.T) {
+	handler, mockRepo, mockCache, cfg := newCookieSessionHandler(t)
+
+	tokenData := &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", SessionID: "session-1"},
+		IssuedAt:  time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	var newRefreshToken string
+	mockCache.On("GetCookieSession", mock.Anything, "cookie-session").Return("old-refresh", nil)
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(&models.Client{ClientID: "test-client", RateLimit: 100}, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("RevokeRefreshToken", mock.Anything, "old-refresh", cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("DeleteRefreshToken", mock.Anything, "old-refresh").Return(nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) { newRefreshToken = args.String(1) }).
+		Return(nil)
+	mockCache.On("StoreCookieSession", mock.Anything, "cookie-session", mock.AnythingOfType("string"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) { assert.Equal(t, newRefreshToken, args.String(2)) }).
+		Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleSessionRefresh(rr, newSessionRefreshRequest("tenant-abc", &http.Cookie{Name: "sid", Value: "cookie-session"}))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Empty(t, response.RefreshToken)
+	assert.Equal(t, "session-1", unverifiedClaims(t, response.AccessToken)["sid"])
+	assert.NotEqual(t, "old-refresh", newRefreshToken)
+
+	cookie := sessionCookie(t, rr)
+	assert.Equal(t, "cookie-session", cookie.Value)
+	assert.Equal(t, int(cfg.RefreshTokenExpiry.Seconds()), cookie.MaxAge)
+	mockCache.AssertExpectations(t)
+}
+
+func TestHandleSessionRefresh_UnknownSessionClearsCookie(t *testing.T) {
+	handler, _, mockCache, _ := newCookieSessionHandler(t)
+	mockCache.On("GetCookieSession", mock.Anything, "expired-session").Return("", nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleSessionRefresh(rr, newSessionRefreshRequest("tenant-abc", &http.Cookie{Name: "sid", Value: "expired-session"}))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	cookie := sessionCookie(t, rr)
+	assert.Empty(t, cookie.Value)
+	assert.Negative(t, cookie.MaxAge)
+}
+
+func TestHandleSessionRefresh_RequiresCookie(t *testing.T) {
+	handler, _, _, _ := newCookieSessionHandler(t)
+
+	rr := httptest.NewRecorder()
+	handler.HandleSessionRefresh(rr, newSessionRefreshRequest("tenant-abc", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestHandleSessionRefresh_NotFoundWhenDisabled(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, _, _ := newRefreshTestHandler(t, cfg)
+
+	rr := httptest.NewRecorder()
+	handler.HandleSessionRefresh(rr, newSessionRefreshRequest("tenant-abc", &http.Cookie{Name: "sid", Value: "cookie-session"}))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,278 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/middleware"
+	"session-service/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func deviceTestConfig() *config.Config {
+	return &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		BaseURL:            "https://auth.example.com",
+		DeviceCodeExpiry:   10 * time.Minute,
+		DevicePollInterval: 5 * time.Second,
+	}
+}
+
+// newDevicePollRequest builds a device_code grant request for tenant-abc.
+func newDevicePollRequest(deviceCode string) *http.Request {
+	form := url.Values{}
+	form.Set("grant_type", handlers.DeviceCodeGrantType)
+	form.Set("client_id", "test-client")
+	form.Set("client_secret", "test-secret")
+	form.Set("device_code", deviceCode)
+
+	req := httptest.NewRequest("POST", "/tenant-abc/oauth2/v2.0/token", nil)
+	req.PostForm = form
+	return mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+}
+
+// pendingDeviceCode returns an unapproved device authorization for test-client in tenant-abc.
+func pendingDeviceCode(lastPolledAt time.Time) *models.DeviceCodeData {
+	return &models.DeviceCodeData{
+		ClientID:     "test-client",
+		TenantID:     "tenant-abc",
+		UserCode:     "BCDFGHJK",
+		Interval:     5,
+		LastPolledAt: lastPolledAt,
+		ExpiresAt:    time.Now().Add(5 * time.Minute),
+	}
+}
+
+func pollDeviceCode(t *testing.T, handler *handlers.TokenHandler) (*httptest.ResponseRecorder, map[string]string) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newDevicePollRequest("device-code"))
+
+	var body map[string]string
+	_ = json.Unmarshal(rr.Body.Bytes(), &body)
+	return rr, body
+}
+
+func TestHandleDeviceAuthorization_IssuesCodes(t *testing.T) {
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, deviceTestConfig())
+	expectAuthenticatedClient(t, mockCache)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockCache.On("GetDeviceCodeByUserCode", mock.Anything, mock.AnythingOfType("string")).Return("", nil)
+
+	var stored *models.DeviceCodeData
+	mockCache.On("StoreDeviceCode", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.DeviceCodeData"), 10*time.Minute).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(*models.DeviceCodeData) }).
+		Return(nil)
+
+	form := url.Values{}
+	form.Set("client_id", "test-client")
+	form.Set("client_secret", "test-secret")
+	req := httptest.NewRequest("POST", "/tenant-abc/oauth2/v1.0/device_authorization", nil)
+	req.PostForm = form
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+
+	rr := httptest.NewRecorder()
+	handler.HandleDeviceAuthorization(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.DeviceAuthorizationResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.DeviceCode)
+	assert.Regexp(t, regexp.MustCompile(`^[A-Z]{4}-[A-Z]{4}$`), response.UserCode)
+	assert.Equal(t, "https://auth.example.com/tenant-abc/oauth2/v1.0/device", response.VerificationURI)
+	assert.Equal(t, int64(600), response.ExpiresIn)
+	assert.Equal(t, 5, response.Interval)
+
+	require.NotNil(t, stored)
+	assert.Equal(t, "test-client", stored.ClientID)
+	assert.Equal(t, "tenant-abc", stored.TenantID)
+	assert.Nil(t, stored.Subject)
+}
+
+func TestHandleToken_DeviceCodePending(t *testing.T) {
+	handler, _, mockCache := newRefreshTestHandler(t, deviceTestConfig())
+	expectAuthenticatedClient(t, mockCache)
+	mockCache.On("GetDeviceCode", mock.Anything, "device-code").Return(pendingDeviceCode(time.Time{}), nil)
+	mockCache.On("StoreDeviceCode", mock.Anything, "device-code", mock.MatchedBy(func(d *models.DeviceCodeData) bool {
+		return !d.LastPolledAt.IsZero() && d.Interval == 5
+	}), mock.AnythingOfType("time.Duration")).Return(nil)
+
+	rr, body := pollDeviceCode(t, handler)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "authorization_pending", body["error"])
+	mockCache.AssertExpectations(t)
+}
+
+func TestHandleToken_DeviceCodeSlowDown(t *testing.T) {
+	handler, _, mockCache := newRefreshTestHandler(t, deviceTestConfig())
+	expectAuthenticatedClient(t, mockCache)
+	mockCache.On("GetDeviceCode", mock.Anything, "device-code").Return(pendingDeviceCode(time.Now().Add(-time.Second)), nil)
+	mockCache.On("StoreDeviceCode", mock.Anything, "device-code", mock.MatchedBy(func(d *models.DeviceCodeData) bool {
+		return d.Interval == 10
+	}), mock.AnythingOfType("time.Duration")).Return(nil)
+
+	rr, body := pollDeviceCode(t, handler)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "slow_down", body["error"])
+	mockCache.AssertExpectations(t)
+}
+
+func TestHandleToken_DeviceCodeExpired(t *testing.T) {
+	handler, _, mockCache := newRefreshTestHandler(t, deviceTestConfig())
+	expectAuthenticatedClient(t, mockCache)
+	data := pendingDeviceCode(time.Time{})
+	data.ExpiresAt = time.Now().Add(-time.Second)
+	mockCache.On("GetDeviceCode", mock.Anything, "device-code").Return(data, nil)
+	mockCache.On("DeleteDeviceCode", mock.Anything, "device-code", "BCDFGHJK").Return(nil)
+
+	rr, body := pollDeviceCode(t, handler)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "expired_token", body["error"])
+}
+
+func TestHandleToken_DeviceCodeApprovedIssuesTokens(t *testing.T) {
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, deviceTestConfig())
+	expectAuthenticatedClient(t, mockCache)
+	data := pendingDeviceCode(time.Now().Add(-10 * time.Second))
+	data.Subject = &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Roles: []string{"reader"}}
+	mockCache.On("GetDeviceCode", mock.Anything, "device-code").Return(data, nil)
+	mockCache.On("DeleteDeviceCode", mock.Anything, "device-code", "BCDFGHJK").Return(nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), 24*time.Hour).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newDevicePollRequest("device-code"))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.NotEmpty(t, response.RefreshToken)
+	claims := unverifiedClaims(t, response.AccessToken)
+	assert.Equal(t, "user-123", claims["sub"])
+	assert.Equal(t, "tenant-abc", claims["tid"])
+	mockCache.AssertCalled(t, "DeleteDeviceCode", mock.Anything, "device-code", "BCDFGHJK")
+}
+
+func TestHandleDeviceApproval_AttachesUser(t *testing.T) {
+	_, tokenGen, tokenValidator := newVerifyTestSetup(t)
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, deviceTestConfig())
+
+	mockCache.On("GetDeviceCodeByUserCode", mock.Anything, "BCDFGHJK").Return("device-code", nil)
+	mockCache.On("GetDeviceCode", mock.Anything, "device-code").Return(pendingDeviceCode(time.Time{}), nil)
+	mockRepo.On("GetUserByID", mock.Anything, "user-123").Return(&models.User{ID: "user-123", TenantID: "tenant-abc"}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{"reader"}, nil)
+	mockCache.On("StoreDeviceCode", mock.Anything, "device-code", mock.MatchedBy(func(d *models.DeviceCodeData) bool {
+		return d.Subject != nil && d.Subject.UserID == "user-123" && d.Subject.TenantID == "tenant-abc"
+	}), mock.AnythingOfType("time.Duration")).Return(nil)
+
+	userToken, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Handle("/{tenant_id}/oauth2/v1.0/device",
+		middleware.RequireTenantToken(tokenValidator, zap.NewNop())(http.HandlerFunc(handler.HandleDeviceApproval)))
+
+	form := url.Values{}
+	form.Set("user_code", "bcdf-ghjk")
+	req := httptest.NewRequest("POST", "/tenant-abc/oauth2/v1.0/device", nil)
+	req.PostForm = form
+	req.Header.Set("Authorization", "Bearer "+userToken)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	mockCache.AssertExpectations(t)
+}
+
+// authorizeDevice starts a device authorization for test-client in
+// tenant-abc with loginHint and returns the stored device code data.
+func authorizeDevice(t *testing.T, cfg *config.Config, loginHint string) *models.DeviceCodeData {
+	t.Helper()
+
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockCache.On("GetDeviceCodeByUserCode", mock.Anything, mock.AnythingOfType("string")).Return("", nil)
+
+	var stored *models.DeviceCodeData
+	mockCache.On("StoreDeviceCode", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.DeviceCodeData"), cfg.DeviceCodeExpiry).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(*models.DeviceCodeData) }).
+		Return(nil)
+
+	form := url.Values{}
+	form.Set("client_id", "test-client")
+	form.Set("client_secret", "test-secret")
+	form.Set("login_hint", loginHint)
+	req := httptest.NewRequest("POST", "/tenant-abc/oauth2/v1.0/device_authorization", nil)
+	req.PostForm = form
+
+	rr := httptest.NewRecorder()
+	handler.HandleDeviceAuthorization(rr, mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"}))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NotNil(t, stored)
+	return stored
+}
+
+func TestHandleDeviceApproval_EchoesLoginHint(t *testing.T) {
+	cfg := deviceTestConfig()
+	cfg.DeviceLoginHint = true
+	data := authorizeDevice(t, cfg, "alice@example.com")
+	require.Equal(t, "alice@example.com", data.LoginHint)
+
+	_, tokenGen, tokenValidator := newVerifyTestSetup(t)
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	mockCache.On("GetDeviceCodeByUserCode", mock.Anything, data.UserCode).Return("device-code", nil)
+	mockCache.On("GetDeviceCode", mock.Anything, "device-code").Return(data, nil)
+	mockRepo.On("GetUserByID", mock.Anything, "user-123").Return(&models.User{ID: "user-123", TenantID: "tenant-abc"}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("StoreDeviceCode", mock.Anything, "device-code", mock.MatchedBy(func(d *models.DeviceCodeData) bool {
+		return d.Subject != nil && d.LoginHint == "alice@example.com"
+	}), mock.AnythingOfType("time.Duration")).Return(nil)
+
+	userToken, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Handle("/{tenant_id}/oauth2/v1.0/device",
+		middleware.RequireTenantToken(tokenValidator, zap.NewNop())(http.HandlerFunc(handler.HandleDeviceApproval)))
+
+	form := url.Values{}
+	form.Set("user_code", data.UserCode)
+	req := httptest.NewRequest("POST", "/tenant-abc/oauth2/v1.0/device", nil)
+	req.PostForm = form
+	req.Header.Set("Authorization", "Bearer "+userToken)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "approved", body["status"])
+	assert.Equal(t, "alice@example.com", body["login_hint"])
+	mockCache.AssertExpectations(t)
+}
+
+func TestHandleDeviceAuthorization_IgnoresLoginHintWhenDisabled(t *testing.T) {
+	data := authorizeDevice(t, deviceTestConfig(), "alice@example.com")
+
+	assert.Empty(t, data.LoginHint)
+}
@@ -0,0 +1,154 @@
+package handlers_test
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/config"
+	"session-service/internal/models"
+	"session-service/test/helpers"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHandleUserProvisioning_EncryptsTokensForOptedInClient(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, JWTAudience: "audience"}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte("test-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := &models.Client{ClientID: "test-client", ClientSecretHash: string(hashedSecret), RateLimit: 100, EncryptAccessTokens: true}
+	rsPrivPEM, rsPubPEM := helpers.GenerateTestPEMKeys(t)
+
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockRepo.On("GetAudienceEncryptionKey", mock.Anything, "audience").Return(rsPubPEM, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), 24*time.Hour).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	block, _ := pem.Decode([]byte(rsPrivPEM))
+	rsKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	require.NoError(t, err)
+	inner, err := jwe.Decrypt([]byte(response.AccessToken), jwe.WithKey(auth.TokenKeyEncryption, rsKey))
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(string(inner), claims)
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", claims["sub"])
+	assert.Equal(t, "tenant-abc", claims["tid"])
+}
+
+func TestHandleUserProvisioning_EncryptsForScopeAudience(t *testing.T) {
+	cfg := &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		JWTAudience:        "audience",
+		ScopeAudiences:     map[string]string{"orders.read": "api://orders"},
+	}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte("test-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := &models.Client{ClientID: "test-client", ClientSecretHash: string(hashedSecret), RateLimit: 100, EncryptAccessTokens: true}
+	ordersPrivPEM, ordersPubPEM := helpers.GenerateTestPEMKeys(t)
+
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockRepo.On("GetAudienceEncryptionKey", mock.Anything, "api://orders").Return(ordersPubPEM, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), 24*time.Hour).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"scope": "orders.read"}))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	block, _ := pem.Decode([]byte(ordersPrivPEM))
+	ordersKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	require.NoError(t, err)
+	inner, err := jwe.Decrypt([]byte(response.AccessToken), jwe.WithKey(auth.TokenKeyEncryption, ordersKey))
+	require.NoError(t, err, "the orders service can decrypt the token")
+
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(string(inner), claims)
+	require.NoError(t, err)
+	assert.Equal(t, "api://orders", claims["aud"])
+	mockRepo.AssertNotCalled(t, "GetAudienceEncryptionKey", mock.Anything, "audience")
+}
+
+func TestHandleUserProvisioning_EncryptionWithoutAudienceKeyFails(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, JWTAudience: "audience"}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte("test-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := &models.Client{ClientID: "test-client", ClientSecretHash: string(hashedSecret), RateLimit: 100, EncryptAccessTokens: true}
+
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockRepo.On("GetAudienceEncryptionKey", mock.Anything, "audience").Return("", nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", nil))
+
+	// Never fall back to a readable token for a client that asked for encryption
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	mockCache.AssertNotCalled(t, "StoreRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleUserProvisioning_EncryptionForSeveralAudiencesFails(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, JWTAudience: "audience,internal-bff"}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte("test-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := &models.Client{ClientID: "test-client", ClientSecretHash: string(hashedSecret), RateLimit: 100, EncryptAccessTokens: true}
+
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", nil))
+
+	// Only one resource server could decrypt the token
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	mockRepo.AssertNotCalled(t, "GetAudienceEncryptionKey", mock.Anything, mock.Anything)
+	mockCache.AssertNotCalled(t, "StoreRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,121 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"session-service/internal/audit"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newEventStreamServer serves the admin event stream of a handler that
+// records its audit events through the returned broadcaster.
+func newEventStreamServer(t *testing.T) (*httptest.Server, *audit.Broadcaster) {
+	t.Helper()
+
+	next := new(mocks.MockAuditRecorder)
+	next.On("Record", mock.Anything, mock.Anything).Return()
+	broadcaster := audit.NewBroadcaster(next, 8)
+	handler := handlers.NewAdminHandler(new(mocks.MockRepository), new(mocks.MockCache), &config.Config{}, broadcaster, zap.NewNop())
+	handler.EnableEventStream(broadcaster)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/{tenant_id}/admin/events", handler.HandleEventStream).Methods("GET")
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv, broadcaster
+}
+
+// readEvent returns the data of the next server-sent event of eventType.
+func readEvent(t *testing.T, reader *bufio.Reader, eventType string) audit.Event {
+	t.Helper()
+
+	current := ""
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if value, ok := strings.CutPrefix(line, "event: "); ok {
+			current = value
+		}
+		if value, ok := strings.CutPrefix(line, "data: "); ok && current == eventType {
+			var event audit.Event
+			require.NoError(t, json.Unmarshal([]byte(value), &event))
+			return event
+		}
+	}
+}
+
+func TestHandleEventStream_DeliversRedactedEvents(t *testing.T) {
+	srv, broadcaster := newEventStreamServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"/tenant-abc/admin/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+
+	// Opening the stream is itself audited, and so streamed
+	readEvent(t, reader, audit.EventTenantEventsStream)
+
+	broadcaster.Record(context.Background(), audit.Event{
+		Type:     audit.EventTenantSigningSecretRotate,
+		TenantID: "tenant-abc",
+		ActorID:  "admin-1",
+		Metadata: map[string]string{"client_secret": "s3cr3t", "client_name": "Billing"},
+	})
+	broadcaster.Record(context.Background(), audit.Event{Type: audit.EventUserDelete, TenantID: "tenant-xyz"})
+
+	event := readEvent(t, reader, audit.EventTenantSigningSecretRotate)
+	assert.Equal(t, "tenant-abc", event.TenantID)
+	assert.Equal(t, "admin-1", event.ActorID)
+	assert.Equal(t, map[string]string{"client_secret": "[REDACTED]", "client_name": "Billing"}, event.Metadata)
+}
+
+func TestHandleEventStream_DisconnectEndsSubscription(t *testing.T) {
+	srv, broadcaster := newEventStreamServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"/tenant-abc/admin/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, 1, broadcaster.Subscribers("tenant-abc"))
+
+	cancel()
+	resp.Body.Close()
+
+	assert.Eventually(t, func() bool { return broadcaster.Subscribers("tenant-abc") == 0 }, time.Second, 10*time.Millisecond)
+	broadcaster.Record(context.Background(), audit.Event{Type: audit.EventUserDelete, TenantID: "tenant-abc"})
+}
+
+func TestHandleEventStream_DisabledIsNotFound(t *testing.T) {
+	handler := handlers.NewAdminHandler(new(mocks.MockRepository), new(mocks.MockCache), &config.Config{}, new(mocks.MockAuditRecorder), zap.NewNop())
+
+	req := httptest.NewRequest("GET", "/tenant-abc/admin/events", nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+	handler.HandleEventStream(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
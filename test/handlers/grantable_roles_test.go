@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// provisionWithRoles provisions "user-123" with userRoles from "test-client",
+// whose grantable_roles are grantableRoles.
+func provisionWithRoles(t *testing.T, cfg *config.Config, grantableRoles []string, userRoles string) (*httptest.ResponseRecorder, *mocks.MockRepository) {
+	t.Helper()
+
+	cfg.JWTExpiry, cfg.RefreshTokenExpiry = time.Hour, 24*time.Hour
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte("test-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := &models.Client{ClientID: "test-client", ClientSecretHash: string(hashedSecret), RateLimit: 100, GrantableRoles: grantableRoles}
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("ListTenantRoles", mock.Anything, "tenant-abc").Return([]string{}, nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), mock.Anything).Return(false, nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"user_roles": userRoles}))
+	return rr, mockRepo
+}
+
+func TestHandleUserProvisioning_GrantableRoles(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            *config.Config
+		grantableRoles []string
+		userRoles      string
+		wantRejected   string
+	}{
+		{name: "roles within the list", grantableRoles: []string{"reader", "writer"}, userRoles: "reader, writer"},
+		{name: "role outside the list", grantableRoles: []string{"reader", "writer"}, userRoles: "reader, tenant-admin", wantRejected: "tenant-admin"},
+		{name: "no list", userRoles: "reader, tenant-admin"},
+		{name: "privileged role without a list", cfg: &config.Config{PrivilegedRoles: []string{"tenant-admin"}}, userRoles: "reader, tenant-admin", wantRejected: "tenant-admin"},
+		{name: "privileged role in the list", cfg: &config.Config{PrivilegedRoles: []string{"tenant-admin"}}, grantableRoles: []string{"reader", "tenant-admin"}, userRoles: "reader, tenant-admin"},
+		{name: "admin role without a list", cfg: &config.Config{AdminRole: "tenant-admin"}, userRoles: "reader, tenant-admin", wantRejected: "tenant-admin"},
+		{name: "admin role with other privileged roles", cfg: &config.Config{AdminRole: "tenant-admin", PrivilegedRoles: []string{"billing-admin"}}, userRoles: "tenant-admin, billing-admin", wantRejected: "tenant-admin, billing-admin"},
+		{name: "admin role in the list", cfg: &config.Config{AdminRole: "tenant-admin"}, grantableRoles: []string{"reader", "tenant-admin"}, userRoles: "reader, tenant-admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if cfg == nil {
+				cfg = &config.Config{}
+			}
+
+			rr, mockRepo := provisionWithRoles(t, cfg, tt.grantableRoles, tt.userRoles)
+
+			if tt.wantRejected == "" {
+				assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+				return
+			}
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, "FORBIDDEN", body["error"])
+			assert.Equal(t, "Client may not grant roles: "+tt.wantRejected, body["error_description"])
+			mockRepo.AssertNotCalled(t, "UpsertUserAndRoles", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -75,6 +75,9 @@ func TestHandleToken_ClientCredentials(t *testing.T) {
 
 	// Tenant must exist
 	mockRepo.On("EnsureTenantExists", mock.Anything, tenantID).Return(nil)
+	mockCache.On("GetTenant", mock.Anything, tenantID).Return(nil, nil)
+	mockRepo.On("GetTenantByID", mock.Anything, tenantID).Return(&models.Tenant{ID: tenantID}, nil)
+	mockCache.On("SetTenant", mock.Anything, mock.AnythingOfType("*models.Tenant"), 15*time.Minute).Return(nil)
 	// User must already exist for client_credentials
 	mockRepo.On("GetUserByID", mock.Anything, userID).Return(existingUser, nil)
 	// Roles fetched from DB
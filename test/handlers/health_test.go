@@ -0,0 +1,88 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/handlers"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleReadiness(t *testing.T) {
+	tests := []struct {
+		name     string
+		repoErr  error
+		cacheErr error
+		want     int
+	}{
+		{"dependencies reachable", nil, nil, http.StatusOK},
+		{"database down", errors.New("connection refused"), nil, http.StatusServiceUnavailable},
+		{"cache down", nil, errors.New("connection refused"), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockRepository)
+			mockCache := new(mocks.MockCache)
+			mockRepo.On("Ping", mock.Anything).Return(tt.repoErr)
+			mockCache.On("Ping", mock.Anything).Return(tt.cacheErr)
+			handler := handlers.NewHealthHandler(mockRepo, mockCache, zap.NewNop())
+
+			rr := httptest.NewRecorder()
+			handler.HandleReadiness(rr, httptest.NewRequest("GET", "/readyz", nil))
+
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+}
+
+func TestHandleReadiness_DeepSigningCheck(t *testing.T) {
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	_, otherPubKey := helpers.GenerateTestPEMKeys(t)
+
+	tests := []struct {
+		name     string
+		pubKey   string
+		audience string
+		query    string
+		want     int
+	}{
+		{"signing and verification work", pubKey, "audience", "?deep=true", http.StatusOK},
+		{"mismatched key pair", otherPubKey, "audience", "?deep=true", http.StatusServiceUnavailable},
+		{"validator expects another audience", pubKey, "other-audience", "?deep=true", http.StatusServiceUnavailable},
+		{"shallow check skips signing", otherPubKey, "audience", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km, err := auth.NewKeyManager(privKey, tt.pubKey)
+			require.NoError(t, err)
+			mockRepo := new(mocks.MockRepository)
+			mockCache := new(mocks.MockCache)
+			mockRepo.On("Ping", mock.Anything).Return(nil)
+			mockCache.On("Ping", mock.Anything).Return(nil)
+			handler := handlers.NewHealthHandler(mockRepo, mockCache, zap.NewNop())
+			handler.EnableSigningCheck(
+				auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32),
+				auth.NewTokenValidator(km, "issuer", tt.audience, mockCache),
+			)
+
+			rr := httptest.NewRecorder()
+			handler.HandleReadiness(rr, httptest.NewRequest("GET", "/readyz"+tt.query, nil))
+
+			assert.Equal(t, tt.want, rr.Code, rr.Body.String())
+			// The round-trip never touches revocation state
+			mockCache.AssertNotCalled(t, "IsTokenRevoked", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// provisionForIDToken provisions "user-123" with the given request overrides
+// and returns the token response.
+func provisionForIDToken(t *testing.T, overrides map[string]string) models.TokenResponse {
+	t.Helper()
+
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", overrides))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return response
+}
+
+func TestHandleToken_IDTokenForOpenIDScope(t *testing.T) {
+	response := provisionForIDToken(t, map[string]string{"scope": "openid", "nonce": "n-0S6_WzA2Mj"})
+	require.NotEmpty(t, response.IDToken)
+
+	claims := unverifiedClaims(t, response.IDToken)
+	assert.Equal(t, "user-123", claims["sub"])
+	assert.Equal(t, "tenant-abc", claims["tid"])
+	assert.Equal(t, "test-client", claims["aud"])
+	assert.Equal(t, "n-0S6_WzA2Mj", claims["nonce"])
+	assert.Contains(t, claims, "auth_time")
+	for _, pii := range []string{"email", "phone_number", "name", "roles"} {
+		assert.NotContains(t, claims, pii)
+	}
+}
+
+func TestHandleToken_IDTokenWithoutNonce(t *testing.T) {
+	response := provisionForIDToken(t, map[string]string{"scope": "openid"})
+	require.NotEmpty(t, response.IDToken)
+
+	assert.NotContains(t, unverifiedClaims(t, response.IDToken), "nonce")
+}
+
+func TestHandleToken_NoIDTokenWithoutOpenIDScope(t *testing.T) {
+	for _, scope := range []string{"", "sessions:read"} {
+		response := provisionForIDToken(t, map[string]string{"scope": scope, "nonce": "n-0S6_WzA2Mj"})
+
+		assert.Empty(t, response.IDToken, "scope %q", scope)
+	}
+}
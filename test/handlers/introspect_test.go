@@ -0,0 +1,135 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newIntrospectTestHandler returns a token handler whose test-client has
+// the given verbose_introspection flag, and the key manager its tokens are
+// validated against. Tokens with jti "revoked-jti" are revoked.
+func newIntrospectTestHandler(t *testing.T, cfg *config.Config, verbose bool) (*handlers.TokenHandler, *auth.KeyManager) {
+	t.Helper()
+
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte("test-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := &models.Client{ClientID: "test-client", ClientSecretHash: string(hashedSecret), RateLimit: 100, VerboseIntrospection: verbose}
+
+	mockCache := new(mocks.MockCache)
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("IsTokenRevoked", mock.Anything, "revoked-jti").Return(true, nil)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+
+	tokenGen := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tokenValidator := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+	return handlers.NewTokenHandler(new(mocks.MockRepository), mockCache, tokenGen, tokenValidator, cfg, zap.NewNop()), km
+}
+
+// introspect posts token to tenant-abc's introspection endpoint and returns
+// the status and decoded body.
+func introspect(t *testing.T, handler *handlers.TokenHandler, token string) (int, map[string]interface{}) {
+	t.Helper()
+
+	form := url.Values{}
+	form.Set("client_id", "test-client")
+	form.Set("client_secret", "test-secret")
+	form.Set("token", token)
+	req := httptest.NewRequest("POST", "/tenant-abc/oauth2/v1.0/introspect", nil)
+	req.PostForm = form
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+
+	handler.HandleIntrospect(rr, req)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	return rr.Code, body
+}
+
+func TestHandleIntrospect_ActiveToken(t *testing.T) {
+	handler, km := newIntrospectTestHandler(t, &config.Config{}, false)
+
+	status, body := introspect(t, handler, signIssuedAt(t, km, time.Now(), nil))
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, body["active"])
+	assert.Equal(t, "user-123", body["sub"])
+	assert.Equal(t, "tenant-abc", body["tid"])
+}
+
+func TestHandleIntrospect_CompliantInactiveResponse(t *testing.T) {
+	// Without verbose_introspection the reason is withheld even when error
+	// statuses are configured
+	handler, km := newIntrospectTestHandler(t, &config.Config{IntrospectionErrorStatus: true}, false)
+
+	for name, token := range map[string]string{
+		"expired":      signIssuedAt(t, km, time.Now().Add(-2*time.Hour), jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}),
+		"revoked":      signIssuedAt(t, km, time.Now(), jwt.MapClaims{"jti": "revoked-jti"}),
+		"other tenant": signIssuedAt(t, km, time.Now(), jwt.MapClaims{"tid": "tenant-xyz"}),
+		"malformed":    "not-a-jwt",
+	} {
+		t.Run(name, func(t *testing.T) {
+			status, body := introspect(t, handler, token)
+
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, map[string]interface{}{"active": false}, body)
+		})
+	}
+}
+
+func TestHandleIntrospect_VerboseInactiveResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		extra      jwt.MapClaims
+		wantReason string
+		wantStatus int
+	}{
+		{"expired", jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}, models.IntrospectionReasonExpired, http.StatusUnauthorized},
+		{"revoked", jwt.MapClaims{"jti": "revoked-jti"}, models.IntrospectionReasonRevoked, http.StatusUnauthorized},
+		{"other tenant", jwt.MapClaims{"tid": "tenant-xyz"}, models.IntrospectionReasonTenantMismatch, http.StatusForbidden},
+		{"wrong audience", jwt.MapClaims{"aud": "other"}, models.IntrospectionReasonInvalid, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, km := newIntrospectTestHandler(t, &config.Config{}, true)
+			token := signIssuedAt(t, km, time.Now().Add(-2*time.Hour), tt.extra)
+
+			status, body := introspect(t, handler, token)
+			assert.Equal(t, http.StatusOK, status, "statuses stay 200 unless configured")
+			assert.Equal(t, map[string]interface{}{"active": false, "reason": tt.wantReason}, body)
+
+			handler, km = newIntrospectTestHandler(t, &config.Config{IntrospectionErrorStatus: true}, true)
+			token = signIssuedAt(t, km, time.Now().Add(-2*time.Hour), tt.extra)
+
+			status, body = introspect(t, handler, token)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantReason, body["reason"])
+		})
+	}
+}
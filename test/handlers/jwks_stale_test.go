@@ -0,0 +1,110 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/handlers"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// flakyKeySource serves a key manager's keys until failing is set.
+type flakyKeySource struct {
+	km      *auth.KeyManager
+	failing bool
+}
+
+func (s *flakyKeySource) PublicKeySet(use, alg, audience string) (jwk.Set, error) {
+	if s.failing {
+		return nil, errors.New("key store unavailable")
+	}
+	return s.km.PublicKeySet(use, alg, audience)
+}
+
+func newFlakyJWKSHandler(t *testing.T) (*handlers.JWKSHandler, *flakyKeySource) {
+	t.Helper()
+
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	source := &flakyKeySource{km: km}
+	return handlers.NewJWKSHandler(mockRepo, source, zap.NewNop()), source
+}
+
+func getJWKS(handler *handlers.JWKSHandler, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/tenant-abc/discovery/v1.0/keys"+query, nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+	handler.HandleJWKS(rr, req)
+	return rr
+}
+
+func TestHandleJWKS_ServesStaleKeySetOnSourceFailure(t *testing.T) {
+	handler, source := newFlakyJWKSHandler(t)
+	handler.EnableStaleIfError(time.Hour)
+
+	fresh := getJWKS(handler, "")
+	require.Equal(t, http.StatusOK, fresh.Code)
+	assert.Empty(t, fresh.Header().Get("Warning"))
+
+	source.failing = true
+	stale := getJWKS(handler, "")
+
+	require.Equal(t, http.StatusOK, stale.Code)
+	assert.JSONEq(t, fresh.Body.String(), stale.Body.String())
+	assert.Equal(t, `111 - "Revalidation Failed"`, stale.Header().Get("Warning"))
+	assert.Equal(t, "public, max-age=60", stale.Header().Get("Cache-Control"))
+
+	var body struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(stale.Body.Bytes(), &body))
+	assert.Len(t, body.Keys, 1)
+}
+
+func TestHandleJWKS_StaleKeySetIsPerFilter(t *testing.T) {
+	handler, source := newFlakyJWKSHandler(t)
+	handler.EnableStaleIfError(time.Hour)
+	require.Equal(t, http.StatusOK, getJWKS(handler, "?alg=RS256").Code)
+
+	source.failing = true
+
+	assert.Equal(t, http.StatusOK, getJWKS(handler, "?alg=RS256").Code)
+	assert.Equal(t, http.StatusInternalServerError, getJWKS(handler, "").Code, "no key set was served without filters")
+}
+
+func TestHandleJWKS_NoStaleKeySetByDefault(t *testing.T) {
+	handler, source := newFlakyJWKSHandler(t)
+	require.Equal(t, http.StatusOK, getJWKS(handler, "").Code)
+
+	source.failing = true
+
+	assert.Equal(t, http.StatusInternalServerError, getJWKS(handler, "").Code)
+}
+
+func TestHandleJWKS_StaleKeySetExpires(t *testing.T) {
+	handler, source := newFlakyJWKSHandler(t)
+	handler.EnableStaleIfError(time.Millisecond)
+	require.Equal(t, http.StatusOK, getJWKS(handler, "").Code)
+
+	time.Sleep(5 * time.Millisecond)
+	source.failing = true
+
+	assert.Equal(t, http.StatusInternalServerError, getJWKS(handler, "").Code)
+}
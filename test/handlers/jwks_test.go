@@ -0,0 +1,73 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"session-service/internal/auth"
+	"session-service/internal/handlers"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fetchJWKS requests the tenant-abc JWKS with the given query string from a
+// key manager holding RS256 and ES256 keys and returns the keys' alg and use.
+func fetchJWKS(t *testing.T, query string) []map[string]interface{} {
+	t.Helper()
+
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+	require.NoError(t, km.AddAlgorithm(auth.AlgES256))
+
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	handler := handlers.NewJWKSHandler(mockRepo, km, zap.NewNop())
+
+	req := httptest.NewRequest("GET", "/tenant-abc/discovery/v1.0/keys"+query, nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+	handler.HandleJWKS(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	return body.Keys
+}
+
+func keyAlgs(keys []map[string]interface{}) []string {
+	var algs []string
+	for _, k := range keys {
+		algs = append(algs, k["alg"].(string))
+	}
+	return algs
+}
+
+func TestHandleJWKS_UnfilteredReturnsAllKeys(t *testing.T) {
+	keys := fetchJWKS(t, "")
+	assert.ElementsMatch(t, []string{"RS256", "ES256"}, keyAlgs(keys))
+}
+
+func TestHandleJWKS_FilterByAlg(t *testing.T) {
+	keys := fetchJWKS(t, "?alg=ES256")
+	assert.Equal(t, []string{"ES256"}, keyAlgs(keys))
+	assert.Equal(t, "EC", keys[0]["kty"])
+
+	assert.Empty(t, fetchJWKS(t, "?alg=PS512"))
+}
+
+func TestHandleJWKS_FilterByUse(t *testing.T) {
+	assert.Len(t, fetchJWKS(t, "?use=sig"), 2)
+	assert.Empty(t, fetchJWKS(t, "?use=enc"))
+	assert.Equal(t, []string{"RS256"}, keyAlgs(fetchJWKS(t, "?use=sig&alg=RS256")))
+}
@@ -0,0 +1,128 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"session-service/internal/audit"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// listUsers requests the given URL of tenant-abc's user list with 45 users in
+// total and returns the response.
+func listUsers(t *testing.T, target string, limit, offset int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	mockRepo := new(mocks.MockRepository)
+	mockAudit := new(mocks.MockAuditRecorder)
+	handler := handlers.NewAdminHandler(mockRepo, new(mocks.MockCache), &config.Config{}, mockAudit, zap.NewNop())
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.Type == audit.EventTenantUsersList && e.TenantID == "tenant-abc"
+	})).Return()
+	mockRepo.On("ListTenantUsers", mock.Anything, "tenant-abc", limit, offset).
+		Return([]*models.UserExport{{ID: "user-123", TenantID: "tenant-abc", Roles: []string{}}}, 45, nil)
+
+	req := httptest.NewRequest("GET", target, nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+	handler.HandleListUsers(rr, req)
+	mockAudit.AssertExpectations(t)
+	return rr
+}
+
+func TestHandleListUsers_LinkHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		offset int
+		want   string
+	}{
+		{
+			name:   "first page",
+			target: "/tenant-abc/admin/users?limit=20",
+			offset: 0,
+			want: `</tenant-abc/admin/users?limit=20&offset=0>; rel="first", ` +
+				`</tenant-abc/admin/users?limit=20&offset=20>; rel="next", ` +
+				`</tenant-abc/admin/users?limit=20&offset=40>; rel="last"`,
+		},
+		{
+			name:   "middle page",
+			target: "/tenant-abc/admin/users?limit=20&offset=20",
+			offset: 20,
+			want: `</tenant-abc/admin/users?limit=20&offset=0>; rel="first", ` +
+				`</tenant-abc/admin/users?limit=20&offset=0>; rel="prev", ` +
+				`</tenant-abc/admin/users?limit=20&offset=40>; rel="next", ` +
+				`</tenant-abc/admin/users?limit=20&offset=40>; rel="last"`,
+		},
+		{
+			name:   "last page",
+			target: "/tenant-abc/admin/users?limit=20&offset=40",
+			offset: 40,
+			want: `</tenant-abc/admin/users?limit=20&offset=0>; rel="first", ` +
+				`</tenant-abc/admin/users?limit=20&offset=20>; rel="prev", ` +
+				`</tenant-abc/admin/users?limit=20&offset=40>; rel="last"`,
+		},
+		{
+			name:   "unaligned offset",
+			target: "/tenant-abc/admin/users?limit=20&offset=5",
+			offset: 5,
+			want: `</tenant-abc/admin/users?limit=20&offset=0>; rel="first", ` +
+				`</tenant-abc/admin/users?limit=20&offset=0>; rel="prev", ` +
+				`</tenant-abc/admin/users?limit=20&offset=25>; rel="next", ` +
+				`</tenant-abc/admin/users?limit=20&offset=40>; rel="last"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := listUsers(t, tt.target, 20, tt.offset)
+
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			assert.Equal(t, tt.want, rr.Header().Get("Link"))
+
+			var body models.UserListResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, 45, body.Total)
+			assert.Equal(t, 20, body.Limit)
+			assert.Equal(t, tt.offset, body.Offset)
+			assert.Len(t, body.Users, 1)
+		})
+	}
+}
+
+func TestHandleListUsers_DefaultsAndKeepsOtherParameters(t *testing.T) {
+	rr := listUsers(t, "/tenant-abc/admin/users?sort=id", handlers.DefaultPageLimit, 0)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t,
+		`</tenant-abc/admin/users?limit=50&offset=0&sort=id>; rel="first", </tenant-abc/admin/users?limit=50&offset=0&sort=id>; rel="last"`,
+		rr.Header().Get("Link"))
+}
+
+func TestHandleListUsers_RejectsInvalidPagination(t *testing.T) {
+	for _, query := range []string{"limit=0", "limit=201", "limit=abc", "offset=-1"} {
+		t.Run(query, func(t *testing.T) {
+			mockRepo := new(mocks.MockRepository)
+			handler := handlers.NewAdminHandler(mockRepo, new(mocks.MockCache), &config.Config{}, new(mocks.MockAuditRecorder), zap.NewNop())
+
+			req := httptest.NewRequest("GET", "/tenant-abc/admin/users?"+query, nil)
+			req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+			rr := httptest.NewRecorder()
+			handler.HandleListUsers(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			mockRepo.AssertNotCalled(t, "ListTenantUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
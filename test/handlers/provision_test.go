@@ -0,0 +1,229 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// uniqueViolation mimics a Postgres unique_violation driver error.
+type uniqueViolation struct{}
+
+func (uniqueViolation) Error() string    { return "pq: duplicate key value violates unique constraint" }
+func (uniqueViolation) SQLState() string { return "23505" }
+
+// expectAuthenticatedClient sets up the cache so "test-client" authenticates and is within its rate limit.
+func expectAuthenticatedClient(t *testing.T, mockCache *mocks.MockCache) {
+	t.Helper()
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte("test-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := &models.Client{ClientID: "test-client", ClientSecretHash: string(hashedSecret), RateLimit: 100}
+
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+}
+
+// newProvisionRequest builds a provision_user grant request; overrides replace the default user fields.
+func newProvisionRequest(tenantID string, overrides map[string]string) *http.Request {
+	form := url.Values{}
+	form.Set("grant_type", "provision_user")
+	form.Set("client_id", "test-client")
+	form.Set("client_secret", "test-secret")
+	form.Set("user_id", "user-123")
+	form.Set("user_full_name", "Jane Doe")
+	form.Set("user_phone", "+15550100")
+	form.Set("user_email", "jane@example.com")
+	for k, v := range overrides {
+		form.Set(k, v)
+	}
+
+	req := httptest.NewRequest("POST", "/"+tenantID+"/oauth2/v2.0/token", nil)
+	req.PostForm = form
+	return mux.SetURLVars(req, map[string]string{"tenant_id": tenantID})
+}
+
+func provisionTestConfig() *config.Config {
+	return &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		MaxFullNameLength:  10,
+		MaxPhoneLength:     5,
+		MaxEmailLength:     8,
+	}
+}
+
+func TestHandleUserProvisioning_UniqueViolationReturnsConflict(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	expectAuthenticatedClient(t, mockCache)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, uniqueViolation{})
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"user_email": "taken@example.com"}))
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "CONFLICT", body["error"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandleUserProvisioning_RejectsOverlongFields(t *testing.T) {
+	tests := []struct {
+		field string
+		value string
+	}{
+		{"user_full_name", strings.Repeat("a", 11)},
+		{"user_phone", "123456"},
+		{"user_email", "a@b.com.x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			handler, mockRepo, mockCache := newRefreshTestHandler(t, provisionTestConfig())
+			expectAuthenticatedClient(t, mockCache)
+
+			rr := httptest.NewRecorder()
+			handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{tt.field: tt.value}))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, "INVALID_REQUEST", body["error"])
+			mockRepo.AssertNotCalled(t, "UpsertUserAndRoles", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleUserProvisioning_RejectsWhitespaceOnlyFields(t *testing.T) {
+	for _, field := range []string{"user_full_name", "user_phone"} {
+		t.Run(field, func(t *testing.T) {
+			handler, mockRepo, mockCache := newRefreshTestHandler(t, provisionTestConfig())
+			expectAuthenticatedClient(t, mockCache)
+
+			rr := httptest.NewRecorder()
+			handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{field: " \t "}))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, "INVALID_REQUEST", body["error"])
+			mockRepo.AssertNotCalled(t, "UpsertUserAndRoles", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleUserProvisioning_StoresTrimmedFields(t *testing.T) {
+	stored := provisionUser(t, map[string]string{
+		"user_full_name": "  Jane Doe ",
+		"user_phone":     "\t+15550100 ",
+		"user_email":     " jane@example.com",
+	})
+
+	assert.Equal(t, "Jane Doe", stored.FullName)
+	assert.Equal(t, "+15550100", stored.PhoneNumber)
+	assert.Equal(t, "jane@example.com", stored.Email)
+}
+
+func TestHandleUserProvisioning_AcceptsFieldsAtMaximumLength(t *testing.T) {
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, provisionTestConfig())
+	expectAuthenticatedClient(t, mockCache)
+
+	// Limits count characters, not bytes
+	fullName := strings.Repeat("é", 10)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.MatchedBy(func(u models.User) bool {
+		return u.FullName == fullName && u.PhoneNumber == "12345" && u.Email == "a@b.com."
+	}), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), 24*time.Hour).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{
+		"user_full_name": fullName,
+		"user_phone":     "12345",
+		"user_email":     "a@b.com.",
+	}))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandleUserProvisioning_RejectsRolesOutsideTenantCatalog(t *testing.T) {
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour})
+	expectAuthenticatedClient(t, mockCache)
+
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("ListTenantRoles", mock.Anything, "tenant-abc").Return([]string{"reader", "writer"}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"user_roles": "reader, wirter, admin"}))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "INVALID_REQUEST", body["error"])
+	assert.Equal(t, "Unknown roles for tenant: wirter, admin", body["error_description"])
+	mockRepo.AssertNotCalled(t, "UpsertUserAndRoles", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleUserProvisioning_FreeFormRolesWithoutCatalog(t *testing.T) {
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour})
+	expectAuthenticatedClient(t, mockCache)
+
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("ListTenantRoles", mock.Anything, "tenant-abc").Return([]string{}, nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string{"anything-goes"}).Return(false, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), 24*time.Hour).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"user_roles": "anything-goes"}))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandleUserProvisioning_DisabledByConfig(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, DisableProvisioning: true}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "UNSUPPORTED_GRANT_TYPE", body["error"])
+	mockCache.AssertNotCalled(t, "GetClient", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpsertUserAndRoles", mock.Anything, mock.Anything, mock.Anything)
+	assert.NotContains(t, handler.GrantTypes(), "provision_user")
+}
+
+func TestHandleUserProvisioning_EnabledByDefault(t *testing.T) {
+	stored := provisionUser(t, nil)
+
+	assert.Equal(t, "user-123", stored.ID)
+	handler, _, _ := newRefreshTestHandler(t, &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour})
+	assert.Contains(t, handler.GrantTypes(), "provision_user")
+}
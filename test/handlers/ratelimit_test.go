@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// getRateLimit calls the rate limit endpoint as test-client, whose limit is
+// 100, with Redis reporting count requests in a window resetting in resetIn.
+func getRateLimit(t *testing.T, secret string, count int64, resetIn time.Duration) *httptest.ResponseRecorder {
+	t.Helper()
+
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, RateLimitEndpoint: true}
+	handler, _, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+	mockCache.On("GetRateLimitUsage", mock.Anything, "test-client").Return(count, resetIn, nil)
+
+	req := httptest.NewRequest("GET", "/tenant-abc/oauth2/v1.0/ratelimit", nil)
+	req.SetBasicAuth("test-client", secret)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+
+	rr := httptest.NewRecorder()
+	handler.HandleRateLimit(rr, req)
+	mockCache.AssertNotCalled(t, "CheckRateLimit", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	return rr
+}
+
+func TestHandleRateLimit_ReportsLimitAndRemaining(t *testing.T) {
+	tests := []struct {
+		name          string
+		count         int64
+		resetIn       time.Duration
+		wantRemaining int
+		wantReset     int64
+	}{
+		{name: "window in progress", count: 30, resetIn: 44500 * time.Millisecond, wantRemaining: 70, wantReset: 45},
+		{name: "no window open", count: 0, resetIn: 0, wantRemaining: 100, wantReset: 0},
+		{name: "limit exceeded", count: 130, resetIn: 10 * time.Second, wantRemaining: 0, wantReset: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := getRateLimit(t, "test-secret", tt.count, tt.resetIn)
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+			var response models.RateLimitResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, "test-client", response.ClientID)
+			assert.Equal(t, 100, response.RateLimit)
+			assert.Equal(t, int64(60), response.WindowSeconds)
+			assert.Equal(t, tt.wantRemaining, response.Remaining)
+			assert.Equal(t, tt.wantReset, response.ResetSeconds)
+		})
+	}
+}
+
+func TestHandleRateLimit_RequiresClientAuthentication(t *testing.T) {
+	rr := getRateLimit(t, "wrong-secret", 0, 0)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestHandleRateLimit_NotFoundWhenDisabled(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, _, _ := newRefreshTestHandler(t, cfg)
+
+	req := httptest.NewRequest("GET", "/tenant-abc/oauth2/v1.0/ratelimit", nil)
+	req.SetBasicAuth("test-client", "test-secret")
+	rr := httptest.NewRecorder()
+	handler.HandleRateLimit(rr, mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"}))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
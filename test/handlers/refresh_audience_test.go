@@ -0,0 +1,148 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// refreshWithResources refreshes a session granted audiences, requesting
+// resources. It returns the response, the new access token's claims (nil on
+// error) and the stored refresh token data.
+func refreshWithResources(t *testing.T, cfg *config.Config, audiences, resources []string) (*httptest.ResponseRecorder, map[string]interface{}, *models.RefreshTokenData) {
+	t.Helper()
+
+	cfg.JWTExpiry, cfg.RefreshTokenExpiry, cfg.JWTAudience = time.Hour, 24*time.Hour, "audience"
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	tokenData := &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+		IssuedAt:  time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(time.Hour),
+		Audiences: audiences,
+	}
+	var stored *models.RefreshTokenData
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(&models.Client{ClientID: "test-client", RateLimit: 100}, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("RevokeRefreshToken", mock.Anything, "old-refresh", cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("DeleteRefreshToken", mock.Anything, "old-refresh").Return(nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(*models.RefreshTokenData) }).
+		Return(nil)
+
+	req := newRefreshRequest("tenant-abc", "old-refresh")
+	for _, resource := range resources {
+		req.PostForm.Add("resource", resource)
+	}
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, req)
+	if rr.Code != http.StatusOK {
+		mockCache.AssertNotCalled(t, "RevokeRefreshToken", mock.Anything, "old-refresh", mock.Anything)
+		return rr, nil, stored
+	}
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return rr, unverifiedClaims(t, response.AccessToken), stored
+}
+
+var grantedAudiences = []string{"https://payments.example.com", "https://reports.example.com"}
+
+func TestRefreshAudienceBinding_PreservesAudience(t *testing.T) {
+	rr, claims, stored := refreshWithResources(t, &config.Config{RefreshAudienceBinding: true}, grantedAudiences, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	assert.Equal(t, []interface{}{"https://payments.example.com", "https://reports.example.com"}, claims["aud"])
+	require.NotNil(t, stored)
+	assert.Equal(t, grantedAudiences, stored.Audiences)
+}
+
+func TestRefreshAudienceBinding_NarrowsToResource(t *testing.T) {
+	rr, claims, stored := refreshWithResources(t, &config.Config{RefreshAudienceBinding: true}, grantedAudiences, []string{"https://reports.example.com"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	assert.Equal(t, "https://reports.example.com", claims["aud"])
+	require.NotNil(t, stored)
+	assert.Equal(t, grantedAudiences, stored.Audiences, "narrowing applies to one access token, not the session")
+}
+
+func TestRefreshAudienceBinding_RejectsWidening(t *testing.T) {
+	rr, _, stored := refreshWithResources(t, &config.Config{RefreshAudienceBinding: true}, grantedAudiences, []string{"https://reports.example.com", "https://admin.example.com"})
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "INVALID_TARGET")
+	assert.Nil(t, stored)
+}
+
+func TestRefreshAudienceBinding_DefaultAudienceSession(t *testing.T) {
+	rr, claims, _ := refreshWithResources(t, &config.Config{RefreshAudienceBinding: true}, nil, []string{"audience"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "audience", claims["aud"])
+
+	rr, _, _ = refreshWithResources(t, &config.Config{RefreshAudienceBinding: true}, nil, []string{"https://payments.example.com"})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestRefreshAudienceBinding_DisabledByDefault(t *testing.T) {
+	rr, claims, _ := refreshWithResources(t, &config.Config{}, grantedAudiences, []string{"https://admin.example.com"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	assert.Equal(t, "audience", claims["aud"])
+}
+
+func TestHandleToken_StoresGrantedAudiences(t *testing.T) {
+	cfg := &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		ScopeAudiences:     map[string]string{"payments.read": "https://payments.example.com"},
+	}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+	var stored *models.RefreshTokenData
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(*models.RefreshTokenData) }).
+		Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"scope": "payments.read"}))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	require.NotNil(t, stored)
+	assert.Equal(t, []string{"https://payments.example.com"}, stored.Audiences)
+}
+
+func TestRefreshAudienceBinding_ResourcesAtAudienceCap(t *testing.T) {
+	cfg := &config.Config{RefreshAudienceBinding: true, MaxAudiences: 2}
+	rr, claims, _ := refreshWithResources(t, cfg, grantedAudiences, grantedAudiences)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	assert.Equal(t, []interface{}{"https://payments.example.com", "https://reports.example.com"}, claims["aud"])
+}
+
+func TestRefreshAudienceBinding_RejectsResourcesBeyondAudienceCap(t *testing.T) {
+	cfg := &config.Config{RefreshAudienceBinding: true, MaxAudiences: 1}
+	rr, _, stored := refreshWithResources(t, cfg, grantedAudiences, grantedAudiences)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "INVALID_REQUEST")
+	assert.Nil(t, stored)
+}
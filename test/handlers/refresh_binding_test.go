@@ -0,0 +1,89 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// refreshFrom refreshes a token bound to fingerprint for a tenant that binds
+// refresh tokens, sending the request from remoteAddr with deviceID.
+func refreshFrom(t *testing.T, fingerprint, remoteAddr, deviceID string) (*httptest.ResponseRecorder, *models.RefreshTokenData) {
+	t.Helper()
+
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	now := time.Now()
+	tokenData := &models.RefreshTokenData{
+		ClientID:    "test-client",
+		Subject:     &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+		IssuedAt:    now,
+		ExpiresAt:   now.Add(24 * time.Hour),
+		Fingerprint: fingerprint,
+	}
+
+	var stored *models.RefreshTokenData
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(&models.Client{ClientID: "test-client", RateLimit: 100}, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc", BindRefreshTokens: true}, nil)
+	mockCache.On("RevokeRefreshToken", mock.Anything, "old-refresh", cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("DeleteRefreshToken", mock.Anything, "old-refresh").Return(nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(*models.RefreshTokenData) }).
+		Return(nil)
+
+	req := newRefreshRequest("tenant-abc", "old-refresh")
+	req.RemoteAddr = remoteAddr
+	if deviceID != "" {
+		req.Header.Set("X-Device-ID", deviceID)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, req)
+	return rr, stored
+}
+
+func TestHandleRefreshToken_BoundTokenFromSameDeviceSucceeds(t *testing.T) {
+	// Issue a token from the default httptest address to capture its fingerprint
+	_, issued := clientCredentialsRoles(t, nil, []string{"reader"})
+	require.NotNil(t, issued)
+	require.NotEmpty(t, issued.Fingerprint)
+
+	rr, stored := refreshFrom(t, issued.Fingerprint, httptest.NewRequest("GET", "/", nil).RemoteAddr, "")
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NotNil(t, stored)
+	assert.Equal(t, issued.Fingerprint, stored.Fingerprint, "the rotated token stays bound to the device")
+}
+
+func TestHandleRefreshToken_BoundTokenFromOtherNetworkRejected(t *testing.T) {
+	_, issued := clientCredentialsRoles(t, nil, []string{"reader"})
+	require.NotNil(t, issued)
+
+	rr, stored := refreshFrom(t, issued.Fingerprint, "198.51.100.7:4321", "")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "bound to a different device")
+	assert.Nil(t, stored)
+}
+
+func TestHandleRefreshToken_BoundTokenFromOtherDeviceRejected(t *testing.T) {
+	_, issued := clientCredentialsRoles(t, nil, []string{"reader"})
+	require.NotNil(t, issued)
+
+	rr, _ := refreshFrom(t, issued.Fingerprint, httptest.NewRequest("GET", "/", nil).RemoteAddr, "other-device")
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
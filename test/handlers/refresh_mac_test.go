@@ -0,0 +1,72 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newRefreshMACTestHandler returns a token handler whose refresh tokens carry
+// a MAC, and the generator that issues them.
+func newRefreshMACTestHandler(t *testing.T) (*handlers.TokenHandler, *auth.TokenGenerator, *mocks.MockCache) {
+	t.Helper()
+
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+
+	mockCache := new(mocks.MockCache)
+	tokenGen := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tokenGen.EnableRefreshTokenMAC([]byte("0123456789abcdef0123456789abcdef"))
+	tokenValidator := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	return handlers.NewTokenHandler(new(mocks.MockRepository), mockCache, tokenGen, tokenValidator, cfg, zap.NewNop()), tokenGen, mockCache
+}
+
+func TestRefreshTokenMAC_TamperedTokenRejectedBeforeLookup(t *testing.T) {
+	handler, tokenGen, mockCache := newRefreshMACTestHandler(t)
+	token, err := tokenGen.GenerateRefreshToken()
+	require.NoError(t, err)
+
+	for name, tampered := range map[string]string{
+		"altered": "x" + token[1:],
+		"guessed": "c29tZS1ndWVzc2VkLXRva2Vu",
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.HandleToken(rr, newRefreshRequest("tenant-abc", tampered))
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+			assert.Contains(t, rr.Body.String(), "INVALID_REFRESH_TOKEN")
+			mockCache.AssertNotCalled(t, "GetRefreshToken", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRefreshTokenMAC_ValidTokenIsLookedUp(t *testing.T) {
+	handler, tokenGen, mockCache := newRefreshMACTestHandler(t)
+	token, err := tokenGen.GenerateRefreshToken()
+	require.NoError(t, err)
+	mockCache.On("GetRefreshToken", mock.Anything, token).Return(nil, nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", token))
+
+	// The MAC checks out, so the token reaches the cache, which has no
+	// record of it
+	assert.Contains(t, rr.Body.String(), "INVALID_REFRESH_TOKEN")
+	mockCache.AssertCalled(t, "GetRefreshToken", mock.Anything, token)
+}
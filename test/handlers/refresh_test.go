@@ -0,0 +1,284 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newRefreshTestHandler builds a token handler with fresh mocks for refresh flow tests.
+func newRefreshTestHandler(t *testing.T, cfg *config.Config) (*handlers.TokenHandler, *mocks.MockRepository, *mocks.MockCache) {
+	t.Helper()
+
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+
+	tokenGen := auth.NewTokenGenerator(km, "issuer", "audience", cfg.JWTExpiry, 32)
+	tokenValidator := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+
+	return handlers.NewTokenHandler(mockRepo, mockCache, tokenGen, tokenValidator, cfg, zap.NewNop()), mockRepo, mockCache
+}
+
+// newRefreshRequest builds a refresh_token grant request for the given tenant.
+func newRefreshRequest(tenantID, refreshToken string) *http.Request {
+	form := url.Values{}
+	form.Add("grant_type", "refresh_token")
+	form.Add("refresh_token", refreshToken)
+
+	req := httptest.NewRequest("POST", "/"+tenantID+"/oauth2/v2.0/token", nil)
+	req.PostForm = form
+	return mux.SetURLVars(req, map[string]string{"tenant_id": tenantID})
+}
+
+func TestHandleRefreshToken_RejectedAfterUserRevocation(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, _, mockCache := newRefreshTestHandler(t, cfg)
+
+	issuedAt := time.Now().Add(-time.Hour)
+	tokenData := &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+		IssuedAt:  issuedAt,
+		ExpiresAt: issuedAt.Add(cfg.RefreshTokenExpiry),
+	}
+
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	// The user was deleted after the refresh token was issued.
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Now(), nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "INVALID_REFRESH_TOKEN")
+	mockCache.AssertNotCalled(t, "StoreRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// refreshWithExpiry runs a successful refresh for a refresh token expiring at
+// expiresAt and returns the token response.
+func refreshWithExpiry(t *testing.T, cfg *config.Config, expiresAt time.Time) *models.TokenResponse {
+	t.Helper()
+
+	return refreshWithData(t, cfg, &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+		IssuedAt:  expiresAt.Add(-cfg.RefreshTokenExpiry),
+		ExpiresAt: expiresAt,
+	})
+}
+
+// refreshWithData runs a successful refresh of tokenData and returns the token response.
+func refreshWithData(t *testing.T, cfg *config.Config, tokenData *models.RefreshTokenData) *models.TokenResponse {
+	t.Helper()
+
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	tenant := &models.Tenant{ID: "tenant-abc"}
+
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(&models.Client{ClientID: "test-client", RateLimit: 100}, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("RevokeRefreshToken", mock.Anything, "old-refresh", cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("DeleteRefreshToken", mock.Anything, "old-refresh").Return(nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(tenant, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return &response
+}
+
+func TestHandleRefreshToken_AccessTokenCappedAtRefreshExpiry(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	expiresAt := time.Now().Add(10 * time.Minute)
+
+	response := refreshWithExpiry(t, cfg, expiresAt)
+
+	assert.InDelta(t, 600, response.ExpiresIn, 2)
+	exp, err := unverifiedClaims(t, response.AccessToken).GetExpirationTime()
+	require.NoError(t, err)
+	assert.False(t, exp.After(expiresAt), "access token must not outlive the refresh token")
+	assert.WithinDuration(t, expiresAt, exp.Time, 2*time.Second)
+}
+
+func TestHandleRefreshToken_RejectedWithUnderASecondLeft(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	expiresAt := time.Now().Add(500 * time.Millisecond)
+	tokenData := &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+		IssuedAt:  expiresAt.Add(-cfg.RefreshTokenExpiry),
+		ExpiresAt: expiresAt,
+	}
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(&models.Client{ClientID: "test-client", RateLimit: 100}, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "INVALID_REFRESH_TOKEN")
+	mockCache.AssertNotCalled(t, "RevokeRefreshToken", mock.Anything, mock.Anything, mock.Anything)
+	mockCache.AssertNotCalled(t, "StoreRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRefreshToken_AccessTokenFullLifetimeWhenRefreshOutlivesIt(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+
+	response := refreshWithExpiry(t, cfg, time.Now().Add(12*time.Hour))
+
+	assert.Equal(t, int64(3600), response.ExpiresIn)
+	assert.Equal(t, time.Hour, accessTokenLifetime(t, response.AccessToken))
+}
+
+func TestHandleRefreshToken_WithinIdleTimeoutSucceeds(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, IdleSessionTimeout: 30 * time.Minute}
+	issuedAt := time.Now().Add(-2 * time.Hour)
+
+	response := refreshWithData(t, cfg, &models.RefreshTokenData{
+		ClientID:   "test-client",
+		Subject:    &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+		IssuedAt:   issuedAt,
+		ExpiresAt:  issuedAt.Add(cfg.RefreshTokenExpiry),
+		LastUsedAt: time.Now().Add(-10 * time.Minute),
+	})
+
+	assert.NotEmpty(t, response.RefreshToken)
+}
+
+func TestHandleRefreshToken_MixedCaseTenantFromBeforeCanonicalIDs(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	issuedAt := time.Now().Add(-time.Hour)
+
+	response := refreshWithData(t, cfg, &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "Tenant-ABC"},
+		IssuedAt:  issuedAt,
+		ExpiresAt: issuedAt.Add(cfg.RefreshTokenExpiry),
+	})
+
+	assert.Equal(t, "tenant-abc", unverifiedClaims(t, response.AccessToken)["tid"], "the reissued token carries the canonical tenant")
+}
+
+func TestHandleRefreshToken_RejectedAfterIdleTimeout(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, IdleSessionTimeout: 30 * time.Minute}
+	handler, _, mockCache := newRefreshTestHandler(t, cfg)
+
+	issuedAt := time.Now().Add(-2 * time.Hour)
+	tokenData := &models.RefreshTokenData{
+		ClientID:   "test-client",
+		Subject:    &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+		IssuedAt:   issuedAt,
+		ExpiresAt:  issuedAt.Add(cfg.RefreshTokenExpiry),
+		LastUsedAt: time.Now().Add(-time.Hour),
+	}
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "INVALID_REFRESH_TOKEN")
+	mockCache.AssertNotCalled(t, "StoreRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRefreshToken_StoresLastUsedAt(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, IdleSessionTimeout: 30 * time.Minute}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	issuedAt := time.Now().Add(-20 * time.Minute)
+	tokenData := &models.RefreshTokenData{
+		ClientID:   "test-client",
+		Subject:    &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+		IssuedAt:   issuedAt,
+		ExpiresAt:  issuedAt.Add(cfg.RefreshTokenExpiry),
+		LastUsedAt: issuedAt,
+	}
+	var stored *models.RefreshTokenData
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(&models.Client{ClientID: "test-client", RateLimit: 100}, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("RevokeRefreshToken", mock.Anything, "old-refresh", cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("DeleteRefreshToken", mock.Anything, "old-refresh").Return(nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(*models.RefreshTokenData) }).
+		Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NotNil(t, stored)
+	assert.WithinDuration(t, time.Now(), stored.LastUsedAt, 5*time.Second)
+}
+
+func TestHandleRefreshToken_VerifyUserRejectsDeletedOrMovedUser(t *testing.T) {
+	tests := []struct {
+		name string
+		user *models.User
+	}{
+		{name: "deleted user", user: nil},
+		{name: "user moved to another tenant", user: &models.User{ID: "user-123", TenantID: "tenant-xyz"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, RefreshVerifyUser: true}
+			handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+			issuedAt := time.Now().Add(-time.Hour)
+			mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(&models.RefreshTokenData{
+				ClientID:  "test-client",
+				Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+				IssuedAt:  issuedAt,
+				ExpiresAt: issuedAt.Add(cfg.RefreshTokenExpiry),
+			}, nil)
+			mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+			mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+			mockRepo.On("GetUserByID", mock.Anything, "user-123").Return(tt.user, nil)
+
+			rr := httptest.NewRecorder()
+			handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+			assert.Contains(t, rr.Body.String(), "INVALID_REFRESH_TOKEN")
+			mockCache.AssertNotCalled(t, "StoreRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// provisionRoleChange provisions user-123 with new roles, the repository
+// reporting that they changed, and returns the cache mock.
+func provisionRoleChange(t *testing.T, cfg *config.Config) *mocks.MockCache {
+	t.Helper()
+
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("ListTenantRoles", mock.Anything, "tenant-abc").Return([]string{}, nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string{"admin"}).Return(true, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("SetUserRolesChangedAt", mock.Anything, "user-123", mock.AnythingOfType("time.Time"), 24*time.Hour).Return(nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), 24*time.Hour).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"user_roles": "admin"}))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	return mockCache
+}
+
+func TestRoleChange_ProvisioningRecordsChangeWhenEnabled(t *testing.T) {
+	mockCache := provisionRoleChange(t, &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, RevokeTokensOnRoleChange: true})
+
+	mockCache.AssertCalled(t, "SetUserRolesChangedAt", mock.Anything, "user-123", mock.AnythingOfType("time.Time"), 24*time.Hour)
+}
+
+func TestRoleChange_ProvisioningIgnoresChangeWhenDisabled(t *testing.T) {
+	mockCache := provisionRoleChange(t, &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour})
+
+	mockCache.AssertNotCalled(t, "SetUserRolesChangedAt", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRoleChange_RefreshPicksUpNewRoles(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, RevokeTokensOnRoleChange: true}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	issuedAt := time.Now().Add(-time.Hour)
+	tokenData := &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Roles: []string{"reader"}},
+		IssuedAt:  issuedAt,
+		ExpiresAt: issuedAt.Add(cfg.RefreshTokenExpiry),
+	}
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(&models.Client{ClientID: "test-client", RateLimit: 100}, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("GetUserRolesChangedAt", mock.Anything, "user-123").Return(time.Now().Add(-time.Minute), nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{"admin"}, nil)
+	mockCache.On("RevokeRefreshToken", mock.Anything, "old-refresh", cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("DeleteRefreshToken", mock.Anything, "old-refresh").Return(nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, []interface{}{"admin"}, unverifiedClaims(t, response.AccessToken)["roles"])
+}
+
+func TestRoleChange_VerifyReportsReason(t *testing.T) {
+	km, tokenGen, _ := newVerifyTestSetup(t)
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Roles: []string{"reader"}})
+	require.NoError(t, err)
+
+	for _, enabled := range []bool{true, false} {
+		mockCache := new(mocks.MockCache)
+		mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+		mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+		mockCache.On("GetUserRolesChangedAt", mock.Anything, "user-123").Return(time.Now().Add(2*time.Second), nil)
+		validator := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+		if enabled {
+			validator.EnableRoleChangeRevocation()
+		}
+		handler := handlers.NewVerifyHandler(validator, nil, false, zap.NewNop())
+
+		response := verifyToken(t, handler, token)
+
+		if enabled {
+			assert.False(t, response.Valid)
+			assert.Equal(t, models.VerifyReasonRolesChanged, response.Reason)
+		} else {
+			assert.True(t, response.Valid, "older tokens stay valid when enforcement is off")
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// clientCredentialsRoles issues a client_credentials token for a user holding
+// userRoles via a client restricted to rolePrefixes and returns the roles claim.
+func clientCredentialsRoles(t *testing.T, rolePrefixes, userRoles []string) ([]interface{}, *models.RefreshTokenData) {
+	t.Helper()
+
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte("test-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := &models.Client{ClientID: "test-client", ClientSecretHash: string(hashedSecret), RateLimit: 100, RolePrefixes: rolePrefixes}
+
+	var stored *models.RefreshTokenData
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("GetUserByID", mock.Anything, "user-123").Return(&models.User{ID: "user-123", TenantID: "tenant-abc"}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return(userRoles, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(*models.RefreshTokenData) }).
+		Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", "test-client")
+	form.Set("client_secret", "test-secret")
+	form.Set("user_id", "user-123")
+	req := httptest.NewRequest("POST", "/tenant-abc/oauth2/v2.0/token", nil)
+	req.PostForm = form
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	roles, _ := unverifiedClaims(t, response.AccessToken)["roles"].([]interface{})
+	return roles, stored
+}
+
+func TestHandleToken_RolesFilteredByClientPrefixes(t *testing.T) {
+	userRoles := []string{"billing:read", "billing:write", "hr:admin", "reader"}
+
+	roles, stored := clientCredentialsRoles(t, []string{"billing:"}, userRoles)
+
+	assert.Equal(t, []interface{}{"billing:read", "billing:write"}, roles)
+	require.NotNil(t, stored)
+	assert.Equal(t, []string{"billing:read", "billing:write"}, stored.Subject.Roles, "refreshes keep the reduced set")
+}
+
+func TestHandleToken_MultipleRolePrefixes(t *testing.T) {
+	roles, _ := clientCredentialsRoles(t, []string{"billing:", "reader"}, []string{"billing:read", "hr:admin", "reader"})
+
+	assert.Equal(t, []interface{}{"billing:read", "reader"}, roles)
+}
+
+func TestHandleToken_NoRolePrefixesKeepsAllRoles(t *testing.T) {
+	roles, _ := clientCredentialsRoles(t, nil, []string{"billing:read", "hr:admin"})
+
+	assert.Equal(t, []interface{}{"billing:read", "hr:admin"}, roles)
+}
+
+func TestHandleToken_NoMatchingRolesOmitsClaim(t *testing.T) {
+	roles, _ := clientCredentialsRoles(t, []string{"billing:"}, []string{"hr:admin"})
+
+	assert.Empty(t, roles)
+}
@@ -0,0 +1,78 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeRotation simulates a key rotation that is in progress while set.
+type fakeRotation struct {
+	rotating atomic.Bool
+}
+
+func (f *fakeRotation) Rotating() bool {
+	return f.rotating.Load()
+}
+
+func TestHandleToken_UnavailableDuringKeyRotation(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	rotation := &fakeRotation{}
+	rotation.rotating.Store(true)
+	handler.EnableRotationGuard(rotation, 1500*time.Millisecond)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "TEMPORARILY_UNAVAILABLE", body["error"])
+	mockCache.AssertNotCalled(t, "GetRefreshToken", mock.Anything, mock.Anything)
+
+	// Issuance resumes as soon as the rotation finishes
+	rotation.rotating.Store(false)
+	tokenData := &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(&models.Client{ClientID: "test-client", RateLimit: 100}, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("RevokeRefreshToken", mock.Anything, "old-refresh", cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("DeleteRefreshToken", mock.Anything, "old-refresh").Return(nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+
+	rr = httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+}
+
+func TestHandleVerify_AvailableDuringKeyRotation(t *testing.T) {
+	_, tokenGen, tokenValidator := newVerifyTestSetup(t)
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	assert.True(t, verifyToken(t, handler, token).Valid)
+}
@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// provisionWithScope provisions "user-123" with scope requested under
+// SCOPE_AUDIENCES and returns the access token's claims.
+func provisionWithScope(t *testing.T, scope string) map[string]interface{} {
+	t.Helper()
+
+	cfg := &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		ScopeAudiences: map[string]string{
+			"openid":        config.ClientIDAudience,
+			"payments.read": "https://payments.example.com",
+		},
+	}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"scope": scope}))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return unverifiedClaims(t, response.AccessToken)
+}
+
+func TestHandleToken_OpenIDScopeAudiencesClient(t *testing.T) {
+	claims := provisionWithScope(t, "openid")
+
+	assert.Equal(t, "test-client", claims["aud"])
+	assert.Equal(t, "test-client", claims["azp"])
+}
+
+func TestHandleToken_APIScopeAudiencesAPI(t *testing.T) {
+	claims := provisionWithScope(t, "payments.read")
+
+	assert.Equal(t, "https://payments.example.com", claims["aud"])
+	assert.NotContains(t, claims, "azp")
+}
+
+func TestHandleToken_MixedScopesCarryEveryAudience(t *testing.T) {
+	claims := provisionWithScope(t, "openid payments.read")
+
+	assert.Equal(t, []interface{}{"test-client", "https://payments.example.com"}, claims["aud"])
+	assert.Equal(t, "test-client", claims["azp"])
+}
+
+func TestHandleToken_UnmappedScopeKeepsDefaultAudience(t *testing.T) {
+	claims := provisionWithScope(t, "sessions:read")
+
+	assert.Equal(t, "audience", claims["aud"])
+	assert.NotContains(t, claims, "azp")
+}
+
+func TestHandleToken_RejectsScopesBeyondAudienceCap(t *testing.T) {
+	cfg := &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		MaxAudiences:       1,
+		ScopeAudiences: map[string]string{
+			"openid":        config.ClientIDAudience,
+			"payments.read": "https://payments.example.com",
+		},
+	}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"scope": "openid payments.read"}))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "INVALID_REQUEST")
+	mockRepo.AssertNotCalled(t, "UpsertUserAndRoles", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,163 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// refreshWithAllowedScopes refreshes a session whose stored subject carries
+// scopes for a client that now allows only allowedScopes. It returns the new
+// access token's claims, the stored refresh token data and the logs.
+func refreshWithAllowedScopes(t *testing.T, scopes, allowedScopes []string) (map[string]interface{}, *models.RefreshTokenData, *observer.ObservedLogs) {
+	t.Helper()
+
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+	tokenGen := auth.NewTokenGenerator(km, "issuer", "audience", cfg.JWTExpiry, 32)
+	core, logs := observer.New(zapcore.InfoLevel)
+	handler := handlers.NewTokenHandler(mockRepo, mockCache, tokenGen, auth.NewTokenValidator(km, "issuer", "audience", mockCache), cfg, zap.New(core))
+
+	tokenData := &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Scopes: scopes},
+		IssuedAt:  time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	client := &models.Client{ClientID: "test-client", RateLimit: 100, AllowedScopes: allowedScopes}
+
+	var stored *models.RefreshTokenData
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("RevokeRefreshToken", mock.Anything, "old-refresh", cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("DeleteRefreshToken", mock.Anything, "old-refresh").Return(nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(*models.RefreshTokenData) }).
+		Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return unverifiedClaims(t, response.AccessToken), stored, logs
+}
+
+func TestHandleRefreshToken_DropsScopesRemovedFromClient(t *testing.T) {
+	claims, stored, logs := refreshWithAllowedScopes(t, []string{"sessions:read", "sessions:write"}, []string{"sessions:read"})
+
+	assert.Equal(t, []interface{}{"sessions:read"}, claims["scp"])
+	require.NotNil(t, stored)
+	assert.Equal(t, []string{"sessions:read"}, stored.Subject.Scopes, "the dropped scope must not come back on later refreshes")
+
+	entries := logs.FilterMessage("Dropped scopes no longer allowed for client from refreshed token").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "test-client", entries[0].ContextMap()["client_id"])
+	assert.Equal(t, []interface{}{"sessions:write"}, entries[0].ContextMap()["dropped_scopes"])
+}
+
+func TestHandleRefreshToken_KeepsScopesStillAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+	}{
+		{name: "unrestricted client", allowed: nil},
+		{name: "all scopes allowed", allowed: []string{"sessions:read", "sessions:write", "admin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, _, logs := refreshWithAllowedScopes(t, []string{"sessions:read", "sessions:write"}, tt.allowed)
+
+			assert.Equal(t, []interface{}{"sessions:read", "sessions:write"}, claims["scp"])
+			assert.Zero(t, logs.FilterMessage("Dropped scopes no longer allowed for client from refreshed token").Len())
+		})
+	}
+}
+
+// requestScopes provisions user-123 with the given scope parameter for a
+// client allowing only sessions:read and sessions:write.
+func requestScopes(t *testing.T, scopeErrorDetails bool, scope string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, ScopeErrorDetails: scopeErrorDetails}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte("test-secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := &models.Client{ClientID: "test-client", ClientSecretHash: string(hashedSecret), RateLimit: 100, AllowedScopes: []string{"sessions:read", "sessions:write"}}
+
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"scope": scope}))
+	return rr
+}
+
+func TestHandleToken_InvalidScopeNamesRejectedScopes(t *testing.T) {
+	rr := requestScopes(t, true, "sessions:read admin billing:write")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "INVALID_SCOPE", body["error"])
+	assert.Equal(t, []interface{}{"admin", "billing:write"}, body["rejected_scopes"])
+
+	description := body["error_description"].(string)
+	assert.Contains(t, description, "admin billing:write")
+	assert.NotContains(t, description, "sessions:write", "the allowed set must not be disclosed")
+	assert.NotContains(t, description, "sessions:read")
+}
+
+func TestHandleToken_InvalidScopeWithoutDetails(t *testing.T) {
+	rr := requestScopes(t, false, "sessions:read admin")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "INVALID_SCOPE", body["error"])
+	assert.NotContains(t, body["error_description"], "admin")
+	assert.NotContains(t, body, "rejected_scopes")
+}
+
+func TestHandleToken_AllowedScopesIssued(t *testing.T) {
+	rr := requestScopes(t, true, "sessions:read sessions:write")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, []interface{}{"sessions:read", "sessions:write"}, unverifiedClaims(t, response.AccessToken)["scp"])
+}
@@ -0,0 +1,86 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// refreshAndCapture refreshes tokenData and returns the new access token's
+// claims and the refresh token data stored for the next refresh.
+func refreshAndCapture(t *testing.T, tokenData *models.RefreshTokenData) (map[string]interface{}, *models.RefreshTokenData) {
+	t.Helper()
+
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	var stored *models.RefreshTokenData
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(tokenData, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(&models.Client{ClientID: "test-client", RateLimit: 100}, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("RevokeRefreshToken", mock.Anything, "old-refresh", cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("DeleteRefreshToken", mock.Anything, "old-refresh").Return(nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(*models.RefreshTokenData) }).
+		Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return unverifiedClaims(t, response.AccessToken), stored
+}
+
+func TestSessionID_StableAcrossRefreshes(t *testing.T) {
+	_, issued := clientCredentialsRoles(t, nil, []string{"reader"})
+	require.NotNil(t, issued)
+	sid := issued.Subject.SessionID
+	require.NotEmpty(t, sid)
+	assert.NotEmpty(t, issued.AccessTokenJTI)
+
+	current := issued
+	for i := 0; i < 2; i++ {
+		current.ExpiresAt = time.Now().Add(time.Hour)
+		claims, next := refreshAndCapture(t, current)
+
+		assert.Equal(t, sid, claims["sid"], "sid must survive refresh %d", i+1)
+		assert.Equal(t, sid, next.Subject.SessionID)
+		assert.Equal(t, claims["jti"], next.AccessTokenJTI, "the stored jti must be the one just issued")
+		assert.NotEqual(t, current.AccessTokenJTI, next.AccessTokenJTI)
+		current = next
+	}
+}
+
+func TestSessionID_DiffersBetweenSessions(t *testing.T) {
+	_, first := clientCredentialsRoles(t, nil, []string{"reader"})
+	_, second := clientCredentialsRoles(t, nil, []string{"reader"})
+
+	require.NotEmpty(t, first.Subject.SessionID)
+	assert.NotEqual(t, first.Subject.SessionID, second.Subject.SessionID)
+}
+
+func TestSessionID_AssignedToLegacyRefreshTokens(t *testing.T) {
+	claims, stored := refreshAndCapture(t, &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+		IssuedAt:  time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	require.NotEmpty(t, stored.Subject.SessionID)
+	assert.Equal(t, stored.Subject.SessionID, claims["sid"])
+}
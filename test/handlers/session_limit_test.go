@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// provisionAtSessionLimit provisions user-123, who already has two
+// sessions, under a limit of two sessions with policy.
+func provisionAtSessionLimit(t *testing.T, policy string) (*httptest.ResponseRecorder, *mocks.MockCache) {
+	t.Helper()
+
+	cfg := &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		MaxSessionsPerUser: 2,
+		SessionLimitPolicy: policy,
+	}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("TrackUserSession", mock.Anything, "user-123", mock.AnythingOfType("string"), mock.AnythingOfType("string"), cfg.RefreshTokenExpiry).Return(nil)
+
+	// Oldest first
+	mockCache.On("GetUserSessions", mock.Anything, "user-123").Return([]models.UserSession{
+		{SessionID: "session-1", RefreshToken: "refresh-1", StartedAt: time.Now().Add(-2 * time.Hour)},
+		{SessionID: "session-2", RefreshToken: "refresh-2", StartedAt: time.Now().Add(-time.Hour)},
+	}, nil)
+	mockCache.On("RevokeRefreshToken", mock.Anything, "refresh-1", cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("DeleteRefreshToken", mock.Anything, "refresh-1").Return(nil)
+	mockCache.On("RemoveUserSession", mock.Anything, "user-123", "session-1").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", nil))
+	return rr, mockCache
+}
+
+func TestSessionLimit_EvictsOldestSession(t *testing.T) {
+	rr, mockCache := provisionAtSessionLimit(t, config.SessionLimitEvictOldest)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	mockCache.AssertCalled(t, "RevokeRefreshToken", mock.Anything, "refresh-1", 24*time.Hour)
+	mockCache.AssertCalled(t, "DeleteRefreshToken", mock.Anything, "refresh-1")
+	mockCache.AssertCalled(t, "RemoveUserSession", mock.Anything, "user-123", "session-1")
+	mockCache.AssertNotCalled(t, "RevokeRefreshToken", mock.Anything, "refresh-2", mock.Anything)
+	mockCache.AssertCalled(t, "TrackUserSession", mock.Anything, "user-123", mock.AnythingOfType("string"), mock.AnythingOfType("string"), 24*time.Hour)
+}
+
+func TestSessionLimit_RejectsNewSession(t *testing.T) {
+	rr, mockCache := provisionAtSessionLimit(t, config.SessionLimitReject)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "SESSION_LIMIT_REACHED")
+	mockCache.AssertNotCalled(t, "RevokeRefreshToken", mock.Anything, mock.Anything, mock.Anything)
+	mockCache.AssertNotCalled(t, "StoreRefreshToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockCache.AssertNotCalled(t, "TrackUserSession", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSessionLimit_RefreshContinuesSession(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, MaxSessionsPerUser: 2}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	issuedAt := time.Now().Add(-time.Hour)
+	mockCache.On("GetRefreshToken", mock.Anything, "old-refresh").Return(&models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", SessionID: "session-1"},
+		IssuedAt:  issuedAt,
+		ExpiresAt: issuedAt.Add(cfg.RefreshTokenExpiry),
+	}, nil)
+	mockCache.On("IsRefreshTokenRevoked", mock.Anything, "old-refresh").Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, "user-123").Return(time.Time{}, nil)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(&models.Client{ClientID: "test-client", RateLimit: 100}, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, "test-client", 100, time.Minute).Return(false, nil)
+	mockCache.On("RevokeRefreshToken", mock.Anything, "old-refresh", cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("DeleteRefreshToken", mock.Anything, "old-refresh").Return(nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+	mockCache.On("TrackUserSession", mock.Anything, "user-123", "session-1", mock.AnythingOfType("string"), cfg.RefreshTokenExpiry).Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newRefreshRequest("tenant-abc", "old-refresh"))
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	mockCache.AssertNotCalled(t, "GetUserSessions", mock.Anything, mock.Anything)
+	mockCache.AssertCalled(t, "TrackUserSession", mock.Anything, "user-123", "session-1", mock.AnythingOfType("string"), cfg.RefreshTokenExpiry)
+}
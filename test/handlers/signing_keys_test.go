@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/audit"
+	"session-service/internal/auth"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandlePromoteStandbyKey(t *testing.T) {
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+	standbyPriv, standbyPub := helpers.GenerateTestPEMKeys(t)
+	standby, err := auth.NewKeyManager(standbyPriv, standbyPub)
+	require.NoError(t, err)
+	require.NoError(t, km.SetStandby(standby))
+
+	mockAudit := new(mocks.MockAuditRecorder)
+	handler := handlers.NewAdminHandler(new(mocks.MockRepository), new(mocks.MockCache), &config.Config{}, mockAudit, zap.NewNop())
+	handler.EnableStandbyPromotion(km, time.Hour)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.Type == audit.EventSigningKeyPromote && e.TargetID == standby.GetCurrentKeyID()
+	})).Return()
+
+	rr := httptest.NewRecorder()
+	handler.HandlePromoteStandbyKey(rr, httptest.NewRequest("POST", "/admin/keys/promote-standby", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var response models.SigningKeyPromotionResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, standby.GetCurrentKeyID(), response.KeyID)
+	assert.Equal(t, auth.AlgRS256, response.Algorithm)
+	assert.Equal(t, standby.GetCurrentKeyID(), km.GetCurrentKeyID())
+	mockAudit.AssertExpectations(t)
+
+	// The standby is consumed by the promotion
+	rr = httptest.NewRecorder()
+	handler.HandlePromoteStandbyKey(rr, httptest.NewRequest("POST", "/admin/keys/promote-standby", nil))
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestHandlePromoteStandbyKey_NotEnabled(t *testing.T) {
+	handler := handlers.NewAdminHandler(new(mocks.MockRepository), new(mocks.MockCache), &config.Config{}, new(mocks.MockAuditRecorder), zap.NewNop())
+
+	rr := httptest.NewRecorder()
+	handler.HandlePromoteStandbyKey(rr, httptest.NewRequest("POST", "/admin/keys/promote-standby", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandlePromoteStandbyKey_DiscoveryAdvertisesNewAlgorithm(t *testing.T) {
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecPriv, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+	ecPub, err := x509.MarshalPKIXPublicKey(ecKey.Public())
+	require.NoError(t, err)
+	standby, err := auth.NewKeyManagerWithAlgorithm(auth.AlgES256,
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecPriv})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: ecPub})))
+	require.NoError(t, err)
+	require.NoError(t, standby.AddAlgorithm(auth.AlgRS256))
+	require.NoError(t, km.SetStandby(standby))
+
+	oidcHandler := handlers.NewOIDCConfigurationHandler("http://localhost", "issuer", zap.NewNop())
+	oidcHandler.SetIDTokenSigningKeys(km)
+	discoveryAlgs := func() []string {
+		rr := httptest.NewRecorder()
+		oidcHandler.HandleOIDCConfiguration(rr, httptest.NewRequest("GET", "/.well-known/openid-configuration", nil))
+		var doc handlers.OIDCConfiguration
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+		return doc.IDTokenSigningAlgValuesSupported
+	}
+	assert.Equal(t, []string{auth.AlgRS256}, discoveryAlgs())
+
+	require.NoError(t, km.PromoteStandby(time.Hour))
+
+	assert.Equal(t, []string{auth.AlgES256}, discoveryAlgs())
+}
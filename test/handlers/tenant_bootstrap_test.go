@@ -0,0 +1,91 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTenantBootstrapHandler returns a token handler accepting tenant
+// bootstrap tokens, and a function signing one for a tenant.
+func newTenantBootstrapHandler(t *testing.T) (*handlers.TokenHandler, *mocks.MockRepository, *mocks.MockCache, func(tenantID string) string) {
+	t.Helper()
+
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	verifier, err := auth.NewTenantBootstrapVerifier(pubPEM)
+	require.NoError(t, err)
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privPEM))
+	require.NoError(t, err)
+
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour})
+	handler.EnableTenantBootstrap(verifier)
+	expectAuthenticatedClient(t, mockCache)
+
+	sign := func(tenantID string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"tenant_id": tenantID,
+			"exp":       time.Now().Add(time.Minute).Unix(),
+		}).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+	return handler, mockRepo, mockCache, sign
+}
+
+func TestTenantBootstrap_CreatesTenantWithValidToken(t *testing.T) {
+	handler, mockRepo, mockCache, sign := newTenantBootstrapHandler(t)
+	mockRepo.On("CreateTenantIfNotExists", mock.Anything, models.Tenant{ID: "tenant-new", Name: "tenant-new"}).Return(true, nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-new").Return(&models.Tenant{ID: "tenant-new"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), 24*time.Hour).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-new", map[string]string{"tenant_bootstrap_token": sign("tenant-new")}))
+
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "EnsureTenantExists", mock.Anything, mock.Anything)
+}
+
+func TestTenantBootstrap_StrictWithoutToken(t *testing.T) {
+	handler, mockRepo, _, _ := newTenantBootstrapHandler(t)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-new").Return(fmt.Errorf("tenant does not exist: tenant-new"))
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-new", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockRepo.AssertNotCalled(t, "CreateTenantIfNotExists", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpsertUserAndRoles", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTenantBootstrap_RejectsTokenForAnotherTenant(t *testing.T) {
+	handler, mockRepo, _, sign := newTenantBootstrapHandler(t)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-new", map[string]string{"tenant_bootstrap_token": sign("tenant-other")}))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "INVALID_REQUEST", body["error"])
+	mockRepo.AssertNotCalled(t, "CreateTenantIfNotExists", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "EnsureTenantExists", mock.Anything, mock.Anything)
+}
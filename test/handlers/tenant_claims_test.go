@@ -0,0 +1,51 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleToken_ExternalTenantIDClaim(t *testing.T) {
+	tenant := &models.Tenant{ID: "tenant-abc", ExternalTID: "7f3c2a10-aad0-4d5e-9a0b-1b2c3d4e5f60"}
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		wantXTID interface{}
+	}{
+		{name: "enabled", enabled: true, wantXTID: tenant.ExternalTID},
+		{name: "disabled", enabled: false, wantXTID: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				JWTExpiry:          time.Hour,
+				RefreshTokenExpiry: 24 * time.Hour,
+				IncludeExternalTID: tt.enabled,
+			}
+
+			rr, stored, _ := issueClientCredentialsToken(t, cfg, tenant)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var response models.TokenResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+			claims := unverifiedClaims(t, response.AccessToken)
+			// tid always carries the internal tenant ID
+			assert.Equal(t, "tenant-abc", claims["tid"])
+			assert.Equal(t, tt.wantXTID, claims["xtid"])
+
+			require.NotNil(t, stored)
+			assert.Equal(t, "tenant-abc", stored.Subject.TenantID)
+		})
+	}
+}
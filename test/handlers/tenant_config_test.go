@@ -0,0 +1,165 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"session-service/internal/audit"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTenantConfig_SessionLimitOverridesGlobal(t *testing.T) {
+	// No global limit; the tenant allows one session
+	cfg := &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		SessionLimitPolicy: config.SessionLimitReject,
+		TenantConfig:       true,
+	}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+
+	mockCache.On("GetTenantConfig", mock.Anything, "tenant-abc").Return(&models.TenantConfig{TenantID: "tenant-abc", MaxSessionsPerUser: 1}, nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("GetUserSessions", mock.Anything, "user-123").Return([]models.UserSession{
+		{SessionID: "session-1", RefreshToken: "refresh-1", StartedAt: time.Now().Add(-time.Hour)},
+	}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", nil))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Contains(t, rr.Body.String(), "SESSION_LIMIT_REACHED")
+	mockRepo.AssertNotCalled(t, "GetTenantConfig", mock.Anything, mock.Anything)
+}
+
+func TestTenantConfig_GrantAllowlist(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, TenantConfig: true}
+	handler, _, mockCache := newRefreshTestHandler(t, cfg)
+	mockCache.On("GetTenantConfig", mock.Anything, "tenant-abc").Return(&models.TenantConfig{
+		TenantID:          "tenant-abc",
+		AllowedGrantTypes: []string{"client_credentials", "refresh_token"},
+	}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "UNSUPPORTED_GRANT_TYPE")
+	mockCache.AssertNotCalled(t, "GetClient", mock.Anything, mock.Anything)
+}
+
+func TestTenantConfig_LoadedOnceThenCached(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, TenantConfig: true}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	stored := &models.TenantConfig{TenantID: "tenant-abc", AllowedGrantTypes: []string{"client_credentials"}}
+	mockCache.On("GetTenantConfig", mock.Anything, "tenant-abc").Return(nil, nil).Once()
+	mockRepo.On("GetTenantConfig", mock.Anything, "tenant-abc").Return(stored, nil).Once()
+	mockCache.On("SetTenantConfig", mock.Anything, stored, 15*time.Minute).Return(nil).Once()
+	mockCache.On("GetTenantConfig", mock.Anything, "tenant-abc").Return(stored, nil)
+
+	for range 2 {
+		rr := httptest.NewRecorder()
+		handler.HandleToken(rr, newProvisionRequest("tenant-abc", nil))
+		assert.Contains(t, rr.Body.String(), "UNSUPPORTED_GRANT_TYPE")
+	}
+
+	mockRepo.AssertNumberOfCalls(t, "GetTenantConfig", 1)
+	mockCache.AssertNumberOfCalls(t, "SetTenantConfig", 1)
+}
+
+func newTenantConfigAdminHandler(enabled bool) (*handlers.AdminHandler, *mocks.MockRepository, *mocks.MockCache, *mocks.MockAuditRecorder) {
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+	mockAudit := new(mocks.MockAuditRecorder)
+	handler := handlers.NewAdminHandler(mockRepo, mockCache, &config.Config{TenantConfig: enabled}, mockAudit, zap.NewNop())
+	return handler, mockRepo, mockCache, mockAudit
+}
+
+func putTenantConfig(handler *handlers.AdminHandler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("PUT", "/tenant-abc/admin/config", strings.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+	handler.HandleUpdateTenantConfig(rr, req)
+	return rr
+}
+
+func TestHandleUpdateTenantConfig_InvalidatesCache(t *testing.T) {
+	handler, mockRepo, mockCache, mockAudit := newTenantConfigAdminHandler(true)
+	want := models.TenantConfig{TenantID: "tenant-abc", AllowedGrantTypes: []string{"client_credentials"}, MaxSessionsPerUser: 3}
+	mockRepo.On("UpsertTenantConfig", mock.Anything, want).Return(true, nil)
+	mockCache.On("DeleteTenantConfig", mock.Anything, "tenant-abc").Return(nil)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.Type == audit.EventTenantConfigUpdate && e.TenantID == "tenant-abc" && e.Metadata["max_sessions_per_user"] == "3"
+	})).Return()
+
+	// The body cannot retarget another tenant
+	rr := putTenantConfig(handler, `{"tenant_id":"tenant-xyz","allowed_grant_types":["client_credentials"],"max_sessions_per_user":3}`)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var got models.TenantConfig
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+	assert.Equal(t, want, got)
+	mockCache.AssertCalled(t, "DeleteTenantConfig", mock.Anything, "tenant-abc")
+	mockAudit.AssertExpectations(t)
+}
+
+func TestHandleUpdateTenantConfig_Rejections(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		body       string
+		wantStatus int
+	}{
+		{"disabled", false, `{}`, http.StatusBadRequest},
+		{"unknown grant type", true, `{"allowed_grant_types":["password"]}`, http.StatusBadRequest},
+		{"negative session limit", true, `{"max_sessions_per_user":-1}`, http.StatusBadRequest},
+		{"invalid JSON", true, `{`, http.StatusBadRequest},
+		{"unknown tenant", true, `{}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo, mockCache, _ := newTenantConfigAdminHandler(tt.enabled)
+			mockRepo.On("UpsertTenantConfig", mock.Anything, mock.Anything).Return(false, nil)
+
+			rr := putTenantConfig(handler, tt.body)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			mockCache.AssertNotCalled(t, "DeleteTenantConfig", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleGetTenantConfig_DefaultsWithoutRow(t *testing.T) {
+	handler, mockRepo, _, mockAudit := newTenantConfigAdminHandler(true)
+	mockRepo.On("GetTenantConfig", mock.Anything, "tenant-abc").Return(&models.TenantConfig{TenantID: "tenant-abc"}, nil)
+	mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e audit.Event) bool {
+		return e.Type == audit.EventTenantConfigRead
+	})).Return()
+
+	req := httptest.NewRequest("GET", "/tenant-abc/admin/config", nil)
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+	handler.HandleGetTenantConfig(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"tenant_id":"tenant-abc","allowed_grant_types":null,"max_sessions_per_user":0}`, rr.Body.String())
+}
@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"session-service/internal/handlers"
+	"session-service/internal/metrics"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// verifyFrom verifies token for tenant-abc as if sent from remoteAddr.
+func verifyFrom(t *testing.T, handler *handlers.VerifyHandler, token, remoteAddr string) *models.VerifyResponse {
+	t.Helper()
+
+	body, err := json.Marshal(models.VerifyRequest{Token: token})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/tenant-abc/oauth2/v1.0/verify", bytes.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+
+	handler.HandleVerify(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response models.VerifyResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return &response
+}
+
+// presentFromThreeSources verifies one token from three client IPs with a
+// threshold of two sources and returns the responses and captured warnings.
+func presentFromThreeSources(t *testing.T, reject bool) ([]*models.VerifyResponse, *observer.ObservedLogs) {
+	t.Helper()
+
+	_, tokenGen, validator := newVerifyTestSetup(t)
+	token, jti, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	sources := new(mocks.MockCache)
+	sources.On("RecordTokenSource", mock.Anything, jti, "198.51.100.1", mock.Anything).Return(int64(1), true, nil)
+	sources.On("RecordTokenSource", mock.Anything, jti, "198.51.100.2", mock.Anything).Return(int64(2), true, nil)
+	sources.On("RecordTokenSource", mock.Anything, jti, "203.0.113.9", mock.Anything).Return(int64(3), true, nil)
+
+	core, logs := observer.New(zap.WarnLevel)
+	handler := handlers.NewVerifyHandler(validator, nil, false, zap.New(core))
+	handler.EnableTokenSourceTracking(sources, 2, reject, nil)
+
+	var responses []*models.VerifyResponse
+	for _, addr := range []string{"198.51.100.1:1000", "198.51.100.2:1000", "203.0.113.9:1000"} {
+		responses = append(responses, verifyFrom(t, handler, token, addr))
+	}
+	sources.AssertExpectations(t)
+	return responses, logs
+}
+
+func TestTokenSources_MultiSourceUseSignalled(t *testing.T) {
+	before := testutil.ToFloat64(metrics.TokenSourceAnomalies)
+
+	responses, logs := presentFromThreeSources(t, false)
+
+	for _, response := range responses {
+		assert.True(t, response.Valid, "observation mode never blocks")
+	}
+	warnings := logs.FilterMessage("Access token presented from many sources; possible token theft")
+	require.Equal(t, 1, warnings.Len())
+	assert.Equal(t, "203.0.113.9", warnings.All()[0].ContextMap()["source"])
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.TokenSourceAnomalies))
+}
+
+func TestTokenSources_RejectMode(t *testing.T) {
+	responses, _ := presentFromThreeSources(t, true)
+
+	assert.True(t, responses[0].Valid)
+	assert.True(t, responses[1].Valid)
+	assert.False(t, responses[2].Valid)
+	assert.Equal(t, models.VerifyReasonTooManySources, responses[2].Reason)
+}
+
+func TestTokenSources_DisabledByDefault(t *testing.T) {
+	_, tokenGen, validator := newVerifyTestSetup(t)
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+	handler := handlers.NewVerifyHandler(validator, nil, false, zap.NewNop())
+
+	for _, addr := range []string{"198.51.100.1:1000", "198.51.100.2:1000", "203.0.113.9:1000"} {
+		assert.True(t, verifyFrom(t, handler, token, addr).Valid)
+	}
+}
@@ -0,0 +1,202 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// issueClientCredentialsToken runs a client_credentials request against a handler
+// whose repository returns the given tenant, and returns the response along with
+// the refresh token data that was stored. A nil cfg uses 1h/24h expiries.
+func issueClientCredentialsToken(t *testing.T, cfg *config.Config, tenant *models.Tenant) (*httptest.ResponseRecorder, *models.RefreshTokenData, time.Duration) {
+	t.Helper()
+
+	if cfg == nil {
+		cfg = &config.Config{
+			JWTExpiry:          1 * time.Hour,
+			RefreshTokenExpiry: 24 * time.Hour,
+		}
+	}
+
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+
+	tokenGen := auth.NewTokenGenerator(km, "issuer", "audience", cfg.JWTExpiry, 32)
+	tokenValidator := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+	handler := handlers.NewTokenHandler(mockRepo, mockCache, tokenGen, tokenValidator, cfg, zap.NewNop())
+
+	clientID := "test-client"
+	clientSecret := "test-secret"
+	hashedSecret, _ := bcrypt.GenerateFromPassword([]byte(clientSecret), bcrypt.MinCost)
+	client := &models.Client{ClientID: clientID, ClientSecretHash: string(hashedSecret), RateLimit: 100}
+	userID := "user-123"
+
+	var stored *models.RefreshTokenData
+	var storedTTL time.Duration
+
+	mockCache.On("GetClient", mock.Anything, clientID).Return(client, nil)
+	mockCache.On("CheckRateLimit", mock.Anything, clientID, 100, time.Minute).Return(false, nil)
+	mockRepo.On("EnsureTenantExists", mock.Anything, tenant.ID).Return(nil)
+	mockCache.On("GetTenant", mock.Anything, tenant.ID).Return(nil, nil)
+	mockRepo.On("GetTenantByID", mock.Anything, tenant.ID).Return(tenant, nil)
+	mockCache.On("SetTenant", mock.Anything, tenant, 15*time.Minute).Return(nil)
+	mockRepo.On("GetUserByID", mock.Anything, userID).Return(&models.User{ID: userID, TenantID: tenant.ID}, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, userID).Return([]string{"reader"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), mock.AnythingOfType("time.Duration")).
+		Run(func(args mock.Arguments) {
+			stored = args.Get(2).(*models.RefreshTokenData)
+			storedTTL = args.Get(3).(time.Duration)
+		}).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, clientID).Return(nil)
+
+	form := url.Values{}
+	form.Add("grant_type", "client_credentials")
+	form.Add("client_id", clientID)
+	form.Add("client_secret", clientSecret)
+	form.Add("user_id", userID)
+
+	req := httptest.NewRequest("POST", "/"+tenant.ID+"/oauth2/v2.0/token", nil)
+	req.PostForm = form
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": tenant.ID})
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, req)
+
+	return rr, stored, storedTTL
+}
+
+// unverifiedClaims decodes the claims of a token without verifying it.
+func unverifiedClaims(t *testing.T, token string) jwt.MapClaims {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	require.NoError(t, err)
+	return claims
+}
+
+// accessTokenLifetime returns exp - iat of an unverified access token.
+func accessTokenLifetime(t *testing.T, accessToken string) time.Duration {
+	t.Helper()
+	claims := unverifiedClaims(t, accessToken)
+	exp, err := claims.GetExpirationTime()
+	require.NoError(t, err)
+	iat, err := claims.GetIssuedAt()
+	require.NoError(t, err)
+	return exp.Sub(iat.Time)
+}
+
+func TestHandleToken_TenantTokenExpiryOverrides(t *testing.T) {
+	tenant := &models.Tenant{
+		ID:              "tenant-secure",
+		AccessTokenTTL:  5 * time.Minute,
+		RefreshTokenTTL: 1 * time.Hour,
+	}
+
+	rr, stored, storedTTL := issueClientCredentialsToken(t, nil, tenant)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, int64(300), response.ExpiresIn)
+	assert.Equal(t, 5*time.Minute, accessTokenLifetime(t, response.AccessToken))
+	assert.Equal(t, time.Hour, storedTTL)
+	require.NotNil(t, stored)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, 5*time.Second)
+}
+
+func TestHandleToken_TenantWithoutOverridesUsesDefaults(t *testing.T) {
+	tenant := &models.Tenant{ID: "tenant-default"}
+
+	rr, stored, storedTTL := issueClientCredentialsToken(t, nil, tenant)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+	assert.Equal(t, int64(3600), response.ExpiresIn)
+	assert.Equal(t, time.Hour, accessTokenLifetime(t, response.AccessToken))
+	assert.Equal(t, 24*time.Hour, storedTTL)
+	require.NotNil(t, stored)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), stored.ExpiresAt, 5*time.Second)
+}
+
+func TestHandleToken_GrantTokenExpiry(t *testing.T) {
+	cfg := &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		GrantExpiry: map[string]time.Duration{
+			"provision_user":     8 * time.Hour,
+			"client_credentials": 15 * time.Minute,
+		},
+	}
+
+	lifetime := func(claims map[string]interface{}) time.Duration {
+		exp, iat := claims["exp"].(float64), claims["iat"].(float64)
+		return time.Duration(exp-iat) * time.Second
+	}
+	provisioned, provisionedSession := issueForGrant(t, cfg, "provision_user")
+	machine, _ := issueForGrant(t, cfg, "client_credentials")
+
+	assert.Equal(t, 8*time.Hour, lifetime(provisioned))
+	assert.Equal(t, 15*time.Minute, lifetime(machine))
+	require.NotNil(t, provisionedSession)
+	assert.Equal(t, "provision_user", provisionedSession.GrantType)
+}
+
+func TestHandleToken_TenantExpiryOverridesGrantExpiry(t *testing.T) {
+	cfg := &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		GrantExpiry:        map[string]time.Duration{"client_credentials": 15 * time.Minute},
+	}
+	tenant := &models.Tenant{ID: "tenant-secure", AccessTokenTTL: 5 * time.Minute}
+
+	rr, _, _ := issueClientCredentialsToken(t, cfg, tenant)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response models.TokenResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 5*time.Minute, accessTokenLifetime(t, response.AccessToken))
+}
+
+func TestHandleRefreshToken_KeepsGrantTokenExpiry(t *testing.T) {
+	cfg := &config.Config{
+		JWTExpiry:          time.Hour,
+		RefreshTokenExpiry: 24 * time.Hour,
+		GrantExpiry:        map[string]time.Duration{"client_credentials": 15 * time.Minute},
+	}
+
+	response := refreshWithData(t, cfg, &models.RefreshTokenData{
+		ClientID:  "test-client",
+		Subject:   &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"},
+		IssuedAt:  time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(12 * time.Hour),
+		GrantType: "client_credentials",
+	})
+
+	assert.Equal(t, 15*time.Minute, accessTokenLifetime(t, response.AccessToken))
+}
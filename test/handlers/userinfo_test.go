@@ -0,0 +1,112 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/config"
+	"session-service/internal/middleware"
+	"session-service/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// provisionUser runs provision_user with the given overrides and returns the
+// user record handed to the repository.
+func provisionUser(t *testing.T, overrides map[string]string) models.User {
+	t.Helper()
+
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+
+	var stored models.User
+	expectAuthenticatedClient(t, mockCache)
+	mockRepo.On("EnsureTenantExists", mock.Anything, "tenant-abc").Return(nil)
+	mockRepo.On("UpsertUserAndRoles", mock.Anything, mock.AnythingOfType("models.User"), []string(nil)).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(models.User) }).
+		Return(false, nil)
+	mockRepo.On("GetUserRoles", mock.Anything, "user-123").Return([]string{}, nil)
+	mockCache.On("GetTenant", mock.Anything, "tenant-abc").Return(&models.Tenant{ID: "tenant-abc"}, nil)
+	mockCache.On("StoreRefreshToken", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("*models.RefreshTokenData"), cfg.RefreshTokenExpiry).Return(nil)
+	mockRepo.On("UpdateClientUpdatedAt", mock.Anything, "test-client").Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", overrides))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	return stored
+}
+
+// fetchUserInfo calls the userinfo endpoint for user-123 in tenant-abc with
+// the repository returning user.
+func fetchUserInfo(t *testing.T, user *models.User) *httptest.ResponseRecorder {
+	t.Helper()
+
+	_, tokenGen, tokenValidator := newVerifyTestSetup(t)
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, _ := newRefreshTestHandler(t, cfg)
+	mockRepo.On("GetUserByID", mock.Anything, "user-123").Return(user, nil)
+
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Handle("/{tenant_id}/oauth2/v1.0/userinfo",
+		middleware.RequireTenantToken(tokenValidator, zap.NewNop())(http.HandlerFunc(handler.HandleUserInfo)))
+
+	req := httptest.NewRequest("GET", "/tenant-abc/oauth2/v1.0/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestVerifiedFlags_RoundTripThroughProvisioningAndUserInfo(t *testing.T) {
+	stored := provisionUser(t, map[string]string{
+		"user_email_verified": "true",
+		"user_phone_verified": "false",
+	})
+	assert.True(t, stored.EmailVerified)
+	assert.False(t, stored.PhoneVerified)
+
+	rr := fetchUserInfo(t, &stored)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &info))
+	assert.Equal(t, "user-123", info["sub"])
+	assert.Equal(t, "jane@example.com", info["email"])
+	assert.Equal(t, true, info["email_verified"])
+	assert.Equal(t, false, info["phone_number_verified"])
+}
+
+func TestVerifiedFlags_DefaultToFalse(t *testing.T) {
+	stored := provisionUser(t, nil)
+
+	assert.False(t, stored.EmailVerified)
+	assert.False(t, stored.PhoneVerified)
+}
+
+func TestHandleUserProvisioning_RejectsInvalidVerifiedFlag(t *testing.T) {
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour}
+	handler, mockRepo, mockCache := newRefreshTestHandler(t, cfg)
+	expectAuthenticatedClient(t, mockCache)
+
+	rr := httptest.NewRecorder()
+	handler.HandleToken(rr, newProvisionRequest("tenant-abc", map[string]string{"user_phone_verified": "maybe"}))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockRepo.AssertNotCalled(t, "UpsertUserAndRoles", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleUserInfo_UserInOtherTenant(t *testing.T) {
+	rr := fetchUserInfo(t, &models.User{ID: "user-123", TenantID: "tenant-xyz"})
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
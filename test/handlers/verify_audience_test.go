@@ -0,0 +1,65 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// verifyForAudience verifies token for tenant-abc with the given
+// expected_audience.
+func verifyForAudience(t *testing.T, handler *handlers.VerifyHandler, token, audience string) *models.VerifyResponse {
+	t.Helper()
+
+	body, err := json.Marshal(models.VerifyRequest{Token: token, ExpectedAudience: audience})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/tenant-abc/oauth2/v1.0/verify", bytes.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+
+	handler.HandleVerify(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var response models.VerifyResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return &response
+}
+
+func TestHandleVerify_ExpectedAudience(t *testing.T) {
+	km, _, tokenValidator := newVerifyTestSetup(t)
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+	token := signIssuedAt(t, km, time.Now(), jwt.MapClaims{"aud": []string{"audience", "billing-api"}})
+
+	t.Run("present and matching", func(t *testing.T) {
+		response := verifyForAudience(t, handler, token, "billing-api")
+
+		assert.True(t, response.Valid, response.Message)
+		assert.Empty(t, response.Reason)
+	})
+
+	t.Run("present and missing", func(t *testing.T) {
+		response := verifyForAudience(t, handler, token, "reports-api")
+
+		assert.False(t, response.Valid)
+		assert.Equal(t, models.VerifyReasonWrongAudience, response.Reason)
+		assert.Nil(t, response.Claims)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		response := verifyForAudience(t, handler, token, "")
+
+		assert.True(t, response.Valid, response.Message)
+	})
+}
@@ -0,0 +1,87 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newCachedVerifySetup(t *testing.T, ttl time.Duration) (*auth.TokenGenerator, *mocks.MockCache, *handlers.VerifyHandler) {
+	t.Helper()
+
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+
+	mockCache := new(mocks.MockCache)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+
+	tokenGen := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tokenValidator := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+	handler := handlers.NewVerifyHandler(tokenValidator, auth.NewVerificationCache(ttl, 100), false, zap.NewNop())
+	return tokenGen, mockCache, handler
+}
+
+func TestHandleVerify_CacheHitSkipsValidation(t *testing.T) {
+	tokenGen, mockCache, handler := newCachedVerifySetup(t, time.Minute)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		response := verifyToken(t, handler, token)
+		require.True(t, response.Valid)
+		assert.Equal(t, "user-123", response.Claims["sub"])
+	}
+
+	mockCache.AssertNumberOfCalls(t, "IsTokenRevoked", 1)
+}
+
+func TestHandleVerify_CacheDoesNotStoreRevokedToken(t *testing.T) {
+	tokenGen, mockCache, handler := newCachedVerifySetup(t, time.Minute)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(true, nil)
+
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	assert.False(t, verifyToken(t, handler, token).Valid)
+	assert.False(t, verifyToken(t, handler, token).Valid)
+	mockCache.AssertNumberOfCalls(t, "IsTokenRevoked", 2)
+}
+
+func TestHandleVerify_RevocationSeenAfterCacheExpiry(t *testing.T) {
+	tokenGen, mockCache, handler := newCachedVerifySetup(t, 50*time.Millisecond)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil).Once()
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(true, nil)
+
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	require.True(t, verifyToken(t, handler, token).Valid)
+
+	time.Sleep(100 * time.Millisecond)
+
+	assert.False(t, verifyToken(t, handler, token).Valid)
+}
+
+func TestHandleVerify_CachedResultStillChecksTenant(t *testing.T) {
+	tokenGen, mockCache, handler := newCachedVerifySetup(t, time.Minute)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "other-tenant"})
+	require.NoError(t, err)
+
+	assert.False(t, verifyToken(t, handler, token).Valid)
+	assert.False(t, verifyToken(t, handler, token).Valid)
+}
@@ -0,0 +1,102 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// verifyWithMaxAge verifies token for tenant-abc with the given max_age.
+func verifyWithMaxAge(t *testing.T, handler *handlers.VerifyHandler, token string, maxAge int64) *models.VerifyResponse {
+	t.Helper()
+
+	body, err := json.Marshal(models.VerifyRequest{Token: token, MaxAge: &maxAge})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/tenant-abc/oauth2/v1.0/verify", bytes.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+
+	handler.HandleVerify(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var response models.VerifyResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return &response
+}
+
+// signIssuedAt signs a tenant-abc token issued at iat, with extra claims.
+func signIssuedAt(t *testing.T, km *auth.KeyManager, iat time.Time, extra jwt.MapClaims) string {
+	t.Helper()
+
+	claims := jwt.MapClaims{
+		"iss": "issuer",
+		"aud": "audience",
+		"sub": "user-123",
+		"tid": "tenant-abc",
+		"iat": iat.Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = km.GetCurrentKeyID()
+	signed, err := token.SignedString(km.GetPrivateKey())
+	require.NoError(t, err)
+	return signed
+}
+
+func TestHandleVerify_MaxAgeFreshTokenPasses(t *testing.T) {
+	km, _, tokenValidator := newVerifyTestSetup(t)
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+
+	response := verifyWithMaxAge(t, handler, signIssuedAt(t, km, time.Now().Add(-time.Minute), nil), 300)
+
+	assert.True(t, response.Valid, response.Message)
+	assert.Empty(t, response.Reason)
+}
+
+func TestHandleVerify_MaxAgeStaleTokenFails(t *testing.T) {
+	km, _, tokenValidator := newVerifyTestSetup(t)
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+
+	response := verifyWithMaxAge(t, handler, signIssuedAt(t, km, time.Now().Add(-10*time.Minute), nil), 300)
+
+	assert.False(t, response.Valid)
+	assert.Equal(t, models.VerifyReasonStale, response.Reason)
+	assert.Nil(t, response.Claims)
+}
+
+func TestHandleVerify_MaxAgePrefersAuthTime(t *testing.T) {
+	km, _, tokenValidator := newVerifyTestSetup(t)
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+
+	// Recently refreshed token whose user authenticated long ago
+	token := signIssuedAt(t, km, time.Now(), jwt.MapClaims{"auth_time": time.Now().Add(-time.Hour).Unix()})
+	response := verifyWithMaxAge(t, handler, token, 300)
+
+	assert.False(t, response.Valid)
+	assert.Equal(t, models.VerifyReasonStale, response.Reason)
+}
+
+func TestHandleVerify_NoMaxAgeIgnoresAge(t *testing.T) {
+	km, _, tokenValidator := newVerifyTestSetup(t)
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+
+	response := verifyToken(t, handler, signIssuedAt(t, km, time.Now().Add(-10*time.Minute), nil))
+
+	assert.True(t, response.Valid, response.Message)
+}
@@ -0,0 +1,150 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/handlers"
+	"session-service/internal/models"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// verifyToken runs token through the verify handler for tenant-abc and decodes the response.
+func verifyToken(t *testing.T, handler *handlers.VerifyHandler, token string) *models.VerifyResponse {
+	t.Helper()
+
+	body, err := json.Marshal(models.VerifyRequest{Token: token})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/tenant-abc/oauth2/v1.0/verify", bytes.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"tenant_id": "tenant-abc"})
+	rr := httptest.NewRecorder()
+
+	handler.HandleVerify(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response models.VerifyResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	return &response
+}
+
+func newVerifyTestSetup(t *testing.T) (*auth.KeyManager, *auth.TokenGenerator, *auth.TokenValidator) {
+	t.Helper()
+
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+
+	mockCache := new(mocks.MockCache)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+
+	tokenGen := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tokenValidator := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+	return km, tokenGen, tokenValidator
+}
+
+func TestHandleVerify_ReportsGracePeriodKey(t *testing.T) {
+	km, tokenGen, tokenValidator := newVerifyTestSetup(t)
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, true, zap.NewNop())
+
+	subject := &models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"}
+	oldToken, _, err := tokenGen.GenerateAccessToken(subject)
+	require.NoError(t, err)
+	oldKeyID := km.GetCurrentKeyID()
+
+	require.NoError(t, km.RotateKeys(time.Hour))
+
+	response := verifyToken(t, handler, oldToken)
+	require.True(t, response.Valid)
+	require.NotNil(t, response.SigningKey)
+	assert.Equal(t, oldKeyID, response.SigningKey.KeyID)
+	assert.True(t, response.SigningKey.InGrace)
+	require.NotNil(t, response.SigningKey.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *response.SigningKey.ExpiresAt, time.Minute)
+
+	newToken, _, err := tokenGen.GenerateAccessToken(subject)
+	require.NoError(t, err)
+
+	response = verifyToken(t, handler, newToken)
+	require.True(t, response.Valid)
+	require.NotNil(t, response.SigningKey)
+	assert.Equal(t, km.GetCurrentKeyID(), response.SigningKey.KeyID)
+	assert.False(t, response.SigningKey.InGrace)
+	assert.Nil(t, response.SigningKey.ExpiresAt)
+}
+
+func TestHandleVerify_OmitsKeyStatusByDefault(t *testing.T) {
+	_, tokenGen, tokenValidator := newVerifyTestSetup(t)
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc"})
+	require.NoError(t, err)
+
+	response := verifyToken(t, handler, token)
+	assert.True(t, response.Valid)
+	assert.Nil(t, response.SigningKey)
+}
+
+func TestHandleVerify_TenantFromIssuer(t *testing.T) {
+	km, _, tokenValidator := newVerifyTestSetup(t)
+	tokenValidator.EnableTenantIssuers()
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+
+	issue := func(issuer string) string {
+		token, _, err := auth.NewTokenGenerator(km, issuer, "audience", time.Hour, 32).
+			GenerateAccessToken(&models.TokenSubject{UserID: "user-123"})
+		require.NoError(t, err)
+		return token
+	}
+
+	assert.True(t, verifyToken(t, handler, issue("issuer/tenant-abc")).Valid)
+
+	response := verifyToken(t, handler, issue("issuer/tenant-xyz"))
+	assert.False(t, response.Valid)
+	assert.Equal(t, "tenant_id in path does not match token tenant_id", response.Message)
+
+	response = verifyToken(t, handler, issue("issuer"))
+	assert.False(t, response.Valid, "tokens that name no tenant never match a path tenant")
+}
+
+func TestHandleVerify_ReportsEveryAudience(t *testing.T) {
+	km, _, tokenValidator := newVerifyTestSetup(t)
+	tokenValidator.EnablePreviousAudiences([]string{"old-audience"})
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+
+	token := signIssuedAt(t, km, time.Now(), jwt.MapClaims{"aud": []string{"old-audience", "audience", "billing"}})
+	response := verifyToken(t, handler, token)
+
+	require.True(t, response.Valid, response.Message)
+	assert.Equal(t, []interface{}{"old-audience", "audience", "billing"}, response.Claims["aud"])
+}
+
+func TestHandleVerify_RenamedClaims(t *testing.T) {
+	_, tokenGen, tokenValidator := newVerifyTestSetup(t)
+	names := auth.ClaimNames{Roles: "groups", Scopes: "scp", Tenant: "tenant"}
+	tokenGen.EnableClaimNames(names)
+	tokenValidator.EnableClaimNames(names)
+	handler := handlers.NewVerifyHandler(tokenValidator, nil, false, zap.NewNop())
+
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "user-123", TenantID: "tenant-abc", Roles: []string{"reader"}})
+	require.NoError(t, err)
+	response := verifyToken(t, handler, token)
+
+	require.True(t, response.Valid, response.Message)
+	assert.Equal(t, []interface{}{"reader"}, response.Claims["groups"])
+	assert.Equal(t, "tenant-abc", response.Claims["tenant"])
+	assert.NotContains(t, response.Claims, "tid")
+}
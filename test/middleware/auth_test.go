@@ -0,0 +1,197 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/middleware"
+	"session-service/internal/models"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRequireRole(t *testing.T) {
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privPEM, pubPEM)
+	require.NoError(t, err)
+
+	mockCache := new(mocks.MockCache)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+
+	tokenGen := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+
+	var sub interface{}
+	router := mux.NewRouter()
+	admin := router.PathPrefix("/{tenant_id}/admin").Subrouter()
+	admin.Use(middleware.RequireRole(validator, "tenant-admin", zap.NewNop()))
+	admin.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := middleware.ClaimsFromContext(r.Context())
+		sub = claims["sub"]
+		w.WriteHeader(http.StatusOK)
+	})
+
+	issue := func(tenantID string, roles ...string) string {
+		token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "admin-1", TenantID: tenantID, Roles: roles})
+		require.NoError(t, err)
+		return token
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic abc", http.StatusUnauthorized},
+		{"invalid token", "Bearer not-a-jwt", http.StatusUnauthorized},
+		{"missing role", "Bearer " + issue("tenant-abc", "reader"), http.StatusForbidden},
+		{"other tenant", "Bearer " + issue("tenant-other", "tenant-admin"), http.StatusForbidden},
+		{"admin", "Bearer " + issue("tenant-abc", "reader", "tenant-admin"), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/tenant-abc/admin/ping", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+
+	assert.Equal(t, "admin-1", sub)
+}
+
+func TestRequireOperatorToken(t *testing.T) {
+	const operatorToken = "operator-token-0123456789abcdef0123"
+
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privPEM, pubPEM)
+	require.NoError(t, err)
+	adminToken, _, err := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32).
+		GenerateAccessToken(&models.TokenSubject{UserID: "admin-1", TenantID: "tenant-abc", Roles: []string{"tenant-admin"}})
+	require.NoError(t, err)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		operator string
+		header   string
+		want     int
+	}{
+		{"operator token", operatorToken, "Bearer " + operatorToken, http.StatusOK},
+		{"missing token", operatorToken, "", http.StatusUnauthorized},
+		{"wrong token", operatorToken, "Bearer " + operatorToken + "x", http.StatusUnauthorized},
+		{"tenant admin token", operatorToken, "Bearer " + adminToken, http.StatusUnauthorized},
+		{"no operator token configured", "", "Bearer " + adminToken, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/admin/keys/promote-standby", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+			middleware.RequireOperatorToken(tt.operator, zap.NewNop())(ok).ServeHTTP(rr, req)
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+}
+
+func TestRequireTenantToken_TenantFromIssuer(t *testing.T) {
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privPEM, pubPEM)
+	require.NoError(t, err)
+
+	mockCache := new(mocks.MockCache)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+
+	validator := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+	validator.EnableTenantIssuers()
+
+	router := mux.NewRouter()
+	router.Handle("/{tenant_id}/userinfo", middleware.RequireTenantToken(validator, zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	issue := func(issuer string) string {
+		token, _, err := auth.NewTokenGenerator(km, issuer, "audience", time.Hour, 32).
+			GenerateAccessToken(&models.TokenSubject{UserID: "user-123"})
+		require.NoError(t, err)
+		return token
+	}
+
+	tests := []struct {
+		name   string
+		issuer string
+		want   int
+	}{
+		{"issuer of path tenant", "issuer/tenant-abc", http.StatusOK},
+		{"issuer of other tenant", "issuer/tenant-other", http.StatusForbidden},
+		{"no tenant", "issuer", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/tenant-abc/userinfo", nil)
+			req.Header.Set("Authorization", "Bearer "+issue(tt.issuer))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+}
+
+func TestRequireRole_RenamedRolesClaim(t *testing.T) {
+	privPEM, pubPEM := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privPEM, pubPEM)
+	require.NoError(t, err)
+
+	mockCache := new(mocks.MockCache)
+	mockCache.On("IsTokenRevoked", mock.Anything, mock.Anything).Return(false, nil)
+	mockCache.On("GetUserRevocationCutoff", mock.Anything, mock.Anything).Return(time.Time{}, nil)
+
+	names := auth.ClaimNames{Roles: "wids", Scopes: "scp", Tenant: "tenant"}
+	tokenGen := auth.NewTokenGenerator(km, "issuer", "audience", time.Hour, 32)
+	tokenGen.EnableClaimNames(names)
+	validator := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+	validator.EnableClaimNames(names)
+
+	var tenantID string
+	router := mux.NewRouter()
+	admin := router.PathPrefix("/{tenant_id}/admin").Subrouter()
+	admin.Use(middleware.RequireRole(validator, "tenant-admin", zap.NewNop()))
+	admin.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		tenantID = middleware.TenantFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	token, _, err := tokenGen.GenerateAccessToken(&models.TokenSubject{UserID: "admin-1", TenantID: "tenant-abc", Roles: []string{"tenant-admin"}})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/tenant-abc/admin/ping", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "tenant-abc", tenantID)
+}
@@ -0,0 +1,87 @@
+package middleware_test
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"session-service/internal/middleware"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func basicHeader(credentials string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+}
+
+func TestBasicCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		wantID     string
+		wantSecret string
+		wantOK     bool
+	}{
+		{"valid", basicHeader("client:secret"), "client", "secret", true},
+		{"scheme is case-insensitive", "bAsIc " + base64.StdEncoding.EncodeToString([]byte("client:secret")), "client", "secret", true},
+		{"surrounding whitespace tolerated", "  " + basicHeader("client:secret") + " ", "client", "secret", true},
+		{"form-urlencoded values decoded", basicHeader("my%3Aclient:p%40ss"), "my:client", "p@ss", true},
+		{"colon allowed in secret", basicHeader("client:se:cret"), "client", "se:cret", true},
+		{"missing header", "", "", "", false},
+		{"bad base64", "Basic not*base64!", "", "", false},
+		{"missing colon", basicHeader("clientsecret"), "", "", false},
+		{"empty credentials", "Basic ", "", "", false},
+		{"empty client id", basicHeader(":secret"), "", "", false},
+		{"empty secret", basicHeader("client:"), "", "", false},
+		{"wrong scheme", "Bearer " + base64.StdEncoding.EncodeToString([]byte("client:secret")), "", "", false},
+		{"extra whitespace between scheme and credentials", "Basic  " + base64.StdEncoding.EncodeToString([]byte("client:secret")), "", "", false},
+		{"no separator", "Basic" + base64.StdEncoding.EncodeToString([]byte("client:secret")), "", "", false},
+		{"bad percent encoding", basicHeader("client:100%"), "", "", false},
+		{"oversized", basicHeader("client:" + strings.Repeat("s", middleware.MaxAuthorizationHeaderLength)), "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			id, secret, ok := middleware.BasicCredentials(req)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantID, id)
+			assert.Equal(t, tt.wantSecret, secret)
+		})
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+		wantOK bool
+	}{
+		{"valid", "Bearer abc.def.ghi", "abc.def.ghi", true},
+		{"scheme is case-insensitive", "bearer abc.def.ghi", "abc.def.ghi", true},
+		{"missing header", "", "", false},
+		{"wrong scheme", "Basic abc.def.ghi", "", false},
+		{"empty token", "Bearer ", "", false},
+		{"scheme only", "Bearer", "", false},
+		{"token with inner whitespace", "Bearer abc def", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			token, ok := middleware.BearerToken(req)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, token)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middleware_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"session-service/internal/config"
+	"session-service/internal/middleware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := config.ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.10"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		want         string
+	}{
+		{"direct client", "198.51.100.7:1234", "", "198.51.100.7"},
+		{"untrusted peer cannot spoof", "198.51.100.7:1234", "203.0.113.9", "198.51.100.7"},
+		{"trusted proxy", "10.1.2.3:1234", "203.0.113.9", "203.0.113.9"},
+		{"chain of trusted proxies", "192.0.2.10:1234", "203.0.113.9, 10.0.0.5", "203.0.113.9"},
+		{"spoofed leftmost entry ignored", "10.1.2.3:1234", "1.1.1.1, 203.0.113.9", "203.0.113.9"},
+		{"trusted proxy without header", "10.1.2.3:1234", "", "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			assert.Equal(t, tt.want, middleware.ClientIP(req, trusted))
+		})
+	}
+}
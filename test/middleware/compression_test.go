@@ -0,0 +1,101 @@
+package middleware_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"session-service/internal/middleware"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var largeBody = strings.Repeat(`{"user_id":"user-123","tenant_id":"tenant-abc"}`, 100)
+
+// compress serves body of contentType through the compression middleware
+// and returns the recorded response.
+func compress(t *testing.T, preference []string, acceptEncoding, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	handler := middleware.Compression(1024, preference)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, body)
+	}))
+	req := httptest.NewRequest("GET", "/tenant-abc/admin/users", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	return rr
+}
+
+// decodeBody decompresses a response body according to its Content-Encoding.
+func decodeBody(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var reader io.Reader = rr.Body
+	switch rr.Header().Get("Content-Encoding") {
+	case "br":
+		reader = brotli.NewReader(rr.Body)
+	case "gzip":
+		gz, err := gzip.NewReader(rr.Body)
+		require.NoError(t, err)
+		reader = gz
+	}
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestCompression_Negotiation(t *testing.T) {
+	tests := []struct {
+		name           string
+		preference     []string
+		acceptEncoding string
+		want           string
+	}{
+		{"br preferred", []string{"br", "gzip"}, "gzip, deflate, br", "br"},
+		{"gzip preferred", []string{"gzip", "br"}, "gzip, deflate, br", "gzip"},
+		{"gzip fallback", []string{"br", "gzip"}, "gzip, deflate", "gzip"},
+		{"client weights win", []string{"br", "gzip"}, "br;q=0.5, gzip", "gzip"},
+		{"refused coding", []string{"br", "gzip"}, "br;q=0, gzip", "gzip"},
+		{"wildcard", []string{"br", "gzip"}, "*", "br"},
+		{"nothing acceptable", []string{"br", "gzip"}, "deflate", ""},
+		{"no Accept-Encoding", []string{"br", "gzip"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := compress(t, tt.preference, tt.acceptEncoding, "application/json", largeBody)
+
+			assert.Equal(t, tt.want, rr.Header().Get("Content-Encoding"))
+			assert.Equal(t, largeBody, decodeBody(t, rr))
+			if tt.want != "" {
+				assert.Less(t, rr.Body.Len(), len(largeBody))
+				assert.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestCompression_SkipsSmallResponses(t *testing.T) {
+	rr := compress(t, []string{"br", "gzip"}, "br, gzip", "application/json", `{"valid":true}`)
+
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"valid":true}`, rr.Body.String())
+}
+
+func TestCompression_SkipsCompressedContent(t *testing.T) {
+	rr := compress(t, []string{"br", "gzip"}, "br, gzip", "application/zip", largeBody)
+
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, largeBody, rr.Body.String())
+}
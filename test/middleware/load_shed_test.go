@@ -0,0 +1,90 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"session-service/internal/metrics"
+	"session-service/internal/middleware"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// blockingHandler holds every request until release is closed, signalling
+// entered as each one starts.
+func blockingHandler(entered chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestLoadShedding(t *testing.T) {
+	entered := make(chan struct{}, 3)
+	release := make(chan struct{})
+	handler := middleware.LoadShedding(2, 20*time.Millisecond, zap.NewNop())(blockingHandler(entered, release))
+	shedBefore := testutil.ToFloat64(metrics.RequestsShed)
+
+	// Two requests fill the limit and are served
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", "/tenant-abc/health", nil))
+			codes[i] = rr.Code
+		}(i)
+	}
+	<-entered
+	<-entered
+
+	// A third is shed once the acquire timeout passes
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/tenant-abc/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+	assert.Contains(t, rr.Body.String(), "TEMPORARILY_UNAVAILABLE")
+	assert.Equal(t, shedBefore+1, testutil.ToFloat64(metrics.RequestsShed))
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK}, codes)
+
+	// Slots are returned once requests finish
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/tenant-abc/health", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestLoadShedding_WaitsForFreedSlot(t *testing.T) {
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+	handler := middleware.LoadShedding(1, time.Second, zap.NewNop())(blockingHandler(entered, release))
+
+	done := make(chan int)
+	go func() {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+		done <- rr.Code
+	}()
+	<-entered
+
+	// The second request waits within the acquire timeout and is served
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, http.StatusOK, <-done)
+}
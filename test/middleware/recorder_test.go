@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"session-service/internal/middleware"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRecordedRouter(recorder *middleware.RequestRecorder) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(recorder.Middleware)
+	router.HandleFunc("/{tenant_id}/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		// Handlers must still see the full body after recording
+		if r.FormValue("client_secret") != "s3cr3t" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "INVALID_CREDENTIALS"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "eyJ.secret.token", "refresh_token": "rt-secret"})
+	})
+	router.HandleFunc("/{tenant_id}/oauth2/v1.0/verify", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return router
+}
+
+func postForm(router http.Handler, path string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestRequestRecorder_RedactsSecretsAndTokens(t *testing.T) {
+	recorder := middleware.NewRequestRecorder(10)
+	router := newRecordedRouter(recorder)
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"test-client"},
+		"client_secret": {"s3cr3t"},
+		"user_id":       {"user-123"},
+	}
+	rr := postForm(router, "/tenant-abc/oauth2/v2.0/token", form)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	form = url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_secret": {"wrong"},
+		"refresh_token": {"rt-secret"},
+	}
+	rr = postForm(router, "/tenant-abc/oauth2/v2.0/token", form)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req := httptest.NewRequest("POST", "/tenant-abc/oauth2/v1.0/verify", strings.NewReader(`{"token":"eyJ.secret.token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "tenant-abc", entries[0].TenantID)
+	assert.Equal(t, "client_credentials", entries[0].GrantType)
+	assert.Equal(t, http.StatusOK, entries[0].Status)
+	assert.Equal(t, "test-client", entries[0].Params["client_id"])
+	assert.Equal(t, "[REDACTED]", entries[0].Params["client_secret"])
+
+	assert.Equal(t, "refresh_token", entries[1].GrantType)
+	assert.Equal(t, http.StatusUnauthorized, entries[1].Status)
+	assert.Equal(t, "INVALID_CREDENTIALS", entries[1].ErrorCode)
+	assert.Equal(t, "[REDACTED]", entries[1].Params["refresh_token"])
+
+	assert.Equal(t, "[REDACTED]", entries[2].Params["token"])
+
+	// Nothing secret may appear anywhere in what the recorder exposes
+	dump, err := json.Marshal(entries)
+	require.NoError(t, err)
+	for _, secret := range []string{"s3cr3t", "wrong", "rt-secret", "eyJ.secret.token"} {
+		assert.NotContains(t, string(dump), secret)
+	}
+}
+
+func TestRequestRecorder_KeepsOnlyMostRecent(t *testing.T) {
+	recorder := middleware.NewRequestRecorder(2)
+	router := newRecordedRouter(recorder)
+
+	for _, clientID := range []string{"client-1", "client-2", "client-3"} {
+		postForm(router, "/tenant-abc/oauth2/v2.0/token", url.Values{"grant_type": {"client_credentials"}, "client_id": {clientID}})
+	}
+
+	entries := recorder.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "client-2", entries[0].Params["client_id"])
+	assert.Equal(t, "client-3", entries[1].Params["client_id"])
+}
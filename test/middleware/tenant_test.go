@@ -0,0 +1,124 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"session-service/internal/config"
+	"session-service/internal/middleware"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTenantRouter(seen *string) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(middleware.NormalizeTenantID)
+	router.HandleFunc("/{tenant_id}/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		*seen = mux.Vars(r)["tenant_id"]
+		w.WriteHeader(http.StatusOK)
+	}).Methods("POST")
+	router.NotFoundHandler = middleware.TrailingSlashFallback(router)
+	return router
+}
+
+func TestTrailingSlashFallback(t *testing.T) {
+	var seen string
+	router := newTenantRouter(&seen)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"canonical path", "/tenant-abc/oauth2/v2.0/token", http.StatusOK},
+		{"trailing slash", "/tenant-abc/oauth2/v2.0/token/", http.StatusOK},
+		{"unknown path with slash", "/tenant-abc/oauth2/v2.0/nope/", http.StatusNotFound},
+		{"root", "/", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = ""
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("POST", tt.path, nil))
+			assert.Equal(t, tt.want, rr.Code)
+			if tt.want == http.StatusOK {
+				assert.Equal(t, "tenant-abc", seen)
+			}
+		})
+	}
+}
+
+func TestNormalizeTenantID(t *testing.T) {
+	var seen string
+	router := newTenantRouter(&seen)
+
+	for _, path := range []string{"/tenant-abc/oauth2/v2.0/token", "/Tenant-ABC/oauth2/v2.0/token", "/TENANT-ABC/oauth2/v2.0/token/"} {
+		seen = ""
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", path, nil))
+		assert.Equal(t, http.StatusOK, rr.Code, path)
+		assert.Equal(t, "tenant-abc", seen, path)
+	}
+
+	assert.Equal(t, "tenant-abc", middleware.CanonicalTenantID(" Tenant-ABC "))
+}
+
+func TestValidateTenantID(t *testing.T) {
+	pattern, err := config.CompileTenantIDPattern(config.DefaultTenantIDPattern)
+	require.NoError(t, err)
+
+	var reached bool
+	router := mux.NewRouter()
+	router.Use(middleware.NormalizeTenantID)
+	router.Use(middleware.ValidateTenantID(pattern))
+	router.HandleFunc("/{tenant_id}/oauth2/v1.0/verify", func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		tenantID string
+		want     int
+	}{
+		{"slug", "tenant-abc", http.StatusOK},
+		{"uuid", "3f2b8c1e-9a4d-4c7e-8b1a-2d5e6f7a8b9c", http.StatusOK},
+		{"mixed case is normalized first", "Tenant-ABC", http.StatusOK},
+		{"underscore", "tenant_abc", http.StatusBadRequest},
+		{"leading hyphen", "-tenant", http.StatusBadRequest},
+		{"too long", strings.Repeat("a", 64), http.StatusBadRequest},
+		{"control character", "tenant%01abc", http.StatusBadRequest},
+		{"dots", "tenant.abc", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached = false
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("POST", "/"+tt.tenantID+"/oauth2/v1.0/verify", nil))
+
+			assert.Equal(t, tt.want, rr.Code)
+			assert.Equal(t, tt.want == http.StatusOK, reached)
+			if tt.want == http.StatusBadRequest {
+				assert.Contains(t, rr.Body.String(), "INVALID_REQUEST")
+			}
+		})
+	}
+}
+
+func TestValidateTenantID_NilPatternAllowsAll(t *testing.T) {
+	handler := middleware.ValidateTenantID(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := mux.SetURLVars(httptest.NewRequest("GET", "/x", nil), map[string]string{"tenant_id": "any thing"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
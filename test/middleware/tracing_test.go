@@ -0,0 +1,110 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"session-service/internal/cache"
+	"session-service/internal/database"
+	"session-service/internal/middleware"
+	"session-service/internal/models"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+// recordSpans installs a tracer provider exporting to memory for the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return exporter
+}
+
+// newTracedRouter serves a route that reads a client from the cache and the
+// database through the instrumented wrappers.
+func newTracedRouter() *mux.Router {
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("GetClientByID", mock.Anything, "test-client").Return(&models.Client{ClientID: "test-client"}, nil)
+	mockCache := new(mocks.MockCache)
+	mockCache.On("GetClient", mock.Anything, "test-client").Return(nil, nil)
+
+	repo := database.NewInstrumentedRepository(mockRepo, 0, zap.NewNop())
+	clientCache := cache.NewInstrumentedCache(mockCache, 0, zap.NewNop())
+
+	router := mux.NewRouter()
+	router.Use(middleware.Tracing())
+	router.HandleFunc("/{tenant_id}/clients/{client_id}", func(w http.ResponseWriter, r *http.Request) {
+		clientID := mux.Vars(r)["client_id"]
+		if _, err := clientCache.GetClient(r.Context(), clientID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if _, err := repo.GetClientByID(r.Context(), clientID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	return router
+}
+
+func TestTracing_RequestSpanHasDatabaseAndCacheChildren(t *testing.T) {
+	exporter := recordSpans(t)
+
+	rr := httptest.NewRecorder()
+	newTracedRouter().ServeHTTP(rr, httptest.NewRequest("GET", "/tenant-abc/clients/test-client", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	byName := make(map[string]tracetest.SpanStub, len(spans))
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+
+	root, ok := byName["GET /{tenant_id}/clients/{client_id}"]
+	require.True(t, ok, "the request span is named after the route template")
+	assert.False(t, root.Parent.IsValid())
+
+	for _, name := range []string{"cache.GetClient", "database.GetClientByID"} {
+		child, ok := byName[name]
+		require.True(t, ok, name)
+		assert.Equal(t, root.SpanContext.TraceID(), child.SpanContext.TraceID(), name)
+		assert.Equal(t, root.SpanContext.SpanID(), child.Parent.SpanID(), name)
+	}
+}
+
+func TestTracing_ContinuesIncomingTraceparent(t *testing.T) {
+	exporter := recordSpans(t)
+
+	req := httptest.NewRequest("GET", "/tenant-abc/clients/test-client", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	newTracedRouter().ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.NotEmpty(t, spans)
+	for _, span := range spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String(), span.Name)
+	}
+	root := spans[len(spans)-1] // ended last
+	assert.Equal(t, "00f067aa0ba902b7", root.Parent.SpanID().String())
+	assert.True(t, root.Parent.IsRemote())
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"session-service/internal/audit"
 	"session-service/internal/models"
 	"time"
 
@@ -18,6 +19,11 @@ func (m *MockRepository) Close() error {
 	return args.Error(0)
 }
 
+func (m *MockRepository) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
 func (m *MockRepository) GetClientByID(ctx context.Context, clientID string) (*models.Client, error) {
 	args := m.Called(ctx, clientID)
 	if args.Get(0) == nil {
@@ -55,10 +61,159 @@ func (m *MockRepository) EnsureTenantExists(ctx context.Context, tenantID string
 	return args.Error(0)
 }
 
+// GetTenantByID mocks fetching a tenant by ID
+func (m *MockRepository) GetTenantByID(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tenant), args.Error(1)
+}
+
+// SetTenantHMACSecret mocks storing a tenant's encrypted HS256 secret
+func (m *MockRepository) SetTenantHMACSecret(ctx context.Context, tenantID string, encryptedSecret []byte) (bool, error) {
+	args := m.Called(ctx, tenantID, encryptedSecret)
+	return args.Bool(0), args.Error(1)
+}
+
+// GetTenantConfig mocks loading a tenant's policy
+func (m *MockRepository) GetTenantConfig(ctx context.Context, tenantID string) (*models.TenantConfig, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TenantConfig), args.Error(1)
+}
+
+// UpsertTenantConfig mocks replacing a tenant's policy
+func (m *MockRepository) UpsertTenantConfig(ctx context.Context, tenantConfig models.TenantConfig) (bool, error) {
+	args := m.Called(ctx, tenantConfig)
+	return args.Bool(0), args.Error(1)
+}
+
+// GetAudienceEncryptionKey mocks looking up a resource server's encryption key
+func (m *MockRepository) GetAudienceEncryptionKey(ctx context.Context, audience string) (string, error) {
+	args := m.Called(ctx, audience)
+	return args.String(0), args.Error(1)
+}
+
+// CreateTenantIfNotExists mocks idempotent tenant creation
+func (m *MockRepository) CreateTenantIfNotExists(ctx context.Context, tenant models.Tenant) (bool, error) {
+	args := m.Called(ctx, tenant)
+	return args.Bool(0), args.Error(1)
+}
+
+// InsertAuditEvent mocks persisting an audit event
+func (m *MockRepository) InsertAuditEvent(ctx context.Context, event audit.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+// LoadSigningKeys mocks loading the stored signing keys
+func (m *MockRepository) LoadSigningKeys(ctx context.Context, fingerprint string) ([]*models.SigningKey, error) {
+	args := m.Called(ctx, fingerprint)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.SigningKey), args.Error(1)
+}
+
+// SaveSigningKey mocks storing a signing key
+func (m *MockRepository) SaveSigningKey(ctx context.Context, key *models.SigningKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// MarkKeyExpired mocks setting a stored signing key's expiry
+func (m *MockRepository) MarkKeyExpired(ctx context.Context, keyID string, expiresAt time.Time) error {
+	args := m.Called(ctx, keyID, expiresAt)
+	return args.Error(0)
+}
+
+// CreateClientIfNotExists mocks idempotent client creation
+func (m *MockRepository) CreateClientIfNotExists(ctx context.Context, client models.Client) (bool, error) {
+	args := m.Called(ctx, client)
+	return args.Bool(0), args.Error(1)
+}
+
+// ListActiveClients mocks listing recently used clients
+func (m *MockRepository) ListActiveClients(ctx context.Context, limit int) ([]*models.Client, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Client), args.Error(1)
+}
+
+// ListTenantClients mocks listing one page of a tenant's clients
+func (m *MockRepository) ListTenantClients(ctx context.Context, tenantID string, limit, offset int) ([]*models.Client, int, error) {
+	args := m.Called(ctx, tenantID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.Client), args.Int(1), args.Error(2)
+}
+
+// UpdateClientMetadata mocks updating a client's name and description
+func (m *MockRepository) UpdateClientMetadata(ctx context.Context, tenantID, clientID string, metadata models.ClientMetadata) (*models.Client, error) {
+	args := m.Called(ctx, tenantID, clientID, metadata)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Client), args.Error(1)
+}
+
+// ListTenantRoles mocks listing a tenant's role catalog
+func (m *MockRepository) ListTenantRoles(ctx context.Context, tenantID string) ([]string, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 // UpsertUserAndRoles mocks upserting a user and roles
-func (m *MockRepository) UpsertUserAndRoles(ctx context.Context, user models.User, roles []string) error {
+func (m *MockRepository) UpsertUserAndRoles(ctx context.Context, user models.User, roles []string) (bool, error) {
 	args := m.Called(ctx, user, roles)
-	return args.Error(0)
+	return args.Bool(0), args.Error(1)
+}
+
+// GetUserExport mocks exporting a single user with roles
+func (m *MockRepository) GetUserExport(ctx context.Context, tenantID, userID string) (*models.UserExport, error) {
+	args := m.Called(ctx, tenantID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserExport), args.Error(1)
+}
+
+// ExportTenantUsers mocks streaming all users of a tenant. The users to stream
+// are taken from the first return value ([]*models.UserExport).
+func (m *MockRepository) ExportTenantUsers(ctx context.Context, tenantID string, fn func(*models.UserExport) error) error {
+	args := m.Called(ctx, tenantID, fn)
+	if users, ok := args.Get(0).([]*models.UserExport); ok {
+		for _, u := range users {
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// ListTenantUsers mocks listing one page of a tenant's users
+func (m *MockRepository) ListTenantUsers(ctx context.Context, tenantID string, limit, offset int) ([]*models.UserExport, int, error) {
+	args := m.Called(ctx, tenantID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*models.UserExport), args.Int(1), args.Error(2)
+}
+
+// DeleteUser mocks deleting a user and their roles
+func (m *MockRepository) DeleteUser(ctx context.Context, tenantID, userID string) (bool, error) {
+	args := m.Called(ctx, tenantID, userID)
+	return args.Bool(0), args.Error(1)
 }
 
 // MockCache is a mock implementation of cache.Cache
@@ -71,6 +226,11 @@ func (m *MockCache) Close() error {
 	return args.Error(0)
 }
 
+func (m *MockCache) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
 func (m *MockCache) GetClient(ctx context.Context, clientID string) (*models.Client, error) {
 	args := m.Called(ctx, clientID)
 	if args.Get(0) == nil {
@@ -84,11 +244,47 @@ func (m *MockCache) SetClient(ctx context.Context, client *models.Client, ttl ti
 	return args.Error(0)
 }
 
+func (m *MockCache) GetTenant(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Tenant), args.Error(1)
+}
+
+func (m *MockCache) SetTenant(ctx context.Context, tenant *models.Tenant, ttl time.Duration) error {
+	args := m.Called(ctx, tenant, ttl)
+	return args.Error(0)
+}
+
+func (m *MockCache) GetTenantConfig(ctx context.Context, tenantID string) (*models.TenantConfig, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TenantConfig), args.Error(1)
+}
+
+func (m *MockCache) SetTenantConfig(ctx context.Context, tenantConfig *models.TenantConfig, ttl time.Duration) error {
+	args := m.Called(ctx, tenantConfig, ttl)
+	return args.Error(0)
+}
+
+func (m *MockCache) DeleteTenantConfig(ctx context.Context, tenantID string) error {
+	args := m.Called(ctx, tenantID)
+	return args.Error(0)
+}
+
 func (m *MockCache) CheckRateLimit(ctx context.Context, clientID string, limit int, window time.Duration) (bool, error) {
 	args := m.Called(ctx, clientID, limit, window)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockCache) GetRateLimitUsage(ctx context.Context, clientID string) (int64, time.Duration, error) {
+	args := m.Called(ctx, clientID)
+	return args.Get(0).(int64), args.Get(1).(time.Duration), args.Error(2)
+}
+
 func (m *MockCache) StoreRefreshToken(ctx context.Context, tokenID string, data *models.RefreshTokenData, ttl time.Duration) error {
 	args := m.Called(ctx, tokenID, data, ttl)
 	return args.Error(0)
@@ -126,3 +322,97 @@ func (m *MockCache) IsRefreshTokenRevoked(ctx context.Context, tokenID string) (
 	args := m.Called(ctx, tokenID)
 	return args.Bool(0), args.Error(1)
 }
+
+func (m *MockCache) RecordTokenSource(ctx context.Context, jti, source string, ttl time.Duration) (int64, bool, error) {
+	args := m.Called(ctx, jti, source, ttl)
+	return args.Get(0).(int64), args.Bool(1), args.Error(2)
+}
+
+func (m *MockCache) SetUserRevocationCutoff(ctx context.Context, userID string, cutoff time.Time, ttl time.Duration) error {
+	args := m.Called(ctx, userID, cutoff, ttl)
+	return args.Error(0)
+}
+
+func (m *MockCache) GetUserRevocationCutoff(ctx context.Context, userID string) (time.Time, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockCache) SetUserRolesChangedAt(ctx context.Context, userID string, changedAt time.Time, ttl time.Duration) error {
+	args := m.Called(ctx, userID, changedAt, ttl)
+	return args.Error(0)
+}
+
+func (m *MockCache) GetUserRolesChangedAt(ctx context.Context, userID string) (time.Time, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+// StoreDeviceCode mocks storing a device authorization
+func (m *MockCache) StoreDeviceCode(ctx context.Context, deviceCode string, data *models.DeviceCodeData, ttl time.Duration) error {
+	args := m.Called(ctx, deviceCode, data, ttl)
+	return args.Error(0)
+}
+
+// GetDeviceCode mocks retrieving a device authorization
+func (m *MockCache) GetDeviceCode(ctx context.Context, deviceCode string) (*models.DeviceCodeData, error) {
+	args := m.Called(ctx, deviceCode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DeviceCodeData), args.Error(1)
+}
+
+// GetDeviceCodeByUserCode mocks the user code lookup
+func (m *MockCache) GetDeviceCodeByUserCode(ctx context.Context, userCode string) (string, error) {
+	args := m.Called(ctx, userCode)
+	return args.String(0), args.Error(1)
+}
+
+// DeleteDeviceCode mocks deleting a device authorization
+func (m *MockCache) DeleteDeviceCode(ctx context.Context, deviceCode, userCode string) error {
+	args := m.Called(ctx, deviceCode, userCode)
+	return args.Error(0)
+}
+
+// StoreCookieSession mocks storing a cookie session
+func (m *MockCache) StoreCookieSession(ctx context.Context, sessionID, refreshToken string, ttl time.Duration) error {
+	args := m.Called(ctx, sessionID, refreshToken, ttl)
+	return args.Error(0)
+}
+
+// GetCookieSession mocks the cookie session lookup
+func (m *MockCache) GetCookieSession(ctx context.Context, sessionID string) (string, error) {
+	args := m.Called(ctx, sessionID)
+	return args.String(0), args.Error(1)
+}
+
+// TrackUserSession mocks recording a user session
+func (m *MockCache) TrackUserSession(ctx context.Context, userID, sessionID, refreshToken string, ttl time.Duration) error {
+	args := m.Called(ctx, userID, sessionID, refreshToken, ttl)
+	return args.Error(0)
+}
+
+// GetUserSessions mocks listing a user's active sessions
+func (m *MockCache) GetUserSessions(ctx context.Context, userID string) ([]models.UserSession, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserSession), args.Error(1)
+}
+
+// RemoveUserSession mocks removing a user session
+func (m *MockCache) RemoveUserSession(ctx context.Context, userID, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+// MockAuditRecorder is a mock implementation of audit.Recorder
+type MockAuditRecorder struct {
+	mock.Mock
+}
+
+func (m *MockAuditRecorder) Record(ctx context.Context, event audit.Event) {
+	m.Called(ctx, event)
+}
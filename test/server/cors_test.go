@@ -0,0 +1,69 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetupRouter_PreflightIsRouteAware(t *testing.T) {
+	public, _ := newRouters(t, false)
+
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantMethods string
+	}{
+		{"route listing OPTIONS", "/tenant-abc/oauth2/v2.0/token", http.StatusNoContent, "POST, OPTIONS"},
+		{"route without OPTIONS", "/tenant-abc/oauth2/v1.0/userinfo", http.StatusNoContent, "GET, POST, OPTIONS"},
+		{"methods of several routes on one path", "/tenant-abc/admin/signing-secret", http.StatusNoContent, "POST, DELETE, OPTIONS"},
+		{"unknown tenant route", "/tenant-abc/oauth2/v1.0/nope", http.StatusNotFound, ""},
+		{"unknown path", "/does/not/exist", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			req.Header.Set("Origin", "https://app.example.com")
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			rr := httptest.NewRecorder()
+
+			public.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.Equal(t, tt.wantMethods, rr.Header().Get("Access-Control-Allow-Methods"))
+			if tt.wantStatus == http.StatusNoContent {
+				assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+				assert.Equal(t, "Content-Type, Authorization", rr.Header().Get("Access-Control-Allow-Headers"))
+			} else {
+				assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+func TestSetupRouter_WrongMethodStillNotAllowed(t *testing.T) {
+	public, _ := newRouters(t, false)
+
+	rr := httptest.NewRecorder()
+	public.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/tenant-abc/oauth2/v1.0/userinfo", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, "GET, POST, OPTIONS", rr.Header().Get("Allow"))
+}
+
+func TestSetupRouter_SimpleRequestSkipsAllowedMethods(t *testing.T) {
+	public, _ := newRouters(t, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rr := httptest.NewRecorder()
+	public.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Methods"))
+}
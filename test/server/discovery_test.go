@@ -0,0 +1,149 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"session-service/internal/handlers"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fetchDiscovery(t *testing.T, router http.Handler) handlers.OIDCConfiguration {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/.well-known/openid-configuration", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var doc handlers.OIDCConfiguration
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	return doc
+}
+
+func TestDiscovery_AdvertisedEndpointsAreRouted(t *testing.T) {
+	public, _ := newRouters(t, false)
+	doc := fetchDiscovery(t, public)
+
+	endpoints := []struct {
+		name, url, method string
+	}{
+		{"token_endpoint", doc.TokenEndpoint, "POST"},
+		{"jwks_uri", doc.JwksURI, "GET"},
+		{"device_authorization_endpoint", doc.DeviceAuthorizationEndpoint, "POST"},
+		{"userinfo_endpoint", doc.UserinfoEndpoint, "GET"},
+		{"introspection_endpoint", doc.IntrospectionEndpoint, "POST"},
+	}
+	for _, ep := range endpoints {
+		t.Run(ep.name, func(t *testing.T) {
+			require.True(t, strings.HasPrefix(ep.url, "http://localhost/{tenant_id}/"), ep.url)
+			path := strings.Replace(strings.TrimPrefix(ep.url, "http://localhost"), "{tenant_id}", "tenant-abc", 1)
+
+			var match mux.RouteMatch
+			assert.True(t, public.Match(httptest.NewRequest(ep.method, path, nil), &match), "%s %s is not routed", ep.method, path)
+		})
+	}
+
+	// Endpoints that are not implemented are not advertised
+	assert.Empty(t, doc.RevocationEndpoint)
+	assert.Empty(t, doc.EndSessionEndpoint)
+}
+
+func TestDiscovery_NoResponseModesWithoutAuthorizeEndpoint(t *testing.T) {
+	public, _ := newRouters(t, false)
+
+	rr := httptest.NewRecorder()
+	public.ServeHTTP(rr, httptest.NewRequest("GET", "/.well-known/openid-configuration", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	assert.NotContains(t, doc, "response_modes_supported")
+	assert.Equal(t, []interface{}{}, doc["response_types_supported"])
+	assert.NotContains(t, doc, "authorization_endpoint")
+}
+
+func TestDiscovery_AdvertisedGrantTypesAreAccepted(t *testing.T) {
+	public, _ := newRouters(t, false)
+	doc := fetchDiscovery(t, public)
+
+	assert.ElementsMatch(t, []string{
+		"client_credentials",
+		"provision_user",
+		"refresh_token",
+		handlers.DeviceCodeGrantType,
+	}, doc.GrantTypesSupported)
+
+	for _, grantType := range append(doc.GrantTypesSupported, "password") {
+		t.Run(grantType, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/tenant-abc/oauth2/v2.0/token", strings.NewReader("grant_type="+grantType))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rr := httptest.NewRecorder()
+			public.ServeHTTP(rr, req)
+
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			advertised := grantType != "password"
+			assert.Equal(t, advertised, body["error"] != "UNSUPPORTED_GRANT_TYPE",
+				"grant type %q: advertised=%v but response was %v", grantType, advertised, body)
+		})
+	}
+}
+
+func TestAuthorizationServerMetadata_AdvertisedEndpointsAreRouted(t *testing.T) {
+	public, _ := newRouters(t, false)
+
+	rr := httptest.NewRecorder()
+	public.ServeHTTP(rr, httptest.NewRequest("GET", "/.well-known/oauth-authorization-server/tenant-abc", nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var metadata handlers.AuthorizationServerMetadata
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &metadata))
+
+	endpoints := []struct {
+		name, url, method string
+	}{
+		{"token_endpoint", metadata.TokenEndpoint, "POST"},
+		{"jwks_uri", metadata.JwksURI, "GET"},
+		{"device_authorization_endpoint", metadata.DeviceAuthorizationEndpoint, "POST"},
+		{"introspection_endpoint", metadata.IntrospectionEndpoint, "POST"},
+	}
+	for _, ep := range endpoints {
+		t.Run(ep.name, func(t *testing.T) {
+			require.True(t, strings.HasPrefix(ep.url, "http://localhost/tenant-abc/"), ep.url)
+			path := strings.TrimPrefix(ep.url, "http://localhost")
+
+			var match mux.RouteMatch
+			assert.True(t, public.Match(httptest.NewRequest(ep.method, path, nil), &match), "%s %s is not routed", ep.method, path)
+		})
+	}
+
+	// Endpoints that are not implemented are not advertised
+	assert.Empty(t, metadata.RevocationEndpoint)
+	assert.Empty(t, metadata.CodeChallengeMethodsSupported)
+}
+
+func TestAuthorizationServerMetadata_MatchesDiscovery(t *testing.T) {
+	public, _ := newRouters(t, false)
+	doc := fetchDiscovery(t, public)
+
+	rr := httptest.NewRecorder()
+	public.ServeHTTP(rr, httptest.NewRequest("GET", "/.well-known/oauth-authorization-server/tenant-abc", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var metadata handlers.AuthorizationServerMetadata
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &metadata))
+
+	forTenant := func(url string) string { return strings.ReplaceAll(url, "{tenant_id}", "tenant-abc") }
+	assert.Equal(t, doc.Issuer, metadata.Issuer)
+	assert.Equal(t, forTenant(doc.TokenEndpoint), metadata.TokenEndpoint)
+	assert.Equal(t, forTenant(doc.JwksURI), metadata.JwksURI)
+	assert.Equal(t, forTenant(doc.DeviceAuthorizationEndpoint), metadata.DeviceAuthorizationEndpoint)
+	assert.Equal(t, doc.GrantTypesSupported, metadata.GrantTypesSupported)
+	assert.Equal(t, doc.TokenEndpointAuthMethodsSupported, metadata.TokenEndpointAuthMethodsSupported)
+	assert.Equal(t, doc.ResponseTypesSupported, metadata.ResponseTypesSupported)
+	assert.Equal(t, doc.ScopesSupported, metadata.ScopesSupported)
+}
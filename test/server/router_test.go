@@ -0,0 +1,95 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"session-service/internal/auth"
+	"session-service/internal/config"
+	"session-service/internal/handlers"
+	"session-service/internal/server"
+	"session-service/test/helpers"
+	"session-service/test/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// denyAll stands in for the admin auth middleware: a reachable admin route
+// answers 401 rather than 404.
+func denyAll(http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+}
+
+func newRouters(t *testing.T, separateAdmin bool) (*mux.Router, *mux.Router) {
+	t.Helper()
+
+	mockRepo := new(mocks.MockRepository)
+	mockCache := new(mocks.MockCache)
+	cfg := &config.Config{JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour, AdminRole: "tenant-admin"}
+	logger := zap.NewNop()
+
+	privKey, pubKey := helpers.GenerateTestPEMKeys(t)
+	km, err := auth.NewKeyManager(privKey, pubKey)
+	require.NoError(t, err)
+	tokenGen := auth.NewTokenGenerator(km, "issuer", "audience", cfg.JWTExpiry, 32)
+	tokenValidator := auth.NewTokenValidator(km, "issuer", "audience", mockCache)
+
+	tokenHandler := handlers.NewTokenHandler(mockRepo, mockCache, tokenGen, tokenValidator, cfg, logger)
+	verifyHandler := handlers.NewVerifyHandler(tokenValidator, nil, false, logger)
+	jwksHandler := handlers.NewJWKSHandler(mockRepo, km, logger)
+	oidcHandler := handlers.NewOIDCConfigurationHandler("http://localhost", "issuer", logger)
+	adminHandler := handlers.NewAdminHandler(mockRepo, mockCache, cfg, new(mocks.MockAuditRecorder), logger)
+	healthHandler := handlers.NewHealthHandler(mockRepo, mockCache, logger)
+
+	public := server.SetupRouter(tokenHandler, verifyHandler, jwksHandler, oidcHandler, adminHandler, healthHandler,
+		denyAll, denyAll, nil, nil, denyAll, denyAll, nil, separateAdmin, logger)
+	admin := server.SetupAdminRouter(adminHandler, healthHandler, denyAll, nil, nil, denyAll, denyAll, nil, logger)
+	return public, admin
+}
+
+func serve(router http.Handler, method, path string) int {
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+	return rr.Code
+}
+
+func TestSetupRouter_SeparateAdminPort(t *testing.T) {
+	public, admin := newRouters(t, true)
+
+	operational := []struct {
+		method, path string
+		want         int
+	}{
+		{"GET", "/metrics", http.StatusOK},
+		{"GET", "/healthz", http.StatusOK},
+		{"GET", "/tenant-abc/admin/users/export", http.StatusUnauthorized},
+		{"DELETE", "/tenant-abc/admin/users/user-123", http.StatusUnauthorized},
+		{"POST", "/admin/keys/promote-standby", http.StatusUnauthorized},
+	}
+	for _, tc := range operational {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.want, serve(admin, tc.method, tc.path), "admin server")
+			assert.Equal(t, http.StatusNotFound, serve(public, tc.method, tc.path), "public server")
+		})
+	}
+
+	// The OAuth2 surface stays on the public server only
+	assert.Equal(t, http.StatusOK, serve(public, "GET", "/.well-known/openid-configuration"))
+	assert.Equal(t, http.StatusNotFound, serve(admin, "GET", "/.well-known/openid-configuration"))
+}
+
+func TestSetupRouter_SinglePortServesEverything(t *testing.T) {
+	public, _ := newRouters(t, false)
+
+	assert.Equal(t, http.StatusOK, serve(public, "GET", "/metrics"))
+	assert.Equal(t, http.StatusOK, serve(public, "GET", "/healthz"))
+	assert.Equal(t, http.StatusUnauthorized, serve(public, "GET", "/tenant-abc/admin/users/export"))
+	assert.Equal(t, http.StatusOK, serve(public, "GET", "/.well-known/openid-configuration"))
+}